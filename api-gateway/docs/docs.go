@@ -0,0 +1,10 @@
+// Package docs holds the OpenAPI specs the gateway merges and serves at
+// /openapi.json. The per-service *.swagger.json files are generated from
+// ../proto by protoc-gen-openapiv2; aggregation.swagger.json describes the
+// hand-written gateway routes and is maintained by hand.
+package docs
+
+import "embed"
+
+//go:embed *.swagger.json
+var Specs embed.FS
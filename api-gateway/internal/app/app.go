@@ -2,94 +2,260 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/docs"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/repository"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/service"
+	connectServ "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/connect"
+	graphqlServ "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/graphql"
 	orderClient "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/grpc/clients/order"
 	paymentClient "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/grpc/clients/payment"
 	productClient "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/grpc/clients/product"
 	authClient "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/grpc/clients/rbacAuth"
 	userClient "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/grpc/clients/user"
+	clientFactory "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/grpc/factory"
 	httpServ "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/http"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/client"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/payment"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/rbacAuth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/problem"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/storage/redis"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+// shutdownTimeout bounds the wait for in-flight requests on shutdown.
+const shutdownTimeout = 10 * time.Second
+
 func Run(ctx context.Context, log *logger.Logger, cfg *config.Config) error {
-	mux := runtime.NewServeMux()
+	problem.SetLogger(log.Logger)
+
+	mux := runtime.NewServeMux(
+		runtime.WithMetadata(httpServ.IdempotencyMetadata),
+		runtime.WithErrorHandler(problem.ErrorHandler),
+		runtime.WithRoutingErrorHandler(problem.RoutingErrorHandler),
+	)
+
+	clients, err := clientFactory.New(ctx, cfg.OTLP, cfg.Caller, log)
+	if err != nil {
+		return fmt.Errorf("failed to create gRPC client factory: %w", err)
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := clients.Close(closeCtx); err != nil {
+			log.Error("failed to close gRPC clients", logger.Err(err))
+		}
+	}()
 
-	paymentCl, err := paymentClient.New(ctx, cfg.PaymentService.Host, cfg.PaymentService.MetricsPort, cfg.OTLP, log)
+	paymentConn, err := clients.Route("payment-client", cfg.PaymentService)
 	if err != nil {
 		return fmt.Errorf("failed to create payment client: %w", err)
 	}
-	err = payment.RegisterPaymentServiceHandlerClient(ctx, mux, paymentCl.PaymentServiceClient)
+	paymentCl := payment.NewPaymentServiceClient(paymentConn)
+	err = payment.RegisterPaymentServiceHandlerClient(ctx, mux, paymentCl)
 	if err != nil {
-		fmt.Errorf("%v:%w", "payment.RegisterPaymentServiceHandlerClient", err)
+		return fmt.Errorf("%v:%w", "payment.RegisterPaymentServiceHandlerClient", err)
 	}
 
-	authCl, err := authClient.New(ctx, cfg.AuthService.Host, cfg.AuthService.MetricsPort, cfg.OTLP, log)
+	authConn, err := clients.Route("auth-client", cfg.AuthService)
 	if err != nil {
 		return fmt.Errorf("failed to create auth client: %w", err)
 	}
-	err = rbacAuth.RegisterAuthServiceHandlerClient(ctx, mux, authCl.AuthServiceClient)
+	authCl := rbacAuth.NewAuthServiceClient(authConn)
+	err = rbacAuth.RegisterAuthServiceHandlerClient(ctx, mux, authCl)
 	if err != nil {
-		fmt.Errorf("%v:%w", "payment.RegisterPaymentServiceHandlerClient", err)
+		return fmt.Errorf("%v:%w", "rbacAuth.RegisterAuthServiceHandlerClient", err)
 	}
-	userCl, err := userClient.New(ctx, cfg.UserService.Host, cfg.UserService.MetricsPort, cfg.OTLP, log)
+
+	userConn, err := clients.Route("user-client", cfg.UserService)
 	if err != nil {
 		return fmt.Errorf("failed to create user client: %w", err)
 	}
-	err = client.RegisterUserServiceHandlerClient(ctx, mux, userCl.UserServiceClient)
+	userCl := client.NewUserServiceClient(userConn)
+	err = client.RegisterUserServiceHandlerClient(ctx, mux, userCl)
 	if err != nil {
-		return fmt.Errorf("failed to create user client: %w", err)
+		return fmt.Errorf("%v:%w", "client.RegisterUserServiceHandlerClient", err)
 	}
+
+	productConn, err := clients.Route("product-client", cfg.ProductService)
 	if err != nil {
-		fmt.Errorf("%v:%w", "payment.RegisterPaymentServiceHandlerClient", err)
+		return fmt.Errorf("failed to create product client: %w", err)
 	}
-	productCl, err := productClient.New(ctx, cfg.ProductService.Host, cfg.ProductService.MetricsPort, cfg.OTLP, log)
+	productCl := product.NewProductServiceClient(productConn)
+	err = product.RegisterProductServiceHandlerClient(ctx, mux, productCl)
 	if err != nil {
-		return fmt.Errorf("failed to create auth client: %w", err)
+		return fmt.Errorf("%v:%w", "product.RegisterProductServiceHandlerClient", err)
 	}
-	err = product.RegisterProductServiceHandlerClient(ctx, mux, productCl.ProductServiceClient)
+
+	orderConn, err := clients.Route("order-client", cfg.OrderService)
 	if err != nil {
-		fmt.Errorf("%v:%w", "payment.RegisterPaymentServiceHandlerClient", err)
+		return fmt.Errorf("failed to create order client: %w", err)
+	}
+	orderCl := order.NewOrderServiceClient(orderConn)
+	err = order.RegisterOrderServiceHandlerClient(ctx, mux, orderCl)
+	if err != nil {
+		return fmt.Errorf("%v:%w", "order.RegisterOrderServiceHandlerClient", err)
 	}
 
-	orderCl, err := orderClient.New(ctx, cfg.OrderService.Host, cfg.OrderService.MetricsPort, cfg.OTLP, log)
+	// Redis Database
+	rdb, err := redis.New(&cfg.Redis, redis.PoolSize(cfg.Redis.PoolSize), redis.PoolTimeout(cfg.Redis.ConnTimeout), redis.MinIdleCons(cfg.Redis.MinIdleCons))
 	if err != nil {
-		return fmt.Errorf("failed to create auth client: %w", err)
+		return fmt.Errorf("failed to create redis client: %w", err)
 	}
-	err = order.RegisterOrderServiceHandlerClient(ctx, mux, orderCl.OrderServiceClient)
+	defer rdb.Close()
+
+	authService := authClient.NewAuthService(authCl)
+	userService := userClient.NewUserService(userCl)
+
+	productService := productClient.NewProductService(productCl)
+	orderService := orderClient.NewOrderService(orderCl)
+	paymentService := paymentClient.NewPaymentService(paymentCl)
+
+	tokenManager, err := auth.New(cfg.Token.SecretKey)
 	if err != nil {
-		fmt.Errorf("%v:%w", "order.RegisterOrderServiceHandlerClient", err)
+		return fmt.Errorf("failed to create token manager: %w", err)
 	}
 
-	authService := authClient.NewAuthService(authCl.AuthServiceClient)
-	userService := userClient.NewUserService(userCl.UserServiceClient)
+	aggregatorService := service.NewAggregatorService(authService, userService, tokenManager, repository.NewSignUpRepository(rdb), log)
+	go aggregatorService.RunRollbacks(ctx, cfg.SignUp.RollbackInterval)
 
-	aggregatorService := service.NewAggregatorService(authService, userService, log)
+	checkoutService := service.NewCheckoutService(productService, orderService, paymentService, repository.NewSagaRepository(rdb), log)
+	// Deferred after rdb.Close, so it runs first: the sagas still save their
+	// state on the way out.
+	defer checkoutService.Shutdown()
+	if err := checkoutService.Resume(ctx); err != nil {
+		log.Error("failed to resume checkout sagas", logger.Err(err))
+	}
 
 	// Создаем HTTP обработчик
 	aggregatorHandler := httpServ.NewAggregatorHandler(aggregatorService)
+	checkoutHandler := httpServ.NewCheckoutHandler(checkoutService)
 
 	// Создаем маршрутизатор Gorilla Mux
 	//router := httpServ.NewRouter(aggregatorHandler)
 
 	// Объединяем Gorilla Mux с gRPC Gateway ServeMux
-	mainMux := httpServ.NewRouter(aggregatorHandler)
-	mainMux.Handle("/", mux) // gRPC Gateway
+	openAPIHandler, err := httpServ.NewOpenAPIHandler(docs.Specs)
+	if err != nil {
+		return fmt.Errorf("%v:%w", "httpServ.NewOpenAPIHandler", err)
+	}
+
+	graphqlServer, err := graphqlServ.NewServer(graphqlServ.Clients{
+		Auth:     authCl,
+		User:     userCl,
+		Product:  productCl,
+		Order:    orderCl,
+		Checkout: checkoutService,
+	}, cfg.GraphQL, log)
+	if err != nil {
+		return fmt.Errorf("%v:%w", "graphqlServ.NewServer", err)
+	}
+
+	authenticator := httpServ.NewAuthenticator(tokenManager, log)
+
+	orderEventsHandler := httpServ.NewOrderEventsHandler(orderCl, repository.NewStreamTicketRepository(rdb), cfg.OrderEvents, log)
+
+	ordersV2Handler := httpServ.NewOrdersV2Handler(orderCl, cfg.Money, log)
+
+	productImagesHandler := httpServ.NewProductImagesHandler(productCl, log)
+
+	productReviewsHandler := httpServ.NewProductReviewsHandler(orderCl, productCl, log)
+
+	productCatalogHandler := httpServ.NewProductCatalogHandler(productCl, log)
+
+	mainMux := httpServ.NewRouter(httpServ.Handlers{
+		Aggregator:     aggregatorHandler,
+		Checkout:       checkoutHandler,
+		OrderEvents:    orderEventsHandler,
+		OrdersV2:       ordersV2Handler,
+		ProductImages:  productImagesHandler,
+		ProductReviews: productReviewsHandler,
+		ProductCatalog: productCatalogHandler,
+		OpenAPI:        openAPIHandler,
+		GraphQL:        graphqlServer,
+	})
+
+	// gRPC-Web and Connect clients call the services at their gRPC paths.
+	rpcHandler := connectServ.NewHandler([]connectServ.Service{
+		{Desc: payment.File_proto_payment_proto.Services().ByName("PaymentService"), Conn: paymentConn},
+		{Desc: rbacAuth.File_proto_rbacAuth_proto.Services().ByName("AuthService"), Conn: authConn},
+		{Desc: client.File_proto_user_proto.Services().ByName("UserService"), Conn: userConn},
+		// Reviews are created through the gateway, which checks the order
+		// first, and stock is only reserved by its checkout.
+		{Desc: product.File_proto_product_proto.Services().ByName("ProductService"), Conn: productConn, Internal: []protoreflect.Name{"CreateReview", "ReserveStock", "ReleaseReservation", "CommitReservation"}},
+		{Desc: order.File_proto_order_proto.Services().ByName("OrderService"), Conn: orderConn, Internal: []protoreflect.Name{"FindDeliveredOrder"}},
+	}, log)
+	for _, prefix := range rpcHandler.Prefixes() {
+		mainMux.PathPrefix(prefix).Methods("GET", "POST").Handler(rpcHandler)
+	}
+	mainMux.PathPrefix("/").Handler(mux) // gRPC Gateway
+
+	idempotency := httpServ.NewIdempotencyMiddleware(repository.NewIdempotencyRepository(rdb), cfg.Idempotency.KeyTTL, cfg.Idempotency.LockTTL, log)
+	catalogCache := httpServ.NewCatalogCache(cfg.HTTPCache, log)
+	apiVersions := httpServ.NewAPIVersions(cfg.APIVersions)
+
+	// The server span gives the problem responses their trace ID.
+	handler := httpServ.CORS(cfg.CORS)(apiVersions.Handler(httpServ.Canary(authenticator.Handler(idempotency.Handler(catalogCache.Handler(mainMux))))))
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: otelhttp.NewHandler(handler, "api-gateway"),
+	}
+	// Streams never become idle, end them so that Shutdown does not wait for them.
+	server.RegisterOnShutdown(orderEventsHandler.Shutdown)
+
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", clients.MetricsHandler(apiVersions.Collectors()...))
+	metricsServer := &http.Server{Addr: cfg.Metrics.Addr, Handler: metricsMux}
+
+	// The admin endpoints are kept off the public listener.
+	adminHandler := httpServ.NewAdminHandler(clients, tokenManager, cfg, log)
+	adminServer := &http.Server{Addr: cfg.Admin.Addr, Handler: adminHandler.Router()}
+	go clients.RunHealthChecks(ctx, cfg.Admin.HealthInterval, cfg.Admin.HealthTimeout)
 
 	// Запускаем HTTP сервер
 	log.Info("Starting API Gateway on :8080")
-	if err := http.ListenAndServe(":8080", mux); err != nil {
+	serverErr := make(chan error, 3)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	go func() {
+		log.Info("Starting metrics server", "addr", metricsServer.Addr)
+		serverErr <- metricsServer.ListenAndServe()
+	}()
+	go func() {
+		log.Info("Starting admin server", "addr", adminServer.Addr)
+		serverErr <- adminServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
 		return fmt.Errorf("failed to start HTTP server: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Info("Shutting down API Gateway")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down HTTP server: %w", err)
+	}
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down metrics server: %w", err)
+	}
+	if err := adminServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down admin server: %w", err)
 	}
 
 	return nil
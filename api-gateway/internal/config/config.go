@@ -3,20 +3,34 @@ package config
 import (
 	"flag"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type (
 	Config struct {
-		App            App           `yaml:"app" env-prefix:"APP_"`
-		Kafka          KafkaConfig   `yaml:"kafka" env-prefix:"KAFKA_"`
-		OTLP           OTLPConfig    `yaml:"otlp" env-prefix:"OTLP_"`
-		ProductService ProductConfig `yaml:"product_service" env-prefix:"PRODUCT_SERVICE_"`
-		UserService    UserConfig    `yaml:"user_service" env-prefix:"USER_SERVICE_"`
-		PaymentService PaymentConfig `yaml:"payment_service" env-prefix:"PAYMENT_SERVICE_"`
-		AuthService    AuthConfig    `yaml:"auth_service" env-prefix:"AUTH_SERVICE_"`
-		OrderService   OrderConfig   `yaml:"order_service" env-prefix:"ORDER_SERVICE_"`
+		App            App          `yaml:"app" env-prefix:"APP_"`
+		Kafka          KafkaConfig  `yaml:"kafka" env-prefix:"KAFKA_"`
+		OTLP           OTLPConfig   `yaml:"otlp" env-prefix:"OTLP_"`
+		Redis          RedisConfig  `yaml:"redis" env-prefix:"REDIS_"`
+		Idempotency    Idempotency  `yaml:"idempotency" env-prefix:"IDEMPOTENCY_"`
+		SignUp         SignUp       `yaml:"sign_up" env-prefix:"SIGN_UP_"`
+		HTTPCache      HTTPCache    `yaml:"http_cache" env-prefix:"HTTP_CACHE_"`
+		Token          TokenConfig  `yaml:"token" env-prefix:"TOKEN_"`
+		Caller         CallerConfig `yaml:"caller" env-prefix:"CALLER_"`
+		GraphQL        GraphQL      `yaml:"graphql" env-prefix:"GRAPHQL_"`
+		OrderEvents    OrderEvents  `yaml:"order_events" env-prefix:"ORDER_EVENTS_"`
+		Metrics        Metrics      `yaml:"metrics" env-prefix:"METRICS_"`
+		Admin          Admin        `yaml:"admin" env-prefix:"ADMIN_"`
+		CORS           CORS         `yaml:"cors" env-prefix:"CORS_"`
+		Money          Money        `yaml:"money" env-prefix:"MONEY_"`
+		APIVersions    APIVersions  `yaml:"api_versions"`
+		ProductService ClientConfig `yaml:"product_service" env-prefix:"PRODUCT_SERVICE_"`
+		UserService    ClientConfig `yaml:"user_service" env-prefix:"USER_SERVICE_"`
+		PaymentService ClientConfig `yaml:"payment_service" env-prefix:"PAYMENT_SERVICE_"`
+		AuthService    ClientConfig `yaml:"auth_service" env-prefix:"AUTH_SERVICE_"`
+		OrderService   ClientConfig `yaml:"order_service" env-prefix:"ORDER_SERVICE_"`
 
 		Env string `yaml:"env" env:"ENV" env-default:"local"`
 	}
@@ -34,29 +48,206 @@ type (
 		Endpoint string `env:"ENDPOINT,required" yaml:"endpoint"`
 	}
 
-	ProductConfig struct {
-		Host        string `env:"HOST,required" yaml:"host"`
-		MetricsPort string `env:"METRICS_PORT,required" yaml:"metrics_port"`
+	RedisConfig struct {
+		Password    string        `env:"PASSWORD" yaml:"password"`
+		Addr        string        `env:"ADDR,required" yaml:"addr"`
+		PoolSize    int           `env:"POOL_SIZE" yaml:"poolSize" env-default:"10"`
+		MinIdleCons int           `env:"MIN_IDLE_CONS" yaml:"minIdleCons" env-default:"2"`
+		ConnTimeout time.Duration `env:"CONN_TIMEOUT" yaml:"connTimeout" env-default:"1s"`
 	}
 
-	UserConfig struct {
-		Host        string `env:"HOST,required" yaml:"host"`
-		MetricsPort string `env:"METRICS_PORT,required" yaml:"metrics_port"`
+	Idempotency struct {
+		KeyTTL  time.Duration `env:"KEY_TTL" yaml:"key_ttl" env-default:"24h"`
+		LockTTL time.Duration `env:"LOCK_TTL" yaml:"lock_ttl" env-default:"30s"`
 	}
 
-	PaymentConfig struct {
-		Host        string `env:"HOST,required" yaml:"host"`
-		MetricsPort string `env:"METRICS_PORT,required" yaml:"metrics_port"`
+	// SignUp settles the sign ups left unfinished every RollbackInterval.
+	SignUp struct {
+		RollbackInterval time.Duration `env:"ROLLBACK_INTERVAL" yaml:"rollback_interval" env-default:"1m"`
 	}
 
-	AuthConfig struct {
-		Host        string `env:"HOST,required" yaml:"host"`
-		MetricsPort string `env:"METRICS_PORT,required" yaml:"metrics_port"`
+	HTTPCache struct {
+		Size                 int           `env:"SIZE" yaml:"size" env-default:"1000"`
+		TTL                  time.Duration `env:"TTL" yaml:"ttl" env-default:"30s"`
+		StaleWhileRevalidate time.Duration `env:"STALE_WHILE_REVALIDATE" yaml:"stale_while_revalidate" env-default:"30s"`
+		Routes               []CacheRoute  `yaml:"routes"`
 	}
 
-	OrderConfig struct {
-		Host        string `env:"HOST,required" yaml:"host"`
-		MetricsPort string `env:"METRICS_PORT,required" yaml:"metrics_port"`
+	// CacheRoute sets the Cache-Control header for anonymous GET requests
+	// whose path matches Path. Path segments in braces match any single
+	// segment.
+	CacheRoute struct {
+		Path         string `yaml:"path"`
+		CacheControl string `yaml:"cache_control"`
+	}
+
+	TokenConfig struct {
+		SecretKey string `env:"SECRET,required" yaml:"secret_key"`
+	}
+
+	// CallerConfig holds the key the gateway signs the caller it passes to
+	// the backend services with. The services must share it.
+	CallerConfig struct {
+		SecretKey string `env:"SECRET,required" yaml:"secret_key"`
+	}
+
+	GraphQL struct {
+		MaxDepth int `env:"MAX_DEPTH" yaml:"max_depth" env-default:"6"`
+		MaxCost  int `env:"MAX_COST" yaml:"max_cost" env-default:"1000"`
+	}
+
+	// OrderEvents configures the streams of order status changes. The
+	// reconnect delay applies to the stream from order-service, clients are
+	// asked to wait the same before they reconnect.
+	OrderEvents struct {
+		HeartbeatInterval time.Duration `env:"HEARTBEAT_INTERVAL" yaml:"heartbeat_interval" env-default:"15s"`
+		ReconnectDelay    time.Duration `env:"RECONNECT_DELAY" yaml:"reconnect_delay" env-default:"1s"`
+		// TicketTTL is how long a stream ticket can be used to open a stream.
+		TicketTTL time.Duration `env:"TICKET_TTL" yaml:"ticket_ttl" env-default:"30s"`
+	}
+
+	// Metrics serves the metrics of the backend clients.
+	Metrics struct {
+		Addr string `env:"ADDR" yaml:"addr" env-default:":9090"`
+	}
+
+	// CORS lets the browser origins in AllowedOrigins call the gateway, the
+	// gRPC-Web and Connect routes included. Without origins CORS is off.
+	CORS struct {
+		AllowedOrigins []string      `env:"ALLOWED_ORIGINS" yaml:"allowed_origins"`
+		MaxAge         time.Duration `env:"MAX_AGE" yaml:"max_age" env-default:"2h"`
+	}
+
+	// Money describes the amounts the services store: integers in the minor
+	// units of Currency, with Exponent digits after the decimal point.
+	Money struct {
+		Currency string `env:"CURRENCY" yaml:"currency" env-default:"RUB"`
+		Exponent int    `env:"EXPONENT" yaml:"exponent" env-default:"2"`
+	}
+
+	// APIVersions lists the deprecated API versions.
+	APIVersions struct {
+		Deprecated []DeprecatedAPI `yaml:"deprecated"`
+	}
+
+	// DeprecatedAPI marks the routes under Prefix as deprecated since
+	// DeprecatedAt, to be removed at Sunset, and points clients at the
+	// Successor routes.
+	DeprecatedAPI struct {
+		Prefix       string    `yaml:"prefix"`
+		DeprecatedAt time.Time `yaml:"deprecated_at"`
+		Sunset       time.Time `yaml:"sunset"`
+		Successor    string    `yaml:"successor"`
+	}
+
+	// Admin serves the state of the backend dependencies to users with the
+	// admin role. The dependencies are health checked every HealthInterval.
+	Admin struct {
+		Addr           string        `env:"ADDR" yaml:"addr" env-default:":9091"`
+		HealthInterval time.Duration `env:"HEALTH_INTERVAL" yaml:"health_interval" env-default:"10s"`
+		HealthTimeout  time.Duration `env:"HEALTH_TIMEOUT" yaml:"health_timeout" env-default:"1s"`
+	}
+
+	// ClientConfig configures the gRPC connection to a backend service.
+	ClientConfig struct {
+		Host string `env:"HOST,required" yaml:"host"`
+		// Timeout bounds unary calls. MethodTimeouts overrides it by method
+		// name, e.g. GetProduct. Streaming calls are not bounded.
+		Timeout        time.Duration            `env:"TIMEOUT" yaml:"timeout" env-default:"500ms"`
+		MethodTimeouts map[string]time.Duration `yaml:"method_timeouts"`
+		// LoadBalancing is a gRPC load balancing policy: pick_first or
+		// round_robin. round_robin needs a target that resolves to all
+		// instances, e.g. dns:///order-service:8080.
+		LoadBalancing string          `env:"LOAD_BALANCING" yaml:"load_balancing" env-default:"pick_first"`
+		TLS           ClientTLS       `yaml:"tls" env-prefix:"TLS_"`
+		Breaker       BreakerConfig   `yaml:"breaker" env-prefix:"BREAKER_"`
+		Retry         RetryConfig     `yaml:"retry" env-prefix:"RETRY_"`
+		Keepalive     KeepaliveConfig `yaml:"keepalive" env-prefix:"KEEPALIVE_"`
+		// Weight is the share of Host among Targets. Without Targets all
+		// calls go to Host.
+		Weight  int            `env:"WEIGHT" yaml:"weight" env-default:"100"`
+		Targets []TargetConfig `yaml:"targets"`
+		Shadow  ShadowConfig   `yaml:"shadow" env-prefix:"SHADOW_"`
+	}
+
+	// TargetConfig is another deployment of the service, e.g. a canary. It
+	// gets calls in proportion to its weight; canary targets also get every
+	// request with the X-Canary: true header, even with a zero weight. All
+	// other settings are shared with Host.
+	TargetConfig struct {
+		Name   string `yaml:"name"`
+		Host   string `yaml:"host"`
+		Weight int    `yaml:"weight"`
+		Canary bool   `yaml:"canary"`
+	}
+
+	// ShadowConfig mirrors the idempotent unary calls, the ones whose method
+	// name starts with one of MethodPrefixes, to Host. The copies are sent
+	// after the primary call returns, their responses are only compared with
+	// the primary ones.
+	ShadowConfig struct {
+		Host           string        `env:"HOST" yaml:"host"`
+		MethodPrefixes []string      `env:"METHOD_PREFIXES" yaml:"method_prefixes" env-default:"Get,List"`
+		Timeout        time.Duration `env:"TIMEOUT" yaml:"timeout" env-default:"2s"`
+	}
+
+	// ClientTLS sets the CA that signs the service certificate. CertFile and
+	// KeyFile are only needed when the service requires client certificates.
+	ClientTLS struct {
+		Insecure   bool   `env:"INSECURE" yaml:"insecure"`
+		CAFile     string `env:"CA_FILE" yaml:"ca_file" env-default:"/app/x509/ca-cert.pem"`
+		CertFile   string `env:"CERT_FILE" yaml:"cert_file"`
+		KeyFile    string `env:"KEY_FILE" yaml:"key_file"`
+		ServerName string `env:"SERVER_NAME" yaml:"server_name"`
+	}
+
+	// BreakerConfig opens the circuit when at least MinRequests calls were
+	// made within Interval and FailureRatio of them failed. After OpenTimeout
+	// MaxRequests trial calls are let through.
+	BreakerConfig struct {
+		MinRequests  uint32        `env:"MIN_REQUESTS" yaml:"min_requests" env-default:"10"`
+		FailureRatio float64       `env:"FAILURE_RATIO" yaml:"failure_ratio" env-default:"0.6"`
+		Interval     time.Duration `env:"INTERVAL" yaml:"interval" env-default:"60s"`
+		OpenTimeout  time.Duration `env:"OPEN_TIMEOUT" yaml:"open_timeout" env-default:"10s"`
+		MaxRequests  uint32        `env:"MAX_REQUESTS" yaml:"max_requests" env-default:"5"`
+	}
+
+	// RetryConfig retries the idempotent calls, the ones whose method name
+	// starts with one of MethodPrefixes. MaxAttempts counts the first call, 1
+	// disables retries. Every call adds Budget.Ratio tokens to the budget of
+	// the client and every retry or hedge takes one, so during an outage the
+	// retries add at most Ratio of the traffic.
+	RetryConfig struct {
+		MaxAttempts       int               `env:"MAX_ATTEMPTS" yaml:"max_attempts" env-default:"3"`
+		InitialBackoff    time.Duration     `env:"INITIAL_BACKOFF" yaml:"initial_backoff" env-default:"100ms"`
+		MaxBackoff        time.Duration     `env:"MAX_BACKOFF" yaml:"max_backoff" env-default:"1s"`
+		BackoffMultiplier float64           `env:"BACKOFF_MULTIPLIER" yaml:"backoff_multiplier" env-default:"2"`
+		RetryableCodes    []string          `env:"RETRYABLE_CODES" yaml:"retryable_codes" env-default:"UNAVAILABLE,RESOURCE_EXHAUSTED"`
+		MethodPrefixes    []string          `env:"METHOD_PREFIXES" yaml:"method_prefixes" env-default:"Get,List"`
+		Budget            RetryBudgetConfig `yaml:"budget" env-prefix:"BUDGET_"`
+		Hedge             HedgeConfig       `yaml:"hedge" env-prefix:"HEDGE_"`
+	}
+
+	RetryBudgetConfig struct {
+		Ratio     float64 `env:"RATIO" yaml:"ratio" env-default:"0.1"`
+		MaxTokens float64 `env:"MAX_TOKENS" yaml:"max_tokens" env-default:"10"`
+	}
+
+	// HedgeConfig sends a second copy of an idempotent call when the first
+	// one has not answered within the Percentile latency of the method, but
+	// not earlier than MinDelay. The first answer wins.
+	HedgeConfig struct {
+		Enabled    bool          `env:"ENABLED" yaml:"enabled"`
+		Percentile float64       `env:"PERCENTILE" yaml:"percentile" env-default:"0.95"`
+		MinDelay   time.Duration `env:"MIN_DELAY" yaml:"min_delay" env-default:"10ms"`
+	}
+
+	// KeepaliveConfig must stay within the keepalive enforcement policy of
+	// the services, which reject pings without active streams.
+	KeepaliveConfig struct {
+		Time                time.Duration `env:"TIME" yaml:"time" env-default:"10s"`
+		Timeout             time.Duration `env:"TIMEOUT" yaml:"timeout" env-default:"1s"`
+		PermitWithoutStream bool          `env:"PERMIT_WITHOUT_STREAM" yaml:"permit_without_stream"`
 	}
 )
 
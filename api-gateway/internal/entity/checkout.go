@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SagaStatus string
+
+const (
+	SagaRunning      SagaStatus = "running"
+	SagaCompensating SagaStatus = "compensating"
+	SagaCompleted    SagaStatus = "completed"
+	SagaFailed       SagaStatus = "failed"
+)
+
+type CheckoutStep string
+
+const (
+	StepCreateOrder      CheckoutStep = "create_order"
+	StepReserveStock     CheckoutStep = "reserve_stock"
+	StepAddItems         CheckoutStep = "add_items"
+	StepAuthorizePayment CheckoutStep = "authorize_payment"
+	StepCommitStock      CheckoutStep = "commit_stock"
+	StepConfirmOrder     CheckoutStep = "confirm_order"
+)
+
+// CheckoutSteps is the order in which a checkout saga runs its steps.
+// Compensation walks the completed steps in reverse. The order is created
+// first because product-service keys stock reservations by order.
+var CheckoutSteps = []CheckoutStep{
+	StepCreateOrder,
+	StepReserveStock,
+	StepAddItems,
+	StepAuthorizePayment,
+	StepCommitStock,
+	StepConfirmOrder,
+}
+
+type CheckoutInput struct {
+	// UserID is the authenticated caller; it is never read from the body.
+	UserID        uuid.UUID      `json:"-"`
+	PaymentMethod string         `json:"payment_method"`
+	Items         []CheckoutItem `json:"items"`
+}
+
+type CheckoutItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	// VariantID selects a SKU of the product. Stock and price are then taken
+	// from the variant.
+	VariantID *uuid.UUID `json:"variant_id,omitempty"`
+	Quantity  int64      `json:"quantity"`
+	// Price is captured from the stock reservation.
+	Price    int64 `json:"price,omitempty"`
+	Reserved bool  `json:"reserved,omitempty"`
+	Added    bool  `json:"added,omitempty"`
+}
+
+// CheckoutSaga is the persisted state of a single checkout. It is saved after
+// every state change so that a restarted gateway can resume it.
+type CheckoutSaga struct {
+	ID             uuid.UUID      `json:"id"`
+	UserID         uuid.UUID      `json:"user_id"`
+	PaymentMethod  string         `json:"payment_method"`
+	Items          []CheckoutItem `json:"items"`
+	OrderID        uuid.UUID      `json:"order_id,omitempty"`
+	Amount         int64          `json:"amount"`
+	Status         SagaStatus     `json:"status"`
+	CompletedSteps []CheckoutStep `json:"completed_steps"`
+	Error          string         `json:"error,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+func (s *CheckoutSaga) NextStep() (CheckoutStep, bool) {
+	if len(s.CompletedSteps) >= len(CheckoutSteps) {
+		return "", false
+	}
+
+	return CheckoutSteps[len(s.CompletedSteps)], true
+}
+
+func (s *CheckoutSaga) Finished() bool {
+	return s.Status == SagaCompleted || s.Status == SagaFailed
+}
@@ -0,0 +1,11 @@
+package entity
+
+import "errors"
+
+var (
+	ErrInvalidCheckout = errors.New("invalid checkout request")
+	ErrSagaNotFound    = errors.New("checkout saga not found")
+	// ErrStreamTicketNotFound is returned for stream tickets that were never
+	// issued, have expired or were already used.
+	ErrStreamTicketNotFound = errors.New("stream ticket not found")
+)
@@ -0,0 +1,21 @@
+package entity
+
+import "net/http"
+
+type IdempotencyState string
+
+const (
+	IdempotencyInProgress IdempotencyState = "in_progress"
+	IdempotencyCompleted  IdempotencyState = "completed"
+)
+
+// IdempotentResponse is what the gateway remembers for an Idempotency-Key:
+// the request fingerprint and, once the first request has finished, the
+// response that is replayed to retries.
+type IdempotentResponse struct {
+	Fingerprint string           `json:"fingerprint"`
+	State       IdempotencyState `json:"state"`
+	StatusCode  int              `json:"status_code,omitempty"`
+	Header      http.Header      `json:"header,omitempty"`
+	Body        []byte           `json:"body,omitempty"`
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Money is an amount in the minor units of its currency, e.g. kopecks, with
+// the decimal form for display.
+type Money struct {
+	Currency   string `json:"currency"`
+	MinorUnits int64  `json:"minor_units"`
+	Amount     string `json:"amount"`
+}
+
+// NewMoney formats minor units of a currency with exponent digits after the
+// decimal point.
+func NewMoney(minorUnits int64, currency string, exponent int) Money {
+	sign := ""
+	units := minorUnits
+	if units < 0 {
+		sign, units = "-", -units
+	}
+
+	amount := fmt.Sprintf("%0*d", exponent+1, units)
+	if exponent > 0 {
+		amount = amount[:len(amount)-exponent] + "." + amount[len(amount)-exponent:]
+	}
+
+	return Money{Currency: currency, MinorUnits: minorUnits, Amount: sign + amount}
+}
+
+// OrderV2 is an order in the v2 order API. It has no user ID, the API only
+// serves the caller's own orders.
+type OrderV2 struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	Total     Money     `json:"total"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OrderItemV2 struct {
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id,omitempty"`
+	Quantity  int64  `json:"quantity"`
+	Price     Money  `json:"price"`
+	Subtotal  Money  `json:"subtotal"`
+}
+
+type OrderPageV2 struct {
+	Orders        []OrderV2 `json:"orders"`
+	NextPageToken string    `json:"next_page_token,omitempty"`
+}
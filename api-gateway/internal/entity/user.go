@@ -11,6 +11,9 @@ type UserRole string
 const (
 	Admin  UserRole = "admin"
 	Client UserRole = "client"
+	// System is the gateway calling the services on its own behalf. No access
+	// token carries it.
+	System UserRole = "system"
 )
 
 type User struct {
@@ -30,3 +33,32 @@ type UserSignUpInput struct {
 	Password        string
 	PasswordConfirm string
 }
+
+// SignUpResult is the response of the sign up with profile aggregation: the
+// tokens issued by auth-service for the new user.
+type SignUpResult struct {
+	UserID       uuid.UUID `json:"user_id"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+}
+
+// PendingProfile is a profile whose creation got no answer during a sign up.
+// Until it is known whether user-service created it, the auth account of the
+// user is neither kept nor deleted.
+type PendingProfile struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+}
+
+// Principal is the caller of a gateway request, taken from the access token
+// issued by auth-service.
+type Principal struct {
+	UserID   uuid.UUID
+	DeviceID uuid.UUID
+	Role     UserRole
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.Role == Admin
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	rds "github.com/redis/go-redis/v9"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/cache"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/storage/redis"
+)
+
+const idempotencyKeyPrefix = "idempotency"
+
+type IdempotencyRepository struct {
+	rdb *redis.Redis
+}
+
+func NewIdempotencyRepository(rdb *redis.Redis) *IdempotencyRepository {
+	return &IdempotencyRepository{rdb: rdb}
+}
+
+// Acquire stores an in-progress record for key unless one already exists.
+// It returns the existing record and false when the key has been seen before.
+func (r *IdempotencyRepository) Acquire(ctx context.Context, key, fingerprint string, ttl time.Duration) (*entity.IdempotentResponse, bool, error) {
+	const op = "repository.idempotency.Acquire"
+
+	data, err := cache.Serialize(entity.IdempotentResponse{
+		Fingerprint: fingerprint,
+		State:       entity.IdempotencyInProgress,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	redisKey := cache.GenerateCacheKey(idempotencyKeyPrefix, key)
+	ok, err := r.rdb.Client.SetNX(ctx, redisKey, data, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+	if ok {
+		return nil, true, nil
+	}
+
+	raw, err := r.rdb.Client.Get(ctx, redisKey).Bytes()
+	if err != nil {
+		if errors.Is(err, rds.Nil) {
+			// The record expired between SETNX and GET; try once more.
+			return r.Acquire(ctx, key, fingerprint, ttl)
+		}
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var existing entity.IdempotentResponse
+	if err = cache.Deserialize(raw, &existing); err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &existing, false, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, resp *entity.IdempotentResponse, ttl time.Duration) error {
+	const op = "repository.idempotency.Complete"
+
+	data, err := cache.Serialize(resp)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = r.rdb.Client.Set(ctx, cache.GenerateCacheKey(idempotencyKeyPrefix, key), data, ttl).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	const op = "repository.idempotency.Release"
+
+	if err := r.rdb.Client.Del(ctx, cache.GenerateCacheKey(idempotencyKeyPrefix, key)).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
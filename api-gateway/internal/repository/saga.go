@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	rds "github.com/redis/go-redis/v9"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/cache"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/storage/redis"
+)
+
+const (
+	sagaKeyPrefix   = "checkout:saga"
+	sagaLeasePrefix = "checkout:lease"
+	activeSagaKey   = "checkout:active"
+)
+
+// leaseScript sets the lease to its owner unless someone else holds it.
+var leaseScript = rds.NewScript(`
+local owner = redis.call("GET", KEYS[1])
+if owner and owner ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+// releaseLeaseScript deletes the lease if it is still held by its owner.
+var releaseLeaseScript = rds.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type SagaRepository struct {
+	rdb *redis.Redis
+}
+
+func NewSagaRepository(rdb *redis.Redis) *SagaRepository {
+	return &SagaRepository{rdb: rdb}
+}
+
+// Save stores the saga and keeps the set of unfinished sagas in sync, so that
+// ListActive returns everything that has to be resumed after a restart.
+func (r *SagaRepository) Save(ctx context.Context, saga *entity.CheckoutSaga) error {
+	const op = "repository.saga.Save"
+
+	data, err := cache.Serialize(saga)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = r.rdb.Client.TxPipelined(ctx, func(pipe rds.Pipeliner) error {
+		pipe.Set(ctx, cache.GenerateCacheKey(sagaKeyPrefix, saga.ID), data, 0)
+		if saga.Finished() {
+			pipe.SRem(ctx, activeSagaKey, saga.ID.String())
+		} else {
+			pipe.SAdd(ctx, activeSagaKey, saga.ID.String())
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SagaRepository) Get(ctx context.Context, id uuid.UUID) (*entity.CheckoutSaga, error) {
+	const op = "repository.saga.Get"
+
+	data, err := r.rdb.Client.Get(ctx, cache.GenerateCacheKey(sagaKeyPrefix, id)).Bytes()
+	if err != nil {
+		if errors.Is(err, rds.Nil) {
+			return nil, entity.ErrSagaNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var saga entity.CheckoutSaga
+	if err = cache.Deserialize(data, &saga); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &saga, nil
+}
+
+func (r *SagaRepository) ListActive(ctx context.Context) ([]*entity.CheckoutSaga, error) {
+	const op = "repository.saga.ListActive"
+
+	ids, err := r.rdb.Client.SMembers(ctx, activeSagaKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sagas := make([]*entity.CheckoutSaga, 0, len(ids))
+	for _, rawID := range ids {
+		id, err := uuid.Parse(rawID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		saga, err := r.Get(ctx, id)
+		if err != nil {
+			if errors.Is(err, entity.ErrSagaNotFound) {
+				r.rdb.Client.SRem(ctx, activeSagaKey, rawID)
+				continue
+			}
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		sagas = append(sagas, saga)
+	}
+
+	return sagas, nil
+}
+
+func (r *SagaRepository) Lease(ctx context.Context, id uuid.UUID, owner string, ttl time.Duration) (bool, error) {
+	const op = "repository.saga.Lease"
+
+	taken, err := leaseScript.Run(ctx, r.rdb.Client, []string{cache.GenerateCacheKey(sagaLeasePrefix, id)}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return taken == 1, nil
+}
+
+func (r *SagaRepository) ReleaseLease(ctx context.Context, id uuid.UUID, owner string) error {
+	const op = "repository.saga.ReleaseLease"
+
+	if err := releaseLeaseScript.Run(ctx, r.rdb.Client, []string{cache.GenerateCacheKey(sagaLeasePrefix, id)}, owner).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
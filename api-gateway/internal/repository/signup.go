@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/cache"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/storage/redis"
+)
+
+const (
+	// orphanedUsersKey is the set of users whose sign up failed after the
+	// auth account was created and whose account could not be deleted yet.
+	orphanedUsersKey = "signup:orphaned"
+	// pendingProfilesKey maps the users whose profile may or may not have
+	// been created to that profile.
+	pendingProfilesKey = "signup:pending"
+)
+
+type SignUpRepository struct {
+	rdb *redis.Redis
+}
+
+func NewSignUpRepository(rdb *redis.Redis) *SignUpRepository {
+	return &SignUpRepository{rdb: rdb}
+}
+
+func (r *SignUpRepository) AddOrphaned(ctx context.Context, userID uuid.UUID) error {
+	const op = "repository.signup.AddOrphaned"
+
+	if err := r.rdb.Client.SAdd(ctx, orphanedUsersKey, userID.String()).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SignUpRepository) RemoveOrphaned(ctx context.Context, userID uuid.UUID) error {
+	const op = "repository.signup.RemoveOrphaned"
+
+	if err := r.rdb.Client.SRem(ctx, orphanedUsersKey, userID.String()).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SignUpRepository) ListOrphaned(ctx context.Context) ([]uuid.UUID, error) {
+	const op = "repository.signup.ListOrphaned"
+
+	rawIDs, err := r.rdb.Client.SMembers(ctx, orphanedUsersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(rawIDs))
+	for _, rawID := range rawIDs {
+		id, err := uuid.Parse(rawID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (r *SignUpRepository) AddPending(ctx context.Context, profile *entity.PendingProfile) error {
+	const op = "repository.signup.AddPending"
+
+	data, err := cache.Serialize(profile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := r.rdb.Client.HSet(ctx, pendingProfilesKey, profile.UserID.String(), data).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SignUpRepository) RemovePending(ctx context.Context, userID uuid.UUID) error {
+	const op = "repository.signup.RemovePending"
+
+	if err := r.rdb.Client.HDel(ctx, pendingProfilesKey, userID.String()).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SignUpRepository) ListPending(ctx context.Context) ([]*entity.PendingProfile, error) {
+	const op = "repository.signup.ListPending"
+
+	values, err := r.rdb.Client.HVals(ctx, pendingProfilesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	profiles := make([]*entity.PendingProfile, 0, len(values))
+	for _, value := range values {
+		var profile entity.PendingProfile
+		if err := cache.Deserialize([]byte(value), &profile); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		profiles = append(profiles, &profile)
+	}
+
+	return profiles, nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	rds "github.com/redis/go-redis/v9"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/cache"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/storage/redis"
+)
+
+const streamTicketKeyPrefix = "stream-ticket"
+
+// StreamTicketRepository keeps the tickets that open an order events stream
+// in place of the access token.
+type StreamTicketRepository struct {
+	rdb *redis.Redis
+}
+
+func NewStreamTicketRepository(rdb *redis.Redis) *StreamTicketRepository {
+	return &StreamTicketRepository{rdb: rdb}
+}
+
+func (r *StreamTicketRepository) Issue(ctx context.Context, ticket string, principal *entity.Principal, ttl time.Duration) error {
+	const op = "repository.streamTicket.Issue"
+
+	data, err := cache.Serialize(principal)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = r.rdb.Client.Set(ctx, cache.GenerateCacheKey(streamTicketKeyPrefix, ticket), data, ttl).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Redeem returns the caller the ticket was issued to and deletes it, so a
+// ticket opens a single stream.
+func (r *StreamTicketRepository) Redeem(ctx context.Context, ticket string) (*entity.Principal, error) {
+	const op = "repository.streamTicket.Redeem"
+
+	raw, err := r.rdb.Client.GetDel(ctx, cache.GenerateCacheKey(streamTicketKeyPrefix, ticket)).Bytes()
+	if err != nil {
+		if errors.Is(err, rds.Nil) {
+			return nil, fmt.Errorf("%s: %w", op, entity.ErrStreamTicketNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var principal entity.Principal
+	if err = cache.Deserialize(raw, &principal); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &principal, nil
+}
@@ -2,74 +2,226 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
-	authClient "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/grpc/clients/rbacAuth"
-	userClient "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/grpc/clients/user"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 type AuthService interface {
-	SignUp(ctx context.Context, input entity.UserSignUpInput) (uuid.UUID, entity.Tokens, error)
+	SignUp(ctx context.Context, input entity.UserSignUpInput) (entity.Tokens, error)
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
 }
 
 type UserService interface {
-	GetUserProfile(ctx context.Context, userID uuid.UUID) (entity.User, error)
+	CreateProfile(ctx context.Context, userID uuid.UUID, username, firstname, middlename, lastname, phoneNumber, email string) error
+}
+
+type TokenParser interface {
+	Parse(accessToken string) (*entity.Principal, error)
+}
+
+// SignUpRepository keeps the users whose sign up has to be rolled back and
+// those whose profile may not have been created.
+type SignUpRepository interface {
+	AddOrphaned(ctx context.Context, userID uuid.UUID) error
+	RemoveOrphaned(ctx context.Context, userID uuid.UUID) error
+	ListOrphaned(ctx context.Context) ([]uuid.UUID, error)
+	AddPending(ctx context.Context, profile *entity.PendingProfile) error
+	RemovePending(ctx context.Context, userID uuid.UUID) error
+	ListPending(ctx context.Context) ([]*entity.PendingProfile, error)
 }
 
 type AggregatorService struct {
-	authService *authClient.AuthService
-	userService *userClient.UserService
+	authService AuthService
+	userService UserService
+	tokens      TokenParser
+	signUps     SignUpRepository
 	log         *logger.Logger
 }
 
-func NewAggregatorService(authService *authClient.AuthService, userService *userClient.UserService, log *logger.Logger) *AggregatorService {
+func NewAggregatorService(authService AuthService, userService UserService, tokens TokenParser, signUps SignUpRepository, log *logger.Logger) *AggregatorService {
 	return &AggregatorService{
 		authService: authService,
 		userService: userService,
+		tokens:      tokens,
+		signUps:     signUps,
 		log:         log,
 	}
 }
 
-func (as *AggregatorService) SignUpUser(ctx context.Context, input entity.UserSignUpInput) (map[string]interface{}, error) {
-	userID, _, errCreateNewUser := as.authService.SignUp(ctx, input)
+// SignUpUser creates the auth account and the profile of a new user. For the
+// client it is all or nothing: when user-service refuses the profile, the
+// account is deleted again, so the sign up can simply be retried. When it
+// doesn't answer, the profile may exist all the same: the request is sent
+// once more, and if that is left unanswered too the profile is settled later
+// by ResumeRollbacks.
+func (as *AggregatorService) SignUpUser(ctx context.Context, input entity.UserSignUpInput) (*entity.SignUpResult, error) {
+	tokens, errCreateNewUser := as.authService.SignUp(ctx, input)
 	if errCreateNewUser != nil {
-		stat := status.Convert(errCreateNewUser)
-		for _, detail := range stat.Details() {
-			switch errType := detail.(type) {
-			case *errdetails.BadRequest:
-				for _, violation := range errType.GetFieldViolations() {
-					as.log.Error("The field %s has invalid value. desc: %v",
-						violation.GetField(), violation.GetDescription())
-				}
-			}
-		}
+		as.logViolations(errCreateNewUser)
 		return nil, errCreateNewUser
 	}
 
-	as.log.Info("Profile created successfully")
-
-	createUserProfileResponse, errCreateUserProfile := as.userService.CreateProfile(ctx, userID, input.Username, "", "", "", "", input.Email)
-	if errCreateUserProfile != nil {
-		stat := status.Convert(errCreateUserProfile)
-		for _, detail := range stat.Details() {
-			switch errType := detail.(type) {
-			case *errdetails.BadRequest:
-				for _, violation := range errType.GetFieldViolations() {
-					as.log.Error("The field %s has invalid value. desc: %v",
-						violation.GetField(), violation.GetDescription())
-				}
-			}
+	principal, err := as.tokens.Parse(tokens.AccessToken)
+	if err != nil {
+		// Without the user id the account cannot be rolled back either.
+		as.log.Error("failed to parse access token of the new user", "username", input.Username, logger.Err(err))
+		return nil, status.Error(codes.Internal, "failed to sign up user")
+	}
+	userID := principal.UserID
+
+	profile := &entity.PendingProfile{UserID: userID, Username: input.Username, Email: input.Email}
+	errCreateUserProfile := as.createProfile(ctx, profile)
+	if isAmbiguous(errCreateUserProfile) {
+		errCreateUserProfile = as.createProfile(ctx, profile)
+	}
+	switch {
+	case isAmbiguous(errCreateUserProfile):
+		as.log.Warn("profile of the new user is unsettled", "user_id", userID, logger.Err(errCreateUserProfile))
+		if err := as.signUps.AddPending(context.WithoutCancel(ctx), profile); err != nil {
+			as.log.Error("failed to remember pending profile", "user_id", userID, logger.Err(err))
 		}
 		return nil, errCreateUserProfile
+	case errCreateUserProfile != nil:
+		as.logViolations(errCreateUserProfile)
+		as.rollbackSignUp(context.WithoutCancel(ctx), userID)
+		return nil, errCreateUserProfile
 	}
 
-	// Агрегируем данные
-	return map[string]interface{}{
-		"user_id":      userID,
-		"user_profile": createUserProfileResponse,
+	as.log.Info("Profile created successfully", "user_id", userID)
+
+	return &entity.SignUpResult{
+		UserID:       userID,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}, nil
 }
+
+// ResumeRollbacks settles the sign ups left unfinished: it creates the
+// pending profiles again, keeping the accounts whose profile exists and
+// deleting those user-service refuses, and deletes the accounts that could
+// not be deleted when their sign up failed.
+func (as *AggregatorService) ResumeRollbacks(ctx context.Context) error {
+	const op = "service.aggregator.ResumeRollbacks"
+
+	profiles, err := as.signUps.ListPending(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, profile := range profiles {
+		err := as.createProfile(ctx, profile)
+		if isAmbiguous(err) {
+			as.log.Warn("failed to settle profile", "user_id", profile.UserID, logger.Err(err))
+			continue
+		}
+
+		if err != nil {
+			as.log.Warn("profile of the new user refused", "user_id", profile.UserID, logger.Err(err))
+			if err := as.signUps.AddOrphaned(ctx, profile.UserID); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		if err := as.signUps.RemovePending(ctx, profile.UserID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	userIDs, err := as.signUps.ListOrphaned(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, userID := range userIDs {
+		if err := as.authService.DeleteUser(ctx, userID); err != nil {
+			as.log.Warn("failed to roll back sign up", "user_id", userID, logger.Err(err))
+			continue
+		}
+
+		if err := as.signUps.RemoveOrphaned(ctx, userID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		as.log.Info("sign up rolled back", "user_id", userID)
+	}
+
+	return nil
+}
+
+// RunRollbacks calls ResumeRollbacks every interval until ctx is done.
+func (as *AggregatorService) RunRollbacks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := as.ResumeRollbacks(ctx); err != nil {
+			as.log.Error("failed to resume sign up rollbacks", logger.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// createProfile creates the profile of a new user. A profile that already
+// exists was created by an earlier attempt whose answer was lost.
+func (as *AggregatorService) createProfile(ctx context.Context, profile *entity.PendingProfile) error {
+	err := as.userService.CreateProfile(ctx, profile.UserID, profile.Username, "", "", "", "", profile.Email)
+	if status.Code(err) == codes.AlreadyExists {
+		return nil
+	}
+
+	return err
+}
+
+// isAmbiguous reports whether err leaves it unknown if user-service carried
+// out the request.
+func isAmbiguous(err error) bool {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.Unavailable, codes.Canceled:
+		return true
+	default:
+		return false
+	}
+}
+
+// rollbackSignUp deletes the auth account of a failed sign up. If auth-service
+// does not answer, the account is remembered and deleted by ResumeRollbacks.
+func (as *AggregatorService) rollbackSignUp(ctx context.Context, userID uuid.UUID) {
+	var err error
+	for attempt := 1; attempt <= compensationAttempts; attempt++ {
+		if err = as.authService.DeleteUser(ctx, userID); err == nil {
+			as.log.Info("sign up rolled back", "user_id", userID)
+			return
+		}
+
+		as.log.Warn("failed to roll back sign up", "user_id", userID, "attempt", attempt, logger.Err(err))
+		if attempt < compensationAttempts {
+			time.Sleep(compensationBackoff * time.Duration(attempt))
+		}
+	}
+
+	if err := as.signUps.AddOrphaned(ctx, userID); err != nil {
+		as.log.Error("failed to remember orphaned user", "user_id", userID, logger.Err(err))
+	}
+}
+
+func (as *AggregatorService) logViolations(err error) {
+	stat := status.Convert(err)
+	for _, detail := range stat.Details() {
+		switch errType := detail.(type) {
+		case *errdetails.BadRequest:
+			for _, violation := range errType.GetFieldViolations() {
+				as.log.Error("invalid field value", "field", violation.GetField(), "desc", violation.GetDescription())
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeAuth struct {
+	userID    uuid.UUID
+	deleteErr error
+	deleted   []uuid.UUID
+}
+
+func (f *fakeAuth) SignUp(context.Context, entity.UserSignUpInput) (entity.Tokens, error) {
+	return entity.Tokens{AccessToken: f.userID.String(), RefreshToken: "refresh"}, nil
+}
+
+func (f *fakeAuth) DeleteUser(_ context.Context, userID uuid.UUID) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	f.deleted = append(f.deleted, userID)
+	return nil
+}
+
+// fakeProfiles answers the calls to CreateProfile with errs in turn, the last
+// one over and over.
+type fakeProfiles struct {
+	errs  []error
+	calls int
+}
+
+func (f *fakeProfiles) CreateProfile(context.Context, uuid.UUID, string, string, string, string, string, string) error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	if len(f.errs) > 1 {
+		f.errs = f.errs[1:]
+	}
+	return err
+}
+
+// fakeTokens treats the access token as the user id.
+type fakeTokens struct{}
+
+func (fakeTokens) Parse(accessToken string) (*entity.Principal, error) {
+	userID, err := uuid.Parse(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.Principal{UserID: userID, Role: entity.Client}, nil
+}
+
+type fakeSignUps struct {
+	orphaned map[uuid.UUID]bool
+	pending  map[uuid.UUID]*entity.PendingProfile
+}
+
+func (f *fakeSignUps) AddOrphaned(_ context.Context, userID uuid.UUID) error {
+	f.orphaned[userID] = true
+	return nil
+}
+
+func (f *fakeSignUps) RemoveOrphaned(_ context.Context, userID uuid.UUID) error {
+	delete(f.orphaned, userID)
+	return nil
+}
+
+func (f *fakeSignUps) ListOrphaned(context.Context) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(f.orphaned))
+	for id := range f.orphaned {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (f *fakeSignUps) AddPending(_ context.Context, profile *entity.PendingProfile) error {
+	f.pending[profile.UserID] = profile
+	return nil
+}
+
+func (f *fakeSignUps) RemovePending(_ context.Context, userID uuid.UUID) error {
+	delete(f.pending, userID)
+	return nil
+}
+
+func (f *fakeSignUps) ListPending(context.Context) ([]*entity.PendingProfile, error) {
+	profiles := make([]*entity.PendingProfile, 0, len(f.pending))
+	for _, profile := range f.pending {
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+func TestAggregatorService_SignUpUser(t *testing.T) {
+	input := entity.UserSignUpInput{Username: "user", Email: "user@example.com", Password: "password", PasswordConfirm: "password"}
+
+	setup := func(profileErrs ...error) (*AggregatorService, *fakeAuth, *fakeProfiles, *fakeSignUps) {
+		authService := &fakeAuth{userID: uuid.New()}
+		profiles := &fakeProfiles{errs: profileErrs}
+		signUps := &fakeSignUps{orphaned: map[uuid.UUID]bool{}, pending: map[uuid.UUID]*entity.PendingProfile{}}
+		as := NewAggregatorService(authService, profiles, fakeTokens{}, signUps, logger.New("local", nil))
+		return as, authService, profiles, signUps
+	}
+
+	t.Run("returns the tokens", func(t *testing.T) {
+		as, authService, _, _ := setup()
+
+		result, err := as.SignUpUser(context.Background(), input)
+		require.NoError(t, err)
+
+		assert.Equal(t, &entity.SignUpResult{
+			UserID:       authService.userID,
+			AccessToken:  authService.userID.String(),
+			RefreshToken: "refresh",
+		}, result)
+		assert.Empty(t, authService.deleted)
+	})
+
+	t.Run("deletes the account when the profile fails", func(t *testing.T) {
+		as, authService, _, signUps := setup(status.Error(codes.InvalidArgument, "invalid profile"))
+
+		_, err := as.SignUpUser(context.Background(), input)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, []uuid.UUID{authService.userID}, authService.deleted)
+		assert.Empty(t, signUps.orphaned)
+	})
+
+	t.Run("remembers accounts it cannot delete", func(t *testing.T) {
+		as, authService, _, signUps := setup(status.Error(codes.Internal, "failed to create user profile"))
+		authService.deleteErr = status.Error(codes.Unavailable, "auth service is down")
+
+		_, err := as.SignUpUser(context.Background(), input)
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.True(t, signUps.orphaned[authService.userID])
+
+		authService.deleteErr = nil
+		require.NoError(t, as.ResumeRollbacks(context.Background()))
+
+		assert.Equal(t, []uuid.UUID{authService.userID}, authService.deleted)
+		assert.Empty(t, signUps.orphaned)
+	})
+	t.Run("keeps the account when the lost profile turns out to exist", func(t *testing.T) {
+		as, authService, profiles, signUps := setup(
+			status.Error(codes.DeadlineExceeded, "deadline exceeded"),
+			status.Error(codes.AlreadyExists, "profile already exists"),
+		)
+
+		result, err := as.SignUpUser(context.Background(), input)
+		require.NoError(t, err)
+
+		assert.Equal(t, authService.userID, result.UserID)
+		assert.Equal(t, 2, profiles.calls)
+		assert.Empty(t, authService.deleted)
+		assert.Empty(t, signUps.pending)
+	})
+
+	t.Run("settles unanswered profiles later", func(t *testing.T) {
+		as, authService, profiles, signUps := setup(status.Error(codes.Unavailable, "user service is down"))
+
+		_, err := as.SignUpUser(context.Background(), input)
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Empty(t, authService.deleted)
+		require.Contains(t, signUps.pending, authService.userID)
+
+		require.NoError(t, as.ResumeRollbacks(context.Background()))
+		assert.Contains(t, signUps.pending, authService.userID)
+
+		profiles.errs = []error{status.Error(codes.AlreadyExists, "profile already exists")}
+		require.NoError(t, as.ResumeRollbacks(context.Background()))
+
+		assert.Empty(t, signUps.pending)
+		assert.Empty(t, authService.deleted)
+	})
+
+	t.Run("deletes the account when the unanswered profile is refused", func(t *testing.T) {
+		as, authService, profiles, signUps := setup(status.Error(codes.Unavailable, "user service is down"))
+
+		_, err := as.SignUpUser(context.Background(), input)
+		require.Error(t, err)
+
+		profiles.errs = []error{status.Error(codes.InvalidArgument, "invalid profile")}
+		require.NoError(t, as.ResumeRollbacks(context.Background()))
+
+		assert.Empty(t, signUps.pending)
+		assert.Empty(t, signUps.orphaned)
+		assert.Equal(t, []uuid.UUID{authService.userID}, authService.deleted)
+	})
+}
@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+)
+
+const (
+	compensationAttempts = 3
+	compensationBackoff  = 500 * time.Millisecond
+	// sagaLeaseTTL bounds how long a saga stays taken by a gateway that died
+	// while running it. The lease is renewed before every step.
+	sagaLeaseTTL = time.Minute
+)
+
+type ProductStock interface {
+	ReserveStock(ctx context.Context, orderID uuid.UUID, items []*product.ReservationItem) (*product.Reservation, error)
+	ReleaseReservation(ctx context.Context, orderID uuid.UUID) error
+	CommitReservation(ctx context.Context, orderID uuid.UUID) error
+}
+
+type OrderManager interface {
+	CreateOrder(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+	AddItem(ctx context.Context, orderID, productID uuid.UUID, variantID *uuid.UUID, quantity, price int64) (uuid.UUID, error)
+	Confirm(ctx context.Context, userID, orderID uuid.UUID) error
+	CancelOrder(ctx context.Context, userID, orderID uuid.UUID) error
+}
+
+type PaymentProcessor interface {
+	Authorize(ctx context.Context, orderID uuid.UUID, amount int64, method string) error
+	Refund(ctx context.Context, orderID uuid.UUID, amount int64, method string) error
+}
+
+type SagaRepository interface {
+	Save(ctx context.Context, saga *entity.CheckoutSaga) error
+	Get(ctx context.Context, id uuid.UUID) (*entity.CheckoutSaga, error)
+	ListActive(ctx context.Context) ([]*entity.CheckoutSaga, error)
+	// Lease takes the saga for owner, or extends the lease owner already
+	// holds. It reports false while another owner holds it.
+	Lease(ctx context.Context, id uuid.UUID, owner string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, id uuid.UUID, owner string) error
+}
+
+// CheckoutService turns a cart into a paid order. Every checkout runs as a
+// saga: the steps in entity.CheckoutSteps are executed in order, the saga is
+// persisted after each change, and a failed step triggers the compensating
+// actions of everything that already ran. A saga is run by one gateway at a
+// time, the one holding its lease.
+type CheckoutService struct {
+	products ProductStock
+	orders   OrderManager
+	payments PaymentProcessor
+	sagas    SagaRepository
+	log      *logger.Logger
+
+	// owner identifies the leases of this gateway.
+	owner    string
+	stopping context.Context
+	stop     context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewCheckoutService(products ProductStock, orders OrderManager, payments PaymentProcessor, sagas SagaRepository, log *logger.Logger) *CheckoutService {
+	stopping, stop := context.WithCancel(context.Background())
+
+	return &CheckoutService{
+		products: products,
+		orders:   orders,
+		payments: payments,
+		sagas:    sagas,
+		log:      log,
+		owner:    uuid.NewString(),
+		stopping: stopping,
+		stop:     stop,
+	}
+}
+
+// Checkout validates the input, persists a new saga and starts it in the
+// background. The returned saga can be polled with Status.
+func (cs *CheckoutService) Checkout(ctx context.Context, input entity.CheckoutInput) (*entity.CheckoutSaga, error) {
+	const op = "service.checkout.Checkout"
+
+	if err := validateCheckout(input); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	saga := &entity.CheckoutSaga{
+		ID:             uuid.New(),
+		UserID:         input.UserID,
+		PaymentMethod:  input.PaymentMethod,
+		Items:          make([]entity.CheckoutItem, 0, len(input.Items)),
+		Status:         entity.SagaRunning,
+		CompletedSteps: []entity.CheckoutStep{},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	for _, item := range input.Items {
+		saga.Items = append(saga.Items, entity.CheckoutItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	if err := cs.sagas.Save(ctx, saga); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	snapshot := *saga
+	snapshot.Items = append([]entity.CheckoutItem(nil), saga.Items...)
+	snapshot.CompletedSteps = []entity.CheckoutStep{}
+	cs.start(context.WithoutCancel(ctx), saga)
+
+	return &snapshot, nil
+}
+
+func (cs *CheckoutService) Status(ctx context.Context, sagaID uuid.UUID) (*entity.CheckoutSaga, error) {
+	return cs.sagas.Get(ctx, sagaID)
+}
+
+// Resume restarts every saga that was still running or compensating when the
+// gateway stopped. Sagas another gateway holds the lease of are left to it.
+func (cs *CheckoutService) Resume(ctx context.Context) error {
+	const op = "service.checkout.Resume"
+
+	sagas, err := cs.sagas.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, saga := range sagas {
+		cs.log.Info("resuming checkout saga", "saga_id", saga.ID, "status", saga.Status)
+		cs.start(context.WithoutCancel(ctx), saga)
+	}
+
+	return nil
+}
+
+// Wait blocks until all sagas started by this service have stopped.
+func (cs *CheckoutService) Wait() {
+	cs.wg.Wait()
+}
+
+// Shutdown stops the sagas once their current step is done and waits for
+// them. The sagas are not compensated: they stay active for Resume.
+func (cs *CheckoutService) Shutdown() {
+	cs.stop()
+	cs.wg.Wait()
+}
+
+func (cs *CheckoutService) start(ctx context.Context, saga *entity.CheckoutSaga) {
+	cs.wg.Add(1)
+	go func() {
+		defer cs.wg.Done()
+
+		if !cs.lease(ctx, saga) {
+			return
+		}
+		defer func() {
+			if err := cs.sagas.ReleaseLease(ctx, saga.ID, cs.owner); err != nil {
+				cs.log.Warn("failed to release checkout saga", "saga_id", saga.ID, logger.Err(err))
+			}
+		}()
+
+		// Another gateway may have moved the saga on before the lease was
+		// taken.
+		current, err := cs.sagas.Get(ctx, saga.ID)
+		if err != nil {
+			cs.log.Error("failed to load checkout saga", "saga_id", saga.ID, logger.Err(err))
+			return
+		}
+		cs.run(ctx, current)
+	}()
+}
+
+// lease takes or renews the lease of the saga and reports whether the saga
+// may go on. It may not once the service is stopping.
+func (cs *CheckoutService) lease(ctx context.Context, saga *entity.CheckoutSaga) bool {
+	if cs.stopping.Err() != nil {
+		cs.log.Info("checkout saga interrupted", "saga_id", saga.ID, "status", saga.Status)
+		return false
+	}
+
+	ok, err := cs.sagas.Lease(ctx, saga.ID, cs.owner, sagaLeaseTTL)
+	if err != nil {
+		cs.log.Error("failed to lease checkout saga", "saga_id", saga.ID, logger.Err(err))
+		return false
+	}
+	if !ok {
+		cs.log.Info("checkout saga is run by another gateway", "saga_id", saga.ID)
+	}
+
+	return ok
+}
+
+func (cs *CheckoutService) run(ctx context.Context, saga *entity.CheckoutSaga) {
+	for saga.Status == entity.SagaRunning {
+		if !cs.lease(ctx, saga) {
+			return
+		}
+
+		step, ok := saga.NextStep()
+		if !ok {
+			saga.Status = entity.SagaCompleted
+			cs.save(ctx, saga)
+			cs.log.Info("checkout saga completed", "saga_id", saga.ID, "order_id", saga.OrderID)
+			return
+		}
+
+		if err := cs.execute(ctx, saga, step); err != nil {
+			cs.log.Warn("checkout step failed", "saga_id", saga.ID, "step", step, logger.Err(err))
+			saga.Status = entity.SagaCompensating
+			saga.Error = fmt.Sprintf("%s: %v", step, err)
+			cs.save(ctx, saga)
+			break
+		}
+
+		saga.CompletedSteps = append(saga.CompletedSteps, step)
+		cs.save(ctx, saga)
+	}
+
+	if saga.Status == entity.SagaCompensating {
+		cs.compensate(ctx, saga)
+	}
+}
+
+func (cs *CheckoutService) execute(ctx context.Context, saga *entity.CheckoutSaga, step entity.CheckoutStep) error {
+	switch step {
+	case entity.StepCreateOrder:
+		return cs.createOrder(ctx, saga)
+	case entity.StepReserveStock:
+		return cs.reserveStock(ctx, saga)
+	case entity.StepAddItems:
+		return cs.addItems(ctx, saga)
+	case entity.StepAuthorizePayment:
+		return cs.payments.Authorize(ctx, saga.OrderID, saga.Amount, saga.PaymentMethod)
+	case entity.StepCommitStock:
+		return cs.products.CommitReservation(ctx, saga.OrderID)
+	case entity.StepConfirmOrder:
+		return cs.orders.Confirm(ctx, saga.UserID, saga.OrderID)
+	default:
+		return fmt.Errorf("unknown checkout step %q", step)
+	}
+}
+
+func (cs *CheckoutService) createOrder(ctx context.Context, saga *entity.CheckoutSaga) error {
+	if saga.OrderID != uuid.Nil {
+		return nil
+	}
+
+	orderID, err := cs.orders.CreateOrder(ctx, saga.UserID)
+	if err != nil {
+		return err
+	}
+
+	saga.OrderID = orderID
+	return nil
+}
+
+// reserveStock reserves the stock of all items at once. product-service
+// returns the existing reservation of the order when the step is retried, so
+// the stock is only taken once.
+func (cs *CheckoutService) reserveStock(ctx context.Context, saga *entity.CheckoutSaga) error {
+	items := make([]*product.ReservationItem, 0, len(saga.Items))
+	for _, item := range saga.Items {
+		reserved := &product.ReservationItem{ProductId: item.ProductID.String(), Quantity: item.Quantity}
+		if item.VariantID != nil {
+			reserved.VariantId = item.VariantID.String()
+		}
+		items = append(items, reserved)
+	}
+
+	reservation, err := cs.products.ReserveStock(ctx, saga.OrderID, items)
+	if err != nil {
+		return err
+	}
+
+	prices := make(map[string]int64, len(reservation.GetItems()))
+	for _, item := range reservation.GetItems() {
+		prices[item.GetProductId()+"/"+item.GetVariantId()] = item.GetPrice()
+	}
+
+	saga.Amount = 0
+	for i := range saga.Items {
+		item := &saga.Items[i]
+		key := item.ProductID.String() + "/"
+		if item.VariantID != nil {
+			key += item.VariantID.String()
+		}
+
+		item.Price = prices[key]
+		item.Reserved = true
+		saga.Amount += item.Price * item.Quantity
+	}
+
+	return nil
+}
+
+func (cs *CheckoutService) addItems(ctx context.Context, saga *entity.CheckoutSaga) error {
+	for i := range saga.Items {
+		item := &saga.Items[i]
+		if item.Added {
+			continue
+		}
+
+		if _, err := cs.orders.AddItem(ctx, saga.OrderID, item.ProductID, item.VariantID, item.Quantity, item.Price); err != nil {
+			return err
+		}
+
+		item.Added = true
+		cs.save(ctx, saga)
+	}
+
+	return nil
+}
+
+// compensate undoes the completed steps in reverse order. The step that
+// failed is compensated as well, because it may have been applied partially;
+// its compensation only touches the parts recorded in the saga.
+func (cs *CheckoutService) compensate(ctx context.Context, saga *entity.CheckoutSaga) {
+	last := len(saga.CompletedSteps)
+	if last == len(entity.CheckoutSteps) {
+		last--
+	}
+
+	for i := last; i >= 0; i-- {
+		if !cs.lease(ctx, saga) {
+			return
+		}
+
+		step := entity.CheckoutSteps[i]
+		completed := i < len(saga.CompletedSteps)
+
+		var err error
+		for attempt := 1; attempt <= compensationAttempts; attempt++ {
+			if err = cs.undo(ctx, saga, step, completed); err == nil {
+				break
+			}
+
+			cs.log.Warn("checkout compensation failed", "saga_id", saga.ID, "step", step, "attempt", attempt, logger.Err(err))
+			time.Sleep(compensationBackoff * time.Duration(attempt))
+		}
+		if err != nil {
+			// The saga stays in the compensating state and is retried on the
+			// next Resume.
+			cs.log.Error("checkout compensation gave up", "saga_id", saga.ID, "step", step, logger.Err(err))
+			return
+		}
+
+		if completed {
+			saga.CompletedSteps = saga.CompletedSteps[:i]
+		}
+		cs.save(ctx, saga)
+	}
+
+	saga.Status = entity.SagaFailed
+	cs.save(ctx, saga)
+	cs.log.Info("checkout saga compensated", "saga_id", saga.ID)
+}
+
+func (cs *CheckoutService) undo(ctx context.Context, saga *entity.CheckoutSaga, step entity.CheckoutStep, completed bool) error {
+	switch step {
+	case entity.StepReserveStock:
+		if saga.OrderID == uuid.Nil {
+			return nil
+		}
+
+		// The reservation may exist even if the step failed, e.g. on a lost
+		// response. Releasing is safe to repeat and also returns stock that
+		// was already committed.
+		if err := cs.products.ReleaseReservation(ctx, saga.OrderID); err != nil {
+			return err
+		}
+
+		for i := range saga.Items {
+			saga.Items[i].Reserved = false
+		}
+	case entity.StepCreateOrder:
+		if saga.OrderID == uuid.Nil {
+			return nil
+		}
+
+		if err := cs.orders.CancelOrder(ctx, saga.UserID, saga.OrderID); err != nil {
+			return err
+		}
+
+		for i := range saga.Items {
+			saga.Items[i].Added = false
+		}
+	case entity.StepAuthorizePayment:
+		if !completed {
+			return nil
+		}
+
+		return cs.payments.Refund(ctx, saga.OrderID, saga.Amount, saga.PaymentMethod)
+	}
+
+	return nil
+}
+
+func (cs *CheckoutService) save(ctx context.Context, saga *entity.CheckoutSaga) {
+	saga.UpdatedAt = time.Now()
+	if err := cs.sagas.Save(ctx, saga); err != nil {
+		cs.log.Error("failed to persist checkout saga", "saga_id", saga.ID, logger.Err(err))
+	}
+}
+
+func validateCheckout(input entity.CheckoutInput) error {
+	if input.UserID == uuid.Nil {
+		return fmt.Errorf("%w: user_id is required", entity.ErrInvalidCheckout)
+	}
+
+	if input.PaymentMethod == "" {
+		return fmt.Errorf("%w: payment_method is required", entity.ErrInvalidCheckout)
+	}
+
+	if len(input.Items) == 0 {
+		return fmt.Errorf("%w: cart is empty", entity.ErrInvalidCheckout)
+	}
+
+	for _, item := range input.Items {
+		if item.ProductID == uuid.Nil {
+			return fmt.Errorf("%w: product_id is required", entity.ErrInvalidCheckout)
+		}
+
+		if item.Quantity < 1 {
+			return fmt.Errorf("%w: quantity of %s must be positive", entity.ErrInvalidCheckout, item.ProductID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,453 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+)
+
+// fakeProducts reserves stock like product-service: all items of an order at
+// once, once per order. stock is keyed by the product or the variant id.
+type fakeProducts struct {
+	mu    sync.Mutex
+	stock map[uuid.UUID]int64
+	price int64
+	// variantPrice is the price of every variant.
+	variantPrice int64
+	reservations map[uuid.UUID][]*product.ReservationItem
+	committed    map[uuid.UUID]bool
+	commitErr    error
+}
+
+func (f *fakeProducts) ReserveStock(_ context.Context, orderID uuid.UUID, items []*product.ReservationItem) (*product.Reservation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.reservations == nil {
+		f.reservations = map[uuid.UUID][]*product.ReservationItem{}
+	}
+	if reserved, ok := f.reservations[orderID]; ok {
+		return &product.Reservation{OrderId: orderID.String(), Items: reserved}, nil
+	}
+
+	reserved := make([]*product.ReservationItem, 0, len(items))
+	for _, item := range items {
+		key, price := uuid.MustParse(item.GetProductId()), f.price
+		if item.GetVariantId() != "" {
+			key, price = uuid.MustParse(item.GetVariantId()), f.variantPrice
+		}
+
+		if f.stock[key] < item.GetQuantity() {
+			for _, taken := range reserved {
+				f.stock[stockKey(taken)] += taken.GetQuantity()
+			}
+			return nil, errors.New("insufficient stock")
+		}
+		f.stock[key] -= item.GetQuantity()
+
+		reserved = append(reserved, &product.ReservationItem{
+			ProductId: item.GetProductId(),
+			VariantId: item.GetVariantId(),
+			Quantity:  item.GetQuantity(),
+			Price:     price,
+		})
+	}
+	f.reservations[orderID] = reserved
+
+	return &product.Reservation{OrderId: orderID.String(), Items: reserved}, nil
+}
+
+func (f *fakeProducts) ReleaseReservation(_ context.Context, orderID uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, item := range f.reservations[orderID] {
+		f.stock[stockKey(item)] += item.GetQuantity()
+	}
+	delete(f.reservations, orderID)
+	delete(f.committed, orderID)
+
+	return nil
+}
+
+func (f *fakeProducts) CommitReservation(_ context.Context, orderID uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.commitErr != nil {
+		return f.commitErr
+	}
+	if _, ok := f.reservations[orderID]; !ok {
+		return errors.New("reservation not found")
+	}
+	if f.committed == nil {
+		f.committed = map[uuid.UUID]bool{}
+	}
+	f.committed[orderID] = true
+
+	return nil
+}
+
+func stockKey(item *product.ReservationItem) uuid.UUID {
+	if item.GetVariantId() != "" {
+		return uuid.MustParse(item.GetVariantId())
+	}
+
+	return uuid.MustParse(item.GetProductId())
+}
+
+type fakeOrders struct {
+	orderID   uuid.UUID
+	items     int
+	variants  []uuid.UUID
+	confirmed bool
+	cancelled bool
+}
+
+func (f *fakeOrders) CreateOrder(context.Context, uuid.UUID) (uuid.UUID, error) {
+	f.orderID = uuid.New()
+	return f.orderID, nil
+}
+
+func (f *fakeOrders) AddItem(_ context.Context, _, _ uuid.UUID, variantID *uuid.UUID, _, _ int64) (uuid.UUID, error) {
+	f.items++
+	if variantID != nil {
+		f.variants = append(f.variants, *variantID)
+	}
+	return uuid.New(), nil
+}
+
+func (f *fakeOrders) Confirm(context.Context, uuid.UUID, uuid.UUID) error {
+	f.confirmed = true
+	return nil
+}
+
+func (f *fakeOrders) CancelOrder(context.Context, uuid.UUID, uuid.UUID) error {
+	f.cancelled = true
+	return nil
+}
+
+type fakePayments struct {
+	authorizeErr error
+	onAuthorize  func()
+	authorized   int64
+	refunded     int64
+}
+
+func (f *fakePayments) Authorize(_ context.Context, _ uuid.UUID, amount int64, _ string) error {
+	if f.onAuthorize != nil {
+		f.onAuthorize()
+	}
+	if f.authorizeErr != nil {
+		return f.authorizeErr
+	}
+	f.authorized += amount
+	return nil
+}
+
+func (f *fakePayments) Refund(_ context.Context, _ uuid.UUID, amount int64, _ string) error {
+	f.refunded += amount
+	return nil
+}
+
+type memorySagas struct {
+	mu     sync.Mutex
+	sagas  map[uuid.UUID]entity.CheckoutSaga
+	leases map[uuid.UUID]string
+}
+
+func (m *memorySagas) Lease(_ context.Context, id uuid.UUID, owner string, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.leases == nil {
+		m.leases = map[uuid.UUID]string{}
+	}
+	if holder, ok := m.leases[id]; ok && holder != owner {
+		return false, nil
+	}
+	m.leases[id] = owner
+	return true, nil
+}
+
+func (m *memorySagas) ReleaseLease(_ context.Context, id uuid.UUID, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.leases[id] == owner {
+		delete(m.leases, id)
+	}
+	return nil
+}
+
+func (m *memorySagas) Save(_ context.Context, saga *entity.CheckoutSaga) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cp := *saga
+	cp.Items = append([]entity.CheckoutItem(nil), saga.Items...)
+	cp.CompletedSteps = append([]entity.CheckoutStep(nil), saga.CompletedSteps...)
+	m.sagas[saga.ID] = cp
+	return nil
+}
+
+func (m *memorySagas) Get(_ context.Context, id uuid.UUID) (*entity.CheckoutSaga, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saga, ok := m.sagas[id]
+	if !ok {
+		return nil, entity.ErrSagaNotFound
+	}
+	return &saga, nil
+}
+
+func (m *memorySagas) ListActive(context.Context) ([]*entity.CheckoutSaga, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var active []*entity.CheckoutSaga
+	for _, saga := range m.sagas {
+		if !saga.Finished() {
+			active = append(active, &saga)
+		}
+	}
+	return active, nil
+}
+
+func TestCheckoutService_Checkout(t *testing.T) {
+	ctx := context.Background()
+	log := logger.New("local", nil)
+	productID := uuid.New()
+
+	input := entity.CheckoutInput{
+		UserID:        uuid.New(),
+		PaymentMethod: "card",
+		Items:         []entity.CheckoutItem{{ProductID: productID, Quantity: 2}},
+	}
+
+	t.Run("successful checkout", func(t *testing.T) {
+		products := &fakeProducts{stock: map[uuid.UUID]int64{productID: 5}, price: 100}
+		orders := &fakeOrders{}
+		payments := &fakePayments{}
+		sagas := &memorySagas{sagas: map[uuid.UUID]entity.CheckoutSaga{}}
+		svc := NewCheckoutService(products, orders, payments, sagas, log)
+
+		started, err := svc.Checkout(ctx, input)
+		require.NoError(t, err)
+		svc.Wait()
+
+		saga, err := svc.Status(ctx, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.SagaCompleted, saga.Status)
+		assert.Equal(t, entity.CheckoutSteps, saga.CompletedSteps)
+		assert.Equal(t, orders.orderID, saga.OrderID)
+		assert.Equal(t, int64(200), saga.Amount)
+		assert.Equal(t, int64(3), products.stock[productID])
+		assert.True(t, products.committed[saga.OrderID])
+		assert.Equal(t, int64(200), payments.authorized)
+		assert.True(t, orders.confirmed)
+	})
+
+	t.Run("variant items reserve the variant stock", func(t *testing.T) {
+		variantID := uuid.New()
+		products := &fakeProducts{stock: map[uuid.UUID]int64{productID: 5, variantID: 4}, price: 100, variantPrice: 150}
+		orders := &fakeOrders{}
+		payments := &fakePayments{}
+		sagas := &memorySagas{sagas: map[uuid.UUID]entity.CheckoutSaga{}}
+		svc := NewCheckoutService(products, orders, payments, sagas, log)
+
+		started, err := svc.Checkout(ctx, entity.CheckoutInput{
+			UserID:        input.UserID,
+			PaymentMethod: "card",
+			Items:         []entity.CheckoutItem{{ProductID: productID, VariantID: &variantID, Quantity: 2}},
+		})
+		require.NoError(t, err)
+		svc.Wait()
+
+		saga, err := svc.Status(ctx, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.SagaCompleted, saga.Status)
+		assert.Equal(t, int64(300), saga.Amount)
+		assert.Equal(t, int64(5), products.stock[productID])
+		assert.Equal(t, int64(2), products.stock[variantID])
+		assert.Equal(t, []uuid.UUID{variantID}, orders.variants)
+	})
+
+	t.Run("payment failure is compensated", func(t *testing.T) {
+		products := &fakeProducts{stock: map[uuid.UUID]int64{productID: 5}, price: 100}
+		orders := &fakeOrders{}
+		payments := &fakePayments{authorizeErr: errors.New("card declined")}
+		sagas := &memorySagas{sagas: map[uuid.UUID]entity.CheckoutSaga{}}
+		svc := NewCheckoutService(products, orders, payments, sagas, log)
+
+		started, err := svc.Checkout(ctx, input)
+		require.NoError(t, err)
+		svc.Wait()
+
+		saga, err := svc.Status(ctx, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.SagaFailed, saga.Status)
+		assert.Empty(t, saga.CompletedSteps)
+		assert.Contains(t, saga.Error, "card declined")
+		assert.Equal(t, int64(5), products.stock[productID])
+		assert.True(t, orders.cancelled)
+		assert.Zero(t, payments.refunded)
+		assert.False(t, orders.confirmed)
+	})
+
+	t.Run("expired reservation is compensated", func(t *testing.T) {
+		products := &fakeProducts{stock: map[uuid.UUID]int64{productID: 5}, price: 100, commitErr: errors.New("reservation has expired")}
+		orders := &fakeOrders{}
+		payments := &fakePayments{}
+		sagas := &memorySagas{sagas: map[uuid.UUID]entity.CheckoutSaga{}}
+		svc := NewCheckoutService(products, orders, payments, sagas, log)
+
+		started, err := svc.Checkout(ctx, input)
+		require.NoError(t, err)
+		svc.Wait()
+
+		saga, err := svc.Status(ctx, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.SagaFailed, saga.Status)
+		assert.Contains(t, saga.Error, string(entity.StepCommitStock))
+		assert.Equal(t, int64(5), products.stock[productID])
+		assert.Equal(t, int64(200), payments.refunded)
+		assert.True(t, orders.cancelled)
+		assert.False(t, orders.confirmed)
+	})
+
+	t.Run("out of stock releases earlier reservations", func(t *testing.T) {
+		otherID := uuid.New()
+		products := &fakeProducts{stock: map[uuid.UUID]int64{productID: 5, otherID: 0}, price: 100}
+		orders := &fakeOrders{}
+		sagas := &memorySagas{sagas: map[uuid.UUID]entity.CheckoutSaga{}}
+		svc := NewCheckoutService(products, orders, &fakePayments{}, sagas, log)
+
+		started, err := svc.Checkout(ctx, entity.CheckoutInput{
+			UserID:        input.UserID,
+			PaymentMethod: "card",
+			Items: []entity.CheckoutItem{
+				{ProductID: productID, Quantity: 2},
+				{ProductID: otherID, Quantity: 1},
+			},
+		})
+		require.NoError(t, err)
+		svc.Wait()
+
+		saga, err := svc.Status(ctx, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.SagaFailed, saga.Status)
+		assert.Equal(t, int64(5), products.stock[productID])
+		assert.Zero(t, orders.items)
+		assert.True(t, orders.cancelled)
+	})
+
+	t.Run("resume continues an interrupted saga", func(t *testing.T) {
+		products := &fakeProducts{stock: map[uuid.UUID]int64{productID: 5}, price: 100}
+		orders := &fakeOrders{}
+		payments := &fakePayments{}
+		sagas := &memorySagas{sagas: map[uuid.UUID]entity.CheckoutSaga{}}
+
+		orderID := uuid.New()
+		_, err := products.ReserveStock(ctx, orderID, []*product.ReservationItem{{ProductId: productID.String(), Quantity: 2}})
+		require.NoError(t, err)
+
+		interrupted := &entity.CheckoutSaga{
+			ID:             uuid.New(),
+			UserID:         input.UserID,
+			PaymentMethod:  "card",
+			Items:          []entity.CheckoutItem{{ProductID: productID, Quantity: 2, Price: 100, Reserved: true}},
+			OrderID:        orderID,
+			Amount:         200,
+			Status:         entity.SagaRunning,
+			CompletedSteps: []entity.CheckoutStep{entity.StepCreateOrder, entity.StepReserveStock},
+		}
+		require.NoError(t, sagas.Save(ctx, interrupted))
+
+		svc := NewCheckoutService(products, orders, payments, sagas, log)
+		require.NoError(t, svc.Resume(ctx))
+		svc.Wait()
+
+		saga, err := svc.Status(ctx, interrupted.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.SagaCompleted, saga.Status)
+		assert.Equal(t, int64(3), products.stock[productID])
+		assert.True(t, products.committed[orderID])
+		assert.Equal(t, 1, orders.items)
+	})
+
+	t.Run("shutdown leaves the saga to resume", func(t *testing.T) {
+		products := &fakeProducts{stock: map[uuid.UUID]int64{productID: 5}, price: 100}
+		orders := &fakeOrders{}
+		payments := &fakePayments{}
+		sagas := &memorySagas{sagas: map[uuid.UUID]entity.CheckoutSaga{}}
+		svc := NewCheckoutService(products, orders, payments, sagas, log)
+		payments.onAuthorize = svc.stop
+
+		started, err := svc.Checkout(ctx, input)
+		require.NoError(t, err)
+		svc.Wait()
+
+		saga, err := svc.Status(ctx, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.SagaRunning, saga.Status)
+		assert.Contains(t, saga.CompletedSteps, entity.StepAuthorizePayment)
+		assert.Zero(t, payments.refunded)
+		assert.False(t, orders.cancelled)
+		assert.Empty(t, sagas.leases)
+
+		payments.onAuthorize = nil
+		resumed := NewCheckoutService(products, orders, payments, sagas, log)
+		require.NoError(t, resumed.Resume(ctx))
+		resumed.Wait()
+
+		saga, err = resumed.Status(ctx, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.SagaCompleted, saga.Status)
+		assert.Equal(t, int64(200), payments.authorized)
+	})
+
+	t.Run("sagas leased by another gateway are not resumed", func(t *testing.T) {
+		products := &fakeProducts{stock: map[uuid.UUID]int64{productID: 5}, price: 100}
+		orders := &fakeOrders{}
+		sagas := &memorySagas{sagas: map[uuid.UUID]entity.CheckoutSaga{}}
+
+		running := &entity.CheckoutSaga{
+			ID:             uuid.New(),
+			UserID:         input.UserID,
+			PaymentMethod:  "card",
+			Items:          []entity.CheckoutItem{{ProductID: productID, Quantity: 2}},
+			Status:         entity.SagaRunning,
+			CompletedSteps: []entity.CheckoutStep{},
+		}
+		require.NoError(t, sagas.Save(ctx, running))
+		_, err := sagas.Lease(ctx, running.ID, "other gateway", time.Minute)
+		require.NoError(t, err)
+
+		svc := NewCheckoutService(products, orders, &fakePayments{}, sagas, log)
+		require.NoError(t, svc.Resume(ctx))
+		svc.Wait()
+
+		saga, err := svc.Status(ctx, running.ID)
+		require.NoError(t, err)
+		assert.Empty(t, saga.CompletedSteps)
+		assert.Equal(t, uuid.Nil, orders.orderID)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		svc := NewCheckoutService(nil, nil, nil, nil, log)
+
+		_, err := svc.Checkout(ctx, entity.CheckoutInput{UserID: uuid.New(), PaymentMethod: "card"})
+		assert.ErrorIs(t, err, entity.ErrInvalidCheckout)
+	})
+}
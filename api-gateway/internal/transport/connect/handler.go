@@ -0,0 +1,212 @@
+package connectServ
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"connectrpc.com/connect"
+	httpServ "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/http"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// forwardedHeaders are the request headers passed on to the backends as gRPC
+// metadata, the same ones the gRPC gateway mux forwards.
+var forwardedHeaders = map[string]string{
+	"Authorization":               "authorization",
+	httpServ.IdempotencyKeyHeader: httpServ.IdempotencyMetadataKey,
+}
+
+// Service is a backend service exposed to browsers. Only the methods with an
+// HTTP route, the public API the REST routes already expose, are served.
+// Internal lists the methods only the gateway itself may call, which are left
+// out even if they get a route.
+type Service struct {
+	Desc     protoreflect.ServiceDescriptor
+	Conn     grpc.ClientConnInterface
+	Internal []protoreflect.Name
+}
+
+// Handler serves the methods of the backend services over the gRPC-Web and
+// Connect protocols, at the usual "/package.Service/Method" paths. Messages
+// are decoded with the descriptors of the services, so the handler needs no
+// code generated per service.
+type Handler struct {
+	mux      *http.ServeMux
+	prefixes []string
+}
+
+func NewHandler(services []Service, log *logger.Logger) *Handler {
+	h := &Handler{mux: http.NewServeMux()}
+
+	for _, service := range services {
+		h.prefixes = append(h.prefixes, "/"+string(service.Desc.FullName())+"/")
+
+		methods := service.Desc.Methods()
+		for i := range methods.Len() {
+			method := methods.Get(i)
+			procedure := fmt.Sprintf("/%s/%s", service.Desc.FullName(), method.Name())
+			opts := []connect.HandlerOption{
+				connect.WithSchema(method),
+				connect.WithRequestInitializer(initRequest),
+			}
+
+			switch {
+			case slices.Contains(service.Internal, method.Name()):
+				log.Debug("skipping internal method", "procedure", procedure)
+			case !hasHTTPRoute(method):
+				log.Debug("skipping method without an HTTP route", "procedure", procedure)
+			case method.IsStreamingClient():
+				// Browsers cannot stream requests, neither protocol supports it.
+				log.Debug("skipping client streaming method", "procedure", procedure)
+			case method.IsStreamingServer():
+				h.mux.Handle(procedure, connect.NewServerStreamHandler(procedure, serverStream(service.Conn, procedure, method), opts...))
+			default:
+				h.mux.Handle(procedure, connect.NewUnaryHandler(procedure, unary(service.Conn, procedure, method), opts...))
+			}
+		}
+	}
+
+	return h
+}
+
+// Prefixes are the path prefixes of the services, one per service.
+func (h *Handler) Prefixes() []string {
+	return h.prefixes
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func unary(conn grpc.ClientConnInterface, procedure string, method protoreflect.MethodDescriptor) func(context.Context, *connect.Request[dynamicpb.Message]) (*connect.Response[dynamicpb.Message], error) {
+	return func(ctx context.Context, req *connect.Request[dynamicpb.Message]) (*connect.Response[dynamicpb.Message], error) {
+		var header, trailer metadata.MD
+		reply := dynamicpb.NewMessage(method.Output())
+
+		err := conn.Invoke(outgoingContext(ctx, req.Header()), procedure, req.Msg, reply, grpc.Header(&header), grpc.Trailer(&trailer))
+		if err != nil {
+			return nil, connectError(err, header, trailer)
+		}
+
+		resp := connect.NewResponse(reply)
+		copyMetadata(resp.Header(), header)
+		copyMetadata(resp.Trailer(), trailer)
+		return resp, nil
+	}
+}
+
+func serverStream(conn grpc.ClientConnInterface, procedure string, method protoreflect.MethodDescriptor) func(context.Context, *connect.Request[dynamicpb.Message], *connect.ServerStream[dynamicpb.Message]) error {
+	desc := &grpc.StreamDesc{StreamName: string(method.Name()), ServerStreams: true}
+
+	return func(ctx context.Context, req *connect.Request[dynamicpb.Message], stream *connect.ServerStream[dynamicpb.Message]) error {
+		backend, err := conn.NewStream(outgoingContext(ctx, req.Header()), desc, procedure)
+		if err != nil {
+			return connectError(err, nil, nil)
+		}
+		if err := backend.SendMsg(req.Msg); err != nil {
+			return connectError(err, nil, nil)
+		}
+		if err := backend.CloseSend(); err != nil {
+			return connectError(err, nil, nil)
+		}
+
+		header, err := backend.Header()
+		if err != nil {
+			return connectError(err, nil, nil)
+		}
+		copyMetadata(stream.ResponseHeader(), header)
+
+		for {
+			msg := dynamicpb.NewMessage(method.Output())
+			if err := backend.RecvMsg(msg); err != nil {
+				if errors.Is(err, io.EOF) {
+					copyMetadata(stream.ResponseTrailer(), backend.Trailer())
+					return nil
+				}
+				return connectError(err, nil, backend.Trailer())
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// hasHTTPRoute reports whether the method has a google.api.http binding.
+func hasHTTPRoute(method protoreflect.MethodDescriptor) bool {
+	rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+	return ok && rule.GetPattern() != nil
+}
+
+// initRequest gives connect an empty request message of the method to decode
+// into.
+func initRequest(spec connect.Spec, msg any) error {
+	method, ok := spec.Schema.(protoreflect.MethodDescriptor)
+	if !ok {
+		return fmt.Errorf("no schema for %s", spec.Procedure)
+	}
+	m, ok := msg.(*dynamicpb.Message)
+	if !ok {
+		return fmt.Errorf("unexpected request message %T for %s", msg, spec.Procedure)
+	}
+
+	*m = *dynamicpb.NewMessage(method.Input())
+	return nil
+}
+
+func outgoingContext(ctx context.Context, header http.Header) context.Context {
+	for name, key := range forwardedHeaders {
+		if value := header.Get(name); value != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, key, value)
+		}
+	}
+
+	return ctx
+}
+
+// connectError converts a backend error, keeping its code, message, details
+// and metadata.
+func connectError(err error, header, trailer metadata.MD) error {
+	st := status.Convert(err)
+	connectErr := connect.NewError(connect.Code(st.Code()), errors.New(st.Message()))
+
+	for _, detail := range st.Proto().GetDetails() {
+		msg, err := anypb.UnmarshalNew(detail, proto.UnmarshalOptions{})
+		if err != nil {
+			continue
+		}
+		if errDetail, err := connect.NewErrorDetail(msg); err == nil {
+			connectErr.AddDetail(errDetail)
+		}
+	}
+	copyMetadata(connectErr.Meta(), header)
+	copyMetadata(connectErr.Meta(), trailer)
+
+	return connectErr
+}
+
+// copyMetadata copies backend metadata to the response, except for the keys
+// reserved by the protocols.
+func copyMetadata(dst http.Header, md metadata.MD) {
+	for key, values := range md {
+		if key == "content-type" || strings.HasPrefix(key, "grpc-") || strings.HasPrefix(key, ":") || strings.HasSuffix(key, "-bin") {
+			continue
+		}
+		for _, value := range values {
+			dst.Add(key, value)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package connectServ
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type fakeOrders struct {
+	order.UnimplementedOrderServiceServer
+}
+
+func (f *fakeOrders) GetOrder(ctx context.Context, req *order.GetOrderRequest) (*order.GetOrderResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md.Get("authorization")) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "no token")
+	}
+	if req.GetOrderId() == "missing" {
+		st, _ := status.New(codes.NotFound, "order not found").WithDetails(&errdetails.ResourceInfo{
+			ResourceType: "order",
+			ResourceName: req.GetOrderId(),
+		})
+		return nil, st.Err()
+	}
+
+	return &order.GetOrderResponse{Order: &order.Order{OrderId: req.GetOrderId(), UserId: req.GetUserId()}}, nil
+}
+
+func (f *fakeOrders) CancelOrder(context.Context, *order.CancelOrderRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeOrders) WatchOrders(req *order.WatchOrdersRequest, stream grpc.ServerStreamingServer[order.OrderEvent]) error {
+	return stream.Send(&order.OrderEvent{EventId: 1, UserId: req.GetUserId()})
+}
+
+func TestHandler(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	order.RegisterOrderServiceServer(srv, &fakeOrders{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	h := NewHandler([]Service{
+		{Desc: order.File_proto_order_proto.Services().ByName("OrderService"), Conn: conn, Internal: []protoreflect.Name{"CancelOrder"}},
+	}, logger.New("local", nil))
+	assert.Equal(t, []string{"/api.OrderService/"}, h.Prefixes())
+
+	gateway := httptest.NewServer(h)
+	t.Cleanup(gateway.Close)
+
+	getOrder := func(opts ...connect.ClientOption) *connect.Client[order.GetOrderRequest, order.GetOrderResponse] {
+		return connect.NewClient[order.GetOrderRequest, order.GetOrderResponse](http.DefaultClient, gateway.URL+"/api.OrderService/GetOrder", opts...)
+	}
+	newRequest := func(orderID string) *connect.Request[order.GetOrderRequest] {
+		req := connect.NewRequest(&order.GetOrderRequest{UserId: "user", OrderId: orderID})
+		req.Header().Set("Authorization", "Bearer token")
+		return req
+	}
+
+	protocols := map[string][]connect.ClientOption{
+		"connect":      nil,
+		"connect json": {connect.WithProtoJSON()},
+		"grpc-web":     {connect.WithGRPCWeb()},
+	}
+	for name, opts := range protocols {
+		t.Run("unary over "+name, func(t *testing.T) {
+			resp, err := getOrder(opts...).CallUnary(context.Background(), newRequest("42"))
+			require.NoError(t, err)
+
+			assert.Equal(t, "42", resp.Msg.GetOrder().GetOrderId())
+			assert.Equal(t, "user", resp.Msg.GetOrder().GetUserId())
+		})
+	}
+
+	t.Run("keeps error codes and details", func(t *testing.T) {
+		_, err := getOrder(connect.WithGRPCWeb()).CallUnary(context.Background(), newRequest("missing"))
+
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		assert.Equal(t, connect.CodeNotFound, connectErr.Code())
+		require.Len(t, connectErr.Details(), 1)
+		detail, err := connectErr.Details()[0].Value()
+		require.NoError(t, err)
+		assert.Equal(t, "missing", detail.(*errdetails.ResourceInfo).GetResourceName())
+	})
+
+	t.Run("forwards the authorization header", func(t *testing.T) {
+		_, err := getOrder().CallUnary(context.Background(), connect.NewRequest(&order.GetOrderRequest{OrderId: "42"}))
+
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	})
+
+	t.Run("internal methods are not served", func(t *testing.T) {
+		cancel := connect.NewClient[order.CancelOrderRequest, emptypb.Empty](http.DefaultClient, gateway.URL+"/api.OrderService/CancelOrder")
+		_, err := cancel.CallUnary(context.Background(), connect.NewRequest(&order.CancelOrderRequest{UserId: "user", OrderId: "42"}))
+
+		assert.Equal(t, connect.CodeUnimplemented, connect.CodeOf(err))
+	})
+
+	t.Run("methods without an HTTP route are not served", func(t *testing.T) {
+		watch := connect.NewClient[order.WatchOrdersRequest, order.OrderEvent](http.DefaultClient, gateway.URL+"/api.OrderService/WatchOrders", connect.WithGRPCWeb())
+		stream, err := watch.CallServerStream(context.Background(), connect.NewRequest(&order.WatchOrdersRequest{UserId: "user"}))
+		require.NoError(t, err)
+		defer stream.Close()
+
+		assert.False(t, stream.Receive())
+		assert.Equal(t, connect.CodeUnimplemented, connect.CodeOf(stream.Err()))
+	})
+}
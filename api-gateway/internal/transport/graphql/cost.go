@@ -0,0 +1,84 @@
+package graphqlServ
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// defaultListSize is the number of elements assumed for list fields that are
+// not bounded by a limit argument.
+const defaultListSize = 10
+
+// costCeiling bounds the estimate so that deeply nested lists cannot
+// overflow it.
+const costCeiling = 1 << 30
+
+// queryCost estimates how many fields a query resolves. Every field costs one
+// per parent it is resolved for, so a list multiplies the cost of everything
+// selected below it.
+func queryCost(schema *ast.Schema, query, operationName string, variables map[string]interface{}) (int, error) {
+	doc, errs := gqlparser.LoadQuery(schema, query)
+	if len(errs) > 0 {
+		return 0, errs
+	}
+
+	operation := doc.Operations.ForName(operationName)
+	if operation == nil {
+		if operationName != "" || len(doc.Operations) != 1 {
+			return 0, errors.New("operation not found")
+		}
+		operation = doc.Operations[0]
+	}
+
+	return selectionCost(operation.SelectionSet, 1, variables), nil
+}
+
+func selectionCost(set ast.SelectionSet, multiplier int, variables map[string]interface{}) int {
+	cost := 0
+	for _, selection := range set {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			if sel.Definition == nil || strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+
+			cost += multiplier
+			children := multiplier
+			if sel.Definition.Type.Elem != nil {
+				children = min(children*listSize(sel, variables), costCeiling)
+			}
+			cost += selectionCost(sel.SelectionSet, children, variables)
+		case *ast.InlineFragment:
+			cost += selectionCost(sel.SelectionSet, multiplier, variables)
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				cost += selectionCost(sel.Definition.SelectionSet, multiplier, variables)
+			}
+		}
+
+		if cost > costCeiling {
+			return costCeiling
+		}
+	}
+
+	return cost
+}
+
+func listSize(field *ast.Field, variables map[string]interface{}) int {
+	limit, ok := field.ArgumentMap(variables)["limit"]
+	if !ok {
+		return defaultListSize
+	}
+
+	switch v := limit.(type) {
+	case int64:
+		return max(int(min(v, 1<<20)), 1)
+	case float64:
+		return max(int(min(v, 1<<20)), 1)
+	default:
+		return defaultListSize
+	}
+}
@@ -0,0 +1,94 @@
+package graphqlServ
+
+import (
+	"context"
+	"sync"
+
+	"github.com/graph-gophers/dataloader/v7"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/rbacAuth"
+)
+
+// maxBatchCalls bounds the number of concurrent backend calls of one batch.
+const maxBatchCalls = 8
+
+type loadersKey struct{}
+
+type itemsKey struct {
+	userID  string
+	orderID string
+}
+
+type reviewsKey struct {
+	productID string
+	limit     int32
+}
+
+type loaders struct {
+	products *dataloader.Loader[string, *product.Product]
+	users    *dataloader.Loader[string, *rbacAuth.UserResponse]
+	orders   *dataloader.Loader[string, []*order.Order]
+	items    *dataloader.Loader[itemsKey, []*order.Item]
+	reviews  *dataloader.Loader[reviewsKey, []*product.Review]
+}
+
+func newLoaders(clients Clients) *loaders {
+	return &loaders{
+		products: dataloader.NewBatchedLoader(fanOut(func(ctx context.Context, id string) (*product.Product, error) {
+			resp, err := clients.Product.GetProduct(ctx, &product.GetProductRequest{Id: id})
+			return resp.GetProduct(), err
+		})),
+		users: dataloader.NewBatchedLoader(fanOut(func(ctx context.Context, id string) (*rbacAuth.UserResponse, error) {
+			return clients.Auth.GetUser(ctx, &rbacAuth.GetUserRequest{UserId: id})
+		})),
+		orders: dataloader.NewBatchedLoader(fanOut(func(ctx context.Context, userID string) ([]*order.Order, error) {
+			resp, err := clients.Order.ListOrdersByUser(ctx, &order.ListOrdersRequest{UserId: userID})
+			return resp.GetOrders(), err
+		})),
+		items: dataloader.NewBatchedLoader(fanOut(func(ctx context.Context, key itemsKey) ([]*order.Item, error) {
+			resp, err := clients.Order.ListItemsFromOrder(ctx, &order.ListItemsRequest{UserId: key.userID, OrderId: key.orderID})
+			return resp.GetItems(), err
+		})),
+		reviews: dataloader.NewBatchedLoader(fanOut(func(ctx context.Context, key reviewsKey) ([]*product.Review, error) {
+			resp, err := clients.Product.ListProductReviews(ctx, &product.ListProductReviewsRequest{ProductId: key.productID, PageSize: key.limit})
+			return resp.GetReviews(), err
+		})),
+	}
+}
+
+// fanOut turns a single-key fetch into a batch function. The backends have no
+// batch RPCs, so a batch is one round of concurrent calls; the loader still
+// deduplicates keys and collapses per-row lookups into that single round.
+func fanOut[K comparable, V any](fetch func(ctx context.Context, key K) (V, error)) dataloader.BatchFunc[K, V] {
+	return func(ctx context.Context, keys []K) []*dataloader.Result[V] {
+		results := make([]*dataloader.Result[V], len(keys))
+		sem := make(chan struct{}, maxBatchCalls)
+
+		var wg sync.WaitGroup
+		for i, key := range keys {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+
+				data, err := fetch(ctx, key)
+				results[i] = &dataloader.Result[V]{Data: data, Error: err}
+			}()
+		}
+		wg.Wait()
+
+		return results
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,439 @@
+package graphqlServ
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/client"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/rbacAuth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("access denied")
+)
+
+// Int64 is the Go side of the Int64 scalar. GraphQL Int is 32-bit, which is
+// too small for prices and amounts.
+type Int64 int64
+
+func (Int64) ImplementsGraphQLType(name string) bool {
+	return name == "Int64"
+}
+
+func (i *Int64) UnmarshalGraphQL(input interface{}) error {
+	switch v := input.(type) {
+	case int32:
+		*i = Int64(v)
+	case int64:
+		*i = Int64(v)
+	case float64:
+		*i = Int64(v)
+	default:
+		return fmt.Errorf("wrong type for Int64: %T", input)
+	}
+
+	return nil
+}
+
+type Resolver struct {
+	clients Clients
+}
+
+func (r *Resolver) Me(ctx context.Context) (*userResolver, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	return loadUser(ctx, principal.UserID.String())
+}
+
+func (r *Resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	if err := authorizeUser(ctx, string(args.ID)); err != nil {
+		return nil, err
+	}
+
+	return loadUser(ctx, string(args.ID))
+}
+
+func (r *Resolver) Profile(ctx context.Context, args struct{ ID graphql.ID }) (*profileResolver, error) {
+	resp, err := r.clients.User.GetProfile(ctx, &client.GetProfileRequest{ProfileID: string(args.ID)})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := authorizeUser(ctx, resp.GetUser().GetUserID()); err != nil {
+		return nil, err
+	}
+
+	return &profileResolver{p: resp.GetUser()}, nil
+}
+
+func (r *Resolver) Product(ctx context.Context, args struct{ ID graphql.ID }) (*productResolver, error) {
+	return loadProduct(ctx, string(args.ID))
+}
+
+func (r *Resolver) Products(ctx context.Context, args struct {
+	Offset int32
+	Limit  int32
+}) ([]*productResolver, error) {
+	resp, err := r.clients.Product.ListProducts(ctx, &product.ListProductsRequest{
+		Offset: int64(args.Offset),
+		Limit:  int64(args.Limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	products := loadersFrom(ctx).products
+	res := make([]*productResolver, 0, len(resp.GetProducts()))
+	for _, p := range resp.GetProducts() {
+		products.Prime(ctx, p.GetId(), p)
+		res = append(res, &productResolver{p: p})
+	}
+
+	return res, nil
+}
+
+func (r *Resolver) Order(ctx context.Context, args struct {
+	ID     graphql.ID
+	UserID *graphql.ID
+}) (*orderResolver, error) {
+	userID, err := orderOwner(ctx, args.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return getOrder(ctx, r.clients.Order, userID, string(args.ID))
+}
+
+func (r *Resolver) Orders(ctx context.Context, args struct{ UserID *graphql.ID }) ([]*orderResolver, error) {
+	userID, err := orderOwner(ctx, args.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadOrders(ctx, userID)
+}
+
+func (r *Resolver) Checkout(ctx context.Context, args struct{ ID graphql.ID }) (*checkoutResolver, error) {
+	sagaID, err := uuid.Parse(string(args.ID))
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkout id: %w", err)
+	}
+
+	saga, err := r.clients.Checkout.Status(ctx, sagaID)
+	if err != nil {
+		if errors.Is(err, entity.ErrSagaNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := authorizeUser(ctx, saga.UserID.String()); err != nil {
+		return nil, err
+	}
+
+	return &checkoutResolver{saga: saga, orders: r.clients.Order}, nil
+}
+
+type userResolver struct {
+	u *rbacAuth.UserResponse
+}
+
+func (u *userResolver) ID() graphql.ID           { return graphql.ID(u.u.GetId()) }
+func (u *userResolver) Username() string         { return u.u.GetUsername() }
+func (u *userResolver) Email() string            { return u.u.GetEmail() }
+func (u *userResolver) Role() string             { return u.u.GetRole() }
+func (u *userResolver) Verified() bool           { return u.u.GetVerified() }
+func (u *userResolver) CreatedAt() *graphql.Time { return timeOf(u.u.GetCreatedAt()) }
+func (u *userResolver) UpdatedAt() *graphql.Time { return timeOf(u.u.GetUpdatedAt()) }
+
+func (u *userResolver) Orders(ctx context.Context) ([]*orderResolver, error) {
+	return loadOrders(ctx, u.u.GetId())
+}
+
+type profileResolver struct {
+	p *client.Profile
+}
+
+func (p *profileResolver) ID() graphql.ID           { return graphql.ID(p.p.GetProfileID()) }
+func (p *profileResolver) UserID() graphql.ID       { return graphql.ID(p.p.GetUserID()) }
+func (p *profileResolver) Username() string         { return p.p.GetUsername() }
+func (p *profileResolver) Firstname() string        { return p.p.GetFirstname() }
+func (p *profileResolver) Middlename() string       { return p.p.GetMiddlename() }
+func (p *profileResolver) Lastname() string         { return p.p.GetLastname() }
+func (p *profileResolver) PhoneNumber() string      { return p.p.GetPhoneNumber() }
+func (p *profileResolver) Email() string            { return p.p.GetEmail() }
+func (p *profileResolver) CreatedAt() *graphql.Time { return timeOf(p.p.GetCreatedAt()) }
+func (p *profileResolver) UpdatedAt() *graphql.Time { return timeOf(p.p.GetUpdatedAt()) }
+
+func (p *profileResolver) User(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, p.p.GetUserID())
+}
+
+type productResolver struct {
+	p *product.Product
+}
+
+func (p *productResolver) ID() graphql.ID           { return graphql.ID(p.p.GetId()) }
+func (p *productResolver) SellerID() graphql.ID     { return graphql.ID(p.p.GetSellerId()) }
+func (p *productResolver) Name() string             { return p.p.GetName() }
+func (p *productResolver) Description() string      { return p.p.GetDescription() }
+func (p *productResolver) Price() Int64             { return Int64(p.p.GetPrice()) }
+func (p *productResolver) Stock() Int64             { return Int64(p.p.GetStock()) }
+func (p *productResolver) CreatedAt() *graphql.Time { return timeOf(p.p.GetCreatedAt()) }
+func (p *productResolver) UpdatedAt() *graphql.Time { return timeOf(p.p.GetUpdatedAt()) }
+
+func (p *productResolver) OriginalPrice() *Int64 {
+	if p.p.OriginalPrice == nil {
+		return nil
+	}
+
+	price := Int64(p.p.GetOriginalPrice())
+	return &price
+}
+
+func (p *productResolver) SaleEndsAt() *graphql.Time { return timeOf(p.p.GetSaleEndsAt()) }
+
+func (p *productResolver) RatingAverage() float64 { return p.p.GetRatingAverage() }
+func (p *productResolver) RatingCount() Int64     { return Int64(p.p.GetRatingCount()) }
+func (p *productResolver) Status() string         { return p.p.GetStatus().String() }
+
+func (p *productResolver) Reviews(ctx context.Context, args struct{ Limit int32 }) ([]*reviewResolver, error) {
+	reviews, err := loadersFrom(ctx).reviews.Load(ctx, reviewsKey{productID: p.p.GetId(), limit: args.Limit})()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*reviewResolver, 0, len(reviews))
+	for _, review := range reviews {
+		res = append(res, &reviewResolver{r: review})
+	}
+
+	return res, nil
+}
+
+type reviewResolver struct {
+	r *product.Review
+}
+
+func (r *reviewResolver) ID() graphql.ID           { return graphql.ID(r.r.GetId()) }
+func (r *reviewResolver) ProductID() graphql.ID    { return graphql.ID(r.r.GetProductId()) }
+func (r *reviewResolver) UserID() graphql.ID       { return graphql.ID(r.r.GetUserId()) }
+func (r *reviewResolver) Rating() int32            { return r.r.GetRating() }
+func (r *reviewResolver) Text() string             { return r.r.GetText() }
+func (r *reviewResolver) RepliedAt() *graphql.Time { return timeOf(r.r.GetRepliedAt()) }
+func (r *reviewResolver) CreatedAt() *graphql.Time { return timeOf(r.r.GetCreatedAt()) }
+func (r *reviewResolver) UpdatedAt() *graphql.Time { return timeOf(r.r.GetUpdatedAt()) }
+
+func (r *reviewResolver) Reply() *string {
+	if r.r.GetReply() == "" {
+		return nil
+	}
+
+	return &r.r.Reply
+}
+
+func (r *reviewResolver) Images() []*reviewImageResolver {
+	res := make([]*reviewImageResolver, 0, len(r.r.GetImages()))
+	for _, image := range r.r.GetImages() {
+		res = append(res, &reviewImageResolver{i: image})
+	}
+
+	return res
+}
+
+type reviewImageResolver struct {
+	i *product.ReviewImage
+}
+
+func (i *reviewImageResolver) ID() graphql.ID { return graphql.ID(i.i.GetId()) }
+func (i *reviewImageResolver) URL() string    { return i.i.GetUrl() }
+func (i *reviewImageResolver) Width() int32   { return i.i.GetWidth() }
+func (i *reviewImageResolver) Height() int32  { return i.i.GetHeight() }
+
+type orderResolver struct {
+	o *order.Order
+}
+
+func (o *orderResolver) ID() graphql.ID           { return graphql.ID(o.o.GetOrderId()) }
+func (o *orderResolver) UserID() graphql.ID       { return graphql.ID(o.o.GetUserId()) }
+func (o *orderResolver) TotalAmount() Int64       { return Int64(o.o.GetTotalAmount()) }
+func (o *orderResolver) Status() string           { return o.o.GetStatus() }
+func (o *orderResolver) CreatedAt() *graphql.Time { return timeOf(o.o.GetCreatedAt()) }
+func (o *orderResolver) UpdatedAt() *graphql.Time { return timeOf(o.o.GetUpdatedAt()) }
+
+func (o *orderResolver) User(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, o.o.GetUserId())
+}
+
+func (o *orderResolver) Items(ctx context.Context) ([]*itemResolver, error) {
+	items, err := loadersFrom(ctx).items.Load(ctx, itemsKey{userID: o.o.GetUserId(), orderID: o.o.GetOrderId()})()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*itemResolver, 0, len(items))
+	for _, item := range items {
+		res = append(res, &itemResolver{i: item})
+	}
+
+	return res, nil
+}
+
+type itemResolver struct {
+	i *order.Item
+}
+
+func (i *itemResolver) ID() graphql.ID        { return graphql.ID(i.i.GetItemId()) }
+func (i *itemResolver) ProductID() graphql.ID { return graphql.ID(i.i.GetProductId()) }
+func (i *itemResolver) Quantity() Int64       { return Int64(i.i.GetQuantity()) }
+func (i *itemResolver) Price() Int64          { return Int64(i.i.GetPrice()) }
+
+func (i *itemResolver) Product(ctx context.Context) (*productResolver, error) {
+	return loadProduct(ctx, i.i.GetProductId())
+}
+
+type checkoutResolver struct {
+	saga   *entity.CheckoutSaga
+	orders order.OrderServiceClient
+}
+
+func (c *checkoutResolver) ID() graphql.ID { return graphql.ID(c.saga.ID.String()) }
+func (c *checkoutResolver) Status() string { return string(c.saga.Status) }
+
+func (c *checkoutResolver) CompletedSteps() []string {
+	steps := make([]string, 0, len(c.saga.CompletedSteps))
+	for _, step := range c.saga.CompletedSteps {
+		steps = append(steps, string(step))
+	}
+
+	return steps
+}
+
+func (c *checkoutResolver) Error() *string {
+	if c.saga.Error == "" {
+		return nil
+	}
+
+	return &c.saga.Error
+}
+
+func (c *checkoutResolver) Order(ctx context.Context) (*orderResolver, error) {
+	if c.saga.OrderID == uuid.Nil {
+		return nil, nil
+	}
+
+	return getOrder(ctx, c.orders, c.saga.UserID.String(), c.saga.OrderID.String())
+}
+
+func loadUser(ctx context.Context, id string) (*userResolver, error) {
+	u, err := loadersFrom(ctx).users.Load(ctx, id)()
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &userResolver{u: u}, nil
+}
+
+func loadProduct(ctx context.Context, id string) (*productResolver, error) {
+	p, err := loadersFrom(ctx).products.Load(ctx, id)()
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &productResolver{p: p}, nil
+}
+
+func loadOrders(ctx context.Context, userID string) ([]*orderResolver, error) {
+	orders, err := loadersFrom(ctx).orders.Load(ctx, userID)()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*orderResolver, 0, len(orders))
+	for _, o := range orders {
+		res = append(res, &orderResolver{o: o})
+	}
+
+	return res, nil
+}
+
+func getOrder(ctx context.Context, orders order.OrderServiceClient, userID, orderID string) (*orderResolver, error) {
+	resp, err := orders.GetOrder(ctx, &order.GetOrderRequest{UserId: userID, OrderId: orderID})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &orderResolver{o: resp.GetOrder()}, nil
+}
+
+// authorizeUser allows access to the data of userID to that user and to
+// admins.
+func authorizeUser(ctx context.Context, userID string) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if !principal.IsAdmin() && principal.UserID.String() != userID {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// orderOwner picks whose orders to read: the caller's own, or those of
+// userID when it is given and the caller may read them.
+func orderOwner(ctx context.Context, userID *graphql.ID) (string, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+
+	if userID == nil {
+		return principal.UserID.String(), nil
+	}
+
+	if err := authorizeUser(ctx, string(*userID)); err != nil {
+		return "", err
+	}
+
+	return string(*userID), nil
+}
+
+func isNotFound(err error) bool {
+	return status.Code(err) == codes.NotFound
+}
+
+func timeOf(ts *timestamppb.Timestamp) *graphql.Time {
+	if ts == nil {
+		return nil
+	}
+
+	return &graphql.Time{Time: ts.AsTime()}
+}
@@ -0,0 +1,136 @@
+package graphqlServ
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/client"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/rbacAuth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/problem"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+type CheckoutReader interface {
+	Status(ctx context.Context, sagaID uuid.UUID) (*entity.CheckoutSaga, error)
+}
+
+// Clients are the backend services the resolvers read from.
+type Clients struct {
+	Auth     rbacAuth.AuthServiceClient
+	User     client.UserServiceClient
+	Product  product.ProductServiceClient
+	Order    order.OrderServiceClient
+	Checkout CheckoutReader
+}
+
+// Server serves the /graphql endpoint. Every request gets its own set of
+// dataloaders, so backend calls are batched and deduplicated within a query
+// but nothing is cached between queries.
+type Server struct {
+	schema   *graphql.Schema
+	analyzer *ast.Schema
+	clients  Clients
+	maxCost  int
+	log      *logger.Logger
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func NewServer(clients Clients, cfg config.GraphQL, log *logger.Logger) (*Server, error) {
+	const op = "graphqlServ.NewServer"
+
+	schema, err := graphql.ParseSchema(schemaSDL, &Resolver{clients: clients},
+		graphql.MaxDepth(cfg.MaxDepth),
+		graphql.UseFieldResolvers(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	analyzer, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: schemaSDL})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Server{
+		schema:   schema,
+		analyzer: analyzer,
+		clients:  clients,
+		maxCost:  cfg.MaxCost,
+		log:      log,
+	}, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid variables: "+err.Error()))
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid request body: "+err.Error()))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		problem.WriteHTTP(w, r, http.StatusMethodNotAllowed, status.Error(codes.Unimplemented, "method not allowed"))
+		return
+	}
+
+	var resp *graphql.Response
+	if cost, err := queryCost(s.analyzer, req.Query, req.OperationName, req.Variables); err == nil && cost > s.maxCost {
+		resp = &graphql.Response{Errors: []*gqlerrors.QueryError{
+			gqlerrors.Errorf("query cost %d exceeds the limit of %d", cost, s.maxCost),
+		}}
+	} else {
+		// Validation errors are left to the executor, which reports them
+		// in the usual GraphQL format.
+		resp = s.schema.Exec(s.context(r), req.Query, req.OperationName, req.Variables)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.log.Error("failed to write graphql response", logger.Err(err))
+	}
+}
+
+// context prepares the resolver context: per-request dataloaders and the
+// caller's Authorization header forwarded to the backends, the same way the
+// gRPC gateway mux forwards it.
+func (s *Server) context(r *http.Request) context.Context {
+	ctx := withLoaders(r.Context(), newLoaders(s.clients))
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+	}
+
+	return ctx
+}
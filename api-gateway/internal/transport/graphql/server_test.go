@@ -0,0 +1,157 @@
+package graphqlServ
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/rbacAuth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"google.golang.org/grpc"
+)
+
+type fakeProducts struct {
+	product.ProductServiceClient
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (f *fakeProducts) GetProduct(_ context.Context, in *product.GetProductRequest, _ ...grpc.CallOption) (*product.GetProductResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls[in.GetId()]++
+	return &product.GetProductResponse{Product: &product.Product{Id: in.GetId(), Name: "name-" + in.GetId()}}, nil
+}
+
+func (f *fakeProducts) ListProductReviews(_ context.Context, in *product.ListProductReviewsRequest, _ ...grpc.CallOption) (*product.ListProductReviewsResponse, error) {
+	reviews := []*product.Review{}
+	for i := range in.GetPageSize() {
+		reviews = append(reviews, &product.Review{Id: fmt.Sprintf("%s-r%d", in.GetProductId(), i), ProductId: in.GetProductId(), Rating: 5})
+	}
+	return &product.ListProductReviewsResponse{Reviews: reviews}, nil
+}
+
+type fakeOrders struct {
+	order.OrderServiceClient
+}
+
+func (f *fakeOrders) ListOrdersByUser(_ context.Context, in *order.ListOrdersRequest, _ ...grpc.CallOption) (*order.ListOrdersResponse, error) {
+	return &order.ListOrdersResponse{Orders: []*order.Order{
+		{OrderId: "o1", UserId: in.GetUserId()},
+		{OrderId: "o2", UserId: in.GetUserId()},
+	}}, nil
+}
+
+func (f *fakeOrders) ListItemsFromOrder(_ context.Context, in *order.ListItemsRequest, _ ...grpc.CallOption) (*order.ListItemsResponse, error) {
+	return &order.ListItemsResponse{Items: []*order.Item{
+		{ItemId: in.GetOrderId() + "-1", ProductId: "p1", Quantity: 1},
+		{ItemId: in.GetOrderId() + "-2", ProductId: "p2", Quantity: 2},
+	}}, nil
+}
+
+type fakeAuth struct {
+	rbacAuth.AuthServiceClient
+}
+
+func (f *fakeAuth) GetUser(_ context.Context, in *rbacAuth.GetUserRequest, _ ...grpc.CallOption) (*rbacAuth.UserResponse, error) {
+	return &rbacAuth.UserResponse{Id: in.GetUserId(), Username: "user"}, nil
+}
+
+type gqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func TestServer(t *testing.T) {
+	products := &fakeProducts{calls: map[string]int{}}
+	srv, err := NewServer(Clients{
+		Auth:    &fakeAuth{},
+		Product: products,
+		Order:   &fakeOrders{},
+	}, config.GraphQL{MaxDepth: 5, MaxCost: 500}, logger.New("local", nil))
+	require.NoError(t, err)
+
+	principal := &entity.Principal{UserID: uuid.New(), Role: entity.Client}
+
+	exec := func(t *testing.T, query string, principal *entity.Principal) gqlResponse {
+		body, _ := json.Marshal(map[string]string{"query": query})
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+		if principal != nil {
+			r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+		}
+
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, r)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp gqlResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("orders with items and product names", func(t *testing.T) {
+		resp := exec(t, `{ me { username orders { id items { quantity product { name } } } } }`, principal)
+		require.Empty(t, resp.Errors)
+
+		assert.Contains(t, string(resp.Data), `"name":"name-p1"`)
+		assert.Contains(t, string(resp.Data), `"name":"name-p2"`)
+		// Four items reference two products: each product is fetched once.
+		assert.Equal(t, map[string]int{"p1": 1, "p2": 1}, products.calls)
+	})
+
+	t.Run("product reviews", func(t *testing.T) {
+		resp := exec(t, `{ product(id: "p3") { name reviews(limit: 2) { id rating reply } } }`, nil)
+		require.Empty(t, resp.Errors)
+
+		assert.JSONEq(t, `{"product":{"name":"name-p3","reviews":[
+			{"id":"p3-r0","rating":5,"reply":null},
+			{"id":"p3-r1","rating":5,"reply":null}
+		]}}`, string(resp.Data))
+	})
+
+	t.Run("requires authentication", func(t *testing.T) {
+		resp := exec(t, `{ orders { id } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, ErrUnauthenticated.Error(), resp.Errors[0].Message)
+	})
+
+	t.Run("other users are forbidden", func(t *testing.T) {
+		resp := exec(t, `{ user(id: "`+uuid.NewString()+`") { id } }`, principal)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, ErrForbidden.Error(), resp.Errors[0].Message)
+	})
+
+	t.Run("rejects deep queries", func(t *testing.T) {
+		resp := exec(t, `{ profile(id: "p") { user { orders { items { product { name } } } } } }`, principal)
+
+		require.NotEmpty(t, resp.Errors)
+		assert.Contains(t, resp.Errors[0].Message, "depth")
+	})
+
+	t.Run("rejects expensive queries", func(t *testing.T) {
+		resp := exec(t, `{ products(limit: 200) { id name price } }`, principal)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Contains(t, resp.Errors[0].Message, "exceeds the limit")
+		assert.Empty(t, resp.Data)
+	})
+}
@@ -0,0 +1,79 @@
+package orderClient
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+)
+
+// Order statuses as defined by order-service.
+const (
+	StatusAccepted   = "Accepted status"
+	StatusDelivering = "Delivery status"
+)
+
+type OrderService struct {
+	client order.OrderServiceClient
+}
+
+func NewOrderService(client order.OrderServiceClient) *OrderService {
+	return &OrderService{
+		client: client,
+	}
+}
+
+func (ors *OrderService) CreateOrder(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	resp, err := ors.client.CreateOrder(ctx, &order.CreateOrderRequest{UserId: userID.String()})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.Parse(resp.GetOrderId())
+}
+
+func (ors *OrderService) AddItem(ctx context.Context, orderID, productID uuid.UUID, variantID *uuid.UUID, quantity, price int64) (uuid.UUID, error) {
+	req := &order.AddItemRequest{
+		OrderId:      orderID.String(),
+		ProductId:    productID.String(),
+		Quantity:     quantity,
+		ProductPrice: uint64(price),
+	}
+	if variantID != nil {
+		req.VariantId = variantID.String()
+	}
+
+	resp, err := ors.client.AddItemToOrder(ctx, req)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.Parse(resp.GetItemId())
+}
+
+func (ors *OrderService) UpdateStatus(ctx context.Context, userID, orderID uuid.UUID, status string) (*order.Order, error) {
+	resp, err := ors.client.UpdateOrderStatus(ctx, &order.UpdateOrderStatusRequest{
+		UserId:  userID.String(),
+		OrderId: orderID.String(),
+		Status:  status,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.GetOrder(), nil
+}
+
+func (ors *OrderService) CancelOrder(ctx context.Context, userID, orderID uuid.UUID) error {
+	_, err := ors.client.CancelOrder(ctx, &order.CancelOrderRequest{
+		UserId:  userID.String(),
+		OrderId: orderID.String(),
+	})
+	return err
+}
+
+// Confirm moves a paid order out of the accepted state.
+func (ors *OrderService) Confirm(ctx context.Context, userID, orderID uuid.UUID) error {
+	_, err := ors.UpdateStatus(ctx, userID, orderID, StatusDelivering)
+	return err
+}
@@ -0,0 +1,52 @@
+package paymentClient
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/payment"
+)
+
+const (
+	StatusAuthorized = "authorized"
+	StatusRefunded   = "refunded"
+)
+
+var ErrPaymentDeclined = errors.New("payment declined")
+
+type PaymentService struct {
+	client payment.PaymentServiceClient
+}
+
+func NewPaymentService(client payment.PaymentServiceClient) *PaymentService {
+	return &PaymentService{
+		client: client,
+	}
+}
+
+func (ps *PaymentService) Authorize(ctx context.Context, orderID uuid.UUID, amount int64, method string) error {
+	return ps.process(ctx, orderID, amount, method, StatusAuthorized)
+}
+
+func (ps *PaymentService) Refund(ctx context.Context, orderID uuid.UUID, amount int64, method string) error {
+	return ps.process(ctx, orderID, amount, method, StatusRefunded)
+}
+
+func (ps *PaymentService) process(ctx context.Context, orderID uuid.UUID, amount int64, method, status string) error {
+	resp, err := ps.client.ProcessPayment(ctx, &payment.ProcessPaymentRequest{
+		OrderId:       orderID.String(),
+		Amount:        amount,
+		PaymentMethod: method,
+		Status:        status,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !resp.GetSuccess() {
+		return ErrPaymentDeclined
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package productClient
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+)
+
+type ProductService struct {
+	client product.ProductServiceClient
+}
+
+func NewProductService(client product.ProductServiceClient) *ProductService {
+	return &ProductService{
+		client: client,
+	}
+}
+
+func (ps *ProductService) GetProduct(ctx context.Context, productID uuid.UUID) (*product.Product, error) {
+	resp, err := ps.client.GetProduct(ctx, &product.GetProductRequest{Id: productID.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.GetProduct(), nil
+}
+
+// ReserveStock holds the stock of the items for the order and returns the
+// reservation with the current prices. Product-service takes reservations
+// only from the gateway, so they are made as the system caller, whoever
+// checks out.
+func (ps *ProductService) ReserveStock(ctx context.Context, orderID uuid.UUID, items []*product.ReservationItem) (*product.Reservation, error) {
+	resp, err := ps.client.ReserveStock(auth.AsSystem(ctx), &product.ReserveStockRequest{
+		OrderId: orderID.String(),
+		Items:   items,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.GetReservation(), nil
+}
+
+// ReleaseReservation returns the stock held for the order, if any.
+func (ps *ProductService) ReleaseReservation(ctx context.Context, orderID uuid.UUID) error {
+	_, err := ps.client.ReleaseReservation(auth.AsSystem(ctx), &product.ReleaseReservationRequest{OrderId: orderID.String()})
+	return err
+}
+
+// CommitReservation keeps the stock held for the order from expiring.
+func (ps *ProductService) CommitReservation(ctx context.Context, orderID uuid.UUID) error {
+	_, err := ps.client.CommitReservation(auth.AsSystem(ctx), &product.CommitReservationRequest{OrderId: orderID.String()})
+	return err
+}
@@ -18,12 +18,24 @@ func NewAuthService(auth rbacAuth.AuthServiceClient) *AuthService {
 	}
 }
 
-func (as *AuthService) SignUp(ctx context.Context, input entity.UserSignUpInput) (uuid.UUID, entity.Tokens, error) {
-	_, errCreate := as.auth.SignUp(ctx, &rbacAuth.SignUpRequest{
+func (as *AuthService) SignUp(ctx context.Context, input entity.UserSignUpInput) (entity.Tokens, error) {
+	resp, errCreate := as.auth.SignUp(ctx, &rbacAuth.SignUpRequest{
 		Username:        input.Username,
 		Email:           input.Email,
 		Password:        input.Password,
 		PasswordConfirm: input.PasswordConfirm,
 	})
-	return uuid.Nil, entity.Tokens{}, errCreate
+	if errCreate != nil {
+		return entity.Tokens{}, errCreate
+	}
+
+	return entity.Tokens{
+		AccessToken:  resp.GetAccessToken(),
+		RefreshToken: resp.GetRefreshToken(),
+	}, nil
+}
+
+func (as *AuthService) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := as.auth.DeleteUser(ctx, &rbacAuth.DeleteUserRequest{UserId: userID.String()})
+	return err
 }
@@ -17,7 +17,7 @@ func NewUserService(client client.UserServiceClient) *UserService {
 	}
 }
 
-func (us *UserService) CreateProfile(ctx context.Context, userID uuid.UUID, username, firstname, middlename, lastname, phoneNumber, email string) (uuid.UUID, error) {
+func (us *UserService) CreateProfile(ctx context.Context, userID uuid.UUID, username, firstname, middlename, lastname, phoneNumber, email string) error {
 	_, errCreate := us.client.CreateProfile(ctx, &client.CreateProfileRequest{
 		UserID:      userID.String(),
 		Username:    username,
@@ -27,5 +27,5 @@ func (us *UserService) CreateProfile(ctx context.Context, userID uuid.UUID, user
 		PhoneNumber: phoneNumber,
 		Email:       email,
 	})
-	return uuid.Nil, errCreate
+	return errCreate
 }
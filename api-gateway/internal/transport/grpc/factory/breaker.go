@@ -0,0 +1,106 @@
+package clientFactory
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/sony/gobreaker/v2"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+)
+
+// BreakerMode lets an operator override the circuit breaker for maintenance.
+type BreakerMode string
+
+const (
+	BreakerAuto         BreakerMode = "auto"
+	BreakerForcedOpen   BreakerMode = "open"
+	BreakerForcedClosed BreakerMode = "closed"
+)
+
+var ErrInvalidBreakerMode = errors.New("invalid circuit breaker mode")
+
+func ParseBreakerMode(s string) (BreakerMode, error) {
+	switch mode := BreakerMode(s); mode {
+	case BreakerAuto, BreakerForcedOpen, BreakerForcedClosed:
+		return mode, nil
+	default:
+		return "", ErrInvalidBreakerMode
+	}
+}
+
+// Breaker is a circuit breaker whose state can be forced. A forced open
+// breaker rejects every call, a forced closed one lets every call through
+// without counting it.
+type Breaker struct {
+	cb   *gobreaker.CircuitBreaker[any]
+	mode atomic.Value // BreakerMode
+}
+
+func NewBreaker(name string, cfg config.BreakerConfig, log *logger.Logger) *Breaker {
+	b := &Breaker{
+		cb: gobreaker.NewCircuitBreaker[any](gobreaker.Settings{
+			Name:        name,
+			MaxRequests: cfg.MaxRequests,
+			Interval:    cfg.Interval,
+			Timeout:     cfg.OpenTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				if counts.Requests < cfg.MinRequests {
+					return false
+				}
+				return float64(counts.TotalFailures)/float64(counts.Requests) >= cfg.FailureRatio
+			},
+			IsSuccessful: isBackendHealthy,
+			OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+				log.Warn("circuit breaker changed state", "name", name, "from", from.String(), "to", to.String())
+			},
+		}),
+	}
+	b.mode.Store(BreakerAuto)
+
+	return b
+}
+
+func (b *Breaker) Name() string {
+	return b.cb.Name()
+}
+
+func (b *Breaker) Mode() BreakerMode {
+	return b.mode.Load().(BreakerMode)
+}
+
+func (b *Breaker) SetMode(mode BreakerMode) {
+	b.mode.Store(mode)
+}
+
+// BreakerState is the state of a breaker as shown to operators.
+type BreakerState struct {
+	State  string        `json:"state"`
+	Mode   BreakerMode   `json:"mode"`
+	Counts BreakerCounts `json:"counts"`
+}
+
+// BreakerCounts are the counts of the current interval.
+type BreakerCounts struct {
+	Requests             uint32 `json:"requests"`
+	TotalSuccesses       uint32 `json:"total_successes"`
+	TotalFailures        uint32 `json:"total_failures"`
+	ConsecutiveSuccesses uint32 `json:"consecutive_successes"`
+	ConsecutiveFailures  uint32 `json:"consecutive_failures"`
+}
+
+func (b *Breaker) State() BreakerState {
+	counts := b.cb.Counts()
+
+	return BreakerState{
+		State: b.cb.State().String(),
+		Mode:  b.Mode(),
+		Counts: BreakerCounts{
+			Requests:             counts.Requests,
+			TotalSuccesses:       counts.TotalSuccesses,
+			TotalFailures:        counts.TotalFailures,
+			ConsecutiveSuccesses: counts.ConsecutiveSuccesses,
+			ConsecutiveFailures:  counts.ConsecutiveFailures,
+		},
+	}
+}
@@ -0,0 +1,138 @@
+package clientFactory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const healthUnknown = "UNKNOWN"
+
+var ErrDependencyNotFound = errors.New("dependency not found")
+
+// dependency is a connection created by Dial, as seen by operators.
+type dependency struct {
+	name    string
+	host    string
+	conn    *grpc.ClientConn
+	breaker *Breaker
+
+	mu     sync.Mutex
+	health HealthState
+}
+
+// HealthState is the result of the last gRPC health check of a dependency.
+// Status is the serving status, or UNKNOWN if the check failed or has not
+// run yet.
+type HealthState struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at,omitzero"`
+	Latency   string    `json:"latency,omitempty"`
+}
+
+type DependencyState struct {
+	Name    string       `json:"name"`
+	Host    string       `json:"host"`
+	Breaker BreakerState `json:"breaker"`
+	Health  HealthState  `json:"health"`
+}
+
+// Dependencies returns the state of every connection in the order of Dial.
+func (f *Factory) Dependencies() []DependencyState {
+	f.mu.Lock()
+	deps := append([]*dependency(nil), f.dependencies...)
+	f.mu.Unlock()
+
+	states := make([]DependencyState, 0, len(deps))
+	for _, d := range deps {
+		d.mu.Lock()
+		health := d.health
+		d.mu.Unlock()
+
+		states = append(states, DependencyState{
+			Name:    d.name,
+			Host:    d.host,
+			Breaker: d.breaker.State(),
+			Health:  health,
+		})
+	}
+
+	return states
+}
+
+// SetBreakerMode forces the breaker of the named dependency open or closed,
+// or returns it to the automatic mode.
+func (f *Factory) SetBreakerMode(name string, mode BreakerMode) error {
+	if _, err := ParseBreakerMode(string(mode)); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, d := range f.dependencies {
+		if d.name == name {
+			d.breaker.SetMode(mode)
+			f.log.Warn("circuit breaker mode changed", "name", name, "mode", string(mode))
+			return nil
+		}
+	}
+
+	return ErrDependencyNotFound
+}
+
+// RunHealthChecks checks the health of every dependency each interval until
+// ctx is done. The checks bypass the circuit breakers.
+func (f *Factory) RunHealthChecks(ctx context.Context, interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		f.checkHealth(ctx, timeout)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *Factory) checkHealth(ctx context.Context, timeout time.Duration) {
+	f.mu.Lock()
+	deps := append([]*dependency(nil), f.dependencies...)
+	f.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, d := range deps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(withoutBreaker(ctx), timeout)
+			defer cancel()
+
+			start := time.Now()
+			resp, err := healthpb.NewHealthClient(d.conn).Check(checkCtx, &healthpb.HealthCheckRequest{})
+			health := HealthState{
+				Status:    resp.GetStatus().String(),
+				CheckedAt: start,
+				Latency:   time.Since(start).String(),
+			}
+			if err != nil {
+				health.Status = healthUnknown
+				health.Error = err.Error()
+			}
+
+			d.mu.Lock()
+			d.health = health
+			d.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+}
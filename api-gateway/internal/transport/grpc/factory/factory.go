@@ -0,0 +1,310 @@
+package clientFactory
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/metric"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/oteltrace"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/health"
+	"google.golang.org/grpc/keepalive"
+)
+
+const tracerName = "api-gateway"
+
+// Factory creates the connections to the backend services. All of them share
+// the tracer provider and the client metrics, and are closed by Close.
+type Factory struct {
+	log           *logger.Logger
+	traceProvider *sdktrace.TracerProvider
+	metrics       *grpcprom.ClientMetrics
+	routeMetrics  *routeMetrics
+	retryMetrics  *retryMetrics
+	callerKey     []byte
+
+	mu           sync.Mutex
+	conns        []*grpc.ClientConn
+	routers      []*Router
+	dependencies []*dependency
+}
+
+func New(ctx context.Context, otlpConfig config.OTLPConfig, callerConfig config.CallerConfig, log *logger.Logger) (*Factory, error) {
+	const op = "clientFactory.New"
+
+	exp, err := oteltrace.NewOTLPExporter(ctx, otlpConfig.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tp, err := oteltrace.NewTraceProvider(exp, tracerName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	metrics := grpcprom.NewClientMetrics(
+		grpcprom.WithClientHandlingTimeHistogram(
+			grpcprom.WithHistogramBuckets([]float64{0.001, 0.01, 0.1, 0.3, 0.6, 1, 3, 6, 9, 20, 30, 60, 90, 120}),
+		),
+	)
+
+	return &Factory{
+		log:           log,
+		traceProvider: tp,
+		metrics:       metrics,
+		routeMetrics:  newRouteMetrics(),
+		retryMetrics:  newRetryMetrics(),
+		callerKey:     []byte(callerConfig.SecretKey),
+	}, nil
+}
+
+// Dial creates a connection to the service. The connection is established
+// lazily by the first call; name labels its logs and circuit breaker.
+func (f *Factory) Dial(name string, cfg config.ClientConfig) (*grpc.ClientConn, error) {
+	const op = "clientFactory.Dial"
+
+	creds, err := transportCredentials(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", op, name, err)
+	}
+
+	serviceConfig, err := serviceConfigJSON(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", op, name, err)
+	}
+
+	retry, err := RetryClientInterceptor(name, cfg.Retry, f.retryMetrics)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", op, name, err)
+	}
+
+	breaker := NewBreaker(name, cfg.Breaker, f.log)
+	rpcLogger := InterceptorLogger(f.log.With("service", "gRPC/client", "component", name))
+	logFields := logging.WithFieldsFromContext(logSpanTraceID)
+	exemplar := grpcprom.WithExemplarFromContext(exemplarFromContext)
+
+	conn, err := grpc.NewClient(
+		cfg.Host,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(otelgrpc.WithTracerProvider(f.traceProvider))),
+		grpc.WithChainUnaryInterceptor(
+			PrincipalClientInterceptor(f.callerKey),
+			CircuitBreakerClientInterceptor(breaker),
+			TimeoutClientInterceptor(cfg.Timeout, cfg.MethodTimeouts),
+			retry,
+			f.metrics.UnaryClientInterceptor(exemplar),
+			logging.UnaryClientInterceptor(rpcLogger, logFields),
+		),
+		grpc.WithChainStreamInterceptor(
+			PrincipalStreamClientInterceptor(f.callerKey),
+			f.metrics.StreamClientInterceptor(exemplar),
+			logging.StreamClientInterceptor(rpcLogger, logFields),
+		),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.Keepalive.Time,
+			Timeout:             cfg.Keepalive.Timeout,
+			PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", op, name, err)
+	}
+
+	f.mu.Lock()
+	f.conns = append(f.conns, conn)
+	f.dependencies = append(f.dependencies, &dependency{
+		name:    name,
+		host:    cfg.Host,
+		conn:    conn,
+		breaker: breaker,
+		health:  HealthState{Status: healthUnknown},
+	})
+	f.mu.Unlock()
+
+	return conn, nil
+}
+
+// Route creates the connections to all targets of the service, see
+// config.TargetConfig and config.ShadowConfig. Without extra targets and
+// shadow it is the same as Dial.
+func (f *Factory) Route(name string, cfg config.ClientConfig) (grpc.ClientConnInterface, error) {
+	const op = "clientFactory.Route"
+
+	if len(cfg.Targets) == 0 && cfg.Shadow.Host == "" {
+		return f.Dial(name, cfg)
+	}
+
+	targets := append([]config.TargetConfig{{Name: primaryTarget, Host: cfg.Host, Weight: cfg.Weight}}, cfg.Targets...)
+	total := 0
+	for _, t := range targets {
+		if t.Weight < 0 {
+			return nil, fmt.Errorf("%s: %s: negative weight of target %s", op, name, t.Name)
+		}
+		total += t.Weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("%s: %s: all targets have a zero weight", op, name)
+	}
+
+	r := &Router{
+		client:  name,
+		cfg:     cfg.Shadow,
+		metrics: f.routeMetrics,
+		log:     f.log,
+	}
+
+	for _, t := range targets {
+		targetCfg := cfg
+		targetCfg.Host = t.Host
+
+		conn, err := f.Dial(name+"/"+t.Name, targetCfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		r.targets = append(r.targets, target{name: t.Name, weight: t.Weight, canary: t.Canary, conn: conn})
+	}
+
+	if cfg.Shadow.Host != "" {
+		shadowCfg := cfg
+		shadowCfg.Host = cfg.Shadow.Host
+
+		conn, err := f.Dial(name+"/shadow", shadowCfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		r.shadow = conn
+	}
+
+	f.mu.Lock()
+	f.routers = append(f.routers, r)
+	f.mu.Unlock()
+
+	return r, nil
+}
+
+// MetricsHandler serves the metrics of all connections, along with the
+// extra collectors of the gateway.
+func (f *Factory) MetricsHandler(extra ...prometheus.Collector) http.Handler {
+	collectors := append(f.routeMetrics.collectors(), f.retryMetrics.collectors()...)
+	collectors = append(collectors, extra...)
+	return metric.NewPrometheusFactory(append(collectors, f.metrics)...).InitHandler()
+}
+
+// Close waits for the mirrored calls, closes the connections and flushes the
+// pending spans.
+func (f *Factory) Close(ctx context.Context) error {
+	f.mu.Lock()
+	conns, routers := f.conns, f.routers
+	f.conns, f.routers = nil, nil
+	f.mu.Unlock()
+
+	for _, r := range routers {
+		r.Wait()
+	}
+
+	var errs []error
+	for _, conn := range conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := f.traceProvider.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func transportCredentials(cfg config.ClientTLS) (credentials.TransportCredentials, error) {
+	if cfg.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	ca, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read CA: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates in %s", cfg.CAFile)
+	}
+
+	tlsConfig := &tls.Config{
+		RootCAs:    roots,
+		ServerName: cfg.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// serviceConfigJSON builds the gRPC service config with the load balancing
+// policy. Health checking makes the balancer skip instances that report
+// NOT_SERVING. Retries are left to RetryClientInterceptor, which knows the
+// idempotent methods and the retry budget.
+func serviceConfigJSON(cfg config.ClientConfig) (string, error) {
+	serviceConfig := map[string]any{
+		"loadBalancingConfig": []any{map[string]any{cfg.LoadBalancing: map[string]any{}}},
+		"healthCheckConfig":   map[string]any{"serviceName": ""},
+	}
+
+	data, err := json.Marshal(serviceConfig)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func logSpanTraceID(ctx context.Context) logging.Fields {
+	if span := trace.SpanContextFromContext(ctx); span.IsSampled() {
+		return logging.Fields{
+			"traceID", span.TraceID().String(),
+			"spanID", span.SpanID().String(),
+		}
+	}
+	return nil
+}
+
+func exemplarFromContext(ctx context.Context) prometheus.Labels {
+	if span := trace.SpanContextFromContext(ctx); span.IsSampled() {
+		return prometheus.Labels{
+			"traceID": span.TraceID().String(),
+			"spanID":  span.SpanID().String(),
+		}
+	}
+	return nil
+}
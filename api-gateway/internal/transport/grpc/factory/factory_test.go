@@ -0,0 +1,218 @@
+package clientFactory
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// scriptedHealth answers Check with the scripted codes in order, then with OK.
+type scriptedHealth struct {
+	healthpb.UnimplementedHealthServer
+
+	mu    sync.Mutex
+	codes []codes.Code
+	delay time.Duration
+	calls int
+	md    metadata.MD
+}
+
+func (s *scriptedHealth) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.mu.Lock()
+	s.calls++
+	s.md, _ = metadata.FromIncomingContext(ctx)
+	code := codes.OK
+	if len(s.codes) > 0 {
+		code, s.codes = s.codes[0], s.codes[1:]
+	}
+	delay := s.delay
+	s.mu.Unlock()
+
+	time.Sleep(delay)
+	if code != codes.OK {
+		return nil, status.Error(code, code.String())
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *scriptedHealth) script(delay time.Duration, codes ...codes.Code) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delay, s.codes, s.calls = delay, codes, 0
+}
+
+func (s *scriptedHealth) lastMetadata() metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.md
+}
+
+func (s *scriptedHealth) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+func TestFactory(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	backend := &scriptedHealth{}
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, backend)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	f := &Factory{
+		log:           logger.New("local", nil),
+		traceProvider: sdktrace.NewTracerProvider(),
+		metrics:       grpcprom.NewClientMetrics(),
+		routeMetrics:  newRouteMetrics(),
+		retryMetrics:  newRetryMetrics(),
+		callerKey:     []byte("caller-key"),
+	}
+	t.Cleanup(func() { require.NoError(t, f.Close(context.Background())) })
+
+	cfg := config.ClientConfig{
+		Host:           lis.Addr().String(),
+		Timeout:        time.Second,
+		MethodTimeouts: map[string]time.Duration{"Check": 100 * time.Millisecond},
+		LoadBalancing:  "pick_first",
+		TLS:            config.ClientTLS{Insecure: true},
+		Breaker: config.BreakerConfig{
+			MinRequests:  2,
+			FailureRatio: 0.5,
+			Interval:     time.Minute,
+			OpenTimeout:  time.Minute,
+			MaxRequests:  1,
+		},
+		Retry: config.RetryConfig{
+			MaxAttempts:       3,
+			InitialBackoff:    time.Millisecond,
+			MaxBackoff:        10 * time.Millisecond,
+			BackoffMultiplier: 2,
+			RetryableCodes:    []string{"UNAVAILABLE"},
+			MethodPrefixes:    []string{"Check"},
+			Budget:            config.RetryBudgetConfig{Ratio: 0.1, MaxTokens: 10},
+		},
+		Keepalive: config.KeepaliveConfig{Time: 10 * time.Second, Timeout: time.Second},
+	}
+
+	// Every client has a breaker of its own.
+	newCheck := func(t *testing.T) func() error {
+		conn, err := f.Dial("test-client", cfg)
+		require.NoError(t, err)
+		cl := healthpb.NewHealthClient(conn)
+
+		return func() error {
+			_, err := cl.Check(context.Background(), &healthpb.HealthCheckRequest{})
+			return err
+		}
+	}
+
+	t.Run("retries unavailable backends", func(t *testing.T) {
+		check := newCheck(t)
+		backend.script(0, codes.Unavailable, codes.Unavailable)
+
+		require.NoError(t, check())
+		assert.Equal(t, 3, backend.callCount())
+	})
+
+	t.Run("applies the method timeout", func(t *testing.T) {
+		check := newCheck(t)
+		backend.script(300 * time.Millisecond)
+
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(check()))
+	})
+
+	t.Run("breaker ignores client errors and opens on backend errors", func(t *testing.T) {
+		check := newCheck(t)
+		backend.script(0, codes.NotFound, codes.NotFound, codes.Internal, codes.Internal)
+
+		for range 2 {
+			assert.Equal(t, codes.NotFound, status.Code(check()))
+		}
+		for range 2 {
+			assert.Equal(t, codes.Internal, status.Code(check()))
+		}
+
+		assert.Equal(t, codes.Unavailable, status.Code(check()))
+		assert.Equal(t, 4, backend.callCount(), "the open circuit does not reach the backend")
+	})
+
+	t.Run("passes the caller to the backend", func(t *testing.T) {
+		conn, err := f.Dial("caller-client", cfg)
+		require.NoError(t, err)
+		cl := healthpb.NewHealthClient(conn)
+		backend.script(0)
+
+		principal := &entity.Principal{UserID: uuid.New(), Role: entity.Client}
+		ctx := auth.WithPrincipal(context.Background(), principal)
+		ctx = metadata.AppendToOutgoingContext(ctx, auth.UserRoleMetadataKey, string(entity.Admin))
+
+		_, err = cl.Check(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{principal.UserID.String()}, backend.lastMetadata().Get(auth.UserIDMetadataKey))
+		assert.Equal(t, []string{string(entity.Client)}, backend.lastMetadata().Get(auth.UserRoleMetadataKey), "the client can't set the role")
+
+		md := backend.lastMetadata()
+		require.Len(t, md.Get(auth.CallerIssuedAtMetadataKey), 1)
+		signature := auth.CallerSignature([]byte("caller-key"), principal.UserID.String(), string(entity.Client), md.Get(auth.CallerIssuedAtMetadataKey)[0])
+		assert.Equal(t, []string{signature}, md.Get(auth.CallerSignatureMetadataKey))
+
+		ctx = metadata.AppendToOutgoingContext(context.Background(), auth.UserRoleMetadataKey, string(entity.Admin))
+		_, err = cl.Check(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, backend.lastMetadata().Get(auth.UserRoleMetadataKey), "anonymous calls carry no caller")
+		assert.Empty(t, backend.lastMetadata().Get(auth.CallerSignatureMetadataKey))
+	})
+
+	t.Run("operators force breakers and health checks bypass them", func(t *testing.T) {
+		conn, err := f.Dial("forced-client", cfg)
+		require.NoError(t, err)
+		cl := healthpb.NewHealthClient(conn)
+		backend.script(0)
+
+		require.NoError(t, f.SetBreakerMode("forced-client", BreakerForcedOpen))
+		_, err = cl.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, 0, backend.callCount())
+
+		f.checkHealth(context.Background(), time.Second)
+		var state DependencyState
+		for _, dep := range f.Dependencies() {
+			if dep.Name == "forced-client" {
+				state = dep
+			}
+		}
+		assert.Equal(t, BreakerForcedOpen, state.Breaker.Mode)
+		assert.Equal(t, "SERVING", state.Health.Status)
+
+		require.NoError(t, f.SetBreakerMode("forced-client", BreakerAuto))
+		_, err = cl.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, f.SetBreakerMode("unknown", BreakerAuto), ErrDependencyNotFound)
+		assert.ErrorIs(t, f.SetBreakerMode("forced-client", "half"), ErrInvalidBreakerMode)
+	})
+}
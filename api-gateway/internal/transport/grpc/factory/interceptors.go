@@ -0,0 +1,106 @@
+package clientFactory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/sony/gobreaker/v2"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func InterceptorLogger(l *slog.Logger) logging.Logger {
+	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+		l.Log(ctx, slog.Level(lvl), msg, fields...)
+	})
+}
+
+// CircuitBreakerClientInterceptor fails calls fast with Unavailable while the
+// circuit is open. Only failures of the backend count, see isBackendHealthy.
+// Health checks of the admin endpoint bypass the breaker.
+func CircuitBreakerClientInterceptor(b *Breaker) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if bypassesBreaker(ctx) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		switch b.Mode() {
+		case BreakerForcedOpen:
+			return status.Errorf(codes.Unavailable, "%s: circuit breaker is forced open", b.Name())
+		case BreakerForcedClosed:
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		_, err := b.cb.Execute(func() (any, error) {
+			return nil, invoker(ctx, method, req, reply, cc, opts...)
+		})
+
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			return status.Errorf(codes.Unavailable, "%s: %v", b.Name(), err)
+		}
+
+		return err
+	}
+}
+
+type bypassBreakerKey struct{}
+
+func withoutBreaker(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassBreakerKey{}, true)
+}
+
+func bypassesBreaker(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassBreakerKey{}).(bool)
+	return bypass
+}
+
+// TimeoutClientInterceptor bounds unary calls by the timeout of the method, or
+// by the default one. A shorter deadline of the caller is kept.
+func TimeoutClientInterceptor(timeout time.Duration, methodTimeouts map[string]time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		d := timeout
+		if methodTimeout, ok := methodTimeouts[path.Base(method)]; ok {
+			d = methodTimeout
+		}
+
+		if d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// isBackendHealthy tells the breaker whether the call succeeded as far as the
+// backend's health is concerned: rejected requests are the caller's fault.
+func isBackendHealthy(err error) bool {
+	switch status.Code(err) {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.FailedPrecondition, codes.OutOfRange, codes.Unauthenticated:
+		return true
+	default:
+		return false
+	}
+}
+
+// PrincipalClientInterceptor passes the caller of the gateway request to the
+// backend, signed with key, see auth.OutgoingPrincipal.
+func PrincipalClientInterceptor(key []byte) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(auth.OutgoingPrincipal(ctx, key), method, req, reply, cc, opts...)
+	}
+}
+
+func PrincipalStreamClientInterceptor(key []byte) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(auth.OutgoingPrincipal(ctx, key), desc, cc, method, opts...)
+	}
+}
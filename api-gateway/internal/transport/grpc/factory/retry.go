@@ -0,0 +1,318 @@
+package clientFactory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// latencyWindowSize is the number of latencies kept per method for the
+	// hedging delay, which is not computed before latencyMinSamples.
+	latencyWindowSize  = 128
+	latencyMinSamples  = 16
+	retryKindRetry     = "retry"
+	retryKindHedge     = "hedge"
+	retryKindThrottled = "throttled"
+)
+
+// retryBudget is a token bucket shared by the calls of a client.
+type retryBudget struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	ratio  float64
+}
+
+func newRetryBudget(cfg config.RetryBudgetConfig) *retryBudget {
+	return &retryBudget{tokens: cfg.MaxTokens, max: cfg.MaxTokens, ratio: cfg.Ratio}
+}
+
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.max, b.tokens+b.ratio)
+}
+
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// latencyWindow keeps the latest latencies of a method.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) < latencyWindowSize {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencyWindowSize
+}
+
+func (w *latencyWindow) percentile(p float64) (time.Duration, bool) {
+	w.mu.Lock()
+	sorted := slices.Clone(w.samples)
+	w.mu.Unlock()
+
+	if len(sorted) < latencyMinSamples {
+		return 0, false
+	}
+
+	slices.Sort(sorted)
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(i, len(sorted)-1))], true
+}
+
+type retryMetrics struct {
+	attempts *prometheus.CounterVec
+}
+
+func newRetryMetrics() *retryMetrics {
+	return &retryMetrics{
+		attempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gateway_client_retries_total",
+			Help: "Extra attempts of idempotent calls: retries, hedges and the ones denied by the retry budget.",
+		}, []string{"client", "method", "kind"}),
+	}
+}
+
+func (m *retryMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.attempts}
+}
+
+type retrier struct {
+	client    string
+	cfg       config.RetryConfig
+	retryable []codes.Code
+	budget    *retryBudget
+	latencies sync.Map // method -> *latencyWindow
+	metrics   *retryMetrics
+}
+
+// RetryClientInterceptor retries idempotent calls that failed with one of the
+// retryable codes, with a jittered exponential backoff, and hedges them if
+// configured. Retries stop at the deadline of the call and when the retry
+// budget is spent.
+func RetryClientInterceptor(client string, cfg config.RetryConfig, metrics *retryMetrics) (grpc.UnaryClientInterceptor, error) {
+	r := &retrier{
+		client:  client,
+		cfg:     cfg,
+		budget:  newRetryBudget(cfg.Budget),
+		metrics: metrics,
+	}
+
+	for _, name := range cfg.RetryableCodes {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(`"` + name + `"`)); err != nil {
+			return nil, fmt.Errorf("retryable code %q: %w", name, err)
+		}
+		r.retryable = append(r.retryable, code)
+	}
+
+	return r.intercept, nil
+}
+
+func (r *retrier) intercept(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !r.idempotent(method) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	r.budget.deposit()
+	name := path.Base(method)
+
+	for attempt := 1; ; attempt++ {
+		err := r.attempt(ctx, method, req, reply, cc, invoker, opts)
+		if err == nil || !r.isRetryable(err) || attempt >= r.cfg.MaxAttempts {
+			return err
+		}
+
+		backoff := r.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= backoff {
+			return err
+		}
+		if !r.budget.withdraw() {
+			r.metrics.attempts.WithLabelValues(r.client, name, retryKindThrottled).Inc()
+			return err
+		}
+		r.metrics.attempts.WithLabelValues(r.client, name, retryKindRetry).Inc()
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// attempt makes one call, or two if the first one is slow and hedging is on.
+func (r *retrier) attempt(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts []grpc.CallOption) error {
+	window := r.latencyWindow(method)
+	delay, ok := r.hedgeDelay(window)
+	replyMsg, isProto := reply.(proto.Message)
+	if !ok || !isProto {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			window.add(time.Since(start))
+		}
+		return err
+	}
+
+	type result struct {
+		reply   proto.Message
+		err     error
+		publish func()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan result, 2)
+	call := func() {
+		msg := replyMsg.ProtoReflect().New().Interface()
+		callOpts, publish := isolateMetadata(opts)
+
+		start := time.Now()
+		err := invoker(ctx, method, req, msg, cc, callOpts...)
+		if err == nil {
+			window.add(time.Since(start))
+		}
+		results <- result{reply: msg, err: err, publish: publish}
+	}
+
+	go call()
+	pending := 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if !r.budget.withdraw() {
+				r.metrics.attempts.WithLabelValues(r.client, path.Base(method), retryKindThrottled).Inc()
+				continue
+			}
+			r.metrics.attempts.WithLabelValues(r.client, path.Base(method), retryKindHedge).Inc()
+			pending++
+			go call()
+		case res := <-results:
+			pending--
+			if res.err != nil && r.isRetryable(res.err) && pending > 0 {
+				continue
+			}
+
+			res.publish()
+			if res.err == nil {
+				proto.Reset(replyMsg)
+				proto.Merge(replyMsg, res.reply)
+			}
+			return res.err
+		}
+	}
+}
+
+func (r *retrier) hedgeDelay(window *latencyWindow) (time.Duration, bool) {
+	if !r.cfg.Hedge.Enabled {
+		return 0, false
+	}
+
+	delay, ok := window.percentile(r.cfg.Hedge.Percentile)
+	if !ok {
+		return 0, false
+	}
+	return max(delay, r.cfg.Hedge.MinDelay), true
+}
+
+func (r *retrier) latencyWindow(method string) *latencyWindow {
+	window, _ := r.latencies.LoadOrStore(method, &latencyWindow{})
+	return window.(*latencyWindow)
+}
+
+// backoff is a random duration up to the exponential backoff of the attempt.
+func (r *retrier) backoff(attempt int) time.Duration {
+	ceiling := float64(r.cfg.InitialBackoff) * math.Pow(r.cfg.BackoffMultiplier, float64(attempt-1))
+	ceiling = math.Min(ceiling, float64(r.cfg.MaxBackoff))
+	if ceiling < 1 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling)))
+}
+
+func (r *retrier) idempotent(method string) bool {
+	if r.cfg.MaxAttempts <= 1 && !r.cfg.Hedge.Enabled {
+		return false
+	}
+
+	name := path.Base(method)
+	for _, prefix := range r.cfg.MethodPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *retrier) isRetryable(err error) bool {
+	return slices.Contains(r.retryable, status.Code(err))
+}
+
+// isolateMetadata gives a hedged call its own header and trailer, so that
+// concurrent calls do not write into the caller's variables. publish copies
+// them to the caller's variables once the call has won.
+func isolateMetadata(opts []grpc.CallOption) ([]grpc.CallOption, func()) {
+	isolated := make([]grpc.CallOption, 0, len(opts))
+	var publishers []func()
+
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			md := new(metadata.MD)
+			isolated = append(isolated, grpc.Header(md))
+			publishers = append(publishers, func() { *o.HeaderAddr = *md })
+		case grpc.TrailerCallOption:
+			md := new(metadata.MD)
+			isolated = append(isolated, grpc.Trailer(md))
+			publishers = append(publishers, func() { *o.TrailerAddr = *md })
+		default:
+			isolated = append(isolated, opt)
+		}
+	}
+
+	return isolated, func() {
+		for _, publish := range publishers {
+			publish()
+		}
+	}
+}
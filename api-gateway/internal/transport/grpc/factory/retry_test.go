@@ -0,0 +1,142 @@
+package clientFactory
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const getMethod = "/product.ProductService/GetProduct"
+
+func TestRetryClientInterceptor(t *testing.T) {
+	cfg := config.RetryConfig{
+		MaxAttempts:       3,
+		InitialBackoff:    time.Millisecond,
+		MaxBackoff:        5 * time.Millisecond,
+		BackoffMultiplier: 2,
+		RetryableCodes:    []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED"},
+		MethodPrefixes:    []string{"Get", "List"},
+		Budget:            config.RetryBudgetConfig{Ratio: 0.1, MaxTokens: 10},
+	}
+
+	newInterceptor := func(t *testing.T, cfg config.RetryConfig) (grpc.UnaryClientInterceptor, *retryMetrics) {
+		metrics := newRetryMetrics()
+		interceptor, err := RetryClientInterceptor("test-client", cfg, metrics)
+		require.NoError(t, err)
+		return interceptor, metrics
+	}
+
+	// failing fails the first n calls with code.
+	failing := func(n int32, code codes.Code) (grpc.UnaryInvoker, *atomic.Int32) {
+		calls := &atomic.Int32{}
+		return func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+			if calls.Add(1) <= n {
+				return status.Error(code, code.String())
+			}
+			return nil
+		}, calls
+	}
+
+	call := func(interceptor grpc.UnaryClientInterceptor, ctx context.Context, method string, invoker grpc.UnaryInvoker) error {
+		return interceptor(ctx, method, &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{}, nil, invoker)
+	}
+
+	t.Run("retries idempotent methods", func(t *testing.T) {
+		interceptor, metrics := newInterceptor(t, cfg)
+		invoker, calls := failing(2, codes.ResourceExhausted)
+
+		require.NoError(t, call(interceptor, context.Background(), getMethod, invoker))
+		assert.Equal(t, int32(3), calls.Load())
+		assert.Equal(t, 2.0, testutil.ToFloat64(metrics.attempts.WithLabelValues("test-client", "GetProduct", retryKindRetry)))
+	})
+
+	t.Run("does not retry other methods and codes", func(t *testing.T) {
+		interceptor, _ := newInterceptor(t, cfg)
+
+		invoker, calls := failing(1, codes.Unavailable)
+		assert.Error(t, call(interceptor, context.Background(), "/order.OrderService/CreateOrder", invoker))
+		assert.Equal(t, int32(1), calls.Load())
+
+		invoker, calls = failing(1, codes.Internal)
+		assert.Error(t, call(interceptor, context.Background(), getMethod, invoker))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("stops when the budget is spent", func(t *testing.T) {
+		budgetCfg := cfg
+		budgetCfg.Budget = config.RetryBudgetConfig{Ratio: 0.1, MaxTokens: 2}
+		interceptor, metrics := newInterceptor(t, budgetCfg)
+		invoker, calls := failing(100, codes.Unavailable)
+
+		for range 3 {
+			assert.Error(t, call(interceptor, context.Background(), getMethod, invoker))
+		}
+
+		assert.Equal(t, int32(5), calls.Load(), "two retries, then the budget is empty")
+		assert.Equal(t, 2.0, testutil.ToFloat64(metrics.attempts.WithLabelValues("test-client", "GetProduct", retryKindThrottled)))
+	})
+
+	t.Run("respects the deadline", func(t *testing.T) {
+		slowCfg := cfg
+		slowCfg.InitialBackoff, slowCfg.MaxBackoff, slowCfg.BackoffMultiplier = time.Hour, time.Hour, 1
+		interceptor, _ := newInterceptor(t, slowCfg)
+		invoker, calls := failing(100, codes.Unavailable)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		assert.Equal(t, codes.Unavailable, status.Code(call(interceptor, ctx, getMethod, invoker)))
+		assert.Less(t, time.Since(start), time.Second)
+		assert.LessOrEqual(t, calls.Load(), int32(2))
+	})
+
+	t.Run("hedges slow calls", func(t *testing.T) {
+		hedgeCfg := cfg
+		hedgeCfg.Hedge = config.HedgeConfig{Enabled: true, Percentile: 0.9, MinDelay: time.Millisecond}
+		interceptor, metrics := newInterceptor(t, hedgeCfg)
+
+		fast := func(ctx context.Context, _ string, _, reply any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			reply.(*healthpb.HealthCheckResponse).Status = healthpb.HealthCheckResponse_SERVING
+			return nil
+		}
+		for range latencyMinSamples {
+			require.NoError(t, call(interceptor, context.Background(), getMethod, fast))
+		}
+
+		// The first call hangs until it is cancelled, the hedge answers.
+		var calls atomic.Int32
+		slowFirst := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			if calls.Add(1) == 1 {
+				<-ctx.Done()
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			for _, opt := range opts {
+				if h, ok := opt.(grpc.HeaderCallOption); ok {
+					*h.HeaderAddr = metadata.Pairs("served-by", "hedge")
+				}
+			}
+			return fast(ctx, method, req, reply, cc)
+		}
+
+		var header metadata.MD
+		reply := &healthpb.HealthCheckResponse{}
+		err := interceptor(context.Background(), getMethod, &healthpb.HealthCheckRequest{}, reply, nil, slowFirst, grpc.Header(&header))
+		require.NoError(t, err)
+
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, reply.GetStatus())
+		assert.Equal(t, []string{"hedge"}, header.Get("served-by"))
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.attempts.WithLabelValues("test-client", "GetProduct", retryKindHedge)))
+	})
+}
@@ -0,0 +1,201 @@
+package clientFactory
+
+import (
+	"context"
+	"math/rand/v2"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const primaryTarget = "primary"
+
+type canaryKey struct{}
+
+// WithCanary pins the calls made with the context to the canary targets.
+func WithCanary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, canaryKey{}, true)
+}
+
+func isCanary(ctx context.Context) bool {
+	canary, _ := ctx.Value(canaryKey{}).(bool)
+	return canary
+}
+
+type target struct {
+	name   string
+	weight int
+	canary bool
+	conn   *grpc.ClientConn
+}
+
+// Router spreads the calls of one client over the targets of the service by
+// weight, and mirrors idempotent calls to the shadow target.
+type Router struct {
+	client  string
+	targets []target
+	shadow  *grpc.ClientConn
+	cfg     config.ShadowConfig
+	metrics *routeMetrics
+	log     *logger.Logger
+
+	wg sync.WaitGroup
+}
+
+func (r *Router) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	t := r.pick(ctx)
+
+	start := time.Now()
+	err := t.conn.Invoke(ctx, method, args, reply, opts...)
+	r.metrics.observe(r.client, t.name, err, time.Since(start))
+
+	if r.shadow != nil && r.mirrored(method) {
+		r.mirror(ctx, method, args, reply, err)
+	}
+
+	return err
+}
+
+// NewStream routes streams like unary calls, they are never mirrored.
+func (r *Router) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	t := r.pick(ctx)
+
+	start := time.Now()
+	stream, err := t.conn.NewStream(ctx, desc, method, opts...)
+	r.metrics.observe(r.client, t.name, err, time.Since(start))
+
+	return stream, err
+}
+
+// Wait blocks until the mirrored calls have finished.
+func (r *Router) Wait() {
+	r.wg.Wait()
+}
+
+// pick chooses a target by weight. Pinned calls choose among the canary
+// targets only, if there are any.
+func (r *Router) pick(ctx context.Context) target {
+	candidates := r.targets
+	if isCanary(ctx) {
+		var canaries []target
+		for _, t := range r.targets {
+			if t.canary {
+				canaries = append(canaries, t)
+			}
+		}
+		if len(canaries) > 0 {
+			candidates = canaries
+		}
+	}
+
+	total := 0
+	for _, t := range candidates {
+		total += t.weight
+	}
+	if total == 0 {
+		return candidates[0]
+	}
+
+	n := rand.IntN(total)
+	for _, t := range candidates {
+		if n < t.weight {
+			return t
+		}
+		n -= t.weight
+	}
+	return candidates[len(candidates)-1]
+}
+
+func (r *Router) mirrored(method string) bool {
+	name := path.Base(method)
+	for _, prefix := range r.cfg.MethodPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// mirror sends a copy of the call to the shadow target in the background and
+// reports whether its response differs from the primary one. The caller's
+// call options are not passed on, they may write into the caller's variables.
+func (r *Router) mirror(ctx context.Context, method string, args, reply any, primaryErr error) {
+	argsMsg, ok := args.(proto.Message)
+	if !ok {
+		return
+	}
+	replyMsg, ok := reply.(proto.Message)
+	if !ok {
+		return
+	}
+
+	argsCopy := proto.Clone(argsMsg)
+	primary := proto.Clone(replyMsg)
+	shadowReply := replyMsg.ProtoReflect().New().Interface()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.cfg.Timeout)
+		defer cancel()
+
+		err := r.shadow.Invoke(ctx, method, argsCopy, shadowReply)
+
+		primaryCode, shadowCode := status.Code(primaryErr), status.Code(err)
+		switch {
+		case primaryCode != shadowCode:
+			r.metrics.shadowResults.WithLabelValues(r.client, "mismatch").Inc()
+			r.log.Warn("shadow response differs", "client", r.client, "method", method,
+				"primary_code", primaryCode.String(), "shadow_code", shadowCode.String())
+		case primaryErr == nil && !proto.Equal(primary, shadowReply):
+			r.metrics.shadowResults.WithLabelValues(r.client, "mismatch").Inc()
+			r.log.Warn("shadow response differs", "client", r.client, "method", method)
+		default:
+			r.metrics.shadowResults.WithLabelValues(r.client, "match").Inc()
+		}
+	}()
+}
+
+// routeMetrics are the metrics of the targets, the grpcprom client metrics
+// do not tell them apart.
+type routeMetrics struct {
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	shadowResults *prometheus.CounterVec
+}
+
+func newRouteMetrics() *routeMetrics {
+	return &routeMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gateway_backend_target_requests_total",
+			Help: "Calls to the backend targets by gRPC code.",
+		}, []string{"client", "target", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gateway_backend_target_request_duration_seconds",
+			Help:    "Duration of the unary calls and of the stream setup per backend target.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"client", "target"}),
+		shadowResults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gateway_shadow_requests_total",
+			Help: "Mirrored calls by whether the shadow response matched the primary one.",
+		}, []string{"client", "result"}),
+	}
+}
+
+func (m *routeMetrics) observe(client, target string, err error, d time.Duration) {
+	m.requests.WithLabelValues(client, target, status.Code(err).String()).Inc()
+	m.duration.WithLabelValues(client, target).Observe(d.Seconds())
+}
+
+func (m *routeMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.requests, m.duration, m.shadowResults}
+}
@@ -0,0 +1,102 @@
+package clientFactory
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func startHealth(t *testing.T) (*scriptedHealth, string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	backend := &scriptedHealth{}
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, backend)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return backend, lis.Addr().String()
+}
+
+func TestRouter(t *testing.T) {
+	primary, primaryAddr := startHealth(t)
+	stable, stableAddr := startHealth(t)
+	canary, canaryAddr := startHealth(t)
+	shadow, shadowAddr := startHealth(t)
+
+	f := &Factory{
+		log:           logger.New("local", nil),
+		traceProvider: sdktrace.NewTracerProvider(),
+		metrics:       grpcprom.NewClientMetrics(),
+		routeMetrics:  newRouteMetrics(),
+		retryMetrics:  newRetryMetrics(),
+	}
+	t.Cleanup(func() { require.NoError(t, f.Close(context.Background())) })
+
+	conn, err := f.Route("test-client", config.ClientConfig{
+		Host:          primaryAddr,
+		Timeout:       time.Second,
+		LoadBalancing: "pick_first",
+		TLS:           config.ClientTLS{Insecure: true},
+		Breaker:       config.BreakerConfig{MinRequests: 100, Interval: time.Minute, OpenTimeout: time.Minute},
+		Retry:         config.RetryConfig{MaxAttempts: 1},
+		Keepalive:     config.KeepaliveConfig{Time: 10 * time.Second, Timeout: time.Second},
+		Weight:        0,
+		Targets: []config.TargetConfig{
+			{Name: "stable", Host: stableAddr, Weight: 1},
+			{Name: "canary", Host: canaryAddr, Weight: 0, Canary: true},
+		},
+		Shadow: config.ShadowConfig{Host: shadowAddr, MethodPrefixes: []string{"Check"}, Timeout: time.Second},
+	})
+	require.NoError(t, err)
+	router := conn.(*Router)
+	cl := healthpb.NewHealthClient(conn)
+
+	check := func(ctx context.Context) error {
+		_, err := cl.Check(ctx, &healthpb.HealthCheckRequest{})
+		router.Wait()
+		return err
+	}
+
+	t.Run("routes by weight", func(t *testing.T) {
+		for range 3 {
+			require.NoError(t, check(context.Background()))
+		}
+
+		assert.Equal(t, 0, primary.callCount())
+		assert.Equal(t, 3, stable.callCount())
+		assert.Equal(t, 0, canary.callCount())
+		assert.Equal(t, 3.0, testutil.ToFloat64(f.routeMetrics.requests.WithLabelValues("test-client", "stable", "OK")))
+	})
+
+	t.Run("pins canary requests", func(t *testing.T) {
+		require.NoError(t, check(WithCanary(context.Background())))
+
+		assert.Equal(t, 1, canary.callCount())
+	})
+
+	t.Run("mirrors to the shadow target", func(t *testing.T) {
+		assert.Equal(t, 4, shadow.callCount())
+		assert.Equal(t, 4.0, testutil.ToFloat64(f.routeMetrics.shadowResults.WithLabelValues("test-client", "match")))
+
+		shadow.script(0, codes.NotFound)
+		require.NoError(t, check(context.Background()))
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(f.routeMetrics.shadowResults.WithLabelValues("test-client", "mismatch")))
+	})
+}
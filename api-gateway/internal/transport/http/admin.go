@@ -0,0 +1,176 @@
+package httpServ
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	clientFactory "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/grpc/factory"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/problem"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const redacted = "REDACTED"
+
+type DependencyRegistry interface {
+	Dependencies() []clientFactory.DependencyState
+	SetBreakerMode(name string, mode clientFactory.BreakerMode) error
+}
+
+// AdminHandler serves the operator endpoints of the gateway: the breaker and
+// health state of the backend dependencies, the active config and the build.
+// Every endpoint requires an access token with the admin role.
+type AdminHandler struct {
+	deps   DependencyRegistry
+	tokens TokenParser
+	cfg    *config.Config
+	log    *logger.Logger
+}
+
+func NewAdminHandler(deps DependencyRegistry, tokens TokenParser, cfg *config.Config, log *logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		deps:   deps,
+		tokens: tokens,
+		cfg:    cfg,
+		log:    log,
+	}
+}
+
+func (h *AdminHandler) Router() *mux.Router {
+	router := mux.NewRouter()
+	router.Use(h.requireAdmin)
+
+	router.HandleFunc("/admin/dependencies", h.Dependencies).Methods("GET")
+	router.HandleFunc("/admin/dependencies/{name:.+}/breaker", h.SetBreakerMode).Methods("PUT")
+	router.HandleFunc("/admin/config", h.Config).Methods("GET")
+	router.HandleFunc("/admin/build", h.Build).Methods("GET")
+
+	return router
+}
+
+func (h *AdminHandler) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			problem.Write(w, r, status.Error(codes.Unauthenticated, "authentication required"))
+			return
+		}
+
+		principal, err := h.tokens.Parse(token)
+		if err != nil {
+			problem.Write(w, r, status.Error(codes.Unauthenticated, "invalid access token"))
+			return
+		}
+		if !principal.IsAdmin() {
+			problem.Write(w, r, status.Error(codes.PermissionDenied, "admin role required"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *AdminHandler) Dependencies(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, map[string]any{"dependencies": h.deps.Dependencies()})
+}
+
+type breakerModeRequest struct {
+	Mode string `json:"mode"`
+}
+
+func (h *AdminHandler) SetBreakerMode(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var req breakerModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid request body"))
+		return
+	}
+
+	mode, err := clientFactory.ParseBreakerMode(req.Mode)
+	if err == nil {
+		err = h.deps.SetBreakerMode(name, mode)
+	}
+	switch {
+	case errors.Is(err, clientFactory.ErrInvalidBreakerMode):
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "mode must be one of auto, open, closed"))
+		return
+	case errors.Is(err, clientFactory.ErrDependencyNotFound):
+		problem.Write(w, r, status.Errorf(codes.NotFound, "dependency %q not found", name))
+		return
+	case err != nil:
+		h.log.Error("failed to set breaker mode", logger.Err(err))
+		problem.Write(w, r, status.Error(codes.Internal, "failed to set breaker mode"))
+		return
+	}
+
+	h.log.Warn("breaker mode set by operator", "name", name, "mode", string(mode))
+	for _, dep := range h.deps.Dependencies() {
+		if dep.Name == name {
+			h.writeJSON(w, dep)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Config returns the active config with the secrets redacted.
+func (h *AdminHandler) Config(w http.ResponseWriter, r *http.Request) {
+	cfg := *h.cfg
+	if cfg.Redis.Password != "" {
+		cfg.Redis.Password = redacted
+	}
+	cfg.Token.SecretKey = redacted
+
+	h.writeJSON(w, cfg)
+}
+
+type buildInfo struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	GoVersion   string `json:"go_version,omitempty"`
+	Module      string `json:"module,omitempty"`
+	Revision    string `json:"revision,omitempty"`
+	RevisionAt  string `json:"revision_time,omitempty"`
+	Modified    bool   `json:"modified,omitempty"`
+	MainVersion string `json:"main_version,omitempty"`
+}
+
+func (h *AdminHandler) Build(w http.ResponseWriter, r *http.Request) {
+	info := buildInfo{
+		Name:    h.cfg.App.Name,
+		Version: h.cfg.App.Version,
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info.GoVersion = build.GoVersion
+		info.Module = build.Main.Path
+		info.MainVersion = build.Main.Version
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Revision = setting.Value
+			case "vcs.time":
+				info.RevisionAt = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	h.writeJSON(w, info)
+}
+
+func (h *AdminHandler) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.log.Error("failed to write admin response", logger.Err(err))
+	}
+}
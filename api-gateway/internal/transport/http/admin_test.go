@@ -0,0 +1,110 @@
+package httpServ
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	clientFactory "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/grpc/factory"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+)
+
+type fakeRegistry struct {
+	deps []clientFactory.DependencyState
+}
+
+func (f *fakeRegistry) Dependencies() []clientFactory.DependencyState {
+	return f.deps
+}
+
+func (f *fakeRegistry) SetBreakerMode(name string, mode clientFactory.BreakerMode) error {
+	for i := range f.deps {
+		if f.deps[i].Name == name {
+			f.deps[i].Breaker.Mode = mode
+			return nil
+		}
+	}
+	return clientFactory.ErrDependencyNotFound
+}
+
+func TestAdminHandler(t *testing.T) {
+	registry := &fakeRegistry{deps: []clientFactory.DependencyState{{
+		Name:    "order-client/stable",
+		Host:    "order:50051",
+		Breaker: clientFactory.BreakerState{State: "closed", Mode: clientFactory.BreakerAuto},
+		Health:  clientFactory.HealthState{Status: "SERVING"},
+	}}}
+	principal := &entity.Principal{UserID: uuid.New(), Role: entity.Admin}
+	cfg := &config.Config{
+		App:   config.App{Name: "api-gateway", Version: "1.2.3"},
+		Redis: config.RedisConfig{Addr: "redis:6379", Password: "redis-secret"},
+		Token: config.TokenConfig{SecretKey: "token-secret"},
+	}
+
+	router := NewAdminHandler(registry, &fakeTokens{principal: principal}, cfg, logger.New("local", nil)).Router()
+
+	do := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("requires an admin", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("GET", "/admin/dependencies", "", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, do("GET", "/admin/dependencies", "invalid", "").Code)
+
+		principal.Role = entity.Client
+		defer func() { principal.Role = entity.Admin }()
+		assert.Equal(t, http.StatusForbidden, do("GET", "/admin/dependencies", "valid", "").Code)
+	})
+
+	t.Run("lists dependencies", func(t *testing.T) {
+		rec := do("GET", "/admin/dependencies", "valid", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp struct {
+			Dependencies []clientFactory.DependencyState `json:"dependencies"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, registry.deps, resp.Dependencies)
+	})
+
+	t.Run("forces a breaker", func(t *testing.T) {
+		rec := do("PUT", "/admin/dependencies/order-client/stable/breaker", "valid", `{"mode":"open"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, clientFactory.BreakerForcedOpen, registry.deps[0].Breaker.Mode)
+
+		assert.Equal(t, http.StatusBadRequest, do("PUT", "/admin/dependencies/order-client/stable/breaker", "valid", `{"mode":"half"}`).Code)
+		assert.Equal(t, http.StatusNotFound, do("PUT", "/admin/dependencies/unknown/breaker", "valid", `{"mode":"closed"}`).Code)
+	})
+
+	t.Run("redacts secrets", func(t *testing.T) {
+		rec := do("GET", "/admin/config", "valid", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		body := rec.Body.String()
+		assert.NotContains(t, body, "redis-secret")
+		assert.NotContains(t, body, "token-secret")
+		assert.Contains(t, body, "redis:6379")
+	})
+
+	t.Run("shows the build", func(t *testing.T) {
+		rec := do("GET", "/admin/build", "valid", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var info buildInfo
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&info))
+		assert.Equal(t, "1.2.3", info.Version)
+	})
+}
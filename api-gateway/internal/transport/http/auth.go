@@ -0,0 +1,49 @@
+package httpServ
+
+import (
+	"net/http"
+	"strings"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+)
+
+type TokenParser interface {
+	Parse(accessToken string) (*entity.Principal, error)
+}
+
+// Authenticator resolves the caller from the bearer access token and stores
+// it in the request context, see auth.PrincipalFromContext. Requests without
+// a valid token are passed on as anonymous: the backend services still decide
+// what an anonymous caller may do.
+type Authenticator struct {
+	tokens TokenParser
+	log    *logger.Logger
+}
+
+func NewAuthenticator(tokens TokenParser, log *logger.Logger) *Authenticator {
+	return &Authenticator{
+		tokens: tokens,
+		log:    log,
+	}
+}
+
+func (a *Authenticator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := a.tokens.Parse(token)
+		if err != nil {
+			a.log.Debug("failed to parse access token", logger.Err(err))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
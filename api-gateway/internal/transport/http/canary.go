@@ -0,0 +1,22 @@
+package httpServ
+
+import (
+	"net/http"
+	"strconv"
+
+	clientFactory "gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/transport/grpc/factory"
+)
+
+const CanaryHeader = "X-Canary"
+
+// Canary pins requests with the X-Canary: true header to the canary targets
+// of the backend services, see config.TargetConfig.
+func Canary(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if canary, _ := strconv.ParseBool(r.Header.Get(CanaryHeader)); canary {
+			r = r.WithContext(clientFactory.WithCanary(r.Context()))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
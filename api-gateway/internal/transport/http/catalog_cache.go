@@ -0,0 +1,309 @@
+package httpServ
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/cache"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+)
+
+const (
+	cacheStatusHeader = "X-Cache"
+	cacheHit          = "HIT"
+	cacheStale        = "STALE"
+	cacheMiss         = "MISS"
+	cacheBypass       = "BYPASS"
+
+	// privateCacheControl keeps the responses to authenticated requests out
+	// of shared caches: they may hold drafts or the owner's view of a page.
+	privateCacheControl = "private, no-store"
+)
+
+// catalogResources are the first path segments of the writes that change
+// what the catalog shows. A product appears on its own page, in the catalog
+// pages, in its categories and on its seller's page, so such a write drops
+// every cached response. Changes the gateway doesn't see pass through the
+// cache within its TTL: writes made through GraphQL or Connect, imports
+// finishing in the background, scheduled price changes and the stock that
+// orders take.
+var catalogResources = map[string]bool{
+	"products":        true,
+	"categories":      true,
+	"attributes":      true,
+	"variants":        true,
+	"price-schedules": true,
+	"reviews":         true,
+	"catalog":         true,
+}
+
+type cachedResponse struct {
+	status   int
+	header   http.Header
+	body     []byte
+	etag     string
+	storedAt time.Time
+}
+
+type cacheRoute struct {
+	segments     []string
+	cacheControl string
+}
+
+// CatalogCache adds strong ETags, conditional GET support and per-route
+// Cache-Control headers to catalog reads. Responses to anonymous requests are
+// also kept in an in-process LRU and served stale while they are revalidated
+// in the background; the others are marked private.
+type CatalogCache struct {
+	routes []cacheRoute
+	ttl    time.Duration
+	stale  time.Duration
+	lru    *cache.LRU[*cachedResponse]
+	log    *logger.Logger
+
+	mu           sync.Mutex
+	revalidating map[string]struct{}
+}
+
+func NewCatalogCache(cfg config.HTTPCache, log *logger.Logger) *CatalogCache {
+	routes := make([]cacheRoute, 0, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes = append(routes, cacheRoute{
+			segments:     splitPath(route.Path),
+			cacheControl: route.CacheControl,
+		})
+	}
+
+	return &CatalogCache{
+		routes:       routes,
+		ttl:          cfg.TTL,
+		stale:        cfg.StaleWhileRevalidate,
+		lru:          cache.NewLRU[*cachedResponse](cfg.Size),
+		log:          log,
+		revalidating: make(map[string]struct{}),
+	}
+}
+
+func (c *CatalogCache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if !isCatalogWrite(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			if rec.status < http.StatusBadRequest {
+				c.Invalidate()
+			}
+			return
+		}
+
+		route, ok := c.match(r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.URL.RequestURI()
+		// Canary responses are not shared either, they may come from a
+		// different version of the service.
+		anonymous := r.Header.Get("Authorization") == "" && r.Header.Get(CanaryHeader) == ""
+
+		if anonymous {
+			if entry, ok := c.lru.Get(key); ok {
+				age := time.Since(entry.storedAt)
+				if age < c.ttl {
+					c.write(w, r, entry, route, cacheHit)
+					return
+				}
+				if age < c.ttl+c.stale {
+					c.revalidate(key, r, next)
+					c.write(w, r, entry, route, cacheStale)
+					return
+				}
+			}
+		}
+
+		entry, ok := c.fetch(r, next)
+		if !ok {
+			entry.writeTo(w)
+			return
+		}
+
+		status := cacheBypass
+		if anonymous {
+			c.lru.Add(key, entry)
+			status = cacheMiss
+		}
+		c.write(w, r, entry, route, status)
+	})
+}
+
+// Invalidate drops every cached response.
+func (c *CatalogCache) Invalidate() {
+	c.lru.RemovePrefix("/")
+}
+
+func isCatalogWrite(path string) bool {
+	segments := splitPath(path)
+	return len(segments) > 0 && catalogResources[segments[0]]
+}
+
+func (c *CatalogCache) match(path string) (cacheRoute, bool) {
+	segments := splitPath(path)
+
+	for _, route := range c.routes {
+		if len(route.segments) != len(segments) {
+			continue
+		}
+
+		matched := true
+		for i, segment := range route.segments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				continue
+			}
+			if segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route, true
+		}
+	}
+
+	return cacheRoute{}, false
+}
+
+// fetch runs the request against next and reports whether the response can
+// be cached. Conditional headers are stripped so the backend always returns a
+// full body to hash.
+func (c *CatalogCache) fetch(r *http.Request, next http.Handler) (*cachedResponse, bool) {
+	req := r.Clone(r.Context())
+	req.Header.Del("If-None-Match")
+
+	buf := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+	next.ServeHTTP(buf, req)
+
+	entry := &cachedResponse{
+		status:   buf.status,
+		header:   buf.header,
+		body:     buf.body.Bytes(),
+		storedAt: time.Now(),
+	}
+	if buf.status != http.StatusOK {
+		return entry, false
+	}
+
+	sum := sha256.Sum256(entry.body)
+	entry.etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	return entry, true
+}
+
+func (c *CatalogCache) revalidate(key string, r *http.Request, next http.Handler) {
+	c.mu.Lock()
+	if _, running := c.revalidating[key]; running {
+		c.mu.Unlock()
+		return
+	}
+	c.revalidating[key] = struct{}{}
+	c.mu.Unlock()
+
+	req := r.Clone(context.WithoutCancel(r.Context()))
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.revalidating, key)
+			c.mu.Unlock()
+		}()
+
+		if entry, ok := c.fetch(req, next); ok {
+			c.lru.Add(key, entry)
+			return
+		}
+		c.log.Warn("catalog cache revalidation failed", "key", key)
+	}()
+}
+
+func (c *CatalogCache) write(w http.ResponseWriter, r *http.Request, entry *cachedResponse, route cacheRoute, status string) {
+	for name, values := range entry.header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.Header().Set("ETag", entry.etag)
+	w.Header().Set(cacheStatusHeader, status)
+	switch {
+	case status == cacheBypass:
+		w.Header().Set("Cache-Control", privateCacheControl)
+	case route.cacheControl != "":
+		w.Header().Set("Cache-Control", route.cacheControl)
+		w.Header().Add("Vary", "Authorization")
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), entry.etag) {
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(entry.status)
+	if r.Method != http.MethodHead {
+		w.Write(entry.body)
+	}
+}
+
+func (e *cachedResponse) writeTo(w http.ResponseWriter) {
+	for name, values := range e.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(e.status)
+	w.Write(e.body)
+}
+
+// etagMatches implements the weak comparison If-None-Match requires.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+}
+
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
@@ -0,0 +1,143 @@
+package httpServ
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+)
+
+func TestCatalogCache(t *testing.T) {
+	cfg := config.HTTPCache{
+		Size:                 10,
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Minute,
+		Routes: []config.CacheRoute{
+			{Path: "/products", CacheControl: "public, max-age=30"},
+			{Path: "/products/{id}", CacheControl: "public, max-age=60"},
+		},
+	}
+
+	setup := func() (http.Handler, *int, *string) {
+		calls := 0
+		body := `{"id":"1","price":100}`
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if r.Method == http.MethodGet {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(body))
+			}
+		})
+		return NewCatalogCache(cfg, logger.New("local", nil)).Handler(next), &calls, &body
+	}
+
+	get := func(h http.Handler, path string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		for name, value := range header {
+			r.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+
+	t.Run("serves repeated anonymous reads from cache", func(t *testing.T) {
+		h, calls, _ := setup()
+
+		first := get(h, "/products/1", nil)
+		second := get(h, "/products/1", nil)
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, cacheMiss, first.Header().Get(cacheStatusHeader))
+		assert.Equal(t, cacheHit, second.Header().Get(cacheStatusHeader))
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "public, max-age=60", second.Header().Get("Cache-Control"))
+		assert.NotEmpty(t, second.Header().Get("ETag"))
+	})
+
+	t.Run("answers a matching If-None-Match with 304", func(t *testing.T) {
+		h, _, _ := setup()
+
+		etag := get(h, "/products", nil).Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		rec := get(h, "/products", map[string]string{"If-None-Match": "W/" + etag})
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+
+		rec = get(h, "/products", map[string]string{"If-None-Match": `"other"`, "Authorization": "Bearer token"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+	})
+
+	t.Run("authenticated reads are not cached", func(t *testing.T) {
+		h, calls, _ := setup()
+
+		auth := map[string]string{"Authorization": "Bearer token"}
+		get(h, "/products/1", auth)
+		rec := get(h, "/products/1", auth)
+
+		assert.Equal(t, 2, *calls)
+		assert.Equal(t, cacheBypass, rec.Header().Get(cacheStatusHeader))
+		assert.Equal(t, "private, no-store", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("shared responses vary on the caller", func(t *testing.T) {
+		h, _, _ := setup()
+
+		rec := get(h, "/products/1", nil)
+
+		assert.Equal(t, "Authorization", rec.Header().Get("Vary"))
+	})
+
+	t.Run("writes invalidate the collection", func(t *testing.T) {
+		h, calls, body := setup()
+
+		before := get(h, "/products", nil).Header().Get("ETag")
+		*body = `{"id":"1","price":200}`
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/products/1", nil))
+		after := get(h, "/products", nil)
+
+		assert.Equal(t, 3, *calls)
+		assert.Equal(t, cacheMiss, after.Header().Get(cacheStatusHeader))
+		assert.NotEqual(t, before, after.Header().Get("ETag"))
+	})
+
+	t.Run("writes to the product's resources invalidate every page", func(t *testing.T) {
+		for _, path := range []string{"/products/1/status", "/products/1/images", "/variants/2", "/price-schedules/3", "/catalog/imports"} {
+			h, calls, _ := setup()
+
+			get(h, "/products", nil)
+			get(h, "/products/1", nil)
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+
+			assert.Equal(t, cacheMiss, get(h, "/products", nil).Header().Get(cacheStatusHeader), path)
+			assert.Equal(t, cacheMiss, get(h, "/products/1", nil).Header().Get(cacheStatusHeader), path)
+			assert.Equal(t, 5, *calls, path)
+		}
+	})
+
+	t.Run("other writes keep the cache", func(t *testing.T) {
+		h, _, _ := setup()
+
+		get(h, "/products", nil)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/orders", nil))
+
+		assert.Equal(t, cacheHit, get(h, "/products", nil).Header().Get(cacheStatusHeader))
+	})
+
+	t.Run("other routes pass through", func(t *testing.T) {
+		h, calls, _ := setup()
+
+		get(h, "/v1/orders", nil)
+		rec := get(h, "/v1/orders", nil)
+
+		assert.Equal(t, 2, *calls)
+		assert.Empty(t, rec.Header().Get("ETag"))
+	})
+}
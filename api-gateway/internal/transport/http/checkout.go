@@ -0,0 +1,87 @@
+package httpServ
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/service"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/problem"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type CheckoutHandler struct {
+	checkoutService *service.CheckoutService
+}
+
+func NewCheckoutHandler(checkoutService *service.CheckoutService) *CheckoutHandler {
+	return &CheckoutHandler{checkoutService: checkoutService}
+}
+
+// Checkout starts a checkout of the caller's cart.
+func (h *CheckoutHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, status.Error(codes.Unauthenticated, "authentication required"))
+		return
+	}
+
+	var input entity.CheckoutInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid request body: "+err.Error()))
+		return
+	}
+	input.UserID = principal.UserID
+
+	saga, err := h.checkoutService.Checkout(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidCheckout) {
+			problem.Write(w, r, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/v1/checkout/"+saga.ID.String())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(saga)
+}
+
+// Status returns a checkout of the caller. The checkouts of other users are
+// reported as missing, so their IDs can't be probed; admins see them all.
+func (h *CheckoutHandler) Status(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, status.Error(codes.Unauthenticated, "authentication required"))
+		return
+	}
+
+	sagaID, err := uuid.Parse(mux.Vars(r)["saga_id"])
+	if err != nil {
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid saga id: "+err.Error()))
+		return
+	}
+
+	saga, err := h.checkoutService.Status(r.Context(), sagaID)
+	if err == nil && !principal.IsAdmin() && saga.UserID != principal.UserID {
+		err = entity.ErrSagaNotFound
+	}
+	if err != nil {
+		if errors.Is(err, entity.ErrSagaNotFound) {
+			problem.Write(w, r, status.Error(codes.NotFound, err.Error()))
+			return
+		}
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saga)
+}
@@ -0,0 +1,103 @@
+package httpServ
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/service"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+)
+
+type fakeSagaRepository struct {
+	sagas map[uuid.UUID]*entity.CheckoutSaga
+}
+
+func (f *fakeSagaRepository) Save(_ context.Context, saga *entity.CheckoutSaga) error {
+	f.sagas[saga.ID] = saga
+	return nil
+}
+
+func (f *fakeSagaRepository) Get(_ context.Context, id uuid.UUID) (*entity.CheckoutSaga, error) {
+	saga, ok := f.sagas[id]
+	if !ok {
+		return nil, entity.ErrSagaNotFound
+	}
+	return saga, nil
+}
+
+func (f *fakeSagaRepository) ListActive(context.Context) ([]*entity.CheckoutSaga, error) {
+	return nil, nil
+}
+
+func (f *fakeSagaRepository) Lease(context.Context, uuid.UUID, string, time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeSagaRepository) ReleaseLease(context.Context, uuid.UUID, string) error {
+	return nil
+}
+
+func TestCheckoutHandler(t *testing.T) {
+	ownerID := uuid.New()
+	saga := &entity.CheckoutSaga{ID: uuid.New(), UserID: ownerID, Status: entity.SagaCompleted}
+	sagas := &fakeSagaRepository{sagas: map[uuid.UUID]*entity.CheckoutSaga{saga.ID: saga}}
+	h := NewCheckoutHandler(service.NewCheckoutService(nil, nil, nil, sagas, logger.New("local", nil)))
+
+	as := func(req *http.Request, userID uuid.UUID, role entity.UserRole) *http.Request {
+		return req.WithContext(auth.WithPrincipal(req.Context(), &entity.Principal{UserID: userID, Role: role}))
+	}
+	status := func(req *http.Request) *httptest.ResponseRecorder {
+		req = mux.SetURLVars(req, map[string]string{"saga_id": saga.ID.String()})
+		rec := httptest.NewRecorder()
+		h.Status(rec, req)
+		return rec
+	}
+
+	t.Run("checkout requires a caller", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/checkout", strings.NewReader(`{"user_id": "`+ownerID.String()+`", "payment_method": "card"}`))
+		rec := httptest.NewRecorder()
+
+		h.Checkout(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Len(t, sagas.sagas, 1)
+	})
+
+	t.Run("the owner sees the checkout", func(t *testing.T) {
+		rec := status(as(httptest.NewRequest(http.MethodGet, "/v1/checkout/"+saga.ID.String(), nil), ownerID, entity.Client))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got entity.CheckoutSaga
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, saga.ID, got.ID)
+	})
+
+	t.Run("other users don't", func(t *testing.T) {
+		rec := status(as(httptest.NewRequest(http.MethodGet, "/v1/checkout/"+saga.ID.String(), nil), uuid.New(), entity.Client))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("admins do", func(t *testing.T) {
+		rec := status(as(httptest.NewRequest(http.MethodGet, "/v1/checkout/"+saga.ID.String(), nil), uuid.New(), entity.Admin))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("anonymous status requests", func(t *testing.T) {
+		rec := status(httptest.NewRequest(http.MethodGet, "/v1/checkout/"+saga.ID.String(), nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
@@ -0,0 +1,67 @@
+package httpServ
+
+import (
+	"net/http"
+
+	"github.com/rs/cors"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+)
+
+// corsAllowedHeaders are the request headers of the REST routes and of the
+// gRPC-Web and Connect protocols.
+var corsAllowedHeaders = []string{
+	"Authorization",
+	"Content-Type",
+	"Accept-Language",
+	"If-None-Match",
+	IdempotencyKeyHeader,
+	CanaryHeader,
+	"Connect-Protocol-Version",
+	"Connect-Timeout-Ms",
+	"Connect-Accept-Encoding",
+	"Connect-Content-Encoding",
+	"Grpc-Timeout",
+	"X-Grpc-Web",
+	"X-User-Agent",
+}
+
+// corsExposedHeaders are the response headers browser clients read: the
+// status of gRPC-Web responses, the caching and retry headers, the location
+// of created resources, idempotent replays and the deprecation of API
+// versions.
+var corsExposedHeaders = []string{
+	"Grpc-Status",
+	"Grpc-Message",
+	"Grpc-Status-Details-Bin",
+	"Retry-After",
+	"ETag",
+	"X-Cache",
+	"Location",
+	idempotentReplayedHeader,
+	"Deprecation",
+	"Sunset",
+}
+
+// CORS answers preflight requests and sets the CORS headers for the allowed
+// origins. With no origins configured it returns next unchanged.
+func CORS(cfg config.CORS) func(http.Handler) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	c := cors.New(cors.Options{
+		AllowedOrigins: cfg.AllowedOrigins,
+		AllowedMethods: []string{
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+		},
+		AllowedHeaders: corsAllowedHeaders,
+		ExposedHeaders: corsExposedHeaders,
+		MaxAge:         int(cfg.MaxAge.Seconds()),
+	})
+
+	return c.Handler
+}
@@ -0,0 +1,61 @@
+package httpServ
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	preflight := func(h http.Handler, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/api.OrderService/GetOrder", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "authorization,content-type,x-grpc-web")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("is off without origins", func(t *testing.T) {
+		rec := preflight(CORS(config.CORS{})(next), "https://shop.example")
+
+		assert.Equal(t, http.StatusTeapot, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	h := CORS(config.CORS{AllowedOrigins: []string{"https://shop.example"}, MaxAge: time.Hour})(next)
+
+	t.Run("answers preflight requests of allowed origins", func(t *testing.T) {
+		rec := preflight(h, "https://shop.example")
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "https://shop.example", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "3600", rec.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("exposes the headers clients read", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/checkout/1", nil)
+		req.Header.Set("Origin", "https://shop.example")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		exposed := rec.Header().Get("Access-Control-Expose-Headers")
+		for _, name := range []string{"Location", "Idempotent-Replayed", "Deprecation", "Sunset", "Retry-After"} {
+			assert.Contains(t, exposed, name)
+		}
+	})
+
+	t.Run("ignores other origins", func(t *testing.T) {
+		rec := preflight(h, "https://evil.example")
+
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
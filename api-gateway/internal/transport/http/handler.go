@@ -6,6 +6,9 @@ import (
 
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/service"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/problem"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type AggregatorHandler struct {
@@ -19,14 +22,14 @@ func NewAggregatorHandler(aggregatorService *service.AggregatorService) *Aggrega
 func (h *AggregatorHandler) SignUpUserWithCreateProfile(w http.ResponseWriter, r *http.Request) {
 	var input entity.UserSignUpInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid request body: "+err.Error()))
 		return
 	}
 
 	// Вызываем SignUpUser с декодированным input
 	data, err := h.aggregatorService.SignUpUser(r.Context(), input)
 	if err != nil {
-		http.Error(w, "failed to sign up user and create profile: "+err.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
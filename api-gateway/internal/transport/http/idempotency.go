@@ -0,0 +1,234 @@
+package httpServ
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/problem"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotencyMetadataKey   = "idempotency-key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	idempotencyRetryAfter    = time.Second
+	// maxIdempotentBodySize bounds the body read to fingerprint the request:
+	// the largest body a route accepts, the catalog import.
+	maxIdempotentBodySize = maxImportUploadSize
+)
+
+type IdempotencyStore interface {
+	Acquire(ctx context.Context, key, fingerprint string, ttl time.Duration) (*entity.IdempotentResponse, bool, error)
+	Complete(ctx context.Context, key string, resp *entity.IdempotentResponse, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyMiddleware makes unsafe requests that carry an Idempotency-Key
+// safe to retry. The first request with a key is executed and its response is
+// stored; retries with the same key and the same request get that response
+// back instead of being executed again. Keys are scoped to the authenticated
+// caller, so anonymous requests are not remembered.
+type IdempotencyMiddleware struct {
+	store   IdempotencyStore
+	keyTTL  time.Duration
+	lockTTL time.Duration
+	log     *logger.Logger
+}
+
+func NewIdempotencyMiddleware(store IdempotencyStore, keyTTL, lockTTL time.Duration, log *logger.Logger) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		store:   store,
+		keyTTL:  keyTTL,
+		lockTTL: lockTTL,
+		log:     log,
+	}
+}
+
+func (m *IdempotencyMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		principal, authenticated := auth.PrincipalFromContext(r.Context())
+		if key == "" || !isUnsafeMethod(r.Method) || !authenticated {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			problem.Write(w, r, status.Error(codes.InvalidArgument, "Idempotency-Key is too long"))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				problem.WriteHTTP(w, r, http.StatusRequestEntityTooLarge, status.Error(codes.InvalidArgument, "the request body is too large"))
+				return
+			}
+			problem.Write(w, r, status.Error(codes.InvalidArgument, "failed to read request body: "+err.Error()))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := principal.UserID.String()
+		storeKey := scope + ":" + key
+		fingerprint := requestFingerprint(scope, r, body)
+
+		existing, acquired, err := m.store.Acquire(r.Context(), storeKey, fingerprint, m.lockTTL)
+		if err != nil {
+			m.log.Error("failed to acquire idempotency key", logger.Err(err))
+			problem.Write(w, r, status.Error(codes.Unavailable, "idempotency store is unavailable"))
+			return
+		}
+
+		if !acquired {
+			m.replay(w, r, existing, fingerprint)
+			return
+		}
+
+		rec := newResponseRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status >= http.StatusInternalServerError {
+			// Server errors are not remembered so the client can retry them.
+			if err := m.store.Release(ctx, storeKey); err != nil {
+				m.log.Error("failed to release idempotency key", logger.Err(err))
+			}
+			return
+		}
+
+		err = m.store.Complete(ctx, storeKey, &entity.IdempotentResponse{
+			Fingerprint: fingerprint,
+			State:       entity.IdempotencyCompleted,
+			StatusCode:  rec.status,
+			Header:      rec.handlerHeader(),
+			Body:        rec.body.Bytes(),
+		}, m.keyTTL)
+		if err != nil {
+			m.log.Error("failed to store idempotent response", logger.Err(err))
+		}
+	})
+}
+
+func (m *IdempotencyMiddleware) replay(w http.ResponseWriter, r *http.Request, existing *entity.IdempotentResponse, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		problem.Write(w, r, status.Error(codes.AlreadyExists, "Idempotency-Key was already used with a different request"))
+		return
+	}
+
+	if existing.State == entity.IdempotencyInProgress {
+		st, _ := status.New(codes.Aborted, "a request with this Idempotency-Key is still being processed").
+			WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(idempotencyRetryAfter)})
+		problem.Write(w, r, st.Err())
+		return
+	}
+
+	for name, values := range existing.Header {
+		w.Header()[name] = slices.Clone(values)
+	}
+	w.Header().Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(existing.StatusCode)
+	w.Write(existing.Body)
+}
+
+// IdempotencyMetadata forwards the Idempotency-Key header to the backend
+// services as gRPC metadata. It is meant for runtime.WithMetadata.
+func IdempotencyMetadata(_ context.Context, r *http.Request) metadata.MD {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		return nil
+	}
+
+	return metadata.Pairs(IdempotencyMetadataKey, key)
+}
+
+func isUnsafeMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func requestFingerprint(principal string, r *http.Request, body []byte) string {
+	bodySum := sha256.Sum256(body)
+
+	h := sha256.New()
+	h.Write([]byte(strings.Join([]string{principal, r.Method, r.URL.Path}, "\n")))
+	h.Write(bodySum[:])
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder copies the response to the client and keeps it to be
+// stored. Of the headers it keeps only those the wrapped handler set: the
+// ones outer middleware set, e.g. CORS, are set again on a replay.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+	outer       http.Header
+	header      http.Header
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{
+		ResponseWriter: w,
+		status:         http.StatusOK,
+		outer:          w.Header().Clone(),
+	}
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.wroteHeader {
+		return
+	}
+	rr.status = status
+	rr.wroteHeader = true
+	rr.header = rr.handlerHeader()
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+// handlerHeader returns the headers the wrapped handler set or changed. Once
+// the header was written it is what was sent.
+func (rr *responseRecorder) handlerHeader() http.Header {
+	if rr.header != nil {
+		return rr.header
+	}
+
+	header := http.Header{}
+	for name, values := range rr.Header() {
+		if !slices.Equal(values, rr.outer[name]) {
+			header[name] = slices.Clone(values)
+		}
+	}
+	return header
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if !rr.wroteHeader {
+		rr.WriteHeader(http.StatusOK)
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
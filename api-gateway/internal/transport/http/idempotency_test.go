@@ -0,0 +1,176 @@
+package httpServ
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+)
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]entity.IdempotentResponse
+}
+
+func (s *memoryIdempotencyStore) Acquire(_ context.Context, key, fingerprint string, _ time.Duration) (*entity.IdempotentResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[key]; ok {
+		return &existing, false, nil
+	}
+	s.records[key] = entity.IdempotentResponse{Fingerprint: fingerprint, State: entity.IdempotencyInProgress}
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, key string, resp *entity.IdempotentResponse, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = *resp
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	principal := &entity.Principal{UserID: uuid.New(), Role: entity.Client}
+
+	newRequest := func(method, body, key string) *http.Request {
+		r := httptest.NewRequest(method, "/v1/orders", strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer token")
+		if key != "" {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		return r.WithContext(auth.WithPrincipal(r.Context(), principal))
+	}
+
+	setup := func(status int) (http.Handler, *int, *memoryIdempotencyStore) {
+		calls := 0
+		store := &memoryIdempotencyStore{records: map[string]entity.IdempotentResponse{}}
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(`{"order_id":"1"}`))
+		})
+		m := NewIdempotencyMiddleware(store, time.Hour, time.Minute, logger.New("local", nil))
+		return m.Handler(next), &calls, store
+	}
+
+	t.Run("replays the stored response", func(t *testing.T) {
+		h, calls, _ := setup(http.StatusCreated)
+
+		first := httptest.NewRecorder()
+		h.ServeHTTP(first, newRequest(http.MethodPost, `{"user_id":"u"}`, "k1"))
+		second := httptest.NewRecorder()
+		h.ServeHTTP(second, newRequest(http.MethodPost, `{"user_id":"u"}`, "k1"))
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "true", second.Header().Get(idempotentReplayedHeader))
+		assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	})
+
+	t.Run("replays only the headers the handler set", func(t *testing.T) {
+		h, _, _ := setup(http.StatusCreated)
+		withCORS := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "https://shop.example")
+			w.Header().Add("Vary", "Origin")
+			h.ServeHTTP(w, r)
+		})
+
+		withCORS.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPost, `{}`, "k1"))
+		second := httptest.NewRecorder()
+		withCORS.ServeHTTP(second, newRequest(http.MethodPost, `{}`, "k1"))
+
+		assert.Equal(t, []string{"https://shop.example"}, second.Header().Values("Access-Control-Allow-Origin"))
+		assert.Equal(t, []string{"Origin"}, second.Header().Values("Vary"))
+		assert.Equal(t, []string{"application/json"}, second.Header().Values("Content-Type"))
+	})
+
+	t.Run("rejects a different body with the same key", func(t *testing.T) {
+		h, calls, _ := setup(http.StatusCreated)
+
+		h.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPost, `{"user_id":"u"}`, "k1"))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newRequest(http.MethodPost, `{"user_id":"other"}`, "k1"))
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("rejects retries while the first request is running", func(t *testing.T) {
+		h, calls, store := setup(http.StatusCreated)
+
+		r := newRequest(http.MethodPost, `{}`, "k1")
+		scope := principal.UserID.String()
+		_, _, _ = store.Acquire(context.Background(), scope+":k1", requestFingerprint(scope, r, []byte(`{}`)), time.Minute)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+
+		assert.Equal(t, 0, *calls)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	})
+
+	t.Run("server errors are not remembered", func(t *testing.T) {
+		h, calls, _ := setup(http.StatusServiceUnavailable)
+
+		h.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPost, `{}`, "k1"))
+		h.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPost, `{}`, "k1"))
+
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("rejects bodies over the limit", func(t *testing.T) {
+		h, calls, _ := setup(http.StatusCreated)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newRequest(http.MethodPost, strings.Repeat("x", maxIdempotentBodySize+1), "k1"))
+
+		assert.Equal(t, 0, *calls)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("anonymous requests are not remembered", func(t *testing.T) {
+		h, calls, store := setup(http.StatusCreated)
+
+		for range 2 {
+			r := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(`{}`))
+			r.Header.Set(IdempotencyKeyHeader, "k1")
+			h.ServeHTTP(httptest.NewRecorder(), r)
+		}
+
+		assert.Equal(t, 2, *calls)
+		assert.Empty(t, store.records)
+	})
+
+	t.Run("safe methods and requests without a key pass through", func(t *testing.T) {
+		h, calls, _ := setup(http.StatusOK)
+
+		h.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodGet, "", "k1"))
+		h.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodGet, "", "k1"))
+		h.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPost, `{}`, ""))
+		h.ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPost, `{}`, ""))
+
+		assert.Equal(t, 4, *calls)
+	})
+}
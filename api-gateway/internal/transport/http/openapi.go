@@ -0,0 +1,234 @@
+package httpServ
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"reflect"
+)
+
+const (
+	OpenAPIPath    = "/openapi.json"
+	SwaggerUIPath  = "/docs"
+	bearerAuthName = "bearerAuth"
+)
+
+// publicOperations are served without an access token. Every other operation
+// in the merged spec requires the bearer scheme.
+var publicOperations = map[string][]string{
+	"/auth/signup":                        {"post"},
+	"/auth/verify":                        {"post"},
+	"/auth/signin":                        {"post"},
+	"/auth/refresh":                       {"post"},
+	"/products":                           {"get"},
+	"/products/search":                    {"get"},
+	"/products/{id}":                      {"get"},
+	"/categories":                         {"get"},
+	"/categories/{id}":                    {"get"},
+	"/categories/{categoryId}/products":   {"get"},
+	"/categories/{categoryId}/attributes": {"get"},
+	"/variants/{id}":                      {"get"},
+	"/sellers/{sellerId}/products":        {"get"},
+	"/products/{productId}/price-history": {"get"},
+	"/products/{productId}/reviews":       {"get"},
+	// GraphQL checks the caller per field.
+	"/graphql": {"get", "post"},
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Marketplace API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "` + OpenAPIPath + `", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// OpenAPIHandler serves one spec merged from the per-proto OpenAPI output and
+// the spec of the hand-written gateway routes, plus Swagger UI on top of it.
+type OpenAPIHandler struct {
+	spec []byte
+}
+
+func NewOpenAPIHandler(specs fs.FS) (*OpenAPIHandler, error) {
+	const op = "httpServ.NewOpenAPIHandler"
+
+	merged, err := mergeOpenAPISpecs(specs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	spec, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &OpenAPIHandler{spec: spec}, nil
+}
+
+func (h *OpenAPIHandler) Spec(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(h.spec)
+}
+
+func (h *OpenAPIHandler) UI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
+
+const problemDefinition = "problemDetails"
+
+var problemResponse = map[string]any{
+	"description": "An error in the RFC 7807 format.",
+	"schema":      map[string]any{"$ref": "#/definitions/" + problemDefinition},
+}
+
+var problemSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"type":        map[string]any{"type": "string", "description": "Stable URI of the problem type, e.g. /problems/not-found."},
+		"title":       map[string]any{"type": "string"},
+		"status":      map[string]any{"type": "integer", "format": "int32"},
+		"detail":      map[string]any{"type": "string"},
+		"instance":    map[string]any{"type": "string"},
+		"trace_id":    map[string]any{"type": "string"},
+		"retry_after": map[string]any{"type": "integer", "format": "int64"},
+		"reason":      map[string]any{"type": "string"},
+		"domain":      map[string]any{"type": "string"},
+		"metadata":    map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+		"invalid_params": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name":   map[string]any{"type": "string"},
+					"reason": map[string]any{"type": "string"},
+					"code":   map[string]any{"type": "string"},
+				},
+			},
+		},
+		"resource": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"type":        map[string]any{"type": "string"},
+				"name":        map[string]any{"type": "string"},
+				"owner":       map[string]any{"type": "string"},
+				"description": map[string]any{"type": "string"},
+			},
+		},
+	},
+}
+
+func mergeOpenAPISpecs(specs fs.FS) (map[string]any, error) {
+	files, err := fs.Glob(specs, "*.swagger.json")
+	if err != nil {
+		return nil, err
+	}
+
+	paths := map[string]any{}
+	definitions := map[string]any{}
+	var tags []any
+	seenTags := map[string]bool{}
+
+	for _, name := range files {
+		raw, err := fs.ReadFile(specs, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var spec struct {
+			Tags        []map[string]any          `json:"tags"`
+			Paths       map[string]map[string]any `json:"paths"`
+			Definitions map[string]any            `json:"definitions"`
+		}
+		if err := json.Unmarshal(raw, &spec); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		for _, tag := range spec.Tags {
+			tagName, _ := tag["name"].(string)
+			if !seenTags[tagName] {
+				seenTags[tagName] = true
+				tags = append(tags, tag)
+			}
+		}
+
+		for path, operations := range spec.Paths {
+			merged, _ := paths[path].(map[string]any)
+			if merged == nil {
+				merged = map[string]any{}
+				paths[path] = merged
+			}
+			for method, operation := range operations {
+				if _, ok := merged[method]; ok {
+					return nil, fmt.Errorf("%s: %s %s is defined twice", name, method, path)
+				}
+				merged[method] = operation
+			}
+		}
+
+		for defName, definition := range spec.Definitions {
+			if existing, ok := definitions[defName]; ok && !reflect.DeepEqual(existing, definition) {
+				return nil, fmt.Errorf("%s: conflicting definition %s", name, defName)
+			}
+			definitions[defName] = definition
+		}
+	}
+
+	// The gateway answers errors with problem details, not with rpcStatus.
+	definitions[problemDefinition] = problemSchema
+	for _, operations := range paths {
+		for _, operation := range operations.(map[string]any) {
+			if operation, ok := operation.(map[string]any); ok {
+				responses, _ := operation["responses"].(map[string]any)
+				if responses == nil {
+					responses = map[string]any{}
+					operation["responses"] = responses
+				}
+				responses["default"] = problemResponse
+			}
+		}
+	}
+
+	for path, methods := range publicOperations {
+		operations, _ := paths[path].(map[string]any)
+		for _, method := range methods {
+			if operation, ok := operations[method].(map[string]any); ok {
+				operation["security"] = []any{}
+			}
+		}
+	}
+
+	return map[string]any{
+		"swagger": "2.0",
+		"info": map[string]any{
+			"title":   "Marketplace API",
+			"version": "1.0",
+		},
+		"tags":     tags,
+		"consumes": []string{"application/json"},
+		"produces": []string{"application/json"},
+		"securityDefinitions": map[string]any{
+			bearerAuthName: map[string]any{
+				"type":        "apiKey",
+				"name":        "Authorization",
+				"in":          "header",
+				"description": "Access token in the form \"Bearer <token>\".",
+			},
+		},
+		"security": []any{
+			map[string]any{bearerAuthName: []any{}},
+		},
+		"paths":       paths,
+		"definitions": definitions,
+	}, nil
+}
@@ -0,0 +1,176 @@
+package httpServ
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/docs"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/client"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/payment"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/rbacAuth"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// pathParam matches a path template variable. protoc-gen-openapiv2 renames
+// variables to their JSON names, so routes are compared with them erased.
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+type route struct {
+	method string
+	path   string
+}
+
+// protoRoutes lists the HTTP bindings the gRPC gateway mux registers.
+func protoRoutes(t *testing.T) []route {
+	files := []protoreflect.FileDescriptor{
+		client.File_proto_user_proto,
+		order.File_proto_order_proto,
+		payment.File_proto_payment_proto,
+		product.File_proto_product_proto,
+		rbacAuth.File_proto_rbacAuth_proto,
+	}
+
+	var add func(rule *annotations.HttpRule)
+	var routes []route
+	add = func(rule *annotations.HttpRule) {
+		switch pattern := rule.GetPattern().(type) {
+		case *annotations.HttpRule_Get:
+			routes = append(routes, route{"get", pattern.Get})
+		case *annotations.HttpRule_Post:
+			routes = append(routes, route{"post", pattern.Post})
+		case *annotations.HttpRule_Put:
+			routes = append(routes, route{"put", pattern.Put})
+		case *annotations.HttpRule_Patch:
+			routes = append(routes, route{"patch", pattern.Patch})
+		case *annotations.HttpRule_Delete:
+			routes = append(routes, route{"delete", pattern.Delete})
+		case *annotations.HttpRule_Custom:
+			routes = append(routes, route{strings.ToLower(pattern.Custom.GetKind()), pattern.Custom.GetPath()})
+		}
+		for _, binding := range rule.GetAdditionalBindings() {
+			add(binding)
+		}
+	}
+
+	for _, file := range files {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				rule, ok := proto.GetExtension(methods.Get(j).Options(), annotations.E_Http).(*annotations.HttpRule)
+				require.True(t, ok)
+				if rule != nil {
+					add(rule)
+				}
+			}
+		}
+	}
+
+	return routes
+}
+
+// routerRoutes lists the hand-written routes registered on the gorilla router.
+func routerRoutes(t *testing.T, router *mux.Router) []route {
+	var routes []route
+	err := router.Walk(func(r *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := r.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		if path == OpenAPIPath || path == SwaggerUIPath {
+			return nil
+		}
+
+		methods, err := r.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			routes = append(routes, route{strings.ToLower(method), path})
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	return routes
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	h, err := NewOpenAPIHandler(docs.Specs)
+	require.NoError(t, err)
+	router := NewRouter(Handlers{
+		Aggregator:     &AggregatorHandler{},
+		Checkout:       &CheckoutHandler{},
+		OrderEvents:    &OrderEventsHandler{},
+		OrdersV2:       &OrdersV2Handler{},
+		ProductImages:  &ProductImagesHandler{},
+		ProductReviews: &ProductReviewsHandler{},
+		ProductCatalog: &ProductCatalogHandler{},
+		OpenAPI:        h,
+		GraphQL:        http.NotFoundHandler(),
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, OpenAPIPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var spec struct {
+		SecurityDefinitions map[string]any                       `json:"securityDefinitions"`
+		Paths               map[string]map[string]map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+
+	t.Run("every registered route is documented", func(t *testing.T) {
+		documented := map[route]bool{}
+		for path, operations := range spec.Paths {
+			for method := range operations {
+				documented[route{method, pathParam.ReplaceAllString(path, "{}")}] = true
+			}
+		}
+
+		routes := append(protoRoutes(t), routerRoutes(t, router)...)
+		require.NotEmpty(t, routes)
+
+		for _, r := range routes {
+			ok := documented[route{r.method, pathParam.ReplaceAllString(r.path, "{}")}]
+			assert.True(t, ok, "%s %s is missing from %s", strings.ToUpper(r.method), r.path, OpenAPIPath)
+		}
+	})
+
+	t.Run("security scheme", func(t *testing.T) {
+		assert.Contains(t, spec.SecurityDefinitions, bearerAuthName)
+
+		signin := spec.Paths["/auth/signin"]["post"]
+		assert.Equal(t, []any{}, signin["security"])
+		_, ok := spec.Paths["/v1/orders"]["post"]["security"]
+		assert.False(t, ok)
+	})
+
+	t.Run("errors are problem details", func(t *testing.T) {
+		for path, operations := range spec.Paths {
+			for method, operation := range operations {
+				responses, _ := operation["responses"].(map[string]any)
+				assert.Equal(t, problemResponse["schema"], responses["default"].(map[string]any)["schema"], "%s %s", method, path)
+			}
+		}
+	})
+
+	t.Run("swagger ui", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, SwaggerUIPath, nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), OpenAPIPath)
+	})
+}
@@ -0,0 +1,341 @@
+package httpServ
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/problem"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	OrderEventsPath       = "/v1/orders/events"
+	OrderEventsWSPath     = "/v1/orders/events/ws"
+	OrderEventsTicketPath = "/v1/orders/events/tickets"
+
+	lastEventIDHeader = "Last-Event-ID"
+	// lastEventIDParam and ticketParam stand in for the headers that
+	// browsers cannot set on EventSource and WebSocket requests. A ticket
+	// is used instead of the access token, which would end up in the logs
+	// of every proxy on the way.
+	lastEventIDParam = "last_event_id"
+	ticketParam      = "ticket"
+	ticketSize       = 32
+
+	orderEventName = "order_status"
+	wsWriteTimeout = 5 * time.Second
+)
+
+type OrderWatcher interface {
+	WatchOrders(ctx context.Context, in *order.WatchOrdersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[order.OrderEvent], error)
+}
+
+// StreamTicketStore keeps the short-lived, single-use tickets that open a
+// stream for the caller they were issued to.
+type StreamTicketStore interface {
+	Issue(ctx context.Context, ticket string, principal *entity.Principal, ttl time.Duration) error
+	Redeem(ctx context.Context, ticket string) (*entity.Principal, error)
+}
+
+// StreamTicket is returned to the caller, who passes the ticket in the query
+// of the stream request.
+type StreamTicket struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// OrderEventsHandler streams status changes of the caller's orders over
+// Server-Sent Events and WebSocket. Every event carries its id, a client that
+// reconnects with it as Last-Event-ID gets the changes it missed.
+type OrderEventsHandler struct {
+	orders         OrderWatcher
+	tickets        StreamTicketStore
+	ticketTTL      time.Duration
+	heartbeat      time.Duration
+	reconnectDelay time.Duration
+	log            *logger.Logger
+
+	upgrader     websocket.Upgrader
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+func NewOrderEventsHandler(orders OrderWatcher, tickets StreamTicketStore, cfg config.OrderEvents, log *logger.Logger) *OrderEventsHandler {
+	return &OrderEventsHandler{
+		orders:         orders,
+		tickets:        tickets,
+		ticketTTL:      cfg.TicketTTL,
+		heartbeat:      cfg.HeartbeatInterval,
+		reconnectDelay: cfg.ReconnectDelay,
+		log:            log,
+		shutdown:       make(chan struct{}),
+	}
+}
+
+// Shutdown ends all open streams. http.Server.Shutdown does not interrupt
+// active requests, so the handler is registered with RegisterOnShutdown.
+func (h *OrderEventsHandler) Shutdown() {
+	h.shutdownOnce.Do(func() { close(h.shutdown) })
+}
+
+// Ticket issues a ticket that opens one stream of the caller's orders.
+func (h *OrderEventsHandler) Ticket(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, status.Error(codes.Unauthenticated, "authentication required"))
+		return
+	}
+
+	raw := make([]byte, ticketSize)
+	if _, err := rand.Read(raw); err != nil {
+		problem.Write(w, r, status.Error(codes.Internal, err.Error()))
+		return
+	}
+	ticket := StreamTicket{
+		Ticket:    base64.RawURLEncoding.EncodeToString(raw),
+		ExpiresAt: time.Now().Add(h.ticketTTL).UTC(),
+	}
+
+	if err := h.tickets.Issue(r.Context(), ticket.Ticket, principal, h.ticketTTL); err != nil {
+		problem.Write(w, r, status.Error(codes.Internal, err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ticket)
+}
+
+func (h *OrderEventsHandler) SSE(w http.ResponseWriter, r *http.Request) {
+	principal, lastEventID, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		problem.Write(w, r, status.Error(codes.Unimplemented, "streaming is not supported"))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	events, errc := h.watch(ctx, principal, lastEventID)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", h.reconnectDelay.Milliseconds())
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-events:
+			data, err := protojson.Marshal(event)
+			if err != nil {
+				h.log.Error("failed to marshal order event", logger.Err(err))
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.GetEventId(), orderEventName, data)
+		case <-ticker.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case err := <-errc:
+			h.log.Error("order events stream failed", logger.Err(err))
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", status.Convert(err).Message())
+			flusher.Flush()
+			return
+		case <-h.shutdown:
+			return
+		case <-ctx.Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (h *OrderEventsHandler) WebSocket(w http.ResponseWriter, r *http.Request) {
+	principal, lastEventID, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// The upgrader has already replied to the client on failure.
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	events, errc := h.watch(ctx, principal, lastEventID)
+
+	// Clients send nothing but control frames. Reading handles them and
+	// notices a closed connection; a client that stops answering pings is
+	// dropped after two heartbeats.
+	conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	closeWith := func(code int, text string) {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(wsWriteTimeout))
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-events:
+			data, err := protojson.Marshal(event)
+			if err != nil {
+				h.log.Error("failed to marshal order event", logger.Err(err))
+				closeWith(websocket.CloseInternalServerErr, "")
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err = conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case err := <-errc:
+			h.log.Error("order events stream failed", logger.Err(err))
+			closeWith(websocket.CloseInternalServerErr, status.Convert(err).Message())
+			return
+		case <-h.shutdown:
+			closeWith(websocket.CloseGoingAway, "server is shutting down")
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// parseRequest resolves the caller and the event to resume after. It replies
+// to the client itself when the request is rejected.
+func (h *OrderEventsHandler) parseRequest(w http.ResponseWriter, r *http.Request) (*entity.Principal, int64, bool) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		if ticket := r.URL.Query().Get(ticketParam); ticket != "" {
+			redeemed, err := h.tickets.Redeem(r.Context(), ticket)
+			switch {
+			case err == nil:
+				principal, ok = redeemed, true
+			case !errors.Is(err, entity.ErrStreamTicketNotFound):
+				problem.Write(w, r, status.Error(codes.Internal, err.Error()))
+				return nil, 0, false
+			}
+		}
+	}
+	if !ok {
+		problem.Write(w, r, status.Error(codes.Unauthenticated, "authentication required"))
+		return nil, 0, false
+	}
+
+	rawID := r.Header.Get(lastEventIDHeader)
+	if rawID == "" {
+		rawID = r.URL.Query().Get(lastEventIDParam)
+	}
+	if rawID == "" {
+		return principal, 0, true
+	}
+
+	lastEventID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || lastEventID < 0 {
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid last event id"))
+		return nil, 0, false
+	}
+
+	return principal, lastEventID, true
+}
+
+// watch follows the order-service stream until ctx is done. The stream is
+// reopened after the last received event whenever order-service ends it, e.g.
+// on a restart or when the connection reaches its maximum age.
+func (h *OrderEventsHandler) watch(ctx context.Context, principal *entity.Principal, lastEventID int64) (<-chan *order.OrderEvent, <-chan error) {
+	events := make(chan *order.OrderEvent)
+	errc := make(chan error, 1)
+
+	go func() {
+		for {
+			err := h.follow(ctx, principal, &lastEventID, events)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil && status.Code(err) != codes.Unavailable {
+				errc <- err
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(h.reconnectDelay):
+			}
+		}
+	}()
+
+	return events, errc
+}
+
+func (h *OrderEventsHandler) follow(ctx context.Context, principal *entity.Principal, lastEventID *int64, events chan<- *order.OrderEvent) error {
+	stream, err := h.orders.WatchOrders(ctx, &order.WatchOrdersRequest{
+		UserId:       principal.UserID.String(),
+		AfterEventId: *lastEventID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case events <- event:
+			*lastEventID = event.GetEventId()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
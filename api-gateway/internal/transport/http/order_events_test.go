@@ -0,0 +1,256 @@
+package httpServ
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeTokens struct {
+	principal *entity.Principal
+}
+
+func (f *fakeTokens) Parse(token string) (*entity.Principal, error) {
+	if token != "valid" {
+		return nil, errors.New("invalid token")
+	}
+	return f.principal, nil
+}
+
+// memoryTickets keeps the stream tickets in memory, ignoring their TTL.
+type memoryTickets struct {
+	mu      sync.Mutex
+	tickets map[string]*entity.Principal
+}
+
+func (m *memoryTickets) Issue(_ context.Context, ticket string, principal *entity.Principal, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tickets[ticket] = principal
+	return nil
+}
+
+func (m *memoryTickets) Redeem(_ context.Context, ticket string) (*entity.Principal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	principal, ok := m.tickets[ticket]
+	if !ok {
+		return nil, entity.ErrStreamTicketNotFound
+	}
+	delete(m.tickets, ticket)
+	return principal, nil
+}
+
+type fakeOrderStream struct {
+	grpc.ClientStream
+
+	ctx    context.Context
+	events []*order.OrderEvent
+	// end is returned once the events are sent. A nil end keeps the stream
+	// open until the call is cancelled.
+	end error
+}
+
+func (s *fakeOrderStream) Recv() (*order.OrderEvent, error) {
+	if len(s.events) > 0 {
+		event := s.events[0]
+		s.events = s.events[1:]
+		return event, nil
+	}
+	if s.end != nil {
+		return nil, s.end
+	}
+	<-s.ctx.Done()
+	return nil, status.FromContextError(s.ctx.Err()).Err()
+}
+
+// fakeWatcher serves one scripted stream per call.
+type fakeWatcher struct {
+	mu       sync.Mutex
+	streams  []*fakeOrderStream
+	requests []*order.WatchOrdersRequest
+}
+
+func (f *fakeWatcher) WatchOrders(ctx context.Context, in *order.WatchOrdersRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[order.OrderEvent], error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, in)
+	stream := &fakeOrderStream{}
+	if len(f.streams) > 0 {
+		stream, f.streams = f.streams[0], f.streams[1:]
+	}
+	stream.ctx = ctx
+	return stream, nil
+}
+
+func (f *fakeWatcher) afterEventIDs() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := make([]int64, len(f.requests))
+	for i, r := range f.requests {
+		ids[i] = r.GetAfterEventId()
+	}
+	return ids
+}
+
+func TestOrderEventsHandler(t *testing.T) {
+	principal := &entity.Principal{UserID: uuid.New(), Role: entity.Client}
+
+	setup := func(streams ...*fakeOrderStream) (*OrderEventsHandler, *fakeWatcher, *httptest.Server) {
+		watcher := &fakeWatcher{streams: streams}
+		h := NewOrderEventsHandler(watcher, &memoryTickets{tickets: map[string]*entity.Principal{}}, config.OrderEvents{
+			HeartbeatInterval: 50 * time.Millisecond,
+			ReconnectDelay:    time.Millisecond,
+			TicketTTL:         time.Minute,
+		}, logger.New("local", nil))
+
+		mux := http.NewServeMux()
+		mux.HandleFunc(OrderEventsPath, h.SSE)
+		mux.HandleFunc(OrderEventsWSPath, h.WebSocket)
+		mux.HandleFunc(OrderEventsTicketPath, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "" {
+				r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+			}
+			h.Ticket(w, r)
+		})
+		srv := httptest.NewServer(mux)
+		t.Cleanup(srv.Close)
+
+		return h, watcher, srv
+	}
+
+	issueTicket := func(t *testing.T, srv *httptest.Server) string {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+OrderEventsTicketPath, nil)
+		req.Header.Set("Authorization", "Bearer valid")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var ticket StreamTicket
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&ticket))
+		require.NotEmpty(t, ticket.Ticket)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), ticket.ExpiresAt, 5*time.Second)
+		return ticket.Ticket
+	}
+
+	t.Run("requires authentication", func(t *testing.T) {
+		_, _, srv := setup()
+
+		resp, err := http.Get(srv.URL + OrderEventsPath)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, err = http.Get(srv.URL + OrderEventsPath + "?access_token=valid")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "access tokens are not accepted in the query")
+
+		resp, err = http.Post(srv.URL+OrderEventsTicketPath, "", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, err = http.Get(srv.URL + OrderEventsPath + "?ticket=" + issueTicket(t, srv) + "&last_event_id=x")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("tickets are single-use", func(t *testing.T) {
+		h, _, srv := setup()
+		defer h.Shutdown()
+
+		ticket := issueTicket(t, srv)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+OrderEventsPath+"?ticket="+ticket, nil)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = http.Get(srv.URL + OrderEventsPath + "?ticket=" + ticket)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("server-sent events resume and survive stream restarts", func(t *testing.T) {
+		h, watcher, srv := setup(
+			&fakeOrderStream{events: []*order.OrderEvent{{EventId: 8, Status: "Delivery status"}}, end: status.Error(codes.Unavailable, "restart")},
+			&fakeOrderStream{events: []*order.OrderEvent{{EventId: 9, Status: "Shipped status"}}},
+		)
+
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+OrderEventsPath+"?ticket="+issueTicket(t, srv), nil)
+		req.Header.Set("Last-Event-ID", "7")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		var ids []string
+		heartbeat := false
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() && (len(ids) < 2 || !heartbeat) {
+			line := scanner.Text()
+			if id, ok := strings.CutPrefix(line, "id: "); ok {
+				ids = append(ids, id)
+			}
+			heartbeat = heartbeat || line == ": heartbeat"
+		}
+
+		assert.Equal(t, []string{"8", "9"}, ids)
+		assert.Equal(t, []int64{7, 8}, watcher.afterEventIDs())
+
+		h.Shutdown()
+		for scanner.Scan() {
+		}
+		assert.NoError(t, scanner.Err(), "the stream ends on shutdown")
+	})
+
+	t.Run("websocket", func(t *testing.T) {
+		h, _, srv := setup(&fakeOrderStream{events: []*order.OrderEvent{{EventId: 3, Status: "Accepted status"}}})
+
+		url := "ws" + strings.TrimPrefix(srv.URL, "http") + OrderEventsWSPath + "?ticket=" + issueTicket(t, srv)
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"eventId":"3"`)
+
+		h.Shutdown()
+		for err == nil {
+			_, _, err = conn.ReadMessage()
+		}
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+	})
+}
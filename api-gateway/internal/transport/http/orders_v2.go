@@ -0,0 +1,222 @@
+package httpServ
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/problem"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	OrdersV2Path = "/v2/orders"
+
+	// defaultOrdersPageSize is sent when the client asks for the first page
+	// without a size: order-service returns every order without one.
+	defaultOrdersPageSize = 20
+)
+
+type OrderClient interface {
+	CreateOrder(ctx context.Context, in *order.CreateOrderRequest, opts ...grpc.CallOption) (*order.CreateOrderResponse, error)
+	GetOrder(ctx context.Context, in *order.GetOrderRequest, opts ...grpc.CallOption) (*order.GetOrderResponse, error)
+	ListOrdersByUser(ctx context.Context, in *order.ListOrdersRequest, opts ...grpc.CallOption) (*order.ListOrdersResponse, error)
+	CancelOrder(ctx context.Context, in *order.CancelOrderRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListItemsFromOrder(ctx context.Context, in *order.ListItemsRequest, opts ...grpc.CallOption) (*order.ListItemsResponse, error)
+}
+
+// OrdersV2Handler serves the v2 order API. Unlike v1 it takes the user from
+// the access token instead of the request, pages the order list with cursors
+// and returns amounts as entity.Money.
+type OrdersV2Handler struct {
+	orders OrderClient
+	money  config.Money
+	log    *logger.Logger
+}
+
+func NewOrdersV2Handler(orders OrderClient, money config.Money, log *logger.Logger) *OrdersV2Handler {
+	return &OrdersV2Handler{
+		orders: orders,
+		money:  money,
+		log:    log,
+	}
+}
+
+func (h *OrdersV2Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	var pageSize int64
+	if raw := r.URL.Query().Get("page_size"); raw != "" {
+		var err error
+		if pageSize, err = strconv.ParseInt(raw, 10, 32); err != nil || pageSize < 1 {
+			problem.Write(w, r, status.Error(codes.InvalidArgument, "page_size must be a positive integer"))
+			return
+		}
+	}
+
+	pageToken := r.URL.Query().Get("page_token")
+	if pageSize == 0 && pageToken == "" {
+		pageSize = defaultOrdersPageSize
+	}
+
+	resp, err := h.orders.ListOrdersByUser(r.Context(), &order.ListOrdersRequest{
+		UserId:    userID,
+		PageSize:  int32(pageSize),
+		PageToken: pageToken,
+	})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	page := entity.OrderPageV2{
+		Orders:        make([]entity.OrderV2, 0, len(resp.GetOrders())),
+		NextPageToken: resp.GetNextPageToken(),
+	}
+	for _, o := range resp.GetOrders() {
+		page.Orders = append(page.Orders, h.order(o))
+	}
+
+	h.writeJSON(w, http.StatusOK, page)
+}
+
+func (h *OrdersV2Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.caller(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.orders.CreateOrder(r.Context(), &order.CreateOrderRequest{UserId: userID})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	resp, err := h.orders.GetOrder(r.Context(), &order.GetOrderRequest{UserId: userID, OrderId: created.GetOrderId()})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", OrdersV2Path+"/"+created.GetOrderId())
+	h.writeJSON(w, http.StatusCreated, h.order(resp.GetOrder()))
+}
+
+func (h *OrdersV2Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.callerOrder(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.orders.GetOrder(r.Context(), &order.GetOrderRequest{UserId: userID, OrderId: orderID})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, h.order(resp.GetOrder()))
+}
+
+func (h *OrdersV2Handler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.callerOrder(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.orders.CancelOrder(r.Context(), &order.CancelOrderRequest{UserId: userID, OrderId: orderID}); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OrdersV2Handler) Items(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.callerOrder(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.orders.ListItemsFromOrder(r.Context(), &order.ListItemsRequest{UserId: userID, OrderId: orderID})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	items := make([]entity.OrderItemV2, 0, len(resp.GetItems()))
+	for _, item := range resp.GetItems() {
+		items = append(items, entity.OrderItemV2{
+			ID:        item.GetItemId(),
+			ProductID: item.GetProductId(),
+			VariantID: item.GetVariantId(),
+			Quantity:  item.GetQuantity(),
+			Price:     h.amount(item.GetPrice()),
+			Subtotal:  h.amount(item.GetPrice() * item.GetQuantity()),
+		})
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// caller returns the ID of the authenticated user, the only user whose orders
+// the v2 API serves.
+func (h *OrdersV2Handler) caller(w http.ResponseWriter, r *http.Request) (string, bool) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, status.Error(codes.Unauthenticated, "authentication required"))
+		return "", false
+	}
+
+	return principal.UserID.String(), true
+}
+
+func (h *OrdersV2Handler) callerOrder(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	userID, ok := h.caller(w, r)
+	if !ok {
+		return "", "", false
+	}
+
+	orderID, err := uuid.Parse(mux.Vars(r)["order_id"])
+	if err != nil {
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid order id: "+err.Error()))
+		return "", "", false
+	}
+
+	return userID, orderID.String(), true
+}
+
+func (h *OrdersV2Handler) order(o *order.Order) entity.OrderV2 {
+	return entity.OrderV2{
+		ID:        o.GetOrderId(),
+		Status:    o.GetStatus(),
+		Total:     h.amount(int64(o.GetTotalAmount())),
+		CreatedAt: o.GetCreatedAt().AsTime(),
+		UpdatedAt: o.GetUpdatedAt().AsTime(),
+	}
+}
+
+func (h *OrdersV2Handler) amount(minorUnits int64) entity.Money {
+	return entity.NewMoney(minorUnits, h.money.Currency, h.money.Exponent)
+}
+
+func (h *OrdersV2Handler) writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.log.Error("failed to write response", logger.Err(err))
+	}
+}
@@ -0,0 +1,165 @@
+package httpServ
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type fakeOrderClient struct {
+	OrderClient
+
+	listReq *order.ListOrdersRequest
+	orders  map[string]*order.Order
+}
+
+func (f *fakeOrderClient) ListOrdersByUser(_ context.Context, in *order.ListOrdersRequest, _ ...grpc.CallOption) (*order.ListOrdersResponse, error) {
+	f.listReq = in
+
+	resp := &order.ListOrdersResponse{NextPageToken: "next"}
+	for _, o := range f.orders {
+		if o.GetUserId() == in.GetUserId() {
+			resp.Orders = append(resp.Orders, o)
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeOrderClient) GetOrder(_ context.Context, in *order.GetOrderRequest, _ ...grpc.CallOption) (*order.GetOrderResponse, error) {
+	o, ok := f.orders[in.GetOrderId()]
+	if !ok || o.GetUserId() != in.GetUserId() {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+	return &order.GetOrderResponse{Order: o}, nil
+}
+
+func (f *fakeOrderClient) CancelOrder(_ context.Context, in *order.CancelOrderRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	if _, err := f.GetOrder(context.Background(), &order.GetOrderRequest{UserId: in.GetUserId(), OrderId: in.GetOrderId()}); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func TestOrdersV2Handler(t *testing.T) {
+	userID, otherID := uuid.New(), uuid.New()
+	ownOrder, otherOrder := uuid.NewString(), uuid.NewString()
+	orders := &fakeOrderClient{orders: map[string]*order.Order{
+		ownOrder:   {OrderId: ownOrder, UserId: userID.String(), TotalAmount: 12345, Status: "Accepted status"},
+		otherOrder: {OrderId: otherOrder, UserId: otherID.String(), TotalAmount: 100},
+	}}
+
+	h := NewOrdersV2Handler(orders, config.Money{Currency: "RUB", Exponent: 2}, logger.New("local", nil))
+	router := NewRouter(Handlers{OrdersV2: h})
+
+	do := func(method, target string, authenticated bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if authenticated {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &entity.Principal{UserID: userID, Role: entity.Client}))
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("requires authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("GET", "/v2/orders", false).Code)
+	})
+
+	t.Run("lists the caller's orders with structured money", func(t *testing.T) {
+		rec := do("GET", "/v2/orders?page_token=abc", true)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var page entity.OrderPageV2
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+		require.Len(t, page.Orders, 1)
+		assert.Equal(t, ownOrder, page.Orders[0].ID)
+		assert.Equal(t, entity.Money{Currency: "RUB", MinorUnits: 12345, Amount: "123.45"}, page.Orders[0].Total)
+		assert.Equal(t, "next", page.NextPageToken)
+		assert.Equal(t, "abc", orders.listReq.GetPageToken())
+	})
+
+	t.Run("always pages", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, do("GET", "/v2/orders", true).Code)
+		assert.Equal(t, int32(defaultOrdersPageSize), orders.listReq.GetPageSize())
+
+		assert.Equal(t, http.StatusBadRequest, do("GET", "/v2/orders?page_size=-1", true).Code)
+	})
+
+	t.Run("serves only the caller's orders", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("GET", "/v2/orders/"+ownOrder, true).Code)
+		assert.Equal(t, http.StatusNotFound, do("GET", "/v2/orders/"+otherOrder, true).Code)
+		assert.Equal(t, http.StatusNoContent, do("POST", "/v2/orders/"+ownOrder+"/cancel", true).Code)
+		assert.Equal(t, http.StatusNotFound, do("POST", "/v2/orders/"+otherOrder+"/cancel", true).Code)
+	})
+}
+
+func TestAPIVersions(t *testing.T) {
+	deprecatedAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	versions := NewAPIVersions(config.APIVersions{Deprecated: []config.DeprecatedAPI{{
+		Prefix:       "/v1/orders",
+		DeprecatedAt: deprecatedAt,
+		Sunset:       sunset,
+		Successor:    "/v2/orders",
+	}}})
+
+	h := versions.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	t.Run("marks deprecated versions", func(t *testing.T) {
+		rec := get("/v1/orders/42")
+
+		assert.Equal(t, "@1748736000", rec.Header().Get("Deprecation"))
+		assert.Equal(t, "Mon, 01 Dec 2025 00:00:00 GMT", rec.Header().Get("Sunset"))
+		assert.Equal(t, `</v2/orders>; rel="successor-version"`, rec.Header().Get("Link"))
+	})
+
+	t.Run("leaves other versions alone", func(t *testing.T) {
+		for _, path := range []string{"/v2/orders", "/v1/ordersx", "/products"} {
+			assert.Empty(t, get(path).Header().Get("Deprecation"), path)
+		}
+	})
+
+	t.Run("counts requests per version", func(t *testing.T) {
+		get("/v2/orders")
+		get("/missing")
+
+		assert.Equal(t, 1.0, counterValue(t, versions, "v1", "orders", "true", "200"))
+		assert.Equal(t, 2.0, counterValue(t, versions, "v2", "orders", "false", "200"))
+		assert.Equal(t, 1.0, counterValue(t, versions, unversioned, "products", "false", "200"))
+		assert.Equal(t, 1.0, counterValue(t, versions, unversioned, unmatchedResource, "false", "404"))
+	})
+}
+
+func counterValue(t *testing.T, versions *APIVersions, labels ...string) float64 {
+	t.Helper()
+
+	counter, err := versions.requests.GetMetricWithLabelValues(labels...)
+	require.NoError(t, err)
+	return testutil.ToFloat64(counter)
+}
@@ -0,0 +1,269 @@
+package httpServ
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/problem"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// maxImportUploadSize bounds the multipart body: the 20 MiB
+	// product-service accepts plus room for the multipart headers.
+	maxImportUploadSize = 20<<20 + 64<<10
+	importChunkSize     = 64 << 10
+	importFormField     = "file"
+)
+
+// catalogFormats are the formats of import and export files, by the name
+// used in the format query parameter and by file extension.
+var catalogFormats = map[string]product.CatalogFormat{
+	"csv":  product.CatalogFormat_CATALOG_FORMAT_CSV,
+	"json": product.CatalogFormat_CATALOG_FORMAT_JSON,
+}
+
+var catalogContentTypes = map[product.CatalogFormat]string{
+	product.CatalogFormat_CATALOG_FORMAT_CSV:  "text/csv; charset=utf-8",
+	product.CatalogFormat_CATALOG_FORMAT_JSON: "application/json",
+}
+
+type ProductCatalogClient interface {
+	ImportProducts(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[product.ImportProductsRequest, product.ImportJob], error)
+	ExportProducts(ctx context.Context, in *product.ExportProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[product.ExportProductsChunk], error)
+}
+
+// ProductCatalogHandler streams import files of sellers to product-service
+// and their exports back, in chunks in both directions.
+type ProductCatalogHandler struct {
+	products ProductCatalogClient
+	log      *logger.Logger
+}
+
+func NewProductCatalogHandler(products ProductCatalogClient, log *logger.Logger) *ProductCatalogHandler {
+	return &ProductCatalogHandler{
+		products: products,
+		log:      log,
+	}
+}
+
+// Import takes the file in the file field of a multipart body. Its format
+// comes from the format query parameter, or else from the extension or
+// content type of the file.
+func (h *ProductCatalogHandler) Import(w http.ResponseWriter, r *http.Request) {
+	if _, ok := auth.PrincipalFromContext(r.Context()); !ok {
+		problem.Write(w, r, status.Error(codes.Unauthenticated, "authentication required"))
+		return
+	}
+
+	dryRun := false
+	if raw := r.URL.Query().Get("dry_run"); raw != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			problem.Write(w, r, status.Error(codes.InvalidArgument, "dry_run must be true or false"))
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportUploadSize)
+	part, err := importPart(r)
+	if err != nil {
+		h.writeImportError(w, r, err)
+		return
+	}
+	defer part.Close()
+
+	format, err := importFormat(r, part)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	stream, err := h.products.ImportProducts(r.Context())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	options := &product.ImportOptions{Format: format, DryRun: dryRun}
+	if err := h.send(stream, part, options); err != nil {
+		_ = stream.CloseSend()
+		h.writeImportError(w, r, err)
+		return
+	}
+
+	job, err := stream.CloseAndRecv()
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	body, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(job)
+	if err != nil {
+		h.log.Error("failed to encode import job", logger.Err(err))
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/catalog/imports/"+job.GetId())
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write(body)
+}
+
+// Export streams the products of the caller as a file, CSV unless the
+// format query parameter asks for JSON.
+func (h *ProductCatalogHandler) Export(w http.ResponseWriter, r *http.Request) {
+	if _, ok := auth.PrincipalFromContext(r.Context()); !ok {
+		problem.Write(w, r, status.Error(codes.Unauthenticated, "authentication required"))
+		return
+	}
+
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "csv"
+	}
+	format, ok := catalogFormats[name]
+	if !ok {
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "format must be csv or json"))
+		return
+	}
+
+	stream, err := h.products.ExportProducts(r.Context(), &product.ExportProductsRequest{Format: format})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	// The status is sent with the first chunk, so a failure before it is
+	// still reported as a problem.
+	chunk, err := stream.Recv()
+	if err != nil && !errors.Is(err, io.EOF) {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", catalogContentTypes[format])
+	w.Header().Set("Content-Disposition", `attachment; filename="products.`+name+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	for err == nil {
+		if _, err := w.Write(chunk.GetData()); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		chunk, err = stream.Recv()
+	}
+	if !errors.Is(err, io.EOF) {
+		// The response has started, so the client only sees it cut short.
+		h.log.Error("failed to stream product export", logger.Err(err))
+	}
+}
+
+// send streams the options and then the file. io.EOF from Send means
+// product-service has already failed the import: CloseAndRecv returns why.
+func (h *ProductCatalogHandler) send(stream grpc.ClientStreamingClient[product.ImportProductsRequest, product.ImportJob], file io.Reader, options *product.ImportOptions) error {
+	err := stream.Send(&product.ImportProductsRequest{Data: &product.ImportProductsRequest_Options{Options: options}})
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, importChunkSize)
+	for {
+		n, readErr := io.ReadFull(file, buf)
+		if n > 0 {
+			chunk := &product.ImportProductsRequest{Data: &product.ImportProductsRequest_Chunk{Chunk: buf[:n]}}
+			if err := stream.Send(chunk); errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+func (h *ProductCatalogHandler) writeImportError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		problem.WriteHTTP(w, r, http.StatusRequestEntityTooLarge, status.Error(codes.InvalidArgument, "the import file must not exceed 20 MiB"))
+	case status.Code(err) != codes.Unknown:
+		problem.Write(w, r, err)
+	default:
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid multipart body: "+err.Error()))
+	}
+}
+
+// importPart returns the file field of a multipart/form-data body, skipping
+// the other fields.
+func importPart(r *http.Request) (*multipart.Part, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "a multipart/form-data body is expected")
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, status.Errorf(codes.InvalidArgument, "the %q field is missing", importFormField)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FormName() == importFormField {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+func importFormat(r *http.Request, part *multipart.Part) (product.CatalogFormat, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		format, ok := catalogFormats[name]
+		if !ok {
+			return 0, status.Error(codes.InvalidArgument, "format must be csv or json")
+		}
+		return format, nil
+	}
+
+	if format, ok := catalogFormats[strings.ToLower(strings.TrimPrefix(path.Ext(part.FileName()), "."))]; ok {
+		return format, nil
+	}
+
+	contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+	for format, known := range catalogContentTypes {
+		if knownType, _, _ := mime.ParseMediaType(known); knownType == contentType {
+			return format, nil
+		}
+	}
+
+	return 0, status.Error(codes.InvalidArgument, "the format of the file is unknown, set format to csv or json")
+}
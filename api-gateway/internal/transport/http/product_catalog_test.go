@@ -0,0 +1,215 @@
+package httpServ
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeImportStream struct {
+	grpc.ClientStream
+
+	options *product.ImportOptions
+	data    []byte
+	chunks  int
+}
+
+func (f *fakeImportStream) Send(req *product.ImportProductsRequest) error {
+	switch data := req.GetData().(type) {
+	case *product.ImportProductsRequest_Options:
+		f.options = data.Options
+	case *product.ImportProductsRequest_Chunk:
+		f.data = append(f.data, data.Chunk...)
+		f.chunks++
+	}
+	return nil
+}
+
+func (f *fakeImportStream) CloseAndRecv() (*product.ImportJob, error) {
+	return &product.ImportJob{
+		Id:     uuid.NewString(),
+		Format: f.options.GetFormat(),
+		DryRun: f.options.GetDryRun(),
+		Status: product.ImportJobStatus_IMPORT_JOB_STATUS_PENDING,
+	}, nil
+}
+
+func (f *fakeImportStream) CloseSend() error { return nil }
+
+type fakeExportStream struct {
+	grpc.ClientStream
+
+	chunks [][]byte
+	err    error
+}
+
+func (f *fakeExportStream) Recv() (*product.ExportProductsChunk, error) {
+	if len(f.chunks) == 0 {
+		if f.err != nil {
+			return nil, f.err
+		}
+		return nil, io.EOF
+	}
+
+	chunk := f.chunks[0]
+	f.chunks = f.chunks[1:]
+	return &product.ExportProductsChunk{Data: chunk}, nil
+}
+
+type fakeProductCatalogClient struct {
+	imports *fakeImportStream
+	exports *fakeExportStream
+	format  product.CatalogFormat
+}
+
+func (f *fakeProductCatalogClient) ImportProducts(context.Context, ...grpc.CallOption) (grpc.ClientStreamingClient[product.ImportProductsRequest, product.ImportJob], error) {
+	return f.imports, nil
+}
+
+func (f *fakeProductCatalogClient) ExportProducts(_ context.Context, in *product.ExportProductsRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[product.ExportProductsChunk], error) {
+	f.format = in.GetFormat()
+	return f.exports, nil
+}
+
+func newCatalogRouter(client *fakeProductCatalogClient) http.Handler {
+	h := NewProductCatalogHandler(client, logger.New("local", nil))
+	return NewRouter(Handlers{ProductCatalog: h})
+}
+
+func withClient(req *http.Request) *http.Request {
+	return req.WithContext(auth.WithPrincipal(req.Context(), &entity.Principal{UserID: uuid.New(), Role: entity.Client}))
+}
+
+func TestProductCatalogHandler_Import(t *testing.T) {
+	file := bytes.Repeat([]byte("NB-1,Ноутбук,Лёгкий,1000,5\n"), 3*importChunkSize/20)
+
+	upload := func(router http.Handler, query, filename string, authenticated bool) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile(importFormField, filename)
+		require.NoError(t, err)
+		_, err = part.Write(file)
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/catalog/imports"+query, &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		if authenticated {
+			req = withClient(req)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("the file is streamed in chunks after the options", func(t *testing.T) {
+		client := &fakeProductCatalogClient{imports: &fakeImportStream{}}
+
+		rec := upload(newCatalogRouter(client), "?dry_run=true", "products.CSV", true)
+
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		assert.Equal(t, product.CatalogFormat_CATALOG_FORMAT_CSV, client.imports.options.GetFormat())
+		assert.True(t, client.imports.options.GetDryRun())
+		assert.Equal(t, file, client.imports.data)
+		assert.Greater(t, client.imports.chunks, 1)
+
+		var job struct {
+			Id     string `json:"id"`
+			Status string `json:"status"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+		assert.Equal(t, "IMPORT_JOB_STATUS_PENDING", job.Status)
+		assert.Equal(t, "/catalog/imports/"+job.Id, rec.Header().Get("Location"))
+	})
+
+	t.Run("the format parameter wins over the extension", func(t *testing.T) {
+		client := &fakeProductCatalogClient{imports: &fakeImportStream{}}
+
+		rec := upload(newCatalogRouter(client), "?format=json", "products.csv", true)
+
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		assert.Equal(t, product.CatalogFormat_CATALOG_FORMAT_JSON, client.imports.options.GetFormat())
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		client := &fakeProductCatalogClient{imports: &fakeImportStream{}}
+
+		rec := upload(newCatalogRouter(client), "", "products.xlsx", true)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Nil(t, client.imports.options)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		client := &fakeProductCatalogClient{imports: &fakeImportStream{}}
+
+		rec := upload(newCatalogRouter(client), "", "products.csv", false)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Nil(t, client.imports.options)
+	})
+}
+
+func TestProductCatalogHandler_Export(t *testing.T) {
+	export := func(router http.Handler, query string) *httptest.ResponseRecorder {
+		req := withClient(httptest.NewRequest(http.MethodGet, "/catalog/export"+query, nil))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("the chunks are written as they come", func(t *testing.T) {
+		client := &fakeProductCatalogClient{exports: &fakeExportStream{chunks: [][]byte{[]byte("[\n  {\"sku\":\"NB-1\"}"), []byte("\n]\n")}}}
+
+		rec := export(newCatalogRouter(client), "?format=json")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, product.CatalogFormat_CATALOG_FORMAT_JSON, client.format)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="products.json"`, rec.Header().Get("Content-Disposition"))
+		assert.Equal(t, "[\n  {\"sku\":\"NB-1\"}\n]\n", rec.Body.String())
+	})
+
+	t.Run("CSV by default", func(t *testing.T) {
+		client := &fakeProductCatalogClient{exports: &fakeExportStream{chunks: [][]byte{[]byte("sku,name,description,price,stock\n")}}}
+
+		rec := export(newCatalogRouter(client), "")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, product.CatalogFormat_CATALOG_FORMAT_CSV, client.format)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("a failure before the first chunk is a problem", func(t *testing.T) {
+		client := &fakeProductCatalogClient{exports: &fakeExportStream{err: status.Error(codes.Unauthenticated, "authentication required")}}
+
+		rec := export(newCatalogRouter(client), "")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, rec.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		client := &fakeProductCatalogClient{exports: &fakeExportStream{}}
+
+		rec := export(newCatalogRouter(client), "?format=xml")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
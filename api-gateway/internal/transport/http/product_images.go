@@ -0,0 +1,170 @@
+package httpServ
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/problem"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// maxImageUploadSize bounds the multipart body: the 10 MiB product-service
+	// accepts plus room for the multipart headers.
+	maxImageUploadSize = 10<<20 + 64<<10
+	imageChunkSize     = 64 << 10
+	imageFormField     = "image"
+)
+
+type ProductImageClient interface {
+	UploadProductImage(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[product.UploadProductImageRequest, product.UploadProductImageResponse], error)
+}
+
+// ProductImagesHandler takes multipart image uploads and streams them to
+// product-service in chunks, without buffering the whole image.
+type ProductImagesHandler struct {
+	products ProductImageClient
+	log      *logger.Logger
+}
+
+func NewProductImagesHandler(products ProductImageClient, log *logger.Logger) *ProductImagesHandler {
+	return &ProductImagesHandler{
+		products: products,
+		log:      log,
+	}
+}
+
+func (h *ProductImagesHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	if _, ok := auth.PrincipalFromContext(r.Context()); !ok {
+		problem.Write(w, r, status.Error(codes.Unauthenticated, "authentication required"))
+		return
+	}
+
+	productID, err := uuid.Parse(mux.Vars(r)["product_id"])
+	if err != nil {
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid product id: "+err.Error()))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageUploadSize)
+	part, err := imagePart(r)
+	if err != nil {
+		writeImageUploadError(w, r, err)
+		return
+	}
+	defer part.Close()
+
+	stream, err := h.products.UploadProductImage(r.Context())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if err := h.send(stream, part, productID.String()); err != nil {
+		_ = stream.CloseSend()
+		writeImageUploadError(w, r, err)
+		return
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	body, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(resp)
+	if err != nil {
+		h.log.Error("failed to encode uploaded image", logger.Err(err))
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(body)
+}
+
+// send streams the product ID and then the image. io.EOF from Send means
+// product-service has already failed the upload: CloseAndRecv returns why.
+func (h *ProductImagesHandler) send(stream grpc.ClientStreamingClient[product.UploadProductImageRequest, product.UploadProductImageResponse], image io.Reader, productID string) error {
+	err := stream.Send(&product.UploadProductImageRequest{Data: &product.UploadProductImageRequest_ProductId{ProductId: productID}})
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return sendImageChunks(image, func(chunk []byte) error {
+		return stream.Send(&product.UploadProductImageRequest{Data: &product.UploadProductImageRequest_Chunk{Chunk: chunk}})
+	})
+}
+
+// sendImageChunks reads the image in chunks and passes them to send. It
+// stops without an error on io.EOF from send, as the stream has failed.
+func sendImageChunks(image io.Reader, send func(chunk []byte) error) error {
+	buf := make([]byte, imageChunkSize)
+	for {
+		n, readErr := io.ReadFull(image, buf)
+		if n > 0 {
+			if err := send(buf[:n]); errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+func writeImageUploadError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		problem.WriteHTTP(w, r, http.StatusRequestEntityTooLarge, status.Error(codes.InvalidArgument, "the image must not exceed 10 MiB"))
+	case status.Code(err) != codes.Unknown:
+		problem.Write(w, r, err)
+	default:
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid multipart body: "+err.Error()))
+	}
+}
+
+// imagePart returns the image field of a multipart/form-data body, skipping
+// the other fields.
+func imagePart(r *http.Request) (io.ReadCloser, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "a multipart/form-data body is expected")
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, status.Errorf(codes.InvalidArgument, "the %q field is missing", imageFormField)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FormName() == imageFormField {
+			return part, nil
+		}
+		part.Close()
+	}
+}
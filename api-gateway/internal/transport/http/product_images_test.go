@@ -0,0 +1,124 @@
+package httpServ
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"google.golang.org/grpc"
+)
+
+type fakeUploadStream struct {
+	grpc.ClientStream
+
+	productID string
+	data      []byte
+	chunks    int
+}
+
+func (f *fakeUploadStream) Send(req *product.UploadProductImageRequest) error {
+	switch data := req.GetData().(type) {
+	case *product.UploadProductImageRequest_ProductId:
+		f.productID = data.ProductId
+	case *product.UploadProductImageRequest_Chunk:
+		f.data = append(f.data, data.Chunk...)
+		f.chunks++
+	}
+	return nil
+}
+
+func (f *fakeUploadStream) CloseAndRecv() (*product.UploadProductImageResponse, error) {
+	return &product.UploadProductImageResponse{Image: &product.ProductImage{
+		Id:   uuid.NewString(),
+		Url:  "http://localhost:9000/product-images/" + f.productID,
+		Size: int64(len(f.data)),
+	}}, nil
+}
+
+func (f *fakeUploadStream) CloseSend() error { return nil }
+
+type fakeProductImageClient struct {
+	stream *fakeUploadStream
+}
+
+func (f *fakeProductImageClient) UploadProductImage(context.Context, ...grpc.CallOption) (grpc.ClientStreamingClient[product.UploadProductImageRequest, product.UploadProductImageResponse], error) {
+	return f.stream, nil
+}
+
+func TestProductImagesHandler_Upload(t *testing.T) {
+	productID := uuid.NewString()
+	image := bytes.Repeat([]byte{0xAB}, 3*imageChunkSize+100)
+
+	newRouter := func(client *fakeProductImageClient) http.Handler {
+		h := NewProductImagesHandler(client, logger.New("local", nil))
+		return NewRouter(Handlers{ProductImages: h})
+	}
+
+	upload := func(router http.Handler, field string, authenticated bool) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		require.NoError(t, form.WriteField("comment", "front view"))
+		part, err := form.CreateFormFile(field, "photo.jpg")
+		require.NoError(t, err)
+		_, err = part.Write(image)
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/products/"+productID+"/images", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		if authenticated {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &entity.Principal{UserID: uuid.New(), Role: entity.Client}))
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("the image is streamed in chunks", func(t *testing.T) {
+		client := &fakeProductImageClient{stream: &fakeUploadStream{}}
+
+		rec := upload(newRouter(client), "image", true)
+
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		assert.Equal(t, productID, client.stream.productID)
+		assert.Equal(t, image, client.stream.data)
+		assert.Equal(t, 4, client.stream.chunks)
+
+		var resp struct {
+			Image struct {
+				Size string `json:"size"`
+			} `json:"image"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "196708", resp.Image.Size)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		client := &fakeProductImageClient{stream: &fakeUploadStream{}}
+
+		rec := upload(newRouter(client), "image", false)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, client.stream.productID)
+	})
+
+	t.Run("no image field", func(t *testing.T) {
+		client := &fakeProductImageClient{stream: &fakeUploadStream{}}
+
+		rec := upload(newRouter(client), "file", true)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, client.stream.productID)
+	})
+}
@@ -0,0 +1,169 @@
+package httpServ
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/problem"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type DeliveredOrderClient interface {
+	FindDeliveredOrder(ctx context.Context, in *order.FindDeliveredOrderRequest, opts ...grpc.CallOption) (*order.FindDeliveredOrderResponse, error)
+}
+
+type ProductReviewClient interface {
+	CreateReview(ctx context.Context, in *product.CreateReviewRequest, opts ...grpc.CallOption) (*product.CreateReviewResponse, error)
+	UploadReviewImage(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[product.UploadReviewImageRequest, product.UploadReviewImageResponse], error)
+}
+
+// ProductReviewsHandler creates reviews and takes their images. A review
+// needs a delivered order of the product, which only order-service knows
+// about, so product-service takes reviews from the gateway alone.
+type ProductReviewsHandler struct {
+	orders   DeliveredOrderClient
+	products ProductReviewClient
+	log      *logger.Logger
+}
+
+func NewProductReviewsHandler(orders DeliveredOrderClient, products ProductReviewClient, log *logger.Logger) *ProductReviewsHandler {
+	return &ProductReviewsHandler{
+		orders:   orders,
+		products: products,
+		log:      log,
+	}
+}
+
+type createReviewInput struct {
+	Rating int32  `json:"rating"`
+	Text   string `json:"text"`
+}
+
+func (h *ProductReviewsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, status.Error(codes.Unauthenticated, "authentication required"))
+		return
+	}
+
+	productID, err := uuid.Parse(mux.Vars(r)["product_id"])
+	if err != nil {
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid product id: "+err.Error()))
+		return
+	}
+
+	var input createReviewInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid request body: "+err.Error()))
+		return
+	}
+
+	delivered, err := h.orders.FindDeliveredOrder(r.Context(), &order.FindDeliveredOrderRequest{
+		UserId:    principal.UserID.String(),
+		ProductId: productID.String(),
+	})
+	if status.Code(err) == codes.NotFound {
+		problem.Write(w, r, status.Error(codes.PermissionDenied, "only buyers with a delivered order of the product can review it"))
+		return
+	}
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	resp, err := h.products.CreateReview(r.Context(), &product.CreateReviewRequest{
+		ProductId: productID.String(),
+		OrderId:   delivered.GetOrder().GetOrderId(),
+		Rating:    input.Rating,
+		Text:      input.Text,
+	})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	h.writeCreated(w, r, resp)
+}
+
+func (h *ProductReviewsHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	if _, ok := auth.PrincipalFromContext(r.Context()); !ok {
+		problem.Write(w, r, status.Error(codes.Unauthenticated, "authentication required"))
+		return
+	}
+
+	reviewID, err := uuid.Parse(mux.Vars(r)["review_id"])
+	if err != nil {
+		problem.Write(w, r, status.Error(codes.InvalidArgument, "invalid review id: "+err.Error()))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageUploadSize)
+	part, err := imagePart(r)
+	if err != nil {
+		writeImageUploadError(w, r, err)
+		return
+	}
+	defer part.Close()
+
+	stream, err := h.products.UploadReviewImage(r.Context())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if err := h.send(stream, part, reviewID.String()); err != nil {
+		_ = stream.CloseSend()
+		writeImageUploadError(w, r, err)
+		return
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	h.writeCreated(w, r, resp)
+}
+
+// send streams the review ID and then the image, like
+// ProductImagesHandler.send.
+func (h *ProductReviewsHandler) send(stream grpc.ClientStreamingClient[product.UploadReviewImageRequest, product.UploadReviewImageResponse], image io.Reader, reviewID string) error {
+	err := stream.Send(&product.UploadReviewImageRequest{Data: &product.UploadReviewImageRequest_ReviewId{ReviewId: reviewID}})
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return sendImageChunks(image, func(chunk []byte) error {
+		return stream.Send(&product.UploadReviewImageRequest{Data: &product.UploadReviewImageRequest_Chunk{Chunk: chunk}})
+	})
+}
+
+func (h *ProductReviewsHandler) writeCreated(w http.ResponseWriter, r *http.Request, resp proto.Message) {
+	body, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(resp)
+	if err != nil {
+		h.log.Error("failed to encode review", logger.Err(err))
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(body)
+}
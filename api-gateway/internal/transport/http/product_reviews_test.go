@@ -0,0 +1,173 @@
+package httpServ
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/order"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/api/product"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/auth"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeDeliveredOrderClient struct {
+	orderID string
+	req     *order.FindDeliveredOrderRequest
+}
+
+func (f *fakeDeliveredOrderClient) FindDeliveredOrder(_ context.Context, in *order.FindDeliveredOrderRequest, _ ...grpc.CallOption) (*order.FindDeliveredOrderResponse, error) {
+	f.req = in
+	if f.orderID == "" {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+	return &order.FindDeliveredOrderResponse{Order: &order.Order{OrderId: f.orderID, UserId: in.GetUserId()}}, nil
+}
+
+type fakeReviewUploadStream struct {
+	grpc.ClientStream
+
+	reviewID string
+	data     []byte
+}
+
+func (f *fakeReviewUploadStream) Send(req *product.UploadReviewImageRequest) error {
+	switch data := req.GetData().(type) {
+	case *product.UploadReviewImageRequest_ReviewId:
+		f.reviewID = data.ReviewId
+	case *product.UploadReviewImageRequest_Chunk:
+		f.data = append(f.data, data.Chunk...)
+	}
+	return nil
+}
+
+func (f *fakeReviewUploadStream) CloseAndRecv() (*product.UploadReviewImageResponse, error) {
+	return &product.UploadReviewImageResponse{Image: &product.ReviewImage{Id: uuid.NewString(), Size: int64(len(f.data))}}, nil
+}
+
+func (f *fakeReviewUploadStream) CloseSend() error { return nil }
+
+type fakeProductReviewClient struct {
+	created *product.CreateReviewRequest
+	stream  *fakeReviewUploadStream
+}
+
+func (f *fakeProductReviewClient) CreateReview(_ context.Context, in *product.CreateReviewRequest, _ ...grpc.CallOption) (*product.CreateReviewResponse, error) {
+	f.created = in
+	return &product.CreateReviewResponse{Review: &product.Review{
+		Id:        uuid.NewString(),
+		ProductId: in.GetProductId(),
+		Rating:    in.GetRating(),
+		Text:      in.GetText(),
+		Status:    product.ReviewStatus_REVIEW_STATUS_PUBLISHED,
+	}}, nil
+}
+
+func (f *fakeProductReviewClient) UploadReviewImage(context.Context, ...grpc.CallOption) (grpc.ClientStreamingClient[product.UploadReviewImageRequest, product.UploadReviewImageResponse], error) {
+	return f.stream, nil
+}
+
+func newReviewsRouter(orders *fakeDeliveredOrderClient, products *fakeProductReviewClient) http.Handler {
+	h := NewProductReviewsHandler(orders, products, logger.New("local", nil))
+	return NewRouter(Handlers{ProductReviews: h})
+}
+
+func TestProductReviewsHandler_Create(t *testing.T) {
+	productID, userID := uuid.NewString(), uuid.New()
+
+	create := func(router http.Handler, body string, authenticated bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/products/"+productID+"/reviews", strings.NewReader(body))
+		if authenticated {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &entity.Principal{UserID: userID, Role: entity.Client}))
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("the review is created with the delivered order", func(t *testing.T) {
+		orders := &fakeDeliveredOrderClient{orderID: uuid.NewString()}
+		products := &fakeProductReviewClient{}
+
+		rec := create(newReviewsRouter(orders, products), `{"rating": 5, "text": "Отличный товар"}`, true)
+
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		assert.Equal(t, userID.String(), orders.req.GetUserId())
+		assert.Equal(t, productID, orders.req.GetProductId())
+		assert.Equal(t, orders.orderID, products.created.GetOrderId())
+		assert.Equal(t, int32(5), products.created.GetRating())
+
+		var resp struct {
+			Review struct {
+				Rating int    `json:"rating"`
+				Status string `json:"status"`
+			} `json:"review"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 5, resp.Review.Rating)
+		assert.Equal(t, "REVIEW_STATUS_PUBLISHED", resp.Review.Status)
+	})
+
+	t.Run("buyers without a delivered order can't review", func(t *testing.T) {
+		products := &fakeProductReviewClient{}
+
+		rec := create(newReviewsRouter(&fakeDeliveredOrderClient{}, products), `{"rating": 5}`, true)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Nil(t, products.created)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		orders := &fakeDeliveredOrderClient{orderID: uuid.NewString()}
+
+		rec := create(newReviewsRouter(orders, &fakeProductReviewClient{}), `{"rating": 5}`, false)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Nil(t, orders.req)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		orders := &fakeDeliveredOrderClient{orderID: uuid.NewString()}
+
+		rec := create(newReviewsRouter(orders, &fakeProductReviewClient{}), `{"rating": "five"}`, true)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Nil(t, orders.req)
+	})
+}
+
+func TestProductReviewsHandler_UploadImage(t *testing.T) {
+	reviewID := uuid.NewString()
+	image := bytes.Repeat([]byte{0xCD}, 2*imageChunkSize+10)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile(imageFormField, "photo.png")
+	require.NoError(t, err)
+	_, err = part.Write(image)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	products := &fakeProductReviewClient{stream: &fakeReviewUploadStream{}}
+	req := httptest.NewRequest(http.MethodPost, "/reviews/"+reviewID+"/images", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req = withClient(req)
+	rec := httptest.NewRecorder()
+	newReviewsRouter(&fakeDeliveredOrderClient{}, products).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, reviewID, products.stream.reviewID)
+	assert.Equal(t, image, products.stream.data)
+}
@@ -1,13 +1,71 @@
 package httpServ
 
 import (
+	"net/http"
+
 	"github.com/gorilla/mux"
 )
 
-func NewRouter(aggregatorHandler *AggregatorHandler) *mux.Router {
+// Handlers are the handlers of the routes the gateway serves itself. The
+// routes of a handler left nil are not registered.
+type Handlers struct {
+	Aggregator     *AggregatorHandler
+	Checkout       *CheckoutHandler
+	OrderEvents    *OrderEventsHandler
+	OrdersV2       *OrdersV2Handler
+	ProductImages  *ProductImagesHandler
+	ProductReviews *ProductReviewsHandler
+	ProductCatalog *ProductCatalogHandler
+	OpenAPI        *OpenAPIHandler
+	GraphQL        http.Handler
+}
+
+func NewRouter(h Handlers) *mux.Router {
 	router := mux.NewRouter()
 
-	router.HandleFunc("/usprofile/profile-with-auth", aggregatorHandler.SignUpUserWithCreateProfile).Methods("GET")
+	if h.Aggregator != nil {
+		router.HandleFunc("/usprofile/profile-with-auth", h.Aggregator.SignUpUserWithCreateProfile).Methods("GET")
+	}
+
+	if h.Checkout != nil {
+		router.HandleFunc("/v1/checkout", h.Checkout.Checkout).Methods("POST")
+		router.HandleFunc("/v1/checkout/{saga_id}", h.Checkout.Status).Methods("GET")
+	}
+
+	if h.OrderEvents != nil {
+		router.HandleFunc(OrderEventsPath, h.OrderEvents.SSE).Methods("GET")
+		router.HandleFunc(OrderEventsWSPath, h.OrderEvents.WebSocket).Methods("GET")
+		router.HandleFunc(OrderEventsTicketPath, h.OrderEvents.Ticket).Methods("POST")
+	}
+
+	if h.OrdersV2 != nil {
+		router.HandleFunc(OrdersV2Path, h.OrdersV2.List).Methods("GET")
+		router.HandleFunc(OrdersV2Path, h.OrdersV2.Create).Methods("POST")
+		router.HandleFunc(OrdersV2Path+"/{order_id}", h.OrdersV2.Get).Methods("GET")
+		router.HandleFunc(OrdersV2Path+"/{order_id}/cancel", h.OrdersV2.Cancel).Methods("POST")
+		router.HandleFunc(OrdersV2Path+"/{order_id}/items", h.OrdersV2.Items).Methods("GET")
+	}
+
+	if h.ProductImages != nil {
+		router.HandleFunc("/products/{product_id}/images", h.ProductImages.Upload).Methods("POST")
+	}
+	if h.ProductReviews != nil {
+		router.HandleFunc("/products/{product_id}/reviews", h.ProductReviews.Create).Methods("POST")
+		router.HandleFunc("/reviews/{review_id}/images", h.ProductReviews.UploadImage).Methods("POST")
+	}
+	if h.ProductCatalog != nil {
+		router.HandleFunc("/catalog/imports", h.ProductCatalog.Import).Methods("POST")
+		router.HandleFunc("/catalog/export", h.ProductCatalog.Export).Methods("GET")
+	}
+
+	if h.GraphQL != nil {
+		router.Handle("/graphql", h.GraphQL).Methods("GET", "POST")
+	}
+
+	if h.OpenAPI != nil {
+		router.HandleFunc(OpenAPIPath, h.OpenAPI.Spec).Methods("GET")
+		router.HandleFunc(SwaggerUIPath, h.OpenAPI.UI).Methods("GET")
+	}
 
 	return router
 }
@@ -0,0 +1,105 @@
+package httpServ
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/config"
+)
+
+const (
+	// unversioned is the version label of routes without a version prefix,
+	// auth, products, payments and profiles among them.
+	unversioned = "unversioned"
+	// unmatchedResource keeps paths that match no route out of the labels.
+	unmatchedResource = "unmatched"
+)
+
+var versionSegment = regexp.MustCompile(`^v[0-9]+$`)
+
+// APIVersions counts requests per API version and marks the responses of
+// deprecated versions with the Deprecation (RFC 9745), Sunset (RFC 8594) and
+// successor Link headers.
+//
+// The version of a route is its first path segment, "/v1/orders" and
+// "/v2/orders" are versions 1 and 2 of the orders resource. A new version of a
+// resource is served next to the old one until the old one is retired.
+type APIVersions struct {
+	deprecated []config.DeprecatedAPI
+	requests   *prometheus.CounterVec
+}
+
+func NewAPIVersions(cfg config.APIVersions) *APIVersions {
+	return &APIVersions{
+		deprecated: cfg.Deprecated,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gateway_api_requests_total",
+			Help: "Requests to the gateway REST API by version, resource and HTTP status.",
+		}, []string{"version", "resource", "deprecated", "code"}),
+	}
+}
+
+func (v *APIVersions) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{v.requests}
+}
+
+func (v *APIVersions) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deprecation, deprecated := v.deprecation(r.URL.Path)
+		if deprecated {
+			setDeprecationHeaders(w.Header(), deprecation)
+		}
+
+		// httpsnoop keeps the Flusher and Hijacker of w for the event streams.
+		metrics := httpsnoop.CaptureMetrics(next, w, r)
+
+		version, resource := apiVersion(r.URL.Path)
+		if metrics.Code == http.StatusNotFound || metrics.Code == http.StatusMethodNotAllowed {
+			resource = unmatchedResource
+		}
+		v.requests.WithLabelValues(version, resource, strconv.FormatBool(deprecated), strconv.Itoa(metrics.Code)).Inc()
+	})
+}
+
+func (v *APIVersions) deprecation(path string) (config.DeprecatedAPI, bool) {
+	for _, api := range v.deprecated {
+		if path == api.Prefix || strings.HasPrefix(path, strings.TrimSuffix(api.Prefix, "/")+"/") {
+			return api, true
+		}
+	}
+
+	return config.DeprecatedAPI{}, false
+}
+
+func setDeprecationHeaders(header http.Header, api config.DeprecatedAPI) {
+	if !api.DeprecatedAt.IsZero() {
+		header.Set("Deprecation", "@"+strconv.FormatInt(api.DeprecatedAt.Unix(), 10))
+	}
+	if !api.Sunset.IsZero() {
+		header.Set("Sunset", api.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if api.Successor != "" {
+		header.Add("Link", "<"+api.Successor+`>; rel="successor-version"`)
+	}
+}
+
+// apiVersion splits a path into the API version and the resource, the
+// segment that follows the version.
+func apiVersion(path string) (string, string) {
+	segments := splitPath(path)
+	if len(segments) == 0 {
+		return unversioned, ""
+	}
+	if !versionSegment.MatchString(segments[0]) {
+		return unversioned, segments[0]
+	}
+	if len(segments) == 1 {
+		return segments[0], ""
+	}
+
+	return segments[0], segments[1]
+}
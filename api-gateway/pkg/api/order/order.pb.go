@@ -28,7 +28,7 @@ type UpdateOrderTotalRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	NewTotal      int64                  `protobuf:"varint,3,opt,name=new_total,json=newTotal,proto3" json:"new_total,omitempty"`
+	NewTotal      uint64                 `protobuf:"varint,3,opt,name=new_total,json=newTotal,proto3" json:"new_total,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -77,7 +77,7 @@ func (x *UpdateOrderTotalRequest) GetOrderId() string {
 	return ""
 }
 
-func (x *UpdateOrderTotalRequest) GetNewTotal() int64 {
+func (x *UpdateOrderTotalRequest) GetNewTotal() uint64 {
 	if x != nil {
 		return x.NewTotal
 	}
@@ -469,8 +469,13 @@ func (x *GetOrderResponse) GetOrder() *Order {
 }
 
 type ListOrdersRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Orders are returned newest first. Without page_size and page_token all
+	// orders of the user are returned at once.
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous page.
+	PageToken     string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -512,16 +517,128 @@ func (x *ListOrdersRequest) GetUserId() string {
 	return ""
 }
 
-type ListOrdersResponse struct {
+func (x *ListOrdersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListOrdersRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type FindDeliveredOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ProductId     string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindDeliveredOrderRequest) Reset() {
+	*x = FindDeliveredOrderRequest{}
+	mi := &file_proto_order_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindDeliveredOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindDeliveredOrderRequest) ProtoMessage() {}
+
+func (x *FindDeliveredOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindDeliveredOrderRequest.ProtoReflect.Descriptor instead.
+func (*FindDeliveredOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *FindDeliveredOrderRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *FindDeliveredOrderRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+type FindDeliveredOrderResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindDeliveredOrderResponse) Reset() {
+	*x = FindDeliveredOrderResponse{}
+	mi := &file_proto_order_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindDeliveredOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindDeliveredOrderResponse) ProtoMessage() {}
+
+func (x *FindDeliveredOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindDeliveredOrderResponse.ProtoReflect.Descriptor instead.
+func (*FindDeliveredOrderResponse) Descriptor() ([]byte, []int) {
+	return file_proto_order_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *FindDeliveredOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type ListOrdersResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Orders []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	// Empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListOrdersResponse) Reset() {
 	*x = ListOrdersResponse{}
-	mi := &file_proto_order_proto_msgTypes[10]
+	mi := &file_proto_order_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -533,7 +650,7 @@ func (x *ListOrdersResponse) String() string {
 func (*ListOrdersResponse) ProtoMessage() {}
 
 func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[10]
+	mi := &file_proto_order_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -546,7 +663,7 @@ func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListOrdersResponse.ProtoReflect.Descriptor instead.
 func (*ListOrdersResponse) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{10}
+	return file_proto_order_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ListOrdersResponse) GetOrders() []*Order {
@@ -556,18 +673,28 @@ func (x *ListOrdersResponse) GetOrders() []*Order {
 	return nil
 }
 
+func (x *ListOrdersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
 type AddItemRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	ProductId     string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
-	Quantity      int64                  `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	OrderId      string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	ProductId    string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity     int64                  `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	ProductPrice uint64                 `protobuf:"varint,4,opt,name=productPrice,proto3" json:"productPrice,omitempty"`
+	// Optional: the variant (SKU) of the product being ordered.
+	VariantId     string `protobuf:"bytes,5,opt,name=variant_id,json=variantId,proto3" json:"variant_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *AddItemRequest) Reset() {
 	*x = AddItemRequest{}
-	mi := &file_proto_order_proto_msgTypes[11]
+	mi := &file_proto_order_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -579,7 +706,7 @@ func (x *AddItemRequest) String() string {
 func (*AddItemRequest) ProtoMessage() {}
 
 func (x *AddItemRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[11]
+	mi := &file_proto_order_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -592,7 +719,7 @@ func (x *AddItemRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddItemRequest.ProtoReflect.Descriptor instead.
 func (*AddItemRequest) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{11}
+	return file_proto_order_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *AddItemRequest) GetOrderId() string {
@@ -616,6 +743,20 @@ func (x *AddItemRequest) GetQuantity() int64 {
 	return 0
 }
 
+func (x *AddItemRequest) GetProductPrice() uint64 {
+	if x != nil {
+		return x.ProductPrice
+	}
+	return 0
+}
+
+func (x *AddItemRequest) GetVariantId() string {
+	if x != nil {
+		return x.VariantId
+	}
+	return ""
+}
+
 type AddItemResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ItemId        string                 `protobuf:"bytes,1,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`
@@ -625,7 +766,7 @@ type AddItemResponse struct {
 
 func (x *AddItemResponse) Reset() {
 	*x = AddItemResponse{}
-	mi := &file_proto_order_proto_msgTypes[12]
+	mi := &file_proto_order_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -637,7 +778,7 @@ func (x *AddItemResponse) String() string {
 func (*AddItemResponse) ProtoMessage() {}
 
 func (x *AddItemResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[12]
+	mi := &file_proto_order_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -650,7 +791,7 @@ func (x *AddItemResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddItemResponse.ProtoReflect.Descriptor instead.
 func (*AddItemResponse) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{12}
+	return file_proto_order_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *AddItemResponse) GetItemId() string {
@@ -669,7 +810,7 @@ type RemoveItemRequest struct {
 
 func (x *RemoveItemRequest) Reset() {
 	*x = RemoveItemRequest{}
-	mi := &file_proto_order_proto_msgTypes[13]
+	mi := &file_proto_order_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -681,7 +822,7 @@ func (x *RemoveItemRequest) String() string {
 func (*RemoveItemRequest) ProtoMessage() {}
 
 func (x *RemoveItemRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[13]
+	mi := &file_proto_order_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -694,7 +835,7 @@ func (x *RemoveItemRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RemoveItemRequest.ProtoReflect.Descriptor instead.
 func (*RemoveItemRequest) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{13}
+	return file_proto_order_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *RemoveItemRequest) GetItemId() string {
@@ -714,7 +855,7 @@ type UpdateItemRequest struct {
 
 func (x *UpdateItemRequest) Reset() {
 	*x = UpdateItemRequest{}
-	mi := &file_proto_order_proto_msgTypes[14]
+	mi := &file_proto_order_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -726,7 +867,7 @@ func (x *UpdateItemRequest) String() string {
 func (*UpdateItemRequest) ProtoMessage() {}
 
 func (x *UpdateItemRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[14]
+	mi := &file_proto_order_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -739,7 +880,7 @@ func (x *UpdateItemRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateItemRequest.ProtoReflect.Descriptor instead.
 func (*UpdateItemRequest) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{14}
+	return file_proto_order_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *UpdateItemRequest) GetItemId() string {
@@ -766,7 +907,7 @@ type ListItemsRequest struct {
 
 func (x *ListItemsRequest) Reset() {
 	*x = ListItemsRequest{}
-	mi := &file_proto_order_proto_msgTypes[15]
+	mi := &file_proto_order_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -778,7 +919,7 @@ func (x *ListItemsRequest) String() string {
 func (*ListItemsRequest) ProtoMessage() {}
 
 func (x *ListItemsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[15]
+	mi := &file_proto_order_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -791,7 +932,7 @@ func (x *ListItemsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListItemsRequest.ProtoReflect.Descriptor instead.
 func (*ListItemsRequest) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{15}
+	return file_proto_order_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *ListItemsRequest) GetUserId() string {
@@ -817,7 +958,7 @@ type ListItemsResponse struct {
 
 func (x *ListItemsResponse) Reset() {
 	*x = ListItemsResponse{}
-	mi := &file_proto_order_proto_msgTypes[16]
+	mi := &file_proto_order_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -829,7 +970,7 @@ func (x *ListItemsResponse) String() string {
 func (*ListItemsResponse) ProtoMessage() {}
 
 func (x *ListItemsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[16]
+	mi := &file_proto_order_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -842,7 +983,7 @@ func (x *ListItemsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListItemsResponse.ProtoReflect.Descriptor instead.
 func (*ListItemsResponse) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{16}
+	return file_proto_order_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *ListItemsResponse) GetItems() []*Item {
@@ -856,7 +997,7 @@ type Order struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
 	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	TotalAmount   int64                  `protobuf:"varint,3,opt,name=total_amount,json=totalAmount,proto3" json:"total_amount,omitempty"`
+	TotalAmount   uint64                 `protobuf:"varint,3,opt,name=total_amount,json=totalAmount,proto3" json:"total_amount,omitempty"`
 	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
 	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
@@ -866,7 +1007,7 @@ type Order struct {
 
 func (x *Order) Reset() {
 	*x = Order{}
-	mi := &file_proto_order_proto_msgTypes[17]
+	mi := &file_proto_order_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -878,7 +1019,7 @@ func (x *Order) String() string {
 func (*Order) ProtoMessage() {}
 
 func (x *Order) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[17]
+	mi := &file_proto_order_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -891,7 +1032,7 @@ func (x *Order) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Order.ProtoReflect.Descriptor instead.
 func (*Order) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{17}
+	return file_proto_order_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *Order) GetOrderId() string {
@@ -908,7 +1049,7 @@ func (x *Order) GetUserId() string {
 	return ""
 }
 
-func (x *Order) GetTotalAmount() int64 {
+func (x *Order) GetTotalAmount() uint64 {
 	if x != nil {
 		return x.TotalAmount
 	}
@@ -936,19 +1077,150 @@ func (x *Order) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+type WatchOrdersRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Events up to and including this one are skipped. Zero starts with the
+	// changes made after the call.
+	AfterEventId  int64 `protobuf:"varint,2,opt,name=after_event_id,json=afterEventId,proto3" json:"after_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchOrdersRequest) Reset() {
+	*x = WatchOrdersRequest{}
+	mi := &file_proto_order_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchOrdersRequest) ProtoMessage() {}
+
+func (x *WatchOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchOrdersRequest.ProtoReflect.Descriptor instead.
+func (*WatchOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *WatchOrdersRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *WatchOrdersRequest) GetAfterEventId() int64 {
+	if x != nil {
+		return x.AfterEventId
+	}
+	return 0
+}
+
+type OrderEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       int64                  `protobuf:"varint,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderEvent) Reset() {
+	*x = OrderEvent{}
+	mi := &file_proto_order_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderEvent) ProtoMessage() {}
+
+func (x *OrderEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderEvent.ProtoReflect.Descriptor instead.
+func (*OrderEvent) Descriptor() ([]byte, []int) {
+	return file_proto_order_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *OrderEvent) GetEventId() int64 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+func (x *OrderEvent) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
 type Item struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ItemId        string                 `protobuf:"bytes,1,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`
 	ProductId     string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
 	Quantity      int64                  `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
 	Price         int64                  `protobuf:"varint,4,opt,name=price,proto3" json:"price,omitempty"`
+	VariantId     string                 `protobuf:"bytes,5,opt,name=variant_id,json=variantId,proto3" json:"variant_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Item) Reset() {
 	*x = Item{}
-	mi := &file_proto_order_proto_msgTypes[18]
+	mi := &file_proto_order_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -960,7 +1232,7 @@ func (x *Item) String() string {
 func (*Item) ProtoMessage() {}
 
 func (x *Item) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[18]
+	mi := &file_proto_order_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -973,7 +1245,7 @@ func (x *Item) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Item.ProtoReflect.Descriptor instead.
 func (*Item) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{18}
+	return file_proto_order_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *Item) GetItemId() string {
@@ -1004,6 +1276,13 @@ func (x *Item) GetPrice() int64 {
 	return 0
 }
 
+func (x *Item) GetVariantId() string {
+	if x != nil {
+		return x.VariantId
+	}
+	return ""
+}
+
 var File_proto_order_proto protoreflect.FileDescriptor
 
 const file_proto_order_proto_rawDesc = "" +
@@ -1012,7 +1291,7 @@ const file_proto_order_proto_rawDesc = "" +
 	"\x17UpdateOrderTotalRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
 	"\border_id\x18\x02 \x01(\tR\aorderId\x12\x1b\n" +
-	"\tnew_total\x18\x03 \x01(\x03R\bnewTotal\"<\n" +
+	"\tnew_total\x18\x03 \x01(\x04R\bnewTotal\"<\n" +
 	"\x18UpdateOrderTotalResponse\x12 \n" +
 	"\x05order\x18\x01 \x01(\v2\n" +
 	".api.OrderR\x05order\"H\n" +
@@ -1035,17 +1314,31 @@ const file_proto_order_proto_rawDesc = "" +
 	".api.OrderR\x05order\"4\n" +
 	"\x10GetOrderResponse\x12 \n" +
 	"\x05order\x18\x01 \x01(\v2\n" +
-	".api.OrderR\x05order\",\n" +
+	".api.OrderR\x05order\"h\n" +
 	"\x11ListOrdersRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\tR\x06userId\"8\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"S\n" +
+	"\x19FindDeliveredOrderRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\tR\tproductId\">\n" +
+	"\x1aFindDeliveredOrderResponse\x12 \n" +
+	"\x05order\x18\x01 \x01(\v2\n" +
+	".api.OrderR\x05order\"`\n" +
 	"\x12ListOrdersResponse\x12\"\n" +
 	"\x06orders\x18\x01 \x03(\v2\n" +
-	".api.OrderR\x06orders\"f\n" +
+	".api.OrderR\x06orders\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xa9\x01\n" +
 	"\x0eAddItemRequest\x12\x19\n" +
 	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x02 \x01(\tR\tproductId\x12\x1a\n" +
-	"\bquantity\x18\x03 \x01(\x03R\bquantity\"*\n" +
+	"\bquantity\x18\x03 \x01(\x03R\bquantity\x12\"\n" +
+	"\fproductPrice\x18\x04 \x01(\x04R\fproductPrice\x12\x1d\n" +
+	"\n" +
+	"variant_id\x18\x05 \x01(\tR\tvariantId\"*\n" +
 	"\x0fAddItemResponse\x12\x17\n" +
 	"\aitem_id\x18\x01 \x01(\tR\x06itemId\",\n" +
 	"\x11RemoveItemRequest\x12\x17\n" +
@@ -1061,18 +1354,31 @@ const file_proto_order_proto_rawDesc = "" +
 	"\x05Order\x12\x19\n" +
 	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12!\n" +
-	"\ftotal_amount\x18\x03 \x01(\x03R\vtotalAmount\x12\x16\n" +
+	"\ftotal_amount\x18\x03 \x01(\x04R\vtotalAmount\x12\x16\n" +
 	"\x06status\x18\x04 \x01(\tR\x06status\x129\n" +
 	"\n" +
 	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"p\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"S\n" +
+	"\x12WatchOrdersRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12$\n" +
+	"\x0eafter_event_id\x18\x02 \x01(\x03R\fafterEventId\"\xae\x01\n" +
+	"\n" +
+	"OrderEvent\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\x03R\aeventId\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x8f\x01\n" +
 	"\x04Item\x12\x17\n" +
 	"\aitem_id\x18\x01 \x01(\tR\x06itemId\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x02 \x01(\tR\tproductId\x12\x1a\n" +
 	"\bquantity\x18\x03 \x01(\x03R\bquantity\x12\x14\n" +
-	"\x05price\x18\x04 \x01(\x03R\x05price2\xaa\b\n" +
+	"\x05price\x18\x04 \x01(\x03R\x05price\x12\x1d\n" +
+	"\n" +
+	"variant_id\x18\x05 \x01(\tR\tvariantId2\xbc\t\n" +
 	"\fOrderService\x12c\n" +
 	"\x0eAddItemToOrder\x12\x13.api.AddItemRequest\x1a\x14.api.AddItemResponse\"&\x82\xd3\xe4\x93\x02 :\x01*\"\x1b/v1/orders/{order_id}/items\x12i\n" +
 	"\x13RemoveItemFromOrder\x12\x16.api.RemoveItemRequest\x1a\x16.google.protobuf.Empty\"\"\x82\xd3\xe4\x93\x02\x1c*\x1a/v1/orders/items/{item_id}\x12j\n" +
@@ -1084,7 +1390,9 @@ const file_proto_order_proto_rawDesc = "" +
 	"\x11UpdateOrderStatus\x12\x1d.api.UpdateOrderStatusRequest\x1a\x1e.api.UpdateOrderStatusResponse\"'\x82\xd3\xe4\x93\x02!:\x01*2\x1c/v1/orders/{order_id}/status\x12w\n" +
 	"\x10UpdateOrderTotal\x12\x1c.api.UpdateOrderTotalRequest\x1a\x1d.api.UpdateOrderTotalResponse\"&\x82\xd3\xe4\x93\x02 :\x01*2\x1b/v1/orders/{order_id}/total\x12V\n" +
 	"\bGetOrder\x12\x14.api.GetOrderRequest\x1a\x15.api.GetOrderResponse\"\x1d\x82\xd3\xe4\x93\x02\x17\x12\x15/v1/orders/{order_id}\x12g\n" +
-	"\x10ListOrdersByUser\x12\x16.api.ListOrdersRequest\x1a\x17.api.ListOrdersResponse\"\"\x82\xd3\xe4\x93\x02\x1c\x12\x1a/v1/users/{user_id}/ordersB\x10Z\x0epkg/api/clientb\x06proto3"
+	"\x10ListOrdersByUser\x12\x16.api.ListOrdersRequest\x1a\x17.api.ListOrdersResponse\"\"\x82\xd3\xe4\x93\x02\x1c\x12\x1a/v1/users/{user_id}/orders\x129\n" +
+	"\vWatchOrders\x12\x17.api.WatchOrdersRequest\x1a\x0f.api.OrderEvent0\x01\x12U\n" +
+	"\x12FindDeliveredOrder\x12\x1e.api.FindDeliveredOrderRequest\x1a\x1f.api.FindDeliveredOrderResponseB\x0fZ\rpkg/api/orderb\x06proto3"
 
 var (
 	file_proto_order_proto_rawDescOnce sync.Once
@@ -1098,63 +1406,73 @@ func file_proto_order_proto_rawDescGZIP() []byte {
 	return file_proto_order_proto_rawDescData
 }
 
-var file_proto_order_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_proto_order_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
 var file_proto_order_proto_goTypes = []any{
-	(*UpdateOrderTotalRequest)(nil),   // 0: api.UpdateOrderTotalRequest
-	(*UpdateOrderTotalResponse)(nil),  // 1: api.UpdateOrderTotalResponse
-	(*CancelOrderRequest)(nil),        // 2: api.CancelOrderRequest
-	(*CreateOrderRequest)(nil),        // 3: api.CreateOrderRequest
-	(*CreateOrderResponse)(nil),       // 4: api.CreateOrderResponse
-	(*GetOrderRequest)(nil),           // 5: api.GetOrderRequest
-	(*UpdateOrderStatusRequest)(nil),  // 6: api.UpdateOrderStatusRequest
-	(*UpdateOrderStatusResponse)(nil), // 7: api.UpdateOrderStatusResponse
-	(*GetOrderResponse)(nil),          // 8: api.GetOrderResponse
-	(*ListOrdersRequest)(nil),         // 9: api.ListOrdersRequest
-	(*ListOrdersResponse)(nil),        // 10: api.ListOrdersResponse
-	(*AddItemRequest)(nil),            // 11: api.AddItemRequest
-	(*AddItemResponse)(nil),           // 12: api.AddItemResponse
-	(*RemoveItemRequest)(nil),         // 13: api.RemoveItemRequest
-	(*UpdateItemRequest)(nil),         // 14: api.UpdateItemRequest
-	(*ListItemsRequest)(nil),          // 15: api.ListItemsRequest
-	(*ListItemsResponse)(nil),         // 16: api.ListItemsResponse
-	(*Order)(nil),                     // 17: api.Order
-	(*Item)(nil),                      // 18: api.Item
-	(*timestamppb.Timestamp)(nil),     // 19: google.protobuf.Timestamp
-	(*emptypb.Empty)(nil),             // 20: google.protobuf.Empty
+	(*UpdateOrderTotalRequest)(nil),    // 0: api.UpdateOrderTotalRequest
+	(*UpdateOrderTotalResponse)(nil),   // 1: api.UpdateOrderTotalResponse
+	(*CancelOrderRequest)(nil),         // 2: api.CancelOrderRequest
+	(*CreateOrderRequest)(nil),         // 3: api.CreateOrderRequest
+	(*CreateOrderResponse)(nil),        // 4: api.CreateOrderResponse
+	(*GetOrderRequest)(nil),            // 5: api.GetOrderRequest
+	(*UpdateOrderStatusRequest)(nil),   // 6: api.UpdateOrderStatusRequest
+	(*UpdateOrderStatusResponse)(nil),  // 7: api.UpdateOrderStatusResponse
+	(*GetOrderResponse)(nil),           // 8: api.GetOrderResponse
+	(*ListOrdersRequest)(nil),          // 9: api.ListOrdersRequest
+	(*FindDeliveredOrderRequest)(nil),  // 10: api.FindDeliveredOrderRequest
+	(*FindDeliveredOrderResponse)(nil), // 11: api.FindDeliveredOrderResponse
+	(*ListOrdersResponse)(nil),         // 12: api.ListOrdersResponse
+	(*AddItemRequest)(nil),             // 13: api.AddItemRequest
+	(*AddItemResponse)(nil),            // 14: api.AddItemResponse
+	(*RemoveItemRequest)(nil),          // 15: api.RemoveItemRequest
+	(*UpdateItemRequest)(nil),          // 16: api.UpdateItemRequest
+	(*ListItemsRequest)(nil),           // 17: api.ListItemsRequest
+	(*ListItemsResponse)(nil),          // 18: api.ListItemsResponse
+	(*Order)(nil),                      // 19: api.Order
+	(*WatchOrdersRequest)(nil),         // 20: api.WatchOrdersRequest
+	(*OrderEvent)(nil),                 // 21: api.OrderEvent
+	(*Item)(nil),                       // 22: api.Item
+	(*timestamppb.Timestamp)(nil),      // 23: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),              // 24: google.protobuf.Empty
 }
 var file_proto_order_proto_depIdxs = []int32{
-	17, // 0: api.UpdateOrderTotalResponse.order:type_name -> api.Order
-	17, // 1: api.UpdateOrderStatusResponse.order:type_name -> api.Order
-	17, // 2: api.GetOrderResponse.order:type_name -> api.Order
-	17, // 3: api.ListOrdersResponse.orders:type_name -> api.Order
-	18, // 4: api.ListItemsResponse.items:type_name -> api.Item
-	19, // 5: api.Order.created_at:type_name -> google.protobuf.Timestamp
-	19, // 6: api.Order.updated_at:type_name -> google.protobuf.Timestamp
-	11, // 7: api.OrderService.AddItemToOrder:input_type -> api.AddItemRequest
-	13, // 8: api.OrderService.RemoveItemFromOrder:input_type -> api.RemoveItemRequest
-	14, // 9: api.OrderService.UpdateItemInOrder:input_type -> api.UpdateItemRequest
-	15, // 10: api.OrderService.ListItemsFromOrder:input_type -> api.ListItemsRequest
-	2,  // 11: api.OrderService.CancelOrder:input_type -> api.CancelOrderRequest
-	3,  // 12: api.OrderService.CreateOrder:input_type -> api.CreateOrderRequest
-	6,  // 13: api.OrderService.UpdateOrderStatus:input_type -> api.UpdateOrderStatusRequest
-	0,  // 14: api.OrderService.UpdateOrderTotal:input_type -> api.UpdateOrderTotalRequest
-	5,  // 15: api.OrderService.GetOrder:input_type -> api.GetOrderRequest
-	9,  // 16: api.OrderService.ListOrdersByUser:input_type -> api.ListOrdersRequest
-	12, // 17: api.OrderService.AddItemToOrder:output_type -> api.AddItemResponse
-	20, // 18: api.OrderService.RemoveItemFromOrder:output_type -> google.protobuf.Empty
-	20, // 19: api.OrderService.UpdateItemInOrder:output_type -> google.protobuf.Empty
-	16, // 20: api.OrderService.ListItemsFromOrder:output_type -> api.ListItemsResponse
-	20, // 21: api.OrderService.CancelOrder:output_type -> google.protobuf.Empty
-	4,  // 22: api.OrderService.CreateOrder:output_type -> api.CreateOrderResponse
-	7,  // 23: api.OrderService.UpdateOrderStatus:output_type -> api.UpdateOrderStatusResponse
-	1,  // 24: api.OrderService.UpdateOrderTotal:output_type -> api.UpdateOrderTotalResponse
-	8,  // 25: api.OrderService.GetOrder:output_type -> api.GetOrderResponse
-	10, // 26: api.OrderService.ListOrdersByUser:output_type -> api.ListOrdersResponse
-	17, // [17:27] is the sub-list for method output_type
-	7,  // [7:17] is the sub-list for method input_type
-	7,  // [7:7] is the sub-list for extension type_name
-	7,  // [7:7] is the sub-list for extension extendee
-	0,  // [0:7] is the sub-list for field type_name
+	19, // 0: api.UpdateOrderTotalResponse.order:type_name -> api.Order
+	19, // 1: api.UpdateOrderStatusResponse.order:type_name -> api.Order
+	19, // 2: api.GetOrderResponse.order:type_name -> api.Order
+	19, // 3: api.FindDeliveredOrderResponse.order:type_name -> api.Order
+	19, // 4: api.ListOrdersResponse.orders:type_name -> api.Order
+	22, // 5: api.ListItemsResponse.items:type_name -> api.Item
+	23, // 6: api.Order.created_at:type_name -> google.protobuf.Timestamp
+	23, // 7: api.Order.updated_at:type_name -> google.protobuf.Timestamp
+	23, // 8: api.OrderEvent.created_at:type_name -> google.protobuf.Timestamp
+	13, // 9: api.OrderService.AddItemToOrder:input_type -> api.AddItemRequest
+	15, // 10: api.OrderService.RemoveItemFromOrder:input_type -> api.RemoveItemRequest
+	16, // 11: api.OrderService.UpdateItemInOrder:input_type -> api.UpdateItemRequest
+	17, // 12: api.OrderService.ListItemsFromOrder:input_type -> api.ListItemsRequest
+	2,  // 13: api.OrderService.CancelOrder:input_type -> api.CancelOrderRequest
+	3,  // 14: api.OrderService.CreateOrder:input_type -> api.CreateOrderRequest
+	6,  // 15: api.OrderService.UpdateOrderStatus:input_type -> api.UpdateOrderStatusRequest
+	0,  // 16: api.OrderService.UpdateOrderTotal:input_type -> api.UpdateOrderTotalRequest
+	5,  // 17: api.OrderService.GetOrder:input_type -> api.GetOrderRequest
+	9,  // 18: api.OrderService.ListOrdersByUser:input_type -> api.ListOrdersRequest
+	20, // 19: api.OrderService.WatchOrders:input_type -> api.WatchOrdersRequest
+	10, // 20: api.OrderService.FindDeliveredOrder:input_type -> api.FindDeliveredOrderRequest
+	14, // 21: api.OrderService.AddItemToOrder:output_type -> api.AddItemResponse
+	24, // 22: api.OrderService.RemoveItemFromOrder:output_type -> google.protobuf.Empty
+	24, // 23: api.OrderService.UpdateItemInOrder:output_type -> google.protobuf.Empty
+	18, // 24: api.OrderService.ListItemsFromOrder:output_type -> api.ListItemsResponse
+	24, // 25: api.OrderService.CancelOrder:output_type -> google.protobuf.Empty
+	4,  // 26: api.OrderService.CreateOrder:output_type -> api.CreateOrderResponse
+	7,  // 27: api.OrderService.UpdateOrderStatus:output_type -> api.UpdateOrderStatusResponse
+	1,  // 28: api.OrderService.UpdateOrderTotal:output_type -> api.UpdateOrderTotalResponse
+	8,  // 29: api.OrderService.GetOrder:output_type -> api.GetOrderResponse
+	12, // 30: api.OrderService.ListOrdersByUser:output_type -> api.ListOrdersResponse
+	21, // 31: api.OrderService.WatchOrders:output_type -> api.OrderEvent
+	11, // 32: api.OrderService.FindDeliveredOrder:output_type -> api.FindDeliveredOrderResponse
+	21, // [21:33] is the sub-list for method output_type
+	9,  // [9:21] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_proto_order_proto_init() }
@@ -1168,7 +1486,7 @@ func file_proto_order_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_order_proto_rawDesc), len(file_proto_order_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   19,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -30,6 +30,8 @@ const (
 	OrderService_UpdateOrderTotal_FullMethodName    = "/api.OrderService/UpdateOrderTotal"
 	OrderService_GetOrder_FullMethodName            = "/api.OrderService/GetOrder"
 	OrderService_ListOrdersByUser_FullMethodName    = "/api.OrderService/ListOrdersByUser"
+	OrderService_WatchOrders_FullMethodName         = "/api.OrderService/WatchOrders"
+	OrderService_FindDeliveredOrder_FullMethodName  = "/api.OrderService/FindDeliveredOrder"
 )
 
 // OrderServiceClient is the client API for OrderService service.
@@ -46,6 +48,13 @@ type OrderServiceClient interface {
 	UpdateOrderTotal(ctx context.Context, in *UpdateOrderTotalRequest, opts ...grpc.CallOption) (*UpdateOrderTotalResponse, error)
 	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*GetOrderResponse, error)
 	ListOrdersByUser(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error)
+	// WatchOrders streams status changes of the user's orders. It has no HTTP
+	// binding: the gateway serves it over SSE and WebSocket itself.
+	WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error)
+	// FindDeliveredOrder returns the latest delivered order of the user that
+	// contains the product, or NotFound. The gateway checks with it that a
+	// product review comes from a buyer; it has no HTTP binding.
+	FindDeliveredOrder(ctx context.Context, in *FindDeliveredOrderRequest, opts ...grpc.CallOption) (*FindDeliveredOrderResponse, error)
 }
 
 type orderServiceClient struct {
@@ -156,6 +165,35 @@ func (c *orderServiceClient) ListOrdersByUser(ctx context.Context, in *ListOrder
 	return out, nil
 }
 
+func (c *orderServiceClient) WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OrderService_ServiceDesc.Streams[0], OrderService_WatchOrders_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchOrdersRequest, OrderEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderService_WatchOrdersClient = grpc.ServerStreamingClient[OrderEvent]
+
+func (c *orderServiceClient) FindDeliveredOrder(ctx context.Context, in *FindDeliveredOrderRequest, opts ...grpc.CallOption) (*FindDeliveredOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindDeliveredOrderResponse)
+	err := c.cc.Invoke(ctx, OrderService_FindDeliveredOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // OrderServiceServer is the server API for OrderService service.
 // All implementations must embed UnimplementedOrderServiceServer
 // for forward compatibility.
@@ -170,6 +208,13 @@ type OrderServiceServer interface {
 	UpdateOrderTotal(context.Context, *UpdateOrderTotalRequest) (*UpdateOrderTotalResponse, error)
 	GetOrder(context.Context, *GetOrderRequest) (*GetOrderResponse, error)
 	ListOrdersByUser(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error)
+	// WatchOrders streams status changes of the user's orders. It has no HTTP
+	// binding: the gateway serves it over SSE and WebSocket itself.
+	WatchOrders(*WatchOrdersRequest, grpc.ServerStreamingServer[OrderEvent]) error
+	// FindDeliveredOrder returns the latest delivered order of the user that
+	// contains the product, or NotFound. The gateway checks with it that a
+	// product review comes from a buyer; it has no HTTP binding.
+	FindDeliveredOrder(context.Context, *FindDeliveredOrderRequest) (*FindDeliveredOrderResponse, error)
 	mustEmbedUnimplementedOrderServiceServer()
 }
 
@@ -210,6 +255,12 @@ func (UnimplementedOrderServiceServer) GetOrder(context.Context, *GetOrderReques
 func (UnimplementedOrderServiceServer) ListOrdersByUser(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListOrdersByUser not implemented")
 }
+func (UnimplementedOrderServiceServer) WatchOrders(*WatchOrdersRequest, grpc.ServerStreamingServer[OrderEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrders not implemented")
+}
+func (UnimplementedOrderServiceServer) FindDeliveredOrder(context.Context, *FindDeliveredOrderRequest) (*FindDeliveredOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindDeliveredOrder not implemented")
+}
 func (UnimplementedOrderServiceServer) mustEmbedUnimplementedOrderServiceServer() {}
 func (UnimplementedOrderServiceServer) testEmbeddedByValue()                      {}
 
@@ -411,6 +462,35 @@ func _OrderService_ListOrdersByUser_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrderService_WatchOrders_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrdersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderServiceServer).WatchOrders(m, &grpc.GenericServerStream[WatchOrdersRequest, OrderEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderService_WatchOrdersServer = grpc.ServerStreamingServer[OrderEvent]
+
+func _OrderService_FindDeliveredOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindDeliveredOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).FindDeliveredOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderService_FindDeliveredOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).FindDeliveredOrder(ctx, req.(*FindDeliveredOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // OrderService_ServiceDesc is the grpc.ServiceDesc for OrderService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -458,7 +538,17 @@ var OrderService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListOrdersByUser",
 			Handler:    _OrderService_ListOrdersByUser_Handler,
 		},
+		{
+			MethodName: "FindDeliveredOrder",
+			Handler:    _OrderService_FindDeliveredOrder_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchOrders",
+			Handler:       _OrderService_WatchOrders_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/order.proto",
 }
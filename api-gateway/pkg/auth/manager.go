@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/api-gateway/internal/entity"
+)
+
+type (
+	// Manager verifies access tokens issued by auth-service. It has to be
+	// configured with the same signing key.
+	Manager struct {
+		signingKey string
+	}
+
+	CustomClaims struct {
+		DeviceID uuid.UUID
+		Role     entity.UserRole
+
+		jwt.RegisteredClaims
+	}
+
+	principalKey struct{}
+)
+
+func New(signingKey string) (*Manager, error) {
+	const op = "auth.manager.New"
+
+	if signingKey == "" {
+		return nil, fmt.Errorf("%s: empty signingKey", op)
+	}
+
+	return &Manager{signingKey: signingKey}, nil
+}
+
+func (m *Manager) Parse(accessToken string) (*entity.Principal, error) {
+	const op = "auth.manager.Parse"
+
+	var claims CustomClaims
+	_, err := jwt.ParseWithClaims(accessToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("%s: unexpected signing method", op)
+		}
+
+		return []byte(m.signingKey), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if claims.Role == entity.System {
+		return nil, fmt.Errorf("%s: the system role is not issued to users", op)
+	}
+
+	return &entity.Principal{
+		UserID:   userID,
+		DeviceID: claims.DeviceID,
+		Role:     claims.Role,
+	}, nil
+}
+
+// AsSystem marks the calls made with ctx as made by the gateway itself rather
+// than by the user of the request.
+func AsSystem(ctx context.Context) context.Context {
+	return WithPrincipal(ctx, &entity.Principal{Role: entity.System})
+}
+
+func WithPrincipal(ctx context.Context, principal *entity.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated caller, if any.
+func PrincipalFromContext(ctx context.Context) (*entity.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*entity.Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,86 @@
+package problem
+
+import (
+	"net/http"
+
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+)
+
+// Languages of the titles, the first one is the default.
+var languages = []language.Tag{language.English, language.Russian}
+
+var matcher = language.NewMatcher(languages)
+
+// kind is a problem type. The slugs are part of the API and must not change.
+type kind struct {
+	slug   string
+	titles map[string]string
+}
+
+func (k kind) title(lang string) string {
+	if title, ok := k.titles[lang]; ok {
+		return title
+	}
+	return k.titles["en"]
+}
+
+func newKind(slug, en, ru string) kind {
+	return kind{slug: slug, titles: map[string]string{"en": en, "ru": ru}}
+}
+
+var (
+	validationError  = newKind("validation-error", "Request validation failed", "Ошибка валидации запроса")
+	methodNotAllowed = newKind("method-not-allowed", "Method not allowed", "Метод не поддерживается")
+	unknownError     = newKind("unknown-error", "Unknown error", "Неизвестная ошибка")
+)
+
+// internalDetails are the details of the errors whose message is only
+// logged, by language.
+var internalDetails = map[string]string{
+	"en": "The request could not be processed. Quote the trace_id when reporting the problem.",
+	"ru": "Не удалось обработать запрос. При обращении в поддержку укажите trace_id.",
+}
+
+func internalDetail(lang string) string {
+	if detail, ok := internalDetails[lang]; ok {
+		return detail
+	}
+	return internalDetails["en"]
+}
+
+var kindsByCode = map[codes.Code]kind{
+	codes.Canceled:           newKind("cancelled", "Request cancelled", "Запрос отменён"),
+	codes.Unknown:            unknownError,
+	codes.InvalidArgument:    newKind("invalid-argument", "Invalid request", "Некорректный запрос"),
+	codes.DeadlineExceeded:   newKind("timeout", "Request timed out", "Истекло время ожидания"),
+	codes.NotFound:           newKind("not-found", "Resource not found", "Ресурс не найден"),
+	codes.AlreadyExists:      newKind("already-exists", "Resource already exists", "Ресурс уже существует"),
+	codes.PermissionDenied:   newKind("forbidden", "Access denied", "Доступ запрещён"),
+	codes.ResourceExhausted:  newKind("rate-limited", "Too many requests", "Слишком много запросов"),
+	codes.FailedPrecondition: newKind("failed-precondition", "Operation is not allowed in the current state", "Операция недоступна в текущем состоянии"),
+	codes.Aborted:            newKind("conflict", "Request conflicts with the current state", "Конфликт с текущим состоянием"),
+	codes.OutOfRange:         newKind("out-of-range", "Value out of range", "Значение вне допустимого диапазона"),
+	codes.Unimplemented:      newKind("not-implemented", "Not implemented", "Не реализовано"),
+	codes.Internal:           newKind("internal-error", "Internal error", "Внутренняя ошибка"),
+	codes.Unavailable:        newKind("service-unavailable", "Service unavailable", "Сервис недоступен"),
+	codes.DataLoss:           newKind("internal-error", "Internal error", "Внутренняя ошибка"),
+	codes.Unauthenticated:    newKind("unauthenticated", "Authentication required", "Требуется аутентификация"),
+}
+
+// Language picks the language of the response from Accept-Language.
+func Language(r *http.Request) string {
+	tags, _, _ := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	_, i, _ := matcher.Match(tags...)
+	return languages[i].String()
+}
+
+// sameLanguage reports whether the locale, e.g. "ru-RU", is in lang.
+func sameLanguage(locale, lang string) bool {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return false
+	}
+	base, _ := tag.Base()
+	return base.String() == lang
+}
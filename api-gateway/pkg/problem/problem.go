@@ -0,0 +1,228 @@
+// Package problem writes errors as RFC 7807 problem details. gRPC status
+// errors keep their code and details, so backend errors look the same whether
+// they come through the grpc-gateway mux or through a gateway handler.
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const ContentType = "application/problem+json"
+
+// TypeBase prefixes the problem types. The types are stable: clients may
+// switch on them, see the catalog in messages.go.
+const TypeBase = "/problems/"
+
+// Problem is the response body. Type, Title, Status, Detail and Instance are
+// the members defined by RFC 7807, the rest are extensions filled from the
+// gRPC status details.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	TraceID  string `json:"trace_id,omitempty"`
+
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+	Resource      *Resource      `json:"resource,omitempty"`
+	// RetryAfter is also sent as the Retry-After header, in seconds.
+	RetryAfter int64             `json:"retry_after,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	Domain     string            `json:"domain,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// InvalidParam is a field violation of errdetails.BadRequest.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	Code   string `json:"code,omitempty"`
+}
+
+// Resource is errdetails.ResourceInfo.
+type Resource struct {
+	Type        string `json:"type,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// errorLog logs the messages of the errors whose detail is hidden from the
+// client, see SetLogger.
+var errorLog = slog.Default()
+
+// SetLogger sets the logger of the errors whose message is not sent to the
+// client: internal, unknown and data loss errors.
+func SetLogger(log *slog.Logger) {
+	errorLog = log
+}
+
+// Write writes err with the HTTP status of its gRPC code. Errors that carry
+// no gRPC status are internal errors.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	WriteHTTP(w, r, runtime.HTTPStatusFromCode(st.Code()), err)
+}
+
+// WriteHTTP writes err with an explicit HTTP status, for errors that have no
+// matching gRPC code, e.g. 405.
+func WriteHTTP(w http.ResponseWriter, r *http.Request, httpStatus int, err error) {
+	lang := Language(r)
+	st := status.Convert(err)
+	p := New(st, httpStatus, lang)
+	p.Instance = r.URL.Path
+	if span := trace.SpanContextFromContext(r.Context()); span.HasTraceID() {
+		p.TraceID = span.TraceID().String()
+	}
+	if hidesDetail(st.Code()) {
+		errorLog.ErrorContext(r.Context(), "request failed", "code", st.Code().String(), "error", st.Message(), "path", r.URL.Path, "trace_id", p.TraceID)
+	}
+
+	if p.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(p.RetryAfter, 10))
+	}
+	if httpStatus == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(p)
+}
+
+// ErrorHandler is the runtime.ErrorHandlerFunc of the grpc-gateway mux.
+func ErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		for k, vs := range md.HeaderMD {
+			for _, v := range vs {
+				w.Header().Add(runtime.MetadataHeaderPrefix+k, v)
+			}
+		}
+	}
+
+	// Routing errors carry their own HTTP status.
+	var httpErr *runtime.HTTPStatusError
+	if errors.As(err, &httpErr) {
+		WriteHTTP(w, r, httpErr.HTTPStatus, httpErr.Err)
+		return
+	}
+
+	Write(w, r, err)
+}
+
+// RoutingErrorHandler is the runtime.RoutingErrorHandlerFunc of the
+// grpc-gateway mux. It keeps the HTTP status of routing errors, which the
+// default handler turns into gRPC codes, e.g. 405 into 501.
+func RoutingErrorHandler(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+	code := codes.Internal
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusMethodNotAllowed:
+		code = codes.Unimplemented
+	}
+
+	WriteHTTP(w, r, httpStatus, status.Error(code, http.StatusText(httpStatus)))
+}
+
+// New builds the problem of a gRPC status in the given language. The message
+// of internal, unknown and data loss errors may leak the internals of the
+// services, so their detail is a generic one.
+func New(st *status.Status, httpStatus int, lang string) *Problem {
+	kind := kindOf(st, httpStatus)
+
+	p := &Problem{
+		Type:   TypeBase + kind.slug,
+		Title:  kind.title(lang),
+		Status: httpStatus,
+		Detail: st.Message(),
+	}
+	if hidesDetail(st.Code()) {
+		p.Detail = internalDetail(lang)
+		return p
+	}
+
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				p.InvalidParams = append(p.InvalidParams, InvalidParam{
+					Name:   v.GetField(),
+					Reason: localized(v.GetLocalizedMessage(), lang, v.GetDescription()),
+					Code:   v.GetReason(),
+				})
+			}
+		case *errdetails.ResourceInfo:
+			p.Resource = &Resource{
+				Type:        d.GetResourceType(),
+				Name:        d.GetResourceName(),
+				Owner:       d.GetOwner(),
+				Description: d.GetDescription(),
+			}
+		case *errdetails.RetryInfo:
+			if delay := d.GetRetryDelay(); delay != nil {
+				p.RetryAfter = int64(math.Ceil(delay.AsDuration().Seconds()))
+			}
+		case *errdetails.ErrorInfo:
+			p.Reason = d.GetReason()
+			p.Domain = d.GetDomain()
+			p.Metadata = d.GetMetadata()
+		case *errdetails.LocalizedMessage:
+			p.Detail = localized(d, lang, p.Detail)
+		}
+	}
+
+	return p
+}
+
+func hidesDetail(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// localized returns the message if it is in lang, and fallback otherwise.
+func localized(msg *errdetails.LocalizedMessage, lang, fallback string) string {
+	if msg == nil || msg.GetMessage() == "" || !sameLanguage(msg.GetLocale(), lang) {
+		return fallback
+	}
+	return msg.GetMessage()
+}
+
+func kindOf(st *status.Status, httpStatus int) kind {
+	if httpStatus == http.StatusMethodNotAllowed {
+		return methodNotAllowed
+	}
+
+	if st.Code() == codes.InvalidArgument {
+		for _, detail := range st.Details() {
+			if _, ok := detail.(*errdetails.BadRequest); ok {
+				return validationError
+			}
+		}
+	}
+
+	if k, ok := kindsByCode[st.Code()]; ok {
+		return k
+	}
+	return unknownError
+}
@@ -0,0 +1,138 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) Problem {
+	t.Helper()
+
+	var p Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	return p
+}
+
+func TestWrite(t *testing.T) {
+	t.Run("maps the status details", func(t *testing.T) {
+		st, err := status.New(codes.InvalidArgument, "invalid product").WithDetails(
+			&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:            "price",
+				Description:      "must be positive",
+				Reason:           "PRICE_NOT_POSITIVE",
+				LocalizedMessage: &errdetails.LocalizedMessage{Locale: "ru-RU", Message: "цена должна быть положительной"},
+			}}},
+			&errdetails.ResourceInfo{ResourceType: "product", ResourceName: "42"},
+			&errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)},
+			&errdetails.ErrorInfo{Reason: "PRICE_NOT_POSITIVE", Domain: "product", Metadata: map[string]string{"field": "price"}},
+			&errdetails.LocalizedMessage{Locale: "ru", Message: "некорректный товар"},
+		)
+		require.NoError(t, err)
+
+		traceID := trace.TraceID{1, 2, 3}
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/v1/products", nil)
+		req.Header.Set("Accept-Language", "ru-RU,ru;q=0.9,en;q=0.8")
+		rec := httptest.NewRecorder()
+
+		Write(rec, req, st.Err())
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+		assert.Equal(t, "ru", rec.Header().Get("Content-Language"))
+		assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+		assert.Equal(t, Problem{
+			Type:          TypeBase + "validation-error",
+			Title:         "Ошибка валидации запроса",
+			Status:        http.StatusBadRequest,
+			Detail:        "некорректный товар",
+			Instance:      "/v1/products",
+			TraceID:       traceID.String(),
+			InvalidParams: []InvalidParam{{Name: "price", Reason: "цена должна быть положительной", Code: "PRICE_NOT_POSITIVE"}},
+			Resource:      &Resource{Type: "product", Name: "42"},
+			RetryAfter:    2,
+			Reason:        "PRICE_NOT_POSITIVE",
+			Domain:        "product",
+			Metadata:      map[string]string{"field": "price"},
+		}, decode(t, rec))
+	})
+
+	t.Run("falls back to english and the status message", func(t *testing.T) {
+		st, err := status.New(codes.NotFound, "product not found").WithDetails(
+			&errdetails.LocalizedMessage{Locale: "ru-RU", Message: "товар не найден"},
+		)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/products/42", nil)
+		req.Header.Set("Accept-Language", "de")
+		rec := httptest.NewRecorder()
+
+		Write(rec, req, st.Err())
+
+		p := decode(t, rec)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "en", rec.Header().Get("Content-Language"))
+		assert.Equal(t, TypeBase+"not-found", p.Type)
+		assert.Equal(t, "Resource not found", p.Title)
+		assert.Equal(t, "product not found", p.Detail)
+	})
+
+	t.Run("plain errors are internal", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		Write(rec, httptest.NewRequest(http.MethodGet, "/", nil), errors.New("boom"))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, TypeBase+"unknown-error", decode(t, rec).Type)
+	})
+
+	t.Run("internal messages are not sent", func(t *testing.T) {
+		for _, code := range []codes.Code{codes.Internal, codes.Unknown, codes.DataLoss} {
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Language", "ru")
+
+			Write(rec, r, status.Error(code, `pq: relation "products" does not exist`))
+
+			p := decode(t, rec)
+			assert.NotContains(t, p.Detail, "products")
+			assert.Equal(t, internalDetail("ru"), p.Detail)
+		}
+	})
+
+	t.Run("unauthenticated asks for a bearer token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		Write(rec, httptest.NewRequest(http.MethodGet, "/", nil), status.Error(codes.Unauthenticated, "no token"))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	})
+}
+
+func TestErrorHandler(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithErrorHandler(ErrorHandler), runtime.WithRoutingErrorHandler(RoutingErrorHandler))
+	require.NoError(t, mux.HandlePath(http.MethodGet, "/v1/orders", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {}))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/v1/orders", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, TypeBase+"method-not-allowed", decode(t, rec).Type)
+}
@@ -726,9 +726,14 @@ func (s *AuthService) DeleteUser(ctx context.Context, userID uuid.UUID) error {
 
 	userCacheKeyPattern := cache.GenerateCacheKey("user:*", userID)
 
-	_ = s.userRepo.DeleteUser(ctx, userID)
+	err := s.userRepo.DeleteUser(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to delete user", logger.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	err := s.cacheRepo.DeleteByPattern(ctx, sessionCacheKeyPattern)
+	err = s.cacheRepo.DeleteByPattern(ctx, sessionCacheKeyPattern)
 	if err != nil {
 		s.log.Error("failed to delete session cache", logger.Err(err))
 
@@ -82,7 +82,7 @@ func (s *AuthService) SignUp(ctx context.Context, input *rbacAuth.SignUpRequest)
 	}
 
 	if len(validationErrors) > 0 {
-		stat := status.New(400, "invalid sign up request ")
+		stat := status.New(codes.InvalidArgument, "invalid sign up request ")
 		badRequest := &errdetails.BadRequest{}
 		badRequest.FieldViolations = validationErrors
 		s, _ := stat.WithDetails(badRequest)
@@ -131,7 +131,7 @@ func (s *AuthService) Verify(ctx context.Context, input *rbacAuth.VerifyRequest)
 	}
 
 	if len(validationErrors) > 0 {
-		stat := status.New(400, "invalid verify request")
+		stat := status.New(codes.InvalidArgument, "invalid verify request")
 		badRequest := &errdetails.BadRequest{}
 		badRequest.FieldViolations = validationErrors
 		s, _ := stat.WithDetails(badRequest)
@@ -166,7 +166,7 @@ func (s *AuthService) SignIn(ctx context.Context, input *rbacAuth.SignInRequest)
 	}
 
 	if len(validationErrors) > 0 {
-		stat := status.New(400, "invalid sign in request")
+		stat := status.New(codes.InvalidArgument, "invalid sign in request")
 		badRequest := &errdetails.BadRequest{}
 		badRequest.FieldViolations = validationErrors
 		s, _ := stat.WithDetails(badRequest)
@@ -219,7 +219,7 @@ func (s *AuthService) RefreshSession(ctx context.Context, input *rbacAuth.Refres
 	}
 
 	if len(validationErrors) > 0 {
-		stat := status.New(400, "invalid refresh session request")
+		stat := status.New(codes.InvalidArgument, "invalid refresh session request")
 		badRequest := &errdetails.BadRequest{}
 		badRequest.FieldViolations = validationErrors
 		s, _ := stat.WithDetails(badRequest)
@@ -249,7 +249,7 @@ func (s *AuthService) LogOut(ctx context.Context, input *rbacAuth.LogOutRequest)
 	}
 
 	if len(validationErrors) > 0 {
-		stat := status.New(400, "invalid log out request")
+		stat := status.New(codes.InvalidArgument, "invalid log out request")
 		badRequest := &errdetails.BadRequest{}
 		badRequest.FieldViolations = validationErrors
 		s, _ := stat.WithDetails(badRequest)
@@ -303,7 +303,7 @@ func (s *AuthService) ChangePassword(ctx context.Context, input *rbacAuth.Change
 	}
 
 	if len(validationErrors) > 0 {
-		stat := status.New(400, "invalid change password request")
+		stat := status.New(codes.InvalidArgument, "invalid change password request")
 		badRequest := &errdetails.BadRequest{}
 		badRequest.FieldViolations = validationErrors
 		s, _ := stat.WithDetails(badRequest)
@@ -349,7 +349,7 @@ func (s *AuthService) ConfirmChangePassword(ctx context.Context, input *rbacAuth
 	}
 
 	if len(validationErrors) > 0 {
-		stat := status.New(400, "invalid confirm change password request")
+		stat := status.New(codes.InvalidArgument, "invalid confirm change password request")
 		badRequest := &errdetails.BadRequest{}
 		badRequest.FieldViolations = validationErrors
 		s, _ := stat.WithDetails(badRequest)
@@ -376,7 +376,7 @@ func (s *AuthService) GetUser(ctx context.Context, input *rbacAuth.GetUserReques
 	}
 
 	if len(validationErrors) > 0 {
-		stat := status.New(400, "invalid get user request")
+		stat := status.New(codes.InvalidArgument, "invalid get user request")
 		badRequest := &errdetails.BadRequest{}
 		badRequest.FieldViolations = validationErrors
 		s, _ := stat.WithDetails(badRequest)
@@ -412,7 +412,7 @@ func (s *AuthService) UpdateUser(ctx context.Context, input *rbacAuth.UpdateUser
 	}
 
 	if len(validationErrors) > 0 {
-		stat := status.New(400, "invalid Update User request")
+		stat := status.New(codes.InvalidArgument, "invalid Update User request")
 		badRequest := &errdetails.BadRequest{}
 		badRequest.FieldViolations = validationErrors
 		s, _ := stat.WithDetails(badRequest)
@@ -454,7 +454,7 @@ func (s *AuthService) DeleteUser(ctx context.Context, input *rbacAuth.DeleteUser
 	}
 
 	if len(validationErrors) > 0 {
-		stat := status.New(400, "invalid delete user request")
+		stat := status.New(codes.InvalidArgument, "invalid delete user request")
 		badRequest := &errdetails.BadRequest{}
 		badRequest.FieldViolations = validationErrors
 		s, _ := stat.WithDetails(badRequest)
@@ -48,12 +48,20 @@ func Run(configPath string) {
 	// Repository
 	orderRepo := repository.NewOrderRepository(db)
 	itemRepo := repository.NewItemRepository(db)
+	eventRepo := repository.NewEventRepository(db)
+
+	// Order events
+	watchCtx, stopWatchers := context.WithCancel(ctx)
+	defer stopWatchers()
+
+	eventListener := repository.NewEventListener(db, log)
+	go eventListener.Run(watchCtx)
 
 	// Cache
 	cache := repository.NewRedisCache(rdb)
 
 	// Service
-	svc := service.New(itemRepo, orderRepo, cache)
+	svc := service.New(itemRepo, orderRepo, eventRepo, eventListener, cache)
 
 	// GRPC Server
 	serv, err := grpcapp.New(ctx, ":8080", ":8081", cfg.OTLP, log, svc)
@@ -69,6 +77,8 @@ func Run(configPath string) {
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
 	<-stop
 
+	// Watch streams never finish on their own, end them before the graceful stop.
+	stopWatchers()
 	serv.Stop(ctx)
 
 	log.Info("Server Stopped")
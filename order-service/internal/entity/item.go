@@ -6,14 +6,15 @@ import (
 )
 
 type Item struct {
-	ItemID    uuid.UUID `json:"item_id"`
-	ProductID uuid.UUID `json:"product_id"`
-	Quantity  int       `json:"quantity"`
-	Price     uint64    `json:"price"`
+	ItemID    uuid.UUID  `json:"item_id"`
+	ProductID uuid.UUID  `json:"product_id"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty"`
+	Quantity  int        `json:"quantity"`
+	Price     uint64     `json:"price"`
 }
 
-func NewItem(productID uuid.UUID, quantity int, price uint64) *Item {
-	return &Item{ProductID: productID, Quantity: quantity, Price: price}
+func NewItem(productID uuid.UUID, variantID *uuid.UUID, quantity int, price uint64) *Item {
+	return &Item{ProductID: productID, VariantID: variantID, Quantity: quantity, Price: price}
 }
 
 // TODO: validate in service
@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderEvent is a status change of an order. Events of one user are ordered
+// by EventID, which clients use to resume a watch.
+type OrderEvent struct {
+	EventID   int64     `json:"event_id"`
+	OrderID   uuid.UUID `json:"order_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
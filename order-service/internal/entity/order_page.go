@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/order-microservice/internal/utils/errs"
+)
+
+// OrderCursor is the position of an order in the newest first order of the
+// user's orders, (created_at, order_id) descending. A page starts right
+// after its cursor.
+type OrderCursor struct {
+	CreatedAt time.Time
+	OrderID   uuid.UUID
+}
+
+func CursorOf(order Order) OrderCursor {
+	return OrderCursor{CreatedAt: order.CreatedAt, OrderID: order.OrderID}
+}
+
+// Token encodes the cursor as an opaque page token.
+func (c OrderCursor) Token() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + ":" + c.OrderID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseOrderCursor(token string) (OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return OrderCursor{}, errs.ErrInvalidPageToken
+	}
+
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return OrderCursor{}, errs.ErrInvalidPageToken
+	}
+
+	createdAt, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return OrderCursor{}, errs.ErrInvalidPageToken
+	}
+
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return OrderCursor{}, errs.ErrInvalidPageToken
+	}
+
+	return OrderCursor{CreatedAt: time.UnixMicro(createdAt).UTC(), OrderID: orderID}, nil
+}
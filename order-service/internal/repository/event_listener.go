@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/order-microservice/pkg/logger"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/order-microservice/pkg/storage/postgres"
+)
+
+const listenRetryTimeout = time.Second
+
+// EventListener wakes up watchers of a user when the order_events trigger
+// notifies about a new event of that user. Notifications carry no payload
+// besides the user id: watchers read the events themselves.
+type EventListener struct {
+	pg  *postgres.Postgres
+	log *logger.Logger
+
+	mu     sync.Mutex
+	subs   map[uuid.UUID]map[chan struct{}]struct{}
+	closed bool
+}
+
+func NewEventListener(pg *postgres.Postgres, log *logger.Logger) *EventListener {
+	return &EventListener{pg: pg, log: log, subs: map[uuid.UUID]map[chan struct{}]struct{}{}}
+}
+
+// Subscribe returns a channel that receives a value after new events of the
+// user are recorded, and a function that cancels the subscription. The channel
+// is closed once the listener stops.
+func (l *EventListener) Subscribe(userID uuid.UUID) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if l.subs[userID] == nil {
+		l.subs[userID] = map[chan struct{}]struct{}{}
+	}
+	l.subs[userID][ch] = struct{}{}
+
+	return ch, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, ok := l.subs[userID][ch]; !ok {
+			return
+		}
+		delete(l.subs[userID], ch)
+		if len(l.subs[userID]) == 0 {
+			delete(l.subs, userID)
+		}
+	}
+}
+
+// Run listens for notifications until ctx is done and then closes all
+// subscriptions. The LISTEN connection is dedicated and does not take a slot
+// in the pool.
+func (l *EventListener) Run(ctx context.Context) {
+	defer l.close()
+
+	for ctx.Err() == nil {
+		if err := l.listen(ctx); err != nil && ctx.Err() == nil {
+			l.log.Error("listening for order events", logger.Err(err))
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(listenRetryTimeout):
+			}
+		}
+	}
+}
+
+func (l *EventListener) listen(ctx context.Context) error {
+	const op = "repository.EventListener.listen"
+
+	conn, err := pgx.ConnectConfig(ctx, l.pg.Pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+OrderEventsChannel); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Notifications sent while the listener was disconnected are lost, so
+	// every watcher rereads its events.
+	l.notifyAll()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		userID, err := uuid.Parse(notification.Payload)
+		if err != nil {
+			l.log.Warn("invalid order event notification", "payload", notification.Payload)
+			continue
+		}
+
+		l.notify(userID)
+	}
+}
+
+func (l *EventListener) notify(userID uuid.UUID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ch := range l.subs[userID] {
+		wake(ch)
+	}
+}
+
+func (l *EventListener) notifyAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, subs := range l.subs {
+		for ch := range subs {
+			wake(ch)
+		}
+	}
+}
+
+func (l *EventListener) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+	for userID, subs := range l.subs {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(l.subs, userID)
+	}
+}
+
+// wake does not block: one pending wake-up is enough for a watcher that has
+// not caught up yet.
+func wake(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
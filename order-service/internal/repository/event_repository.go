@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/order-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/order-microservice/pkg/storage/postgres"
+)
+
+// Events are written by the record_order_event trigger, see migrations.
+const (
+	OrderEventsTable   = "order_events"
+	OrderEventsChannel = "order_events"
+	EventIdColumn      = "event_id"
+	CreatedAtColumn    = "created_at"
+)
+
+type EventRepository struct {
+	pg *postgres.Postgres
+}
+
+func NewEventRepository(pg *postgres.Postgres) *EventRepository {
+	return &EventRepository{pg: pg}
+}
+
+func (er *EventRepository) ListEventsAfter(ctx context.Context, userID uuid.UUID, afterEventID int64, limit int) ([]entity.OrderEvent, error) {
+	const op = "repository.ListEventsAfter"
+
+	query, args, err := er.pg.Builder.
+		Select(EventIdColumn, OrderIdColumn, UserIdColumn, StatusColumn, CreatedAtColumn).
+		From(OrderEventsTable).
+		Where(sq.Eq{UserIdColumn: userID}).
+		Where(sq.Gt{EventIdColumn: afterEventID}).
+		OrderBy(EventIdColumn).
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := er.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var events []entity.OrderEvent
+	for rows.Next() {
+		var event entity.OrderEvent
+
+		err = rows.Scan(&event.EventID, &event.OrderID, &event.UserID, &event.Status, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return events, nil
+}
+
+func (er *EventRepository) LastEventID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	const op = "repository.LastEventID"
+
+	query, args, err := er.pg.Builder.
+		Select("COALESCE(MAX(" + EventIdColumn + "), 0)").
+		From(OrderEventsTable).
+		Where(sq.Eq{UserIdColumn: userID}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var eventID int64
+	if err = er.pg.Pool.QueryRow(ctx, query, args...).Scan(&eventID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return eventID, nil
+}
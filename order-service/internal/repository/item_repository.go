@@ -17,6 +17,7 @@ const (
 	ItemTable       = "items"
 	ItemIdColumn    = "item_id"
 	ProductIdColumn = "product_id"
+	VariantIdColumn = "variant_id"
 	QuantityColumn  = "quantity"
 	PriceColumn     = "price"
 )
@@ -38,8 +39,8 @@ func (ir *ItemRepository) AddItemOrder(ctx context.Context, item *entity.Item, o
 	}
 
 	queryItems, argsItems, err := ir.pg.Builder.Insert(ItemTable).
-		Columns(ProductIdColumn, QuantityColumn, PriceColumn).
-		Values(item.ProductID, item.Quantity, item.Price).
+		Columns(ProductIdColumn, VariantIdColumn, QuantityColumn, PriceColumn).
+		Values(item.ProductID, item.VariantID, item.Quantity, item.Price).
 		Suffix("RETURNING " + ItemIdColumn).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
@@ -123,7 +124,7 @@ func (ir *ItemRepository) UpdateItem(ctx context.Context, itemID uuid.UUID, quan
 func (ir *ItemRepository) ListItemsByOrder(ctx context.Context, orderID uuid.UUID) ([]entity.Item, error) {
 	const op = "item_repository.ListItemByOrder"
 
-	query, args, err := ir.pg.Builder.Select(ItemIdColumn, ProductIdColumn, QuantityColumn, PriceColumn).
+	query, args, err := ir.pg.Builder.Select(ItemIdColumn, ProductIdColumn, VariantIdColumn, QuantityColumn, PriceColumn).
 		From(fmt.Sprintf("%s %s", ItemTable, "i")).InnerJoin(fmt.Sprintf("%s o ON i.%s = o.%s", JunctionTable, ItemIdColumn, ItemIdColumn)).
 		Where(sq.Eq{OrderIdColumn: orderID}).
 		PlaceholderFormat(sq.Dollar).
@@ -142,7 +143,7 @@ func (ir *ItemRepository) ListItemsByOrder(ctx context.Context, orderID uuid.UUI
 	var itemsOrder []entity.Item
 	for rows.Next() {
 		var item entity.Item
-		if err = rows.Scan(&item.ItemID, &item.ProductID, &item.Quantity, &item.Price); err != nil {
+		if err = rows.Scan(&item.ItemID, &item.ProductID, &item.VariantID, &item.Quantity, &item.Price); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 
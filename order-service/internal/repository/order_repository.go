@@ -147,6 +147,78 @@ func (or *OrderRepository) GetOrdersByUser(ctx context.Context, userID uuid.UUID
 	return orders, nil
 }
 
+// GetOrdersByUserPage returns up to limit orders of the user, newest first,
+// starting after the cursor if there is one.
+func (or *OrderRepository) GetOrdersByUserPage(ctx context.Context, userID uuid.UUID, after *entity.OrderCursor, limit int) ([]entity.Order, error) {
+	const op = "repository.GetOrdersByUserPage"
+
+	builder := or.pg.Builder.Select("*").From(OrdersTable).
+		Where(sq.Eq{UserIdColumn: userID}).
+		OrderBy("created_at DESC", OrderIdColumn+" DESC").
+		Limit(uint64(limit))
+	if after != nil {
+		builder = builder.Where(sq.Expr("(created_at, "+OrderIdColumn+") < (?, ?)", after.CreatedAt, after.OrderID))
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := or.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	orders := make([]entity.Order, 0, limit)
+	for rows.Next() {
+		var order entity.Order
+		err = rows.Scan(&order.OrderID, &order.UserID, &order.TotalAmount, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+// FindOrderWithProduct returns the most recently updated order of the user
+// in the status that has an item of the product.
+func (or *OrderRepository) FindOrderWithProduct(ctx context.Context, userID, productID uuid.UUID, status string) (*entity.Order, error) {
+	const op = "repository.FindOrderWithProduct"
+
+	query, args, err := or.pg.Builder.Select("o.order_id", "o.user_id", "o.total_amount", "o.status", "o.created_at", "o.updated_at").
+		From(OrdersTable + " o").
+		Where(sq.Eq{"o." + UserIdColumn: userID, "o." + StatusColumn: status}).
+		Where(sq.Expr(fmt.Sprintf("EXISTS (SELECT 1 FROM %s oi JOIN %s i ON i.%s = oi.%s WHERE oi.%s = o.%s AND i.%s = ?)",
+			JunctionTable, ItemTable, ItemIdColumn, ItemIdColumn, OrderIdColumn, OrderIdColumn, ProductIdColumn), productID)).
+		OrderBy("o.updated_at DESC").
+		Limit(1).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var order entity.Order
+	err = or.pg.Pool.QueryRow(ctx, query, args...).Scan(&order.OrderID, &order.UserID, &order.TotalAmount, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &order, nil
+}
+
 func (or *OrderRepository) UpdateOrder(ctx context.Context, order *entity.Order) error {
 	const op = "repository.updateOrder"
 
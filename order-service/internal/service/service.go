@@ -14,19 +14,38 @@ import (
 
 const duration = time.Second * 30
 
+const (
+	// watchBatchSize bounds the number of events read from the store at once.
+	watchBatchSize = 100
+	// watchPollInterval rereads events even without a notification, in case
+	// one was lost.
+	watchPollInterval = time.Second * 30
+)
+
+const (
+	// DefaultOrdersPageSize is used when a page is requested by token only.
+	DefaultOrdersPageSize = 20
+	MaxOrdersPageSize     = 100
+)
+
 const (
 	StatusDelivering = "Delivery status"
 	StatusShipped    = "Shipped status"
 	StatusCancelled  = "Cancel status"
 	StatusAccepted   = "Accepted status"
+	// StatusDelivered is set once the buyer has the order; only its products
+	// can be reviewed.
+	StatusDelivered = "Delivered status"
 )
 
 type OrderRepository interface {
 	CreateOrder(ctx context.Context, order *entity.Order) (uuid.UUID, error)
 	GetOrder(ctx context.Context, orderID uuid.UUID) (*entity.Order, error)
 	GetOrdersByUser(ctx context.Context, userID uuid.UUID) ([]entity.Order, error)
+	GetOrdersByUserPage(ctx context.Context, userID uuid.UUID, after *entity.OrderCursor, limit int) ([]entity.Order, error)
 	UpdateOrder(ctx context.Context, order *entity.Order) error
 	DeleteOrder(ctx context.Context, orderID uuid.UUID) error
+	FindOrderWithProduct(ctx context.Context, userID, productID uuid.UUID, status string) (*entity.Order, error)
 }
 
 type ItemRepository interface {
@@ -36,6 +55,15 @@ type ItemRepository interface {
 	ListItemsByOrder(ctx context.Context, orderID uuid.UUID) ([]entity.Item, error)
 }
 
+type EventRepository interface {
+	ListEventsAfter(ctx context.Context, userID uuid.UUID, afterEventID int64, limit int) ([]entity.OrderEvent, error)
+	LastEventID(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type EventNotifier interface {
+	Subscribe(userID uuid.UUID) (<-chan struct{}, func())
+}
+
 type Cache interface {
 	Set(ctx context.Context, key string, value []byte, expiration time.Duration) error
 	Get(ctx context.Context, key string) ([]byte, error)
@@ -43,13 +71,15 @@ type Cache interface {
 }
 
 type Service struct {
-	ir    ItemRepository
-	or    OrderRepository
-	cache Cache
+	ir       ItemRepository
+	or       OrderRepository
+	er       EventRepository
+	notifier EventNotifier
+	cache    Cache
 }
 
-func New(ir ItemRepository, or OrderRepository, cache Cache) *Service {
-	return &Service{ir: ir, or: or, cache: cache}
+func New(ir ItemRepository, or OrderRepository, er EventRepository, notifier EventNotifier, cache Cache) *Service {
+	return &Service{ir: ir, or: or, er: er, notifier: notifier, cache: cache}
 }
 
 func (s *Service) CreateOrder(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
@@ -113,7 +143,7 @@ func (s *Service) GetOrder(ctx context.Context, userID uuid.UUID, orderID uuid.U
 func (s *Service) UpdateOrder(ctx context.Context, userID uuid.UUID, orderID uuid.UUID, status string, total uint64) (*entity.Order, error) {
 	const op = "service.UpdateOrder"
 
-	if status != StatusAccepted && status != StatusCancelled && status != StatusDelivering && status != StatusShipped {
+	if status != StatusAccepted && status != StatusCancelled && status != StatusDelivering && status != StatusShipped && status != StatusDelivered {
 		return nil, errs.ErrInvalidStatus
 	}
 
@@ -201,10 +231,64 @@ func (s *Service) ListOrdersByUser(ctx context.Context, userID uuid.UUID) ([]ent
 	return orders, nil
 }
 
-func (s *Service) AddItemOrder(ctx context.Context, orderID uuid.UUID, productID uuid.UUID, productPrice uint64, quantity int) (uuid.UUID, error) {
+// ListOrdersPage returns a page of the user's orders, newest first, and the
+// token of the next page, empty on the last one.
+func (s *Service) ListOrdersPage(ctx context.Context, userID uuid.UUID, pageSize int, pageToken string) ([]entity.Order, string, error) {
+	const op = "service.ListOrdersPage"
+
+	switch {
+	case pageSize < 0:
+		return nil, "", errs.ErrInvalidPageSize
+	case pageSize == 0:
+		pageSize = DefaultOrdersPageSize
+	case pageSize > MaxOrdersPageSize:
+		pageSize = MaxOrdersPageSize
+	}
+
+	var after *entity.OrderCursor
+	if pageToken != "" {
+		cursor, err := entity.ParseOrderCursor(pageToken)
+		if err != nil {
+			return nil, "", err
+		}
+		after = &cursor
+	}
+
+	// One more order tells whether there is a next page.
+	orders, err := s.or.GetOrdersByUserPage(ctx, userID, after, pageSize+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(orders) <= pageSize {
+		return orders, "", nil
+	}
+
+	orders = orders[:pageSize]
+	return orders, entity.CursorOf(orders[pageSize-1]).Token(), nil
+}
+
+// FindDeliveredOrder returns the latest delivered order of the user that
+// contains the product.
+func (s *Service) FindDeliveredOrder(ctx context.Context, userID, productID uuid.UUID) (*entity.Order, error) {
+	const op = "service.FindDeliveredOrder"
+
+	order, err := s.or.FindOrderWithProduct(ctx, userID, productID, StatusDelivered)
+	if err != nil {
+		if errors.Is(err, errs.ErrOrderNotFound) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return order, nil
+}
+
+func (s *Service) AddItemOrder(ctx context.Context, orderID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID, productPrice uint64, quantity int) (uuid.UUID, error) {
 	const op = "service.AddItemOrder"
 
-	item, err := entity.ValidateItem(entity.NewItem(productID, quantity, productPrice))
+	item, err := entity.ValidateItem(entity.NewItem(productID, variantID, quantity, productPrice))
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -287,3 +371,61 @@ func (s *Service) ListItemsByOrder(ctx context.Context, userID uuid.UUID, orderI
 
 	return items, nil
 }
+
+// WatchOrders sends status changes of the user's orders recorded after
+// afterEventID, then keeps sending new ones until ctx is done or the notifier
+// stops. A zero afterEventID starts from the changes made after the call.
+func (s *Service) WatchOrders(ctx context.Context, userID uuid.UUID, afterEventID int64, send func(entity.OrderEvent) error) error {
+	const op = "service.WatchOrders"
+
+	// Subscribe before reading so that no event falls between the read and the wait.
+	notify, unsubscribe := s.notifier.Subscribe(userID)
+	defer unsubscribe()
+
+	if afterEventID <= 0 {
+		lastEventID, err := s.er.LastEventID(ctx, userID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		afterEventID = lastEventID
+	}
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			events, err := s.er.ListEventsAfter(ctx, userID, afterEventID, watchBatchSize)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			for _, event := range events {
+				if err = send(event); err != nil {
+					return fmt.Errorf("%s: %w", op, err)
+				}
+				afterEventID = event.EventID
+			}
+
+			if len(events) < watchBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-notify:
+			if !ok {
+				return nil
+			}
+		case <-ticker.C:
+		}
+	}
+}
@@ -1 +1,227 @@
 package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/order-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/order-microservice/internal/utils/errs"
+)
+
+type fakeEvents struct {
+	mu     sync.Mutex
+	events []entity.OrderEvent
+}
+
+func (f *fakeEvents) ListEventsAfter(_ context.Context, userID uuid.UUID, afterEventID int64, limit int) ([]entity.OrderEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var events []entity.OrderEvent
+	for _, event := range f.events {
+		if event.UserID == userID && event.EventID > afterEventID && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (f *fakeEvents) LastEventID(_ context.Context, userID uuid.UUID) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var last int64
+	for _, event := range f.events {
+		if event.UserID == userID {
+			last = event.EventID
+		}
+	}
+	return last, nil
+}
+
+func (f *fakeEvents) add(event entity.OrderEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	event.EventID = int64(len(f.events) + 1)
+	f.events = append(f.events, event)
+}
+
+type fakeNotifier struct {
+	ch chan struct{}
+}
+
+func (f *fakeNotifier) Subscribe(uuid.UUID) (<-chan struct{}, func()) {
+	return f.ch, func() {}
+}
+
+func TestService_WatchOrders(t *testing.T) {
+	userID, otherID := uuid.New(), uuid.New()
+
+	newService := func() (*Service, *fakeEvents, *fakeNotifier) {
+		events := &fakeEvents{}
+		events.add(entity.OrderEvent{UserID: userID, Status: StatusAccepted})
+		events.add(entity.OrderEvent{UserID: otherID, Status: StatusAccepted})
+		events.add(entity.OrderEvent{UserID: userID, Status: StatusDelivering})
+
+		notifier := &fakeNotifier{ch: make(chan struct{}, 1)}
+		return New(nil, nil, events, notifier, nil), events, notifier
+	}
+
+	t.Run("resumes after the given event", func(t *testing.T) {
+		svc, _, notifier := newService()
+		close(notifier.ch)
+
+		var got []int64
+		err := svc.WatchOrders(context.Background(), userID, 1, func(event entity.OrderEvent) error {
+			got = append(got, event.EventID)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3}, got)
+	})
+
+	t.Run("streams new events only", func(t *testing.T) {
+		svc, events, notifier := newService()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		received := make(chan entity.OrderEvent)
+		done := make(chan error)
+		go func() {
+			done <- svc.WatchOrders(ctx, userID, 0, func(event entity.OrderEvent) error {
+				received <- event
+				return nil
+			})
+		}()
+
+		// Let the watcher read the last event id before the change.
+		time.Sleep(50 * time.Millisecond)
+		events.add(entity.OrderEvent{UserID: userID, Status: StatusShipped})
+		notifier.ch <- struct{}{}
+
+		select {
+		case event := <-received:
+			assert.Equal(t, int64(4), event.EventID)
+			assert.Equal(t, StatusShipped, event.Status)
+		case <-time.After(time.Second):
+			t.Fatal("event was not sent")
+		}
+
+		cancel()
+		require.NoError(t, <-done)
+	})
+}
+
+// fakeOrders keeps the orders newest first, as the repository returns them.
+type fakeOrders struct {
+	OrderRepository
+
+	orders []entity.Order
+}
+
+func (f *fakeOrders) GetOrdersByUserPage(_ context.Context, userID uuid.UUID, after *entity.OrderCursor, limit int) ([]entity.Order, error) {
+	var orders []entity.Order
+	for _, order := range f.orders {
+		if order.UserID != userID || len(orders) == limit {
+			continue
+		}
+		if after != nil {
+			older := order.CreatedAt.Before(after.CreatedAt) ||
+				order.CreatedAt.Equal(after.CreatedAt) && order.OrderID.String() < after.OrderID.String()
+			if !older {
+				continue
+			}
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+func TestService_ListOrdersPage(t *testing.T) {
+	userID := uuid.New()
+	start := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	repo := &fakeOrders{}
+	for i := range 5 {
+		repo.orders = append(repo.orders, entity.Order{
+			OrderID:   uuid.New(),
+			UserID:    userID,
+			CreatedAt: start.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	repo.orders = append(repo.orders, entity.Order{OrderID: uuid.New(), UserID: uuid.New(), CreatedAt: start})
+	svc := New(nil, repo, nil, nil, nil)
+
+	t.Run("walks the pages", func(t *testing.T) {
+		var got []uuid.UUID
+		token := ""
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 5)
+
+			orders, next, err := svc.ListOrdersPage(context.Background(), userID, 2, token)
+			require.NoError(t, err)
+			for _, order := range orders {
+				got = append(got, order.OrderID)
+			}
+			if next == "" {
+				break
+			}
+			token = next
+		}
+
+		require.Len(t, got, 5)
+		for i, id := range got {
+			assert.Equal(t, repo.orders[i].OrderID, id)
+		}
+	})
+
+	t.Run("rejects invalid tokens and sizes", func(t *testing.T) {
+		_, _, err := svc.ListOrdersPage(context.Background(), userID, 2, "not a token")
+		assert.ErrorIs(t, err, errs.ErrInvalidPageToken)
+
+		_, _, err = svc.ListOrdersPage(context.Background(), userID, -1, "")
+		assert.ErrorIs(t, err, errs.ErrInvalidPageSize)
+	})
+
+	t.Run("round trips cursors", func(t *testing.T) {
+		cursor := entity.CursorOf(repo.orders[2])
+		parsed, err := entity.ParseOrderCursor(cursor.Token())
+		require.NoError(t, err)
+		assert.Equal(t, cursor, parsed)
+	})
+}
+
+func (f *fakeOrders) FindOrderWithProduct(_ context.Context, userID, _ uuid.UUID, status string) (*entity.Order, error) {
+	for _, order := range f.orders {
+		if order.UserID == userID && order.Status == status {
+			return &order, nil
+		}
+	}
+	return nil, errs.ErrOrderNotFound
+}
+
+func TestService_FindDeliveredOrder(t *testing.T) {
+	userID, otherID := uuid.New(), uuid.New()
+	delivered := entity.Order{OrderID: uuid.New(), UserID: userID, Status: StatusDelivered}
+
+	repo := &fakeOrders{orders: []entity.Order{
+		{OrderID: uuid.New(), UserID: userID, Status: StatusDelivering},
+		delivered,
+		{OrderID: uuid.New(), UserID: otherID, Status: StatusShipped},
+	}}
+	svc := New(nil, repo, nil, nil, nil)
+
+	order, err := svc.FindDeliveredOrder(context.Background(), userID, uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, delivered.OrderID, order.OrderID)
+
+	_, err = svc.FindDeliveredOrder(context.Background(), otherID, uuid.New())
+	assert.ErrorIs(t, err, errs.ErrOrderNotFound)
+}
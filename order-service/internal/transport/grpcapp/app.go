@@ -128,6 +128,11 @@ func New(ctx context.Context, port, metricsPort string, otlpConfig config.OTLPCo
 			logging.UnaryServerInterceptor(InterceptorLogger(log), logging.WithFieldsFromContext(logSpanTraceID)),
 			recovery.UnaryServerInterceptor(recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
 		),
+		grpc.ChainStreamInterceptor(
+			srvMetrics.StreamServerInterceptor(grpcprom.WithExemplarFromContext(spanTraceFromContext)),
+			logging.StreamServerInterceptor(InterceptorLogger(log), logging.WithFieldsFromContext(logSpanTraceID)),
+			recovery.StreamServerInterceptor(recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
+		),
 		grpc.KeepaliveEnforcementPolicy(kaep),
 		grpc.KeepaliveParams(kasp),
 		grpc.Creds(credentials.NewServerTLSFromCert(&cert)),
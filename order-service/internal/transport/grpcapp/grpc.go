@@ -15,10 +15,13 @@ type Service interface {
 	UpdateOrder(ctx context.Context, userID uuid.UUID, orderID uuid.UUID, status string, total uint64) (*entity.Order, error)
 	DeleteOrder(ctx context.Context, userID uuid.UUID, orderID uuid.UUID) error
 	ListOrdersByUser(ctx context.Context, userID uuid.UUID) ([]entity.Order, error)
-	AddItemOrder(ctx context.Context, orderID uuid.UUID, productID uuid.UUID, productPrice uint64, quantity int) (uuid.UUID, error)
+	ListOrdersPage(ctx context.Context, userID uuid.UUID, pageSize int, pageToken string) ([]entity.Order, string, error)
+	FindDeliveredOrder(ctx context.Context, userID, productID uuid.UUID) (*entity.Order, error)
+	AddItemOrder(ctx context.Context, orderID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID, productPrice uint64, quantity int) (uuid.UUID, error)
 	DeleteItemOrder(ctx context.Context, itemID uuid.UUID) error
 	UpdateItem(ctx context.Context, itemID uuid.UUID, quantity int) error
 	ListItemsByOrder(ctx context.Context, userID uuid.UUID, orderID uuid.UUID) ([]entity.Item, error)
+	WatchOrders(ctx context.Context, userID uuid.UUID, afterEventID int64, send func(entity.OrderEvent) error) error
 }
 
 type OrderService struct {
@@ -41,7 +44,12 @@ func (s *OrderService) AddItemToOrder(ctx context.Context, req *client.AddItemRe
 		return nil, HandleErrors(err)
 	}
 
-	itemID, err := s.service.AddItemOrder(ctx, orderID, productID, req.GetProductPrice(), int(req.GetQuantity()))
+	variantID, err := parseVariantID(req.GetVariantId())
+	if err != nil {
+		return nil, HandleErrors(err)
+	}
+
+	itemID, err := s.service.AddItemOrder(ctx, orderID, productID, variantID, req.GetProductPrice(), int(req.GetQuantity()))
 	if err != nil {
 		return nil, HandleErrors(err)
 	}
@@ -113,7 +121,12 @@ func (s *OrderService) AddItemOrder(ctx context.Context, req *client.AddItemRequ
 		return nil, HandleErrors(err)
 	}
 
-	itemID, err := s.service.AddItemOrder(ctx, orderID, productID, req.GetProductPrice(), int(req.GetQuantity()))
+	variantID, err := parseVariantID(req.GetVariantId())
+	if err != nil {
+		return nil, HandleErrors(err)
+	}
+
+	itemID, err := s.service.AddItemOrder(ctx, orderID, productID, variantID, req.GetProductPrice(), int(req.GetQuantity()))
 	if err != nil {
 
 		return nil, HandleErrors(err)
@@ -239,10 +252,70 @@ func (s *OrderService) ListOrdersByUser(ctx context.Context, req *client.ListOrd
 		return nil, HandleErrors(err)
 	}
 
-	orders, err := s.service.ListOrdersByUser(ctx, userID)
+	// Callers that do not page get all orders, as before paging existed.
+	if req.GetPageSize() == 0 && req.GetPageToken() == "" {
+		orders, err := s.service.ListOrdersByUser(ctx, userID)
+		if err != nil {
+			return nil, HandleErrors(err)
+		}
+
+		return &client.ListOrdersResponse{Orders: MapToGrpcOrdersList(orders)}, nil
+	}
+
+	orders, nextPageToken, err := s.service.ListOrdersPage(ctx, userID, int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, HandleErrors(err)
+	}
+
+	return &client.ListOrdersResponse{Orders: MapToGrpcOrdersList(orders), NextPageToken: nextPageToken}, nil
+}
+
+func (s *OrderService) FindDeliveredOrder(ctx context.Context, req *client.FindDeliveredOrderRequest) (*client.FindDeliveredOrderResponse, error) {
+	userID, err := uuid.Parse(req.GetUserId())
 	if err != nil {
 		return nil, HandleErrors(err)
 	}
 
-	return &client.ListOrdersResponse{Orders: MapToGrpcOrdersList(orders)}, nil
+	productID, err := uuid.Parse(req.GetProductId())
+	if err != nil {
+		return nil, HandleErrors(err)
+	}
+
+	order, err := s.service.FindDeliveredOrder(ctx, userID, productID)
+	if err != nil {
+		return nil, HandleErrors(err)
+	}
+
+	return &client.FindDeliveredOrderResponse{Order: MapToGrpcOrder(*order)}, nil
+}
+
+func (s *OrderService) WatchOrders(req *client.WatchOrdersRequest, stream client.OrderService_WatchOrdersServer) error {
+	userID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return HandleErrors(err)
+	}
+
+	err = s.service.WatchOrders(stream.Context(), userID, req.GetAfterEventId(), func(event entity.OrderEvent) error {
+		return stream.Send(MapToGrpcOrderEvent(event))
+	})
+	if err != nil {
+		return HandleErrors(err)
+	}
+
+	return nil
+}
+
+// parseVariantID parses the variant of an item, which is left empty for
+// products without variants.
+func parseVariantID(raw string) (*uuid.UUID, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &id, nil
 }
@@ -8,7 +8,8 @@ import (
 
 func HandleErrors(err error) error {
 	switch err {
-	case errs.ErrInvalidStatus, errs.ErrInvalidPrice, errs.ErrInvalidStock, errs.ErrInvalidTotal, errs.ErrInvalidID:
+	case errs.ErrInvalidStatus, errs.ErrInvalidPrice, errs.ErrInvalidStock, errs.ErrInvalidTotal, errs.ErrInvalidID,
+		errs.ErrInvalidPageToken, errs.ErrInvalidPageSize:
 		return status.Error(codes.InvalidArgument, err.Error())
 	case errs.ErrItemNotFound, errs.ErrOrderNotFound, errs.ErrUserNotFound:
 		return status.Error(codes.NotFound, err.Error())
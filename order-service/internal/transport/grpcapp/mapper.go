@@ -26,12 +26,27 @@ func MapToGrpcOrdersList(orders []entity.Order) []*client.Order {
 	return grpcOrders
 }
 
+func MapToGrpcOrderEvent(event entity.OrderEvent) *client.OrderEvent {
+	return &client.OrderEvent{
+		EventId:   event.EventID,
+		OrderId:   event.OrderID.String(),
+		UserId:    event.UserID.String(),
+		Status:    event.Status,
+		CreatedAt: timestamppb.New(event.CreatedAt),
+	}
+}
+
 func MapToGrpcItem(item entity.Item) *client.Item {
-	return &client.Item{
+	grpcItem := &client.Item{
 		ItemId:    item.ItemID.String(),
 		ProductId: item.ProductID.String(),
 		Quantity:  int64(item.Quantity),
 		Price:     int64(item.Price)}
+	if item.VariantID != nil {
+		grpcItem.VariantId = item.VariantID.String()
+	}
+
+	return grpcItem
 }
 
 func MapToGrpcItemsList(items []entity.Item) []*client.Item {
@@ -16,4 +16,6 @@ var (
 	ErrInvalidID        = errors.New("invalid id")
 	ErrSerialization    = errors.New("error while serialize")
 	ErrCacheNotFound    = errors.New("not found value in cache")
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrInvalidPageSize  = errors.New("invalid page size")
 )
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS record_order_event ON "orders";
+
+DROP FUNCTION IF EXISTS record_order_event();
+
+DROP TABLE IF EXISTS order_events;
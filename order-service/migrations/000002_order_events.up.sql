@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS order_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    order_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_events_user_id_event_id_idx ON order_events (user_id, event_id);
+
+CREATE OR REPLACE FUNCTION record_order_event()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    INSERT INTO order_events (order_id, user_id, status) VALUES (OLD.order_id, OLD.user_id, 'Cancel status');
+    PERFORM pg_notify('order_events', OLD.user_id::text);
+    RETURN OLD;
+  END IF;
+
+  IF TG_OP = 'UPDATE' AND NEW.status IS NOT DISTINCT FROM OLD.status THEN
+    RETURN NEW;
+  END IF;
+
+  INSERT INTO order_events (order_id, user_id, status) VALUES (NEW.order_id, NEW.user_id, NEW.status);
+  PERFORM pg_notify('order_events', NEW.user_id::text);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_order_event
+AFTER INSERT OR UPDATE OR DELETE ON "orders"
+FOR EACH ROW
+EXECUTE PROCEDURE record_order_event();
@@ -0,0 +1,2 @@
+-- Serves ListOrdersByUser pages, newest first.
+CREATE INDEX IF NOT EXISTS orders_user_created_at_idx ON orders (user_id, created_at DESC, order_id DESC);
@@ -0,0 +1,2 @@
+-- The variant (SKU) of the product, NULL for products without variants.
+ALTER TABLE items ADD COLUMN IF NOT EXISTS variant_id UUID;
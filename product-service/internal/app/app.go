@@ -2,7 +2,9 @@ package app
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -49,11 +51,27 @@ func Run(ctx context.Context, configPath string) {
 	// Cache
 	cache := repository.NewRedisCache(rdb)
 
+	// Image storage. Without it the service runs, but refuses uploads.
+	var opts []service.Option
+	images, imagesServer, err := newImageStorage(ctx, &cfg.Images)
+	if err != nil {
+		l.Error("creating image storage:", logger.Err(err))
+	} else {
+		opts = append(opts, service.WithImageStorage(images))
+	}
+	if imagesServer != nil {
+		go func() {
+			if err := imagesServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				l.Error("serving images:", logger.Err(err))
+			}
+		}()
+	}
+
 	// Service
-	svc := service.NewProductService(repo, cache)
+	svc := service.NewProductService(repo, cache, opts...)
 
 	// GRPC Server
-	serv, err := grpcapp.New(ctx, ":8080", ":8081", cfg.OTLP, l, svc)
+	serv, err := grpcapp.New(ctx, ":8080", ":8081", cfg.OTLP, cfg.Caller, l, svc)
 	l.Info("Starting server...")
 	go func() {
 		if err = serv.Start(context.Background()); err != nil {
@@ -61,10 +79,37 @@ func Run(ctx context.Context, configPath string) {
 		}
 	}()
 
+	sweepCtx, stopSweeper := context.WithCancel(ctx)
+	sweeperDone := make(chan struct{})
+	go func() {
+		defer close(sweeperDone)
+		sweepReservations(sweepCtx, svc, cfg.Reservations.SweepInterval, l)
+	}()
+
+	pricesDone := make(chan struct{})
+	go func() {
+		defer close(pricesDone)
+		applyPriceSchedules(sweepCtx, svc, cfg.Prices.ApplyInterval, l)
+	}()
+
+	importsDone := make(chan struct{})
+	go func() {
+		defer close(importsDone)
+		processImports(sweepCtx, svc, cfg.Imports.ProcessInterval, l)
+	}()
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
 	<-stop
 
+	stopSweeper()
+	<-sweeperDone
+	<-pricesDone
+	<-importsDone
+
+	if imagesServer != nil {
+		_ = imagesServer.Shutdown(ctx)
+	}
 	serv.Stop(ctx)
 
 	log.Print("Server Stopped")
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/config"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/storage/blob"
+)
+
+// newImageStorage sets up the storage of product images. The local storage
+// is served by the service itself: the returned server is nil for the others.
+func newImageStorage(ctx context.Context, cfg *config.ImagesConfig) (blob.Storage, *http.Server, error) {
+	switch cfg.Storage {
+	case "s3":
+		storage, err := blob.NewS3(ctx, &cfg.S3)
+		if err != nil {
+			return nil, nil, err
+		}
+		return storage, nil, nil
+	case "local":
+		storage, err := blob.NewLocal(cfg.Local.Dir, cfg.Local.BaseURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return storage, &http.Server{Addr: cfg.Local.Addr, Handler: storage.Handler(), ReadHeaderTimeout: 10 * time.Second}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown image storage %q", cfg.Storage)
+	}
+}
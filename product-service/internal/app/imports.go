@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/service"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/logger"
+)
+
+// processImports writes the queued rows of product imports every interval
+// until ctx is cancelled.
+func processImports(ctx context.Context, svc *service.ProductService, interval time.Duration, l *logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		written, err := svc.ProcessImports(ctx)
+		if err != nil {
+			l.Error("processing product imports", logger.Err(err))
+		}
+		if written > 0 {
+			l.Info("imported products", "products", written)
+		}
+	}
+}
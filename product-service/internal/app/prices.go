@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/service"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/logger"
+)
+
+// applyPriceSchedules starts and ends scheduled price changes and sales every
+// interval until ctx is cancelled.
+func applyPriceSchedules(ctx context.Context, svc *service.ProductService, interval time.Duration, l *logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := svc.ApplyPriceSchedules(ctx)
+		if err != nil {
+			l.Error("applying price schedules", logger.Err(err))
+		}
+		if changed > 0 {
+			l.Info("applied price schedules", "products", changed)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/service"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/logger"
+)
+
+// sweepReservations releases expired stock reservations every interval until
+// ctx is cancelled. Several instances may sweep at once: each reservation is
+// released by one of them.
+func sweepReservations(ctx context.Context, svc *service.ProductService, interval time.Duration, l *logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		released, err := svc.ReleaseExpiredReservations(ctx)
+		if err != nil {
+			l.Error("releasing expired reservations", logger.Err(err))
+		}
+		if released > 0 {
+			l.Info("released expired reservations", "orders", released)
+		}
+	}
+}
@@ -32,6 +32,64 @@ type Config struct {
 	Redis    RedisConfig    `yaml:"redis"`
 	Kafka    KafkaConfig    `yaml:"kafka" env-prefix:"KAFKA_"`
 	OTLP     OTLPConfig     `yaml:"otlp" env-prefix:"OTLP_"`
+	Caller   CallerConfig   `yaml:"caller"`
+
+	Reservations ReservationsConfig `yaml:"reservations"`
+	Images       ImagesConfig       `yaml:"images"`
+	Prices       PricesConfig       `yaml:"prices"`
+	Imports      ImportsConfig      `yaml:"imports"`
+}
+
+// CallerConfig holds the key the gateway signs the caller metadata with.
+type CallerConfig struct {
+	SecretKey string `yaml:"secret_key" env:"CALLER_SECRET,required"`
+	// MaxAge is how long a signature is accepted after the gateway made it.
+	MaxAge time.Duration `yaml:"max_age" env:"CALLER_MAX_AGE" env-default:"1m"`
+}
+
+type ReservationsConfig struct {
+	// SweepInterval is how often expired stock reservations are released.
+	SweepInterval time.Duration `yaml:"sweep_interval" env:"RESERVATIONS_SWEEP_INTERVAL" env-default:"1m"`
+}
+
+type PricesConfig struct {
+	// ApplyInterval is how often scheduled price changes and sales are
+	// started and ended.
+	ApplyInterval time.Duration `yaml:"apply_interval" env:"PRICES_APPLY_INTERVAL" env-default:"1m"`
+}
+
+type ImportsConfig struct {
+	// ProcessInterval is how often the queued rows of product imports are
+	// written.
+	ProcessInterval time.Duration `yaml:"process_interval" env:"IMPORTS_PROCESS_INTERVAL" env-default:"5s"`
+}
+
+type ImagesConfig struct {
+	// Storage is where product images are kept: "local" or "s3".
+	Storage string             `yaml:"storage" env:"IMAGES_STORAGE" env-default:"local"`
+	Local   LocalStorageConfig `yaml:"local"`
+	S3      S3StorageConfig    `yaml:"s3"`
+}
+
+type LocalStorageConfig struct {
+	Dir string `yaml:"dir" env:"IMAGES_LOCAL_DIR" env-default:"/var/lib/product-service/images"`
+	// Addr is where the service serves Dir, BaseURL is the public URL of
+	// that server.
+	Addr    string `yaml:"addr" env:"IMAGES_LOCAL_ADDR" env-default:":8082"`
+	BaseURL string `yaml:"base_url" env:"IMAGES_LOCAL_BASE_URL" env-default:"http://localhost:8082"`
+}
+
+// S3StorageConfig points to an S3-compatible storage, such as MinIO.
+type S3StorageConfig struct {
+	Endpoint  string `yaml:"endpoint" env:"IMAGES_S3_ENDPOINT"`
+	Region    string `yaml:"region" env:"IMAGES_S3_REGION"`
+	AccessKey string `yaml:"access_key" env:"IMAGES_S3_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" env:"IMAGES_S3_SECRET_KEY"`
+	Bucket    string `yaml:"bucket" env:"IMAGES_S3_BUCKET" env-default:"product-images"`
+	UseSSL    bool   `yaml:"use_ssl" env:"IMAGES_S3_USE_SSL"`
+	// PublicURL is the URL the bucket is readable at, by default the bucket
+	// URL on Endpoint.
+	PublicURL string `yaml:"public_url" env:"IMAGES_S3_PUBLIC_URL"`
 }
 
 type KafkaConfig struct {
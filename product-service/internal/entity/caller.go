@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RoleAdmin = "admin"
+	// RoleSystem is the gateway calling on its own behalf, e.g. for the stock
+	// reservations of a checkout. No user has it.
+	RoleSystem = "system"
+)
+
+// Caller is the user on whose behalf the gateway calls the service.
+type Caller struct {
+	UserId uuid.UUID
+	Role   string
+}
+
+func (c Caller) IsAdmin() bool {
+	return c.Role == RoleAdmin
+}
+
+func (c Caller) IsSystem() bool {
+	return c.Role == RoleSystem
+}
+
+type callerKey struct{}
+
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller of an authenticated request.
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(Caller)
+	return caller, ok
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Category struct {
+	Id       uuid.UUID
+	ParentId *uuid.UUID
+	Name     string
+	Slug     string
+	// Path lists the ids from the root down to the category, e.g.
+	// "/<root id>/<parent id>/<id>/".
+	Path      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Depth is 0 for root categories.
+func (c *Category) Depth() int {
+	return strings.Count(c.Path, "/") - 2
+}
+
+type CreateCategoryRequest struct {
+	Name     string
+	Slug     string
+	ParentId *uuid.UUID
+}
+
+type UpdateCategoryRequest struct {
+	Id       uuid.UUID
+	Name     string
+	Slug     string
+	ParentId *uuid.UUID
+}
+
+type SetProductCategoriesRequest struct {
+	ProductId uuid.UUID
+	// PrimaryId is nil when the product is taken out of every category.
+	PrimaryId    *uuid.UUID
+	SecondaryIds []uuid.UUID
+}
+
+type ProductCategories struct {
+	// Breadcrumbs lead from the root category to the primary one.
+	Breadcrumbs []*Category
+	Secondary   []*Category
+}
+
+// Primary is the primary category of the product, nil if it has none.
+func (pc *ProductCategories) Primary() *Category {
+	if len(pc.Breadcrumbs) == 0 {
+		return nil
+	}
+
+	return pc.Breadcrumbs[len(pc.Breadcrumbs)-1]
+}
@@ -7,4 +7,90 @@ var (
 	ErrInvalidDescription = errors.New("invalid description")
 	ErrInvalidPrice       = errors.New("invalid price")
 	ErrInvalidStock       = errors.New("invalid stock")
+	ErrInvalidUpdatedAt   = errors.New("updated_at of the product as read is required")
+)
+
+var (
+	ErrInvalidQuery      = errors.New("invalid search query")
+	ErrInvalidPriceRange = errors.New("invalid price range")
+	ErrInvalidPage       = errors.New("invalid offset or limit")
+	ErrInvalidPageToken  = errors.New("invalid page token")
+	ErrInvalidPageSize   = errors.New("invalid page size")
+)
+
+var (
+	ErrUnauthenticated  = errors.New("authentication required")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrProductNotFound  = errors.New("product not found")
+)
+
+var (
+	ErrInvalidCategory   = errors.New("invalid category")
+	ErrInvalidSlug       = errors.New("invalid slug")
+	ErrCategoryNotFound  = errors.New("category not found")
+	ErrCategoryExists    = errors.New("category with this slug already exists")
+	ErrCategoryNotEmpty  = errors.New("category has subcategories or products")
+	ErrCategoryCycle     = errors.New("category can't be moved into its own subtree")
+	ErrSecondaryCategory = errors.New("secondary categories require a primary category")
+)
+
+var (
+	ErrInvalidAttributeDefinition = errors.New("invalid attribute definition")
+	ErrAttributeExists            = errors.New("attribute with this code is already defined for the category")
+	ErrAttributeNotFound          = errors.New("attribute definition not found")
+	ErrInvalidAttributes          = errors.New("invalid attribute values")
+	ErrInvalidSku                 = errors.New("invalid sku")
+	ErrVariantNotFound            = errors.New("variant not found")
+	ErrVariantExists              = errors.New("variant with this sku already exists")
+	ErrDuplicateVariant           = errors.New("product already has a variant with these attribute values")
+)
+
+var (
+	ErrInvalidReservation  = errors.New("invalid reservation")
+	ErrInsufficientStock   = errors.New("insufficient stock")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationExpired  = errors.New("reservation has expired or was released")
+)
+
+var (
+	ErrInvalidImage       = errors.New("invalid image")
+	ErrImageTooLarge      = errors.New("image is too large")
+	ErrTooManyImages      = errors.New("product has too many images")
+	ErrImageNotFound      = errors.New("image not found")
+	ErrInvalidImageOrder  = errors.New("image order must list every image of the product once")
+	ErrImagesNotSupported = errors.New("image storage is not configured")
+)
+
+var (
+	ErrInvalidPriceSchedule  = errors.New("invalid price schedule")
+	ErrPriceScheduleNotFound = errors.New("price schedule not found")
+	ErrSaleOverlap           = errors.New("product already has a sale in this period")
+	ErrPriceScheduleFinished = errors.New("price schedule has already been applied or cancelled")
+	ErrInvalidHistoryPeriod  = errors.New("invalid price history period")
+)
+
+var (
+	ErrInvalidImportFile    = errors.New("invalid import file")
+	ErrImportTooLarge       = errors.New("import file is too large")
+	ErrImportJobNotFound    = errors.New("import job not found")
+	ErrInvalidCatalogFormat = errors.New("format must be csv or json")
+)
+
+var (
+	ErrInvalidReview       = errors.New("invalid review")
+	ErrInvalidRating       = errors.New("rating must be between 1 and 5")
+	ErrInvalidReviewReply  = errors.New("invalid review reply")
+	ErrInvalidReport       = errors.New("invalid review report")
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewExists        = errors.New("product has already been reviewed by this user")
+	ErrTooManyReviewImages = errors.New("review has too many images")
+)
+
+var (
+	ErrInvalidStatus       = errors.New("invalid product status")
+	ErrInvalidStatusReason = errors.New("invalid status change reason")
+	ErrInvalidStatusChange = errors.New("product status change is not allowed")
+	ErrStatusConflict      = errors.New("product status has changed meanwhile")
+	ErrProductConflict     = errors.New("product has changed meanwhile")
+	ErrProductUnavailable  = errors.New("product is not on sale")
 )
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxImageSize is the largest image upload, in bytes.
+const MaxImageSize = 10 << 20
+
+// ProductImage is an uploaded image of a product. The image and its
+// thumbnails are kept in the blob storage under Key and Thumbnail.Key, their
+// URLs are resolved by the storage on every read.
+type ProductImage struct {
+	Id          uuid.UUID   `json:"id"`
+	ProductId   uuid.UUID   `json:"product_id"`
+	Position    int32       `json:"position"`
+	ContentType string      `json:"content_type"`
+	Width       int         `json:"width"`
+	Height      int         `json:"height"`
+	Size        int64       `json:"size"`
+	Key         string      `json:"key"`
+	URL         string      `json:"-"`
+	Thumbnails  []Thumbnail `json:"thumbnails"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+type Thumbnail struct {
+	Size   string `json:"size"`
+	Key    string `json:"key"`
+	URL    string `json:"-"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// ThumbnailSize is a box a thumbnail fits in, keeping the aspect ratio.
+type ThumbnailSize struct {
+	Name string
+	Side int
+}
+
+type UploadImageRequest struct {
+	ProductId uuid.UUID
+	Data      []byte
+}
@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxImportSize is the largest file ImportProducts takes.
+	MaxImportSize = 20 << 20
+	// MaxImportRows is the largest number of products in one import.
+	MaxImportRows = 10_000
+	// MaxImportErrors is the number of refused rows an import job keeps.
+	MaxImportErrors = 1000
+)
+
+type CatalogFormat string
+
+const (
+	CatalogCSV  CatalogFormat = "csv"
+	CatalogJSON CatalogFormat = "json"
+)
+
+type ImportStatus string
+
+const (
+	ImportPending   ImportStatus = "pending"
+	ImportRunning   ImportStatus = "running"
+	ImportCompleted ImportStatus = "completed"
+)
+
+// ImportJob writes the checked rows of an import file in the background.
+// Refused rows are counted as processed and failed right away.
+type ImportJob struct {
+	Id            uuid.UUID
+	SellerId      uuid.UUID
+	Format        CatalogFormat
+	DryRun        bool
+	Status        ImportStatus
+	TotalRows     int
+	ProcessedRows int
+	Created       int
+	Updated       int
+	Failed        int
+	// Errors are the first MaxImportErrors refused rows, by row.
+	Errors     []*ImportRowError
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// ImportRow is a product of an import file. Row is the line of a CSV file,
+// or the position in the array of a JSON file, counting from 1.
+type ImportRow struct {
+	Row         int
+	Sku         string
+	Name        string
+	Description string
+	Price       int64
+	Stock       int64
+}
+
+type ImportRowError struct {
+	Row     int
+	Sku     string
+	Message string
+}
+
+type ImportProductsRequest struct {
+	Format CatalogFormat
+	DryRun bool
+	Data   []byte
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductCursor is the position of a product in the newest first order of
+// the catalog, (created_at, id) descending. A page starts right after its
+// cursor.
+type ProductCursor struct {
+	CreatedAt time.Time
+	Id        uuid.UUID
+}
+
+func CursorOf(product *Product) ProductCursor {
+	return ProductCursor{CreatedAt: product.CreatedAt, Id: product.Id}
+}
+
+// Token encodes the cursor as an opaque page token.
+func (c ProductCursor) Token() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + ":" + c.Id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseProductCursor(token string) (ProductCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ProductCursor{}, ErrInvalidPageToken
+	}
+
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return ProductCursor{}, ErrInvalidPageToken
+	}
+
+	createdAt, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return ProductCursor{}, ErrInvalidPageToken
+	}
+
+	productID, err := uuid.Parse(id)
+	if err != nil {
+		return ProductCursor{}, ErrInvalidPageToken
+	}
+
+	return ProductCursor{CreatedAt: time.UnixMicro(createdAt).UTC(), Id: productID}, nil
+}
+
+// ProductPage is a page of the catalog. NextPageToken is empty on the last
+// page.
+type ProductPage struct {
+	Products      []*Product
+	NextPageToken string
+}
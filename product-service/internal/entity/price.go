@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PriceScheduleKind string
+
+const (
+	// PriceChange sets the regular price for good.
+	PriceChange PriceScheduleKind = "change"
+	// PriceSale sets a sale price until the schedule ends.
+	PriceSale PriceScheduleKind = "sale"
+)
+
+type PriceScheduleStatus string
+
+const (
+	PriceSchedulePending   PriceScheduleStatus = "pending"
+	PriceScheduleActive    PriceScheduleStatus = "active"
+	PriceScheduleCompleted PriceScheduleStatus = "completed"
+	PriceScheduleCancelled PriceScheduleStatus = "cancelled"
+	// PriceScheduleSkipped is a sale whose whole period passed before it
+	// could be applied.
+	PriceScheduleSkipped PriceScheduleStatus = "skipped"
+)
+
+// PriceSchedule is a price change planned by the seller. A sale is active
+// between StartsAt and EndsAt; a change is completed once applied.
+type PriceSchedule struct {
+	Id        uuid.UUID
+	ProductId uuid.UUID
+	Kind      PriceScheduleKind
+	Price     int64
+	StartsAt  time.Time
+	EndsAt    *time.Time
+	Status    PriceScheduleStatus
+	CreatedAt time.Time
+}
+
+type CreatePriceScheduleRequest struct {
+	ProductId uuid.UUID
+	Price     int64
+	// StartsAt is now if zero.
+	StartsAt time.Time
+	// EndsAt makes the schedule a sale.
+	EndsAt *time.Time
+}
+
+type PriceChangeReason string
+
+const (
+	PriceCreated     PriceChangeReason = "created"
+	PriceUpdated     PriceChangeReason = "updated"
+	PriceScheduled   PriceChangeReason = "scheduled"
+	PriceSaleStarted PriceChangeReason = "sale_started"
+	PriceSaleEnded   PriceChangeReason = "sale_ended"
+)
+
+// PriceHistoryEntry is a change of the price of a product. Price is what the
+// product sold at from ChangedAt on, OriginalPrice its regular price if it was
+// on sale.
+type PriceHistoryEntry struct {
+	ProductId     uuid.UUID
+	Price         int64
+	OriginalPrice *int64
+	Reason        PriceChangeReason
+	ScheduleId    *uuid.UUID
+	ChangedAt     time.Time
+}
+
+type PriceHistory struct {
+	// Changes start with the change in effect at Since, if there is one.
+	Changes     []*PriceHistoryEntry
+	LowestPrice int64
+	Since       time.Time
+}
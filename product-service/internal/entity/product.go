@@ -6,14 +6,47 @@ import (
 	"github.com/google/uuid"
 )
 
+// SystemSellerId owns the products created before products had sellers.
+var SystemSellerId = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
 type Product struct {
-	Id          uuid.UUID `json:"id"`
+	Id       uuid.UUID `json:"id"`
+	SellerId uuid.UUID `json:"seller_id"`
+	// Sku identifies the product among the products of its seller in
+	// imports. Products created one by one have none.
+	Sku         string    `json:"sku,omitempty"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	Price       int64     `json:"price"`
 	Stock       int64     `json:"stock"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	Status ProductStatus `json:"status"`
+	// PublishedAt is when the product was first published, nil until then.
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+
+	// OriginalPrice is the regular price while the product is on sale until
+	// SaleEndsAt; Price is the sale price then.
+	OriginalPrice *int64     `json:"original_price,omitempty"`
+	SaleEndsAt    *time.Time `json:"sale_ends_at,omitempty"`
+
+	// RatingSum and RatingCount sum up the ratings of the published reviews.
+	RatingSum   int64 `json:"rating_sum"`
+	RatingCount int64 `json:"rating_count"`
+
+	// Images are ordered by position.
+	Images []*ProductImage `json:"images,omitempty"`
+}
+
+// RatingAverage is the average rating of the product, 0 without reviews.
+func (p *Product) RatingAverage() float64 {
+	if p.RatingCount == 0 {
+		return 0
+	}
+
+	return float64(p.RatingSum) / float64(p.RatingCount)
 }
 
 type CreateProductRequest struct {
@@ -29,4 +62,7 @@ type UpdateProductRequest struct {
 	Description string    `json:"description"`
 	Price       int64     `json:"price"`
 	Stock       int64     `json:"stock"`
+	// UpdatedAt is the updated_at of the product the client read. The update
+	// fails with ErrProductConflict if the product changed since.
+	UpdatedAt time.Time `json:"updated_at"`
 }
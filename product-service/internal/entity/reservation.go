@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"
+	ReservationCommitted ReservationStatus = "committed"
+	ReservationReleased  ReservationStatus = "released"
+)
+
+// ReservationItem is the stock of a product, or of one of its variants, held
+// for an order.
+type ReservationItem struct {
+	ProductId uuid.UUID
+	VariantId *uuid.UUID
+	Quantity  int64
+	// Price is the price of the product or variant at the time of the
+	// reservation.
+	Price int64
+}
+
+// Reservation is the stock held for an order. An active reservation returns
+// its stock when it is released or expires; a committed one keeps it until it
+// is released.
+type Reservation struct {
+	OrderId   uuid.UUID
+	Items     []ReservationItem
+	Status    ReservationStatus
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type ReserveStockRequest struct {
+	OrderId uuid.UUID
+	Items   []ReservationItem
+	// TTL is how long the reservation stays active if it isn't committed.
+	TTL time.Duration
+}
@@ -0,0 +1,95 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MinRating = 1
+	MaxRating = 5
+
+	// The longest review, reply and report reason, in characters.
+	MaxReviewTextLength   = 4000
+	MaxReviewReplyLength  = 2000
+	MaxReportReasonLength = 500
+)
+
+// ReviewStatus tells whether a review is shown. Hidden reviews are left out
+// of the listing and of the rating of the product.
+type ReviewStatus string
+
+const (
+	ReviewPublished ReviewStatus = "published"
+	ReviewHidden    ReviewStatus = "hidden"
+)
+
+// Review is the feedback of a buyer on a product, one per buyer. OrderId is
+// the delivered order the product was bought in.
+type Review struct {
+	Id        uuid.UUID    `json:"id"`
+	ProductId uuid.UUID    `json:"product_id"`
+	UserId    uuid.UUID    `json:"user_id"`
+	OrderId   uuid.UUID    `json:"order_id"`
+	Rating    int          `json:"rating"`
+	Text      string       `json:"text"`
+	Status    ReviewStatus `json:"status"`
+	// Reply is the answer of the seller, empty if there is none.
+	Reply     string     `json:"reply"`
+	RepliedAt *time.Time `json:"replied_at"`
+	// OpenReports counts the reports received since the last moderation.
+	OpenReports int            `json:"open_reports"`
+	Images      []*ReviewImage `json:"images,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// ReviewImage is an image of a review, kept like a ProductImage.
+type ReviewImage struct {
+	Id          uuid.UUID   `json:"id"`
+	ReviewId    uuid.UUID   `json:"review_id"`
+	Position    int32       `json:"position"`
+	ContentType string      `json:"content_type"`
+	Width       int         `json:"width"`
+	Height      int         `json:"height"`
+	Size        int64       `json:"size"`
+	Key         string      `json:"key"`
+	URL         string      `json:"-"`
+	Thumbnails  []Thumbnail `json:"thumbnails"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+type ReviewReport struct {
+	ReviewId  uuid.UUID
+	UserId    uuid.UUID
+	Reason    string
+	CreatedAt time.Time
+}
+
+type CreateReviewRequest struct {
+	ProductId uuid.UUID
+	OrderId   uuid.UUID
+	Rating    int
+	Text      string
+}
+
+type UploadReviewImageRequest struct {
+	ReviewId uuid.UUID
+	Data     []byte
+}
+
+// ReviewCursor is the position of a review in the newest first order of
+// the reviews of a product, which is paged like the catalog.
+type ReviewCursor = ProductCursor
+
+func ReviewCursorOf(review *Review) ReviewCursor {
+	return ReviewCursor{CreatedAt: review.CreatedAt, Id: review.Id}
+}
+
+// ReviewPage is a page of the reviews of a product. NextPageToken is empty
+// on the last page.
+type ReviewPage struct {
+	Reviews       []*Review
+	NextPageToken string
+}
@@ -0,0 +1,65 @@
+package entity
+
+import "github.com/google/uuid"
+
+type SearchSort int
+
+const (
+	// SortDefault sorts by relevance when there is a query and by creation
+	// time otherwise.
+	SortDefault SearchSort = iota
+	SortRelevance
+	SortPriceAsc
+	SortPriceDesc
+	SortNewest
+)
+
+type SearchProductsRequest struct {
+	Query    string
+	MinPrice *int64
+	MaxPrice *int64
+	InStock  bool
+	Sort     SearchSort
+	Offset   int64
+	Limit    int64
+	// CategoryId matches the products of the category and its descendants.
+	CategoryId *uuid.UUID
+}
+
+type SearchHit struct {
+	Product *Product
+	// NameHighlight and DescriptionSnippet are HTML-escaped, with the matched
+	// words wrapped in <mark> tags.
+	NameHighlight      string
+	DescriptionSnippet string
+	Rank               float32
+}
+
+// PriceRangeFacet counts the matches priced in [Min, Max). Max is 0 for the
+// last, open-ended range.
+type PriceRangeFacet struct {
+	Min   int64
+	Max   int64
+	Count int64
+}
+
+type CategoryFacet struct {
+	CategoryId uuid.UUID
+	Name       string
+	Count      int64
+}
+
+type SearchFacets struct {
+	PriceRanges []PriceRangeFacet
+	InStock     int64
+	OutOfStock  int64
+	// Categories count the matches per subcategory of the searched category,
+	// or per root category.
+	Categories []CategoryFacet
+}
+
+type SearchResult struct {
+	Hits   []*SearchHit
+	Total  int64
+	Facets SearchFacets
+}
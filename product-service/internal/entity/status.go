@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxStatusReasonLength is the longest reason of a status change, in
+// characters.
+const MaxStatusReasonLength = 500
+
+// ProductStatus is the stage of a product in its lifecycle. Only published
+// products are listed and sold.
+type ProductStatus string
+
+const (
+	ProductDraft         ProductStatus = "draft"
+	ProductPendingReview ProductStatus = "pending_review"
+	ProductPublished     ProductStatus = "published"
+	ProductArchived      ProductStatus = "archived"
+	// ProductDeleted is a soft delete: the product is kept for the orders
+	// and reviews that refer to it, but can't be changed any more.
+	ProductDeleted ProductStatus = "deleted"
+)
+
+// ProductStatusChange is an entry of the status history of a product.
+// FromStatus is empty for the entry of its creation.
+type ProductStatusChange struct {
+	Id         uuid.UUID     `json:"id"`
+	ProductId  uuid.UUID     `json:"product_id"`
+	FromStatus ProductStatus `json:"from_status"`
+	ToStatus   ProductStatus `json:"to_status"`
+	ChangedBy  uuid.UUID     `json:"changed_by"`
+	Reason     string        `json:"reason"`
+	ChangedAt  time.Time     `json:"changed_at"`
+}
+
+type ChangeProductStatusRequest struct {
+	ProductId uuid.UUID
+	Status    ProductStatus
+	Reason    string
+}
@@ -0,0 +1,178 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AttributeType string
+
+const (
+	AttributeText   AttributeType = "text"
+	AttributeNumber AttributeType = "number"
+	AttributeEnum   AttributeType = "enum"
+)
+
+// AttributeDefinition describes an attribute of the variants of the products
+// in a category and in its subcategories.
+type AttributeDefinition struct {
+	Id            uuid.UUID
+	CategoryId    uuid.UUID
+	Code          string
+	Name          string
+	Type          AttributeType
+	AllowedValues []string
+	Required      bool
+	CreatedAt     time.Time
+}
+
+type CreateAttributeDefinitionRequest struct {
+	CategoryId    uuid.UUID
+	Code          string
+	Name          string
+	Type          AttributeType
+	AllowedValues []string
+	Required      bool
+}
+
+// Variant is a SKU of a product.
+type Variant struct {
+	Id         uuid.UUID
+	ProductId  uuid.UUID
+	Sku        string
+	Price      int64
+	Stock      int64
+	Attributes map[string]string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type CreateVariantRequest struct {
+	ProductId  uuid.UUID
+	Sku        string
+	Price      int64
+	Stock      int64
+	Attributes map[string]string
+}
+
+type UpdateVariantRequest struct {
+	Id         uuid.UUID
+	Sku        string
+	Price      int64
+	Stock      int64
+	Attributes map[string]string
+}
+
+// VariantAxis is an attribute the variants of a product differ in.
+type VariantAxis struct {
+	Code   string
+	Name   string
+	Values []string
+}
+
+// resolveDefinitions indexes definitions by code. definitions are ordered
+// from the root category down, so a subcategory overrides the definition of
+// an ancestor.
+func resolveDefinitions(definitions []*AttributeDefinition) map[string]*AttributeDefinition {
+	byCode := make(map[string]*AttributeDefinition, len(definitions))
+	for _, def := range definitions {
+		byCode[def.Code] = def
+	}
+
+	return byCode
+}
+
+// ValidateAttributes checks attribute values against the definitions that
+// apply to the product.
+func ValidateAttributes(definitions []*AttributeDefinition, attributes map[string]string) error {
+	byCode := resolveDefinitions(definitions)
+
+	for code, value := range attributes {
+		def, ok := byCode[code]
+		if !ok {
+			return fmt.Errorf("%w: unknown attribute %q", ErrInvalidAttributes, code)
+		}
+
+		switch def.Type {
+		case AttributeNumber:
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return fmt.Errorf("%w: %s must be a number", ErrInvalidAttributes, code)
+			}
+		case AttributeEnum:
+			if !slices.Contains(def.AllowedValues, value) {
+				return fmt.Errorf("%w: %s must be one of %v", ErrInvalidAttributes, code, def.AllowedValues)
+			}
+		default:
+			if value == "" {
+				return fmt.Errorf("%w: %s is empty", ErrInvalidAttributes, code)
+			}
+		}
+	}
+
+	for code, def := range byCode {
+		if _, ok := attributes[code]; def.Required && !ok {
+			return fmt.Errorf("%w: %s is required", ErrInvalidAttributes, code)
+		}
+	}
+
+	return nil
+}
+
+// VariantAxes builds the variant matrix of a product: the attributes its
+// variants have, in the order of the definitions, each with the values in
+// use. Enum values keep the order of the definition, other values are
+// sorted. Attributes without a definition come last, named by their code.
+func VariantAxes(definitions []*AttributeDefinition, variants []*Variant) []VariantAxis {
+	values := map[string]map[string]bool{}
+	for _, variant := range variants {
+		for code, value := range variant.Attributes {
+			if values[code] == nil {
+				values[code] = map[string]bool{}
+			}
+			values[code][value] = true
+		}
+	}
+
+	axes := []VariantAxis{}
+	byCode := resolveDefinitions(definitions)
+	for _, def := range definitions {
+		if byCode[def.Code] != def || values[def.Code] == nil {
+			continue
+		}
+
+		axis := VariantAxis{Code: def.Code, Name: def.Name}
+		if def.Type == AttributeEnum {
+			for _, value := range def.AllowedValues {
+				if values[def.Code][value] {
+					axis.Values = append(axis.Values, value)
+				}
+			}
+		} else {
+			axis.Values = sortedKeys(values[def.Code])
+		}
+
+		axes = append(axes, axis)
+		delete(values, def.Code)
+	}
+
+	for _, code := range sortedKeys(values) {
+		axes = append(axes, VariantAxis{Code: code, Name: code, Values: sortedKeys(values[code])})
+	}
+
+	return axes
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+var categoryColumns = []string{"id", "parent_id", "name", "slug", "path", "created_at", "updated_at"}
+
+func (pr *PostgresRepository) CreateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error) {
+	const op = "repository.postgres.CreateCategory"
+
+	// The path of a root category is "/<id>/", a missing parent is caught by
+	// the foreign key.
+	parentPath := squirrel.Expr("COALESCE((SELECT path FROM categories WHERE id = ?), '/')", category.ParentId)
+
+	query, args, err := pr.pg.Builder.Insert("categories").
+		Columns(categoryColumns...).
+		Values(
+			category.Id, category.ParentId, category.Name, category.Slug,
+			squirrel.Expr("? || ? || '/'", parentPath, category.Id.String()),
+			category.CreatedAt, category.UpdatedAt,
+		).
+		Suffix("RETURNING " + strings.Join(categoryColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := scanCategory(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, categoryError(err))
+	}
+
+	return created, nil
+}
+
+func (pr *PostgresRepository) GetCategory(ctx context.Context, id uuid.UUID) (*entity.Category, error) {
+	const op = "repository.postgres.GetCategory"
+
+	query, args, err := pr.pg.Builder.Select(categoryColumns...).
+		From("categories").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	category, err := scanCategory(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, categoryError(err))
+	}
+
+	return category, nil
+}
+
+// UpdateCategory renames the category and, when the parent changes, moves it
+// with its subtree by rewriting the paths under it.
+func (pr *PostgresRepository) UpdateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error) {
+	const op = "repository.postgres.UpdateCategory"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var oldPath string
+	if err := tx.QueryRow(ctx, "SELECT path FROM categories WHERE id = $1 FOR UPDATE", category.Id).Scan(&oldPath); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, categoryError(err))
+	}
+
+	newPath := "/" + category.Id.String() + "/"
+	if category.ParentId != nil {
+		var parentPath string
+		if err := tx.QueryRow(ctx, "SELECT path FROM categories WHERE id = $1", *category.ParentId).Scan(&parentPath); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, categoryError(err))
+		}
+		if strings.HasPrefix(parentPath, oldPath) {
+			return nil, fmt.Errorf("%s: %w", op, entity.ErrCategoryCycle)
+		}
+		newPath = parentPath + category.Id.String() + "/"
+	}
+
+	if newPath != oldPath {
+		query, args, err := pr.pg.Builder.Update("categories").
+			Set("path", squirrel.Expr("? || substr(path, ?)", newPath, len(oldPath)+1)).
+			Where(squirrel.Like{"path": oldPath + "%"}).
+			ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, categoryError(err))
+		}
+	}
+
+	query, args, err := pr.pg.Builder.Update("categories").
+		Set("parent_id", category.ParentId).
+		Set("name", category.Name).
+		Set("slug", category.Slug).
+		Set("updated_at", category.UpdatedAt).
+		Where(squirrel.Eq{"id": category.Id}).
+		Suffix("RETURNING " + strings.Join(categoryColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := scanCategory(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, categoryError(err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// DeleteCategory relies on the foreign keys to keep categories with
+// subcategories or products.
+func (pr *PostgresRepository) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.postgres.DeleteCategory"
+
+	query, args, err := pr.pg.Builder.Delete("categories").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := pr.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			return fmt.Errorf("%s: %w", op, entity.ErrCategoryNotEmpty)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, entity.ErrCategoryNotFound)
+	}
+
+	return nil
+}
+
+// ListCategories returns the whole tree ordered by path, so every category
+// follows its parent.
+func (pr *PostgresRepository) ListCategories(ctx context.Context) ([]*entity.Category, error) {
+	const op = "repository.postgres.ListCategories"
+
+	query, args, err := pr.pg.Builder.Select(categoryColumns...).
+		From("categories").
+		OrderBy("path").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	categories, err := pr.queryCategories(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return categories, nil
+}
+
+// ListCategoryProducts lists the published products assigned, as primary or
+// secondary, to the category or to any of its descendants.
+func (pr *PostgresRepository) ListCategoryProducts(ctx context.Context, categoryID uuid.UUID, offset, limit int64) ([]*entity.Product, error) {
+	const op = "repository.postgres.ListCategoryProducts"
+
+	category, err := pr.GetCategory(ctx, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query, args, err := pr.pg.Builder.Select(productColumns...).
+		From("products").
+		Where(inCategorySubtree(category.Path)).
+		Where(published).
+		OrderBy("created_at DESC", "id").
+		Offset(uint64(offset)).
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	products := []*entity.Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return products, nil
+}
+
+// SetProductCategories replaces the categories of a product in one
+// transaction.
+func (pr *PostgresRepository) SetProductCategories(ctx context.Context, req *entity.SetProductCategoriesRequest) error {
+	const op = "repository.postgres.SetProductCategories"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", req.ProductId).Scan(&exists); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return fmt.Errorf("%s: %w", op, entity.ErrProductNotFound)
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM product_categories WHERE product_id = $1", req.ProductId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if req.PrimaryId != nil {
+		insert := pr.pg.Builder.Insert("product_categories").
+			Columns("product_id", "category_id", "is_primary").
+			Values(req.ProductId, *req.PrimaryId, true)
+		for _, id := range req.SecondaryIds {
+			insert = insert.Values(req.ProductId, id, false)
+		}
+
+		query, args, err := insert.ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("%s: %w", op, categoryError(err))
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (pr *PostgresRepository) GetProductCategories(ctx context.Context, productID uuid.UUID) (*entity.ProductCategories, error) {
+	const op = "repository.postgres.GetProductCategories"
+
+	// The ancestors of the primary category are the categories whose path is
+	// a prefix of its path.
+	query, args, err := pr.pg.Builder.Select(prefixed("c", categoryColumns)...).
+		From("categories c").
+		Join("categories p ON p.path LIKE c.path || '%'").
+		Join("product_categories pc ON pc.category_id = p.id").
+		Where(squirrel.Eq{"pc.product_id": productID, "pc.is_primary": true}).
+		OrderBy("length(c.path)").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	breadcrumbs, err := pr.queryCategories(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query, args, err = pr.pg.Builder.Select(prefixed("c", categoryColumns)...).
+		From("categories c").
+		Join("product_categories pc ON pc.category_id = c.id").
+		Where(squirrel.Eq{"pc.product_id": productID, "pc.is_primary": false}).
+		OrderBy("c.path").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	secondary, err := pr.queryCategories(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &entity.ProductCategories{Breadcrumbs: breadcrumbs, Secondary: secondary}, nil
+}
+
+func (pr *PostgresRepository) queryCategories(ctx context.Context, query string, args ...any) ([]*entity.Category, error) {
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*entity.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// inCategorySubtree matches the products assigned to the category with the
+// given path or to any of its descendants.
+func inCategorySubtree(path string) squirrel.Sqlizer {
+	return squirrel.Expr(`EXISTS (
+		SELECT 1 FROM product_categories pc JOIN categories c ON c.id = pc.category_id
+		WHERE pc.product_id = products.id AND c.path LIKE ?
+	)`, path+"%")
+}
+
+func scanCategory(row pgx.Row) (*entity.Category, error) {
+	category := &entity.Category{}
+	if err := row.Scan(&category.Id, &category.ParentId, &category.Name, &category.Slug, &category.Path, &category.CreatedAt, &category.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// categoryError translates the errors of category queries: missing rows and
+// broken foreign keys mean a missing category, a duplicate slug is reported
+// as such.
+func categoryError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.ErrCategoryNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return entity.ErrCategoryExists
+		case pgForeignKeyViolation:
+			return entity.ErrCategoryNotFound
+		}
+	}
+
+	return err
+}
+
+func prefixed(table string, columns []string) []string {
+	out := make([]string, 0, len(columns))
+	for _, column := range columns {
+		out = append(out, table+"."+column)
+	}
+
+	return out
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+var imageColumns = []string{"id", "product_id", "position", "content_type", "width", "height", "size", "storage_key", "thumbnails", "created_at"}
+
+// CreateImage adds an image after the last image of the product, unless the
+// product already has maxImages images.
+func (pr *PostgresRepository) CreateImage(ctx context.Context, image *entity.ProductImage, maxImages int) (*entity.ProductImage, error) {
+	const op = "repository.postgres.CreateImage"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockProductImages(ctx, tx, image.ProductId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query, args, err := pr.pg.Builder.Select("count(*)", "COALESCE(max(position) + 1, 0)").
+		From("product_images").
+		Where(squirrel.Eq{"product_id": image.ProductId}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, query, args...).Scan(&count, &image.Position); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if count >= maxImages {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrTooManyImages)
+	}
+
+	query, args, err = pr.pg.Builder.Insert("product_images").
+		Columns(imageColumns...).
+		Values(image.Id, image.ProductId, image.Position, image.ContentType, image.Width, image.Height, image.Size, image.Key, image.Thumbnails, image.CreatedAt).
+		Suffix("RETURNING " + strings.Join(imageColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := scanImage(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, imageError(err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+func (pr *PostgresRepository) GetImage(ctx context.Context, id uuid.UUID) (*entity.ProductImage, error) {
+	const op = "repository.postgres.GetImage"
+
+	query, args, err := pr.pg.Builder.Select(imageColumns...).
+		From("product_images").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	image, err := scanImage(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, imageError(err))
+	}
+
+	return image, nil
+}
+
+func (pr *PostgresRepository) DeleteImage(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.postgres.DeleteImage"
+
+	query, args, err := pr.pg.Builder.Delete("product_images").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := pr.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, entity.ErrImageNotFound)
+	}
+
+	return nil
+}
+
+// ListImages returns the images of the products, each product's in order.
+func (pr *PostgresRepository) ListImages(ctx context.Context, productIDs []uuid.UUID) ([]*entity.ProductImage, error) {
+	const op = "repository.postgres.ListImages"
+
+	query, args, err := pr.pg.Builder.Select(imageColumns...).
+		From("product_images").
+		Where(squirrel.Eq{"product_id": productIDs}).
+		OrderBy("product_id", "position").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	images := []*entity.ProductImage{}
+	for rows.Next() {
+		image, err := scanImage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		images = append(images, image)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return images, nil
+}
+
+// ReorderImages sets the positions of the images of a product to their
+// order in imageIDs, which must list every image of the product once.
+func (pr *PostgresRepository) ReorderImages(ctx context.Context, productID uuid.UUID, imageIDs []uuid.UUID) error {
+	const op = "repository.postgres.ReorderImages"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockProductImages(ctx, tx, productID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query, args, err := pr.pg.Builder.Select("id").
+		From("product_images").
+		Where(squirrel.Eq{"product_id": productID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	current, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !samePermutation(current, imageIDs) {
+		return fmt.Errorf("%s: %w", op, entity.ErrInvalidImageOrder)
+	}
+
+	// The (product_id, position) constraint is deferred, so positions can be
+	// swapped in one statement.
+	if _, err := tx.Exec(ctx, `UPDATE product_images SET position = o.position - 1
+		FROM unnest($1::uuid[]) WITH ORDINALITY AS o(id, position)
+		WHERE product_images.id = o.id`, imageIDs); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// lockProductImages serializes the writes to the images of a product, so
+// concurrent uploads don't take the same position.
+func lockProductImages(ctx context.Context, tx pgx.Tx, productID uuid.UUID) error {
+	_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1, 0))", "product-images:"+productID.String())
+	return err
+}
+
+// samePermutation tells if ids lists every one of want exactly once.
+func samePermutation(want, ids []uuid.UUID) bool {
+	if len(want) != len(ids) {
+		return false
+	}
+
+	left := make(map[uuid.UUID]bool, len(want))
+	for _, id := range want {
+		left[id] = true
+	}
+	for _, id := range ids {
+		if !left[id] {
+			return false
+		}
+		delete(left, id)
+	}
+
+	return true
+}
+
+func scanImage(row pgx.Row) (*entity.ProductImage, error) {
+	image := &entity.ProductImage{}
+	if err := row.Scan(&image.Id, &image.ProductId, &image.Position, &image.ContentType, &image.Width, &image.Height, &image.Size, &image.Key, &image.Thumbnails, &image.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// imageError translates the errors of image queries: a broken foreign key
+// means the product is missing.
+func imageError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.ErrImageNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+		return entity.ErrProductNotFound
+	}
+
+	return err
+}
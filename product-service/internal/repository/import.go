@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+var importJobColumns = []string{"id", "seller_id", "format", "dry_run", "status", "total_rows", "processed_rows", "created", "updated", "failed", "created_at", "finished_at"}
+
+// CreateImportJob stores a job with the rows it is to write and the rows
+// that were refused.
+func (pr *PostgresRepository) CreateImportJob(ctx context.Context, job *entity.ImportJob, rows []*entity.ImportRow) (*entity.ImportJob, error) {
+	const op = "repository.postgres.CreateImportJob"
+
+	query, args, err := pr.pg.Builder.Insert("import_jobs").
+		Columns(importJobColumns...).
+		Values(job.Id, job.SellerId, job.Format, job.DryRun, job.Status, job.TotalRows, job.ProcessedRows, job.Created, job.Updated, job.Failed, job.CreatedAt, job.FinishedAt).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"import_rows"},
+		[]string{"job_id", "row_index", "sku", "name", "description", "price", "stock"},
+		pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+			row := rows[i]
+			return []any{job.Id, row.Row, row.Sku, row.Name, row.Description, row.Price, row.Stock}, nil
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"import_errors"},
+		[]string{"job_id", "row_index", "sku", "message"},
+		pgx.CopyFromSlice(len(job.Errors), func(i int) ([]any, error) {
+			rowErr := job.Errors[i]
+			return []any{job.Id, rowErr.Row, rowErr.Sku, rowErr.Message}, nil
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// GetImportJob returns a job with its first entity.MaxImportErrors refused
+// rows.
+func (pr *PostgresRepository) GetImportJob(ctx context.Context, id uuid.UUID) (*entity.ImportJob, error) {
+	const op = "repository.postgres.GetImportJob"
+
+	query, args, err := pr.pg.Builder.Select(importJobColumns...).
+		From("import_jobs").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	job, err := scanImportJob(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrImportJobNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query, args, err = pr.pg.Builder.Select("row_index", "sku", "message").
+		From("import_errors").
+		Where(squirrel.Eq{"job_id": id}).
+		OrderBy("row_index").
+		Limit(entity.MaxImportErrors).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	job.Errors = []*entity.ImportRowError{}
+	for rows.Next() {
+		rowErr := &entity.ImportRowError{}
+		if err := rows.Scan(&rowErr.Row, &rowErr.Sku, &rowErr.Message); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		job.Errors = append(job.Errors, rowErr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// ProcessImportBatch writes up to limit rows of the oldest unfinished job in
+// one transaction, creating the products whose SKU the seller doesn't have
+// yet and updating the others, and returns the job and the products it
+// wrote. A dry run only counts them. Products changed after the job was
+// created, e.g. by a reservation taking their stock, are not written over:
+// their rows are refused. The job row stays locked until the
+// batch is written, so concurrent calls work on different jobs. It returns a
+// nil job when there is nothing to do.
+func (pr *PostgresRepository) ProcessImportBatch(ctx context.Context, limit int, now time.Time) (*entity.ImportJob, []uuid.UUID, error) {
+	const op = "repository.postgres.ProcessImportBatch"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := pr.pg.Builder.Select(importJobColumns...).
+		From("import_jobs").
+		Where(squirrel.NotEq{"status": entity.ImportCompleted}).
+		OrderBy("created_at").
+		Limit(1).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	job, err := scanImportJob(tx.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := pr.importRows(ctx, tx, job.Id, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	existing, err := pr.lockSellerSkus(ctx, tx, job.SellerId, rows)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var written []uuid.UUID
+	var refused []*entity.ImportRowError
+	created, updated := 0, 0
+	for _, row := range rows {
+		product := &entity.Product{
+			SellerId:    job.SellerId,
+			Sku:         row.Sku,
+			Name:        row.Name,
+			Description: row.Description,
+			Price:       row.Price,
+			Stock:       row.Stock,
+			Status:      entity.ProductDraft,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		id, ok := existing[row.Sku]
+		switch {
+		case ok:
+			if job.DryRun {
+				updated++
+				continue
+			}
+			product.Id = id
+			err := pr.updateProduct(ctx, tx, product, job.CreatedAt)
+			if errors.Is(err, entity.ErrProductConflict) {
+				refused = append(refused, &entity.ImportRowError{Row: row.Row, Sku: row.Sku, Message: "the product has changed since the file was uploaded"})
+				continue
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: row %d: %w", op, row.Row, err)
+			}
+			updated++
+		default:
+			created++
+			if job.DryRun {
+				continue
+			}
+			product.Id = entity.GenerateID()
+			if id, err = pr.insertProduct(ctx, tx, product); err != nil {
+				return nil, nil, fmt.Errorf("%s: row %d: %w", op, row.Row, err)
+			}
+		}
+		written = append(written, id)
+	}
+
+	if len(rows) > 0 {
+		last := rows[len(rows)-1].Row
+		if _, err := tx.Exec(ctx, "DELETE FROM import_rows WHERE job_id = $1 AND row_index <= $2", job.Id, last); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// Like the rows refused on upload, only the first MaxImportErrors are
+	// kept.
+	kept := refused[:min(len(refused), max(entity.MaxImportErrors-job.Failed, 0))]
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"import_errors"},
+		[]string{"job_id", "row_index", "sku", "message"},
+		pgx.CopyFromSlice(len(kept), func(i int) ([]any, error) {
+			rowErr := kept[i]
+			return []any{job.Id, rowErr.Row, rowErr.Sku, rowErr.Message}, nil
+		}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	job.ProcessedRows += len(rows)
+	job.Created += created
+	job.Updated += updated
+	job.Failed += len(refused)
+	job.Status = entity.ImportRunning
+	if len(rows) < limit {
+		job.Status = entity.ImportCompleted
+		job.FinishedAt = &now
+	}
+
+	query, args, err = pr.pg.Builder.Update("import_jobs").
+		Set("status", job.Status).
+		Set("processed_rows", job.ProcessedRows).
+		Set("created", job.Created).
+		Set("updated", job.Updated).
+		Set("failed", job.Failed).
+		Set("started_at", squirrel.Expr("COALESCE(started_at, ?)", now)).
+		Set("finished_at", job.FinishedAt).
+		Where(squirrel.Eq{"id": job.Id}).
+		ToSql()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, written, nil
+}
+
+func (pr *PostgresRepository) importRows(ctx context.Context, tx pgx.Tx, jobID uuid.UUID, limit int) ([]*entity.ImportRow, error) {
+	query, args, err := pr.pg.Builder.Select("row_index", "sku", "name", "description", "price", "stock").
+		From("import_rows").
+		Where(squirrel.Eq{"job_id": jobID}).
+		OrderBy("row_index").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	importRows := make([]*entity.ImportRow, 0, limit)
+	for rows.Next() {
+		row := &entity.ImportRow{}
+		if err := rows.Scan(&row.Row, &row.Sku, &row.Name, &row.Description, &row.Price, &row.Stock); err != nil {
+			return nil, err
+		}
+		importRows = append(importRows, row)
+	}
+
+	return importRows, rows.Err()
+}
+
+// lockSellerSkus locks the products of the seller with the SKUs of the rows
+// and returns their IDs by SKU.
+func (pr *PostgresRepository) lockSellerSkus(ctx context.Context, tx pgx.Tx, sellerID uuid.UUID, rows []*entity.ImportRow) (map[string]uuid.UUID, error) {
+	skus := make([]string, 0, len(rows))
+	for _, row := range rows {
+		skus = append(skus, row.Sku)
+	}
+
+	query, args, err := pr.pg.Builder.Select("sku", "id").
+		From("products").
+		Where(squirrel.Eq{"seller_id": sellerID, "sku": skus}).
+		Where(squirrel.NotEq{"status": entity.ProductDeleted}).
+		OrderBy("id").
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	ids := make(map[string]uuid.UUID, len(rows))
+	for result.Next() {
+		var (
+			sku string
+			id  uuid.UUID
+		)
+		if err := result.Scan(&sku, &id); err != nil {
+			return nil, err
+		}
+		ids[sku] = id
+	}
+
+	return ids, result.Err()
+}
+
+func scanImportJob(row pgx.Row) (*entity.ImportJob, error) {
+	job := &entity.ImportJob{}
+	err := row.Scan(&job.Id, &job.SellerId, &job.Format, &job.DryRun, &job.Status, &job.TotalRows, &job.ProcessedRows,
+		&job.Created, &job.Updated, &job.Failed, &job.CreatedAt, &job.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+func createTestImport(t *testing.T, repo *PostgresRepository, sellerID uuid.UUID, dryRun bool, rows ...*entity.ImportRow) *entity.ImportJob {
+	t.Helper()
+
+	job, err := repo.CreateImportJob(context.Background(), &entity.ImportJob{
+		Id:            entity.GenerateID(),
+		SellerId:      sellerID,
+		Format:        entity.CatalogCSV,
+		DryRun:        dryRun,
+		Status:        entity.ImportPending,
+		TotalRows:     len(rows) + 1,
+		ProcessedRows: 1,
+		Failed:        1,
+		Errors:        []*entity.ImportRowError{{Row: 100, Sku: "BAD", Message: "invalid price"}},
+		CreatedAt:     time.Now(),
+	}, rows)
+	require.NoError(t, err)
+
+	return job
+}
+
+func processAllImports(t *testing.T, repo *PostgresRepository, limit int) {
+	t.Helper()
+
+	for {
+		job, _, err := repo.ProcessImportBatch(context.Background(), limit, time.Now())
+		require.NoError(t, err)
+		if job == nil {
+			return
+		}
+	}
+}
+
+func TestPostgresRepository_Import(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	sellerID := uuid.New()
+
+	row := func(n int, sku string, price int64) *entity.ImportRow {
+		return &entity.ImportRow{Row: n, Sku: sku, Name: "Ноутбук " + sku, Description: "Лёгкий ноутбук", Price: price, Stock: 3}
+	}
+
+	first := createTestImport(t, repo, sellerID, false, row(2, "NB-1", 100), row(3, "NB-2", 200), row(4, "NB-3", 300))
+	processAllImports(t, repo, 2)
+
+	job, err := repo.GetImportJob(ctx, first.Id)
+	require.NoError(t, err)
+	assert.Equal(t, entity.ImportCompleted, job.Status)
+	assert.Equal(t, 4, job.ProcessedRows)
+	assert.Equal(t, 3, job.Created)
+	assert.Equal(t, 0, job.Updated)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, []*entity.ImportRowError{{Row: 100, Sku: "BAD", Message: "invalid price"}}, job.Errors)
+
+	products, err := repo.ListSellerPage(ctx, sellerID, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, products, 3)
+
+	t.Run("a dry run only counts", func(t *testing.T) {
+		dry := createTestImport(t, repo, sellerID, true, row(2, "NB-1", 150), row(3, "NB-4", 400))
+		processAllImports(t, repo, 10)
+
+		job, err := repo.GetImportJob(ctx, dry.Id)
+		require.NoError(t, err)
+		assert.Equal(t, 1, job.Created)
+		assert.Equal(t, 1, job.Updated)
+
+		products, err := repo.ListSellerPage(ctx, sellerID, nil, 10)
+		require.NoError(t, err)
+		assert.Len(t, products, 3)
+	})
+
+	t.Run("a known SKU updates the product and records its price", func(t *testing.T) {
+		again := createTestImport(t, repo, sellerID, false, row(2, "NB-1", 150))
+		processAllImports(t, repo, 10)
+
+		job, err := repo.GetImportJob(ctx, again.Id)
+		require.NoError(t, err)
+		assert.Equal(t, 1, job.Updated)
+
+		products, err := repo.ListSellerPage(ctx, sellerID, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, products, 3)
+		for _, product := range products {
+			if product.Sku != "NB-1" {
+				continue
+			}
+			assert.Equal(t, int64(150), product.Price)
+
+			history, err := repo.ListPriceHistory(ctx, product.Id, time.Now().Add(-time.Hour))
+			require.NoError(t, err)
+			assert.Len(t, history, 2)
+		}
+	})
+
+	t.Run("products changed after the upload are refused", func(t *testing.T) {
+		stale := createTestImport(t, repo, sellerID, false, row(2, "NB-2", 250))
+
+		products, err := repo.ListSellerPage(ctx, sellerID, nil, 10)
+		require.NoError(t, err)
+		for _, product := range products {
+			if product.Sku != "NB-2" {
+				continue
+			}
+			readAt := product.UpdatedAt
+			product.Stock = 1
+			product.UpdatedAt = time.Now()
+			_, err := repo.Update(ctx, product, readAt)
+			require.NoError(t, err)
+		}
+		processAllImports(t, repo, 10)
+
+		job, err := repo.GetImportJob(ctx, stale.Id)
+		require.NoError(t, err)
+		assert.Equal(t, 0, job.Updated)
+		assert.Equal(t, 2, job.Failed)
+		assert.Contains(t, job.Errors, &entity.ImportRowError{Row: 2, Sku: "NB-2", Message: "the product has changed since the file was uploaded"})
+
+		products, err = repo.ListSellerPage(ctx, sellerID, nil, 10)
+		require.NoError(t, err)
+		for _, product := range products {
+			if product.Sku == "NB-2" {
+				assert.Equal(t, int64(200), product.Price)
+			}
+		}
+	})
+
+	t.Run("SKUs belong to their seller", func(t *testing.T) {
+		other := uuid.New()
+		createTestImport(t, repo, other, false, row(2, "NB-1", 500))
+		processAllImports(t, repo, 10)
+
+		products, err := repo.ListSellerPage(ctx, other, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, products, 1)
+		assert.Equal(t, int64(500), products[0].Price)
+	})
+}
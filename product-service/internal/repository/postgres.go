@@ -2,14 +2,23 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/storage/postgres"
 )
 
+// productColumns are the columns scanProduct reads, in its order.
+var productColumns = []string{"id", "seller_id", "name", "description", "price", "stock", "created_at", "updated_at", "original_price", "sale_ends_at", "sku", "rating_sum", "rating_count", "status", "published_at", "deleted_at"}
+
+// published matches the products on sale, the only ones the catalog lists.
+var published = squirrel.Eq{"products.status": entity.ProductPublished}
+
 type PostgresRepository struct {
 	pg *postgres.Postgres
 }
@@ -18,22 +27,62 @@ func NewPostgresRepository(pg *postgres.Postgres) *PostgresRepository {
 	return &PostgresRepository{pg: pg}
 }
 
+// Create inserts a product and starts its price history.
 func (pr *PostgresRepository) Create(ctx context.Context, product *entity.Product) (uuid.UUID, error) {
 	const op = "repository.postgres.Create"
 
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := pr.insertProduct(ctx, tx, product)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (pr *PostgresRepository) insertProduct(ctx context.Context, tx pgx.Tx, product *entity.Product) (uuid.UUID, error) {
 	query, args, err := pr.pg.Builder.Insert("products").
-		Columns("id", "name", "description", "price", "stock", "created_at", "updated_at").
-		Values(product.Id, product.Name, product.Description, product.Price, product.Stock, product.CreatedAt, product.UpdatedAt).
+		Columns(productColumns...).
+		Values(product.Id, product.SellerId, product.Name, product.Description, product.Price, product.Stock, product.CreatedAt, product.UpdatedAt, nil, nil, product.Sku, 0, 0, product.Status, product.PublishedAt, nil).
 		Suffix("RETURNING id").
 		ToSql()
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+		return uuid.Nil, err
 	}
 
 	var id uuid.UUID
-	row := pr.pg.Pool.QueryRow(ctx, query, args...)
+	row := tx.QueryRow(ctx, query, args...)
 	if err = row.Scan(&id); err != nil {
-		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+		return uuid.Nil, err
+	}
+
+	err = pr.recordPrice(ctx, tx, &entity.PriceHistoryEntry{
+		ProductId: id,
+		Price:     product.Price,
+		Reason:    entity.PriceCreated,
+		ChangedAt: product.CreatedAt,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	err = pr.recordStatus(ctx, tx, &entity.ProductStatusChange{
+		ProductId: id,
+		ToStatus:  product.Status,
+		ChangedBy: product.SellerId,
+		ChangedAt: product.CreatedAt,
+	})
+	if err != nil {
+		return uuid.Nil, err
 	}
 
 	return id, nil
@@ -42,7 +91,7 @@ func (pr *PostgresRepository) Create(ctx context.Context, product *entity.Produc
 func (pr *PostgresRepository) Get(ctx context.Context, id uuid.UUID) (*entity.Product, error) {
 	const op = "repository.postgres.Get"
 
-	query, args, err := pr.pg.Builder.Select("*").
+	query, args, err := pr.pg.Builder.Select(productColumns...).
 		From("products").
 		Where(squirrel.Eq{"id": id}).
 		ToSql()
@@ -50,61 +99,101 @@ func (pr *PostgresRepository) Get(ctx context.Context, id uuid.UUID) (*entity.Pr
 		return &entity.Product{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	product := &entity.Product{}
-	row := pr.pg.Pool.QueryRow(ctx, query, args...)
-	if err = row.Scan(&product.Id, &product.Name, &product.Description, &product.Price, &product.Stock, &product.CreatedAt, &product.UpdatedAt); err != nil {
+	product, err := scanProduct(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &entity.Product{}, fmt.Errorf("%s: %w", op, entity.ErrProductNotFound)
+	}
+	if err != nil {
 		return &entity.Product{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return product, err
+	return product, nil
 }
 
-func (pr *PostgresRepository) Update(ctx context.Context, product *entity.Product) (*entity.Product, error) {
+// Update overwrites a product and records a change of its price. While the
+// product is on sale, product.Price is its new regular price, unless it is
+// the sale price sent back unchanged. It fails with
+// entity.ErrProductConflict if the product was changed after
+// unchangedSince, e.g. by a reservation taking its stock, so a stock read
+// before is not written over it.
+func (pr *PostgresRepository) Update(ctx context.Context, product *entity.Product, unchangedSince time.Time) (*entity.Product, error) {
 	const op = "repository.postgres.Update"
 
-	query, args, err := pr.pg.Builder.Update("products").
-		Set("name", product.Name).
-		Set("description", product.Description).
-		Set("price", product.Price).
-		Set("stock", product.Stock).
-		Set("updated_at", product.UpdatedAt).
-		Where("id = ?", product.Id).
-		ToSql()
+	tx, err := pr.pg.Pool.Begin(ctx)
 	if err != nil {
 		return &entity.Product{}, fmt.Errorf("%s: %w", op, err)
 	}
+	defer tx.Rollback(ctx)
 
-	_, err = pr.pg.Pool.Exec(ctx, query, args...)
-	if err != nil {
+	if err := pr.updateProduct(ctx, tx, product, unchangedSince); err != nil {
+		return &entity.Product{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
 		return &entity.Product{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return pr.Get(ctx, product.Id)
 }
 
-func (pr *PostgresRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	const op = "repository.postgres.Delete"
+func (pr *PostgresRepository) updateProduct(ctx context.Context, tx pgx.Tx, product *entity.Product, unchangedSince time.Time) error {
+	current, err := lockPrice(ctx, tx, product.Id)
+	if err != nil {
+		return err
+	}
 
-	query, args, err := pr.pg.Builder.Delete("products").
-		Where("id = ?", id).
+	next := current
+	switch {
+	case current.original == nil:
+		next.price = product.Price
+	case product.Price != current.price:
+		next.original = &product.Price
+	}
+
+	query, args, err := pr.pg.Builder.Update("products").
+		Set("name", product.Name).
+		Set("description", product.Description).
+		Set("price", next.price).
+		Set("original_price", next.original).
+		Set("stock", product.Stock).
+		Set("updated_at", product.UpdatedAt).
+		Where("id = ?", product.Id).
+		Where(squirrel.LtOrEq{"updated_at": unchangedSince}).
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return err
 	}
 
-	_, err = pr.pg.Pool.Exec(ctx, query, args...)
+	tag, err := tx.Exec(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.ErrProductConflict
+	}
+
+	if next.equal(current) {
+		return nil
 	}
 
-	return nil
+	return pr.recordPrice(ctx, tx, &entity.PriceHistoryEntry{
+		ProductId:     product.Id,
+		Price:         next.price,
+		OriginalPrice: next.original,
+		Reason:        entity.PriceUpdated,
+		ChangedAt:     product.UpdatedAt,
+	})
 }
 
+// List lists the published products newest first, in the order of
+// ListPage.
 func (pr *PostgresRepository) List(ctx context.Context, offset, limit int64) ([]*entity.Product, error) {
 	const op = "repository.postgres.List"
 
-	query, args, err := pr.pg.Builder.Select("id", "name", "description", "price", "stock", "created_at", "updated_at").
+	query, args, err := pr.pg.Builder.Select(productColumns...).
 		From("products").
+		Where(published).
+		OrderBy("created_at DESC", "id DESC").
 		Offset(uint64(offset)).
 		Limit(uint64(limit)).
 		ToSql()
@@ -120,8 +209,8 @@ func (pr *PostgresRepository) List(ctx context.Context, offset, limit int64) ([]
 
 	products := []*entity.Product{}
 	for rows.Next() {
-		product := &entity.Product{}
-		if err := rows.Scan(&product.Id, &product.Name, &product.Description, &product.Price, &product.Stock, &product.CreatedAt, &product.UpdatedAt); err != nil {
+		product, err := scanProduct(rows)
+		if err != nil {
 			return []*entity.Product{}, fmt.Errorf("%s: %w", op, err)
 		}
 
@@ -134,3 +223,160 @@ func (pr *PostgresRepository) List(ctx context.Context, offset, limit int64) ([]
 
 	return products, nil
 }
+
+// ListPage returns up to limit published products, newest first, starting
+// after the cursor if there is one.
+func (pr *PostgresRepository) ListPage(ctx context.Context, after *entity.ProductCursor, limit int) ([]*entity.Product, error) {
+	const op = "repository.postgres.ListPage"
+
+	products, err := pr.listPage(ctx, published, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return products, nil
+}
+
+// ListStatusPage is ListPage over the products in the given status.
+func (pr *PostgresRepository) ListStatusPage(ctx context.Context, status entity.ProductStatus, after *entity.ProductCursor, limit int) ([]*entity.Product, error) {
+	const op = "repository.postgres.ListStatusPage"
+
+	products, err := pr.listPage(ctx, squirrel.Eq{"status": status}, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return products, nil
+}
+
+// ListSellerPage is ListPage over the products of a seller that aren't
+// deleted, whatever their status.
+func (pr *PostgresRepository) ListSellerPage(ctx context.Context, sellerID uuid.UUID, after *entity.ProductCursor, limit int) ([]*entity.Product, error) {
+	const op = "repository.postgres.ListSellerPage"
+
+	products, err := pr.listPage(ctx, squirrel.And{
+		squirrel.Eq{"seller_id": sellerID},
+		squirrel.NotEq{"status": entity.ProductDeleted},
+	}, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return products, nil
+}
+
+func (pr *PostgresRepository) listPage(ctx context.Context, filter squirrel.Sqlizer, after *entity.ProductCursor, limit int) ([]*entity.Product, error) {
+	builder := pr.pg.Builder.Select(productColumns...).
+		From("products").
+		OrderBy("created_at DESC", "id DESC").
+		Limit(uint64(limit))
+	if filter != nil {
+		builder = builder.Where(filter)
+	}
+	if after != nil {
+		builder = builder.Where(squirrel.Expr("(created_at, id) < (?, ?)", after.CreatedAt, after.Id))
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]*entity.Product, 0, limit)
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// EstimateCount estimates the number of published products from the
+// planner statistics of their index, which is cheap however large the
+// table is. Before the table is first analyzed it counts the products.
+func (pr *PostgresRepository) EstimateCount(ctx context.Context) (int64, error) {
+	const op = "repository.postgres.EstimateCount"
+
+	var estimate float64
+	err := pr.pg.Pool.QueryRow(ctx, "SELECT reltuples FROM pg_class WHERE oid = 'products_published_idx'::regclass").Scan(&estimate)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if estimate >= 0 {
+		return int64(estimate), nil
+	}
+
+	var count int64
+	if err := pr.pg.Pool.QueryRow(ctx, "SELECT count(*) FROM products WHERE status = $1", entity.ProductPublished).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// ListBySeller lists the products of a seller in one of the statuses,
+// newest first.
+func (pr *PostgresRepository) ListBySeller(ctx context.Context, sellerID uuid.UUID, statuses []entity.ProductStatus, offset, limit int64) ([]*entity.Product, error) {
+	const op = "repository.postgres.ListBySeller"
+
+	query, args, err := pr.pg.Builder.Select(productColumns...).
+		From("products").
+		Where(squirrel.Eq{"seller_id": sellerID, "status": statuses}).
+		OrderBy("created_at DESC", "id").
+		Offset(uint64(offset)).
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	products := []*entity.Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return products, nil
+}
+
+func scanProduct(row pgx.Row) (*entity.Product, error) {
+	product := &entity.Product{}
+	if err := row.Scan(productFields(product)...); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// productFields are the scan destinations of productColumns.
+func productFields(p *entity.Product) []any {
+	return []any{&p.Id, &p.SellerId, &p.Name, &p.Description, &p.Price, &p.Stock, &p.CreatedAt, &p.UpdatedAt, &p.OriginalPrice, &p.SaleEndsAt, &p.Sku, &p.RatingSum, &p.RatingCount, &p.Status, &p.PublishedAt, &p.DeletedAt}
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+func TestPostgresRepository_ListPage(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	created := map[uuid.UUID]bool{}
+	for range 5 {
+		created[createTestProduct(t, repo, 1)] = true
+	}
+
+	seen := map[uuid.UUID]bool{}
+	var after *entity.ProductCursor
+	for {
+		products, err := repo.ListPage(ctx, after, 2)
+		require.NoError(t, err)
+		if len(products) == 0 {
+			break
+		}
+
+		for _, product := range products {
+			require.False(t, seen[product.Id], "product %s listed twice", product.Id)
+			seen[product.Id] = true
+
+			if after != nil {
+				assert.False(t, product.CreatedAt.After(after.CreatedAt), "products are listed newest first")
+			}
+			cursor := entity.CursorOf(product)
+			after = &cursor
+		}
+	}
+
+	for id := range created {
+		assert.True(t, seen[id], "product %s was not listed", id)
+	}
+}
+
+func TestPostgresRepository_Update(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	t.Run("an update from a stale read does not overwrite the stock", func(t *testing.T) {
+		productID := createTestProduct(t, repo, 10)
+		product, err := repo.Get(ctx, productID)
+		require.NoError(t, err)
+
+		_, err = repo.ReserveStock(ctx, testReservation(productID, 3, time.Minute))
+		require.NoError(t, err)
+
+		readAt := product.UpdatedAt
+		product.Stock = 12
+		product.UpdatedAt = time.Now()
+		_, err = repo.Update(ctx, product, readAt)
+		assert.ErrorIs(t, err, entity.ErrProductConflict)
+		assert.Equal(t, int64(7), stockOf(t, repo, productID))
+
+		product, err = repo.Get(ctx, productID)
+		require.NoError(t, err)
+		readAt = product.UpdatedAt
+		product.Stock = 12
+		product.UpdatedAt = time.Now()
+		_, err = repo.Update(ctx, product, readAt)
+		require.NoError(t, err)
+		assert.Equal(t, int64(12), stockOf(t, repo, productID))
+	})
+}
@@ -0,0 +1,441 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+var (
+	priceScheduleColumns = []string{"id", "product_id", "kind", "price", "starts_at", "ends_at", "status", "created_at"}
+	priceHistoryColumns  = []string{"product_id", "price", "original_price", "reason", "schedule_id", "changed_at"}
+)
+
+// priceState is the price of a product and, while it is on sale, its regular
+// price.
+type priceState struct {
+	price    int64
+	original *int64
+	saleEnds *time.Time
+}
+
+func (p priceState) equal(other priceState) bool {
+	if p.price != other.price || (p.original == nil) != (other.original == nil) {
+		return false
+	}
+
+	return p.original == nil || *p.original == *other.original
+}
+
+// CreatePriceSchedule stores a schedule, unless it is a sale that overlaps
+// another pending or active sale of the product.
+func (pr *PostgresRepository) CreatePriceSchedule(ctx context.Context, schedule *entity.PriceSchedule) (*entity.PriceSchedule, error) {
+	const op = "repository.postgres.CreatePriceSchedule"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Serializes the schedules of a product, so two overlapping sales can't
+	// both pass the check.
+	if _, err := lockPrice(ctx, tx, schedule.ProductId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if schedule.Kind == entity.PriceSale {
+		query, args, err := pr.pg.Builder.Select("1").
+			From("price_schedules").
+			Where(squirrel.Eq{
+				"product_id": schedule.ProductId,
+				"kind":       entity.PriceSale,
+				"status":     []entity.PriceScheduleStatus{entity.PriceSchedulePending, entity.PriceScheduleActive},
+			}).
+			Where(squirrel.Lt{"starts_at": schedule.EndsAt}).
+			Where(squirrel.Gt{"ends_at": schedule.StartsAt}).
+			Limit(1).
+			ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		var overlap int
+		err = tx.QueryRow(ctx, query, args...).Scan(&overlap)
+		if err == nil {
+			return nil, fmt.Errorf("%s: %w", op, entity.ErrSaleOverlap)
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	query, args, err := pr.pg.Builder.Insert("price_schedules").
+		Columns(priceScheduleColumns...).
+		Values(schedule.Id, schedule.ProductId, schedule.Kind, schedule.Price, schedule.StartsAt, schedule.EndsAt, schedule.Status, schedule.CreatedAt).
+		Suffix("RETURNING " + strings.Join(priceScheduleColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := scanPriceSchedule(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+func (pr *PostgresRepository) GetPriceSchedule(ctx context.Context, id uuid.UUID) (*entity.PriceSchedule, error) {
+	const op = "repository.postgres.GetPriceSchedule"
+
+	query, args, err := pr.pg.Builder.Select(priceScheduleColumns...).
+		From("price_schedules").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	schedule, err := scanPriceSchedule(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrPriceScheduleNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return schedule, nil
+}
+
+// ListPriceSchedules lists every schedule of a product by start time.
+func (pr *PostgresRepository) ListPriceSchedules(ctx context.Context, productID uuid.UUID) ([]*entity.PriceSchedule, error) {
+	const op = "repository.postgres.ListPriceSchedules"
+
+	query, args, err := pr.pg.Builder.Select(priceScheduleColumns...).
+		From("price_schedules").
+		Where(squirrel.Eq{"product_id": productID}).
+		OrderBy("starts_at", "created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	schedules, err := queryPriceSchedules(ctx, pr.pg.Pool, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return schedules, nil
+}
+
+// CancelPriceSchedule cancels a pending schedule. An active sale is ended
+// right away and the product goes back to its regular price.
+func (pr *PostgresRepository) CancelPriceSchedule(ctx context.Context, id uuid.UUID, now time.Time) (*entity.PriceSchedule, error) {
+	const op = "repository.postgres.CancelPriceSchedule"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := pr.pg.Builder.Select(priceScheduleColumns...).
+		From("price_schedules").
+		Where(squirrel.Eq{"id": id}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	schedule, err := scanPriceSchedule(tx.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrPriceScheduleNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch schedule.Status {
+	case entity.PriceSchedulePending:
+	case entity.PriceScheduleActive:
+		if err := pr.endSale(ctx, tx, schedule, now); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	default:
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrPriceScheduleFinished)
+	}
+
+	if err := pr.setScheduleStatus(ctx, tx, schedule, entity.PriceScheduleCancelled, now); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return schedule, nil
+}
+
+// ApplyPriceSchedules starts and ends the schedules due by now, up to limit of
+// them in one transaction, and returns them with their new status. The
+// schedules of a product are applied in the order they take effect. Several
+// instances may run it at once: one of them applies the schedules at a time.
+func (pr *PostgresRepository) ApplyPriceSchedules(ctx context.Context, now time.Time, limit uint64) ([]*entity.PriceSchedule, error) {
+	const op = "repository.postgres.ApplyPriceSchedules"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock(hashtextextended($1, 0))", "price-schedules").Scan(&locked); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !locked {
+		return nil, nil
+	}
+
+	query, args, err := pr.pg.Builder.Select(priceScheduleColumns...).
+		From("price_schedules").
+		Where(squirrel.Or{
+			squirrel.And{squirrel.Eq{"status": entity.PriceSchedulePending}, squirrel.LtOrEq{"starts_at": now}},
+			squirrel.And{squirrel.Eq{"status": entity.PriceScheduleActive}, squirrel.LtOrEq{"ends_at": now}},
+		}).
+		OrderBy("CASE WHEN status = 'active' THEN ends_at ELSE starts_at END", "created_at").
+		Limit(limit).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	schedules, err := queryPriceSchedules(ctx, tx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Products are locked in the same order by every transaction; the
+	// schedules of each product keep the order they take effect in.
+	slices.SortStableFunc(schedules, func(a, b *entity.PriceSchedule) int {
+		return strings.Compare(a.ProductId.String(), b.ProductId.String())
+	})
+
+	for _, schedule := range schedules {
+		status, err := pr.applySchedule(ctx, tx, schedule, now)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := pr.setScheduleStatus(ctx, tx, schedule, status, now); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return schedules, nil
+}
+
+// applySchedule applies a due schedule to the price of its product and
+// returns the status the schedule moves to.
+func (pr *PostgresRepository) applySchedule(ctx context.Context, tx pgx.Tx, schedule *entity.PriceSchedule, now time.Time) (entity.PriceScheduleStatus, error) {
+	if schedule.Status == entity.PriceScheduleActive {
+		return entity.PriceScheduleCompleted, pr.endSale(ctx, tx, schedule, now)
+	}
+
+	if schedule.Kind == entity.PriceSale && !schedule.EndsAt.After(now) {
+		return entity.PriceScheduleSkipped, nil
+	}
+
+	current, err := lockPrice(ctx, tx, schedule.ProductId)
+	if err != nil {
+		return "", err
+	}
+
+	next := current
+	reason := entity.PriceScheduled
+	status := entity.PriceScheduleCompleted
+	switch {
+	case schedule.Kind == entity.PriceSale:
+		if current.original == nil {
+			next.original = &current.price
+		}
+		next.price = schedule.Price
+		next.saleEnds = schedule.EndsAt
+		reason = entity.PriceSaleStarted
+		status = entity.PriceScheduleActive
+	case current.original != nil:
+		// The new regular price takes over when the sale ends.
+		next.original = &schedule.Price
+	default:
+		next.price = schedule.Price
+	}
+
+	return status, pr.setPrice(ctx, tx, schedule, next, reason, now)
+}
+
+// endSale puts the product of an active sale back to its regular price.
+func (pr *PostgresRepository) endSale(ctx context.Context, tx pgx.Tx, schedule *entity.PriceSchedule, now time.Time) error {
+	current, err := lockPrice(ctx, tx, schedule.ProductId)
+	if err != nil {
+		return err
+	}
+	if current.original == nil {
+		return nil
+	}
+
+	return pr.setPrice(ctx, tx, schedule, priceState{price: *current.original}, entity.PriceSaleEnded, now)
+}
+
+// setPrice writes the price of the product of a schedule and records it.
+func (pr *PostgresRepository) setPrice(ctx context.Context, tx pgx.Tx, schedule *entity.PriceSchedule, next priceState, reason entity.PriceChangeReason, now time.Time) error {
+	query, args, err := pr.pg.Builder.Update("products").
+		Set("price", next.price).
+		Set("original_price", next.original).
+		Set("sale_ends_at", next.saleEnds).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": schedule.ProductId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return pr.recordPrice(ctx, tx, &entity.PriceHistoryEntry{
+		ProductId:     schedule.ProductId,
+		Price:         next.price,
+		OriginalPrice: next.original,
+		Reason:        reason,
+		ScheduleId:    &schedule.Id,
+		ChangedAt:     now,
+	})
+}
+
+// ListPriceHistory returns the price changes of a product since a time,
+// oldest first, after the change that was in effect at that time.
+func (pr *PostgresRepository) ListPriceHistory(ctx context.Context, productID uuid.UUID, since time.Time) ([]*entity.PriceHistoryEntry, error) {
+	const op = "repository.postgres.ListPriceHistory"
+
+	columns := strings.Join(priceHistoryColumns, ", ")
+	query := `(SELECT ` + columns + ` FROM price_history WHERE product_id = $1 AND changed_at < $2 ORDER BY changed_at DESC LIMIT 1)
+		UNION ALL
+		(SELECT ` + columns + ` FROM price_history WHERE product_id = $1 AND changed_at >= $2)
+		ORDER BY changed_at`
+
+	rows, err := pr.pg.Pool.Query(ctx, query, productID, since)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	history := []*entity.PriceHistoryEntry{}
+	for rows.Next() {
+		entry := &entity.PriceHistoryEntry{}
+		if err := rows.Scan(&entry.ProductId, &entry.Price, &entry.OriginalPrice, &entry.Reason, &entry.ScheduleId, &entry.ChangedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		history = append(history, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return history, nil
+}
+
+func (pr *PostgresRepository) recordPrice(ctx context.Context, tx pgx.Tx, entry *entity.PriceHistoryEntry) error {
+	query, args, err := pr.pg.Builder.Insert("price_history").
+		Columns(append([]string{"id"}, priceHistoryColumns...)...).
+		Values(entity.GenerateID(), entry.ProductId, entry.Price, entry.OriginalPrice, entry.Reason, entry.ScheduleId, entry.ChangedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	return err
+}
+
+// lockPrice locks the row of a product until the end of tx and returns its
+// price.
+func lockPrice(ctx context.Context, tx pgx.Tx, productID uuid.UUID) (priceState, error) {
+	var state priceState
+	err := tx.QueryRow(ctx, "SELECT price, original_price, sale_ends_at FROM products WHERE id = $1 FOR UPDATE", productID).
+		Scan(&state.price, &state.original, &state.saleEnds)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return priceState{}, entity.ErrProductNotFound
+	}
+
+	return state, err
+}
+
+func (pr *PostgresRepository) setScheduleStatus(ctx context.Context, tx pgx.Tx, schedule *entity.PriceSchedule, status entity.PriceScheduleStatus, now time.Time) error {
+	query, args, err := pr.pg.Builder.Update("price_schedules").
+		Set("status", status).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": schedule.Id}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return err
+	}
+
+	schedule.Status = status
+	return nil
+}
+
+func queryPriceSchedules(ctx context.Context, q querier, query string, args ...any) ([]*entity.PriceSchedule, error) {
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*entity.PriceSchedule{}
+	for rows.Next() {
+		schedule, err := scanPriceSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+func scanPriceSchedule(row pgx.Row) (*entity.PriceSchedule, error) {
+	schedule := &entity.PriceSchedule{}
+	if err := row.Scan(&schedule.Id, &schedule.ProductId, &schedule.Kind, &schedule.Price, &schedule.StartsAt, &schedule.EndsAt, &schedule.Status, &schedule.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+func createTestSchedule(t *testing.T, repo *PostgresRepository, productID uuid.UUID, price int64, startsAt time.Time, endsAt *time.Time) *entity.PriceSchedule {
+	t.Helper()
+
+	kind := entity.PriceChange
+	if endsAt != nil {
+		kind = entity.PriceSale
+	}
+
+	schedule, err := repo.CreatePriceSchedule(context.Background(), &entity.PriceSchedule{
+		Id:        entity.GenerateID(),
+		ProductId: productID,
+		Kind:      kind,
+		Price:     price,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		Status:    entity.PriceSchedulePending,
+		CreatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	return schedule
+}
+
+func TestPostgresRepository_PriceSchedules(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	t.Run("a sale runs over its period and a change during it waits for its end", func(t *testing.T) {
+		productID := createTestProduct(t, repo, 1)
+		start := time.Now()
+		end := start.Add(time.Hour)
+
+		createTestSchedule(t, repo, productID, 60, start, &end)
+		createTestSchedule(t, repo, productID, 120, start.Add(time.Minute), nil)
+
+		_, err := repo.ApplyPriceSchedules(ctx, start, 100)
+		require.NoError(t, err)
+		_, err = repo.ApplyPriceSchedules(ctx, start.Add(time.Minute), 100)
+		require.NoError(t, err)
+
+		product, err := repo.Get(ctx, productID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(60), product.Price)
+		require.NotNil(t, product.OriginalPrice)
+		assert.Equal(t, int64(120), *product.OriginalPrice)
+
+		_, err = repo.ApplyPriceSchedules(ctx, end, 100)
+		require.NoError(t, err)
+
+		product, err = repo.Get(ctx, productID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(120), product.Price)
+		assert.Nil(t, product.OriginalPrice)
+		assert.Nil(t, product.SaleEndsAt)
+
+		history, err := repo.ListPriceHistory(ctx, productID, start.Add(-time.Hour))
+		require.NoError(t, err)
+		reasons := make([]entity.PriceChangeReason, 0, len(history))
+		for _, entry := range history {
+			reasons = append(reasons, entry.Reason)
+		}
+		assert.Equal(t, []entity.PriceChangeReason{entity.PriceCreated, entity.PriceSaleStarted, entity.PriceScheduled, entity.PriceSaleEnded}, reasons)
+	})
+
+	t.Run("overlapping sales are refused", func(t *testing.T) {
+		productID := createTestProduct(t, repo, 1)
+		start := time.Now().Add(time.Hour)
+		end := start.Add(time.Hour)
+		createTestSchedule(t, repo, productID, 60, start, &end)
+
+		overlapEnd := end.Add(time.Hour)
+		_, err := repo.CreatePriceSchedule(ctx, &entity.PriceSchedule{
+			Id:        entity.GenerateID(),
+			ProductId: productID,
+			Kind:      entity.PriceSale,
+			Price:     50,
+			StartsAt:  end.Add(-time.Minute),
+			EndsAt:    &overlapEnd,
+			Status:    entity.PriceSchedulePending,
+			CreatedAt: time.Now(),
+		})
+
+		assert.ErrorIs(t, err, entity.ErrSaleOverlap)
+	})
+
+	t.Run("cancelling a running sale restores the regular price", func(t *testing.T) {
+		productID := createTestProduct(t, repo, 1)
+		start := time.Now()
+		end := start.Add(time.Hour)
+		sale := createTestSchedule(t, repo, productID, 60, start, &end)
+
+		_, err := repo.ApplyPriceSchedules(ctx, start.Add(time.Second), 100)
+		require.NoError(t, err)
+		assert.Equal(t, int64(60), priceOf(t, repo, productID))
+
+		cancelled, err := repo.CancelPriceSchedule(ctx, sale.Id, start.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, entity.PriceScheduleCancelled, cancelled.Status)
+		assert.Equal(t, int64(100), priceOf(t, repo, productID))
+
+		_, err = repo.CancelPriceSchedule(ctx, sale.Id, start.Add(time.Minute))
+		assert.ErrorIs(t, err, entity.ErrPriceScheduleFinished)
+	})
+
+	t.Run("the sale price sent back by an update is kept", func(t *testing.T) {
+		productID := createTestProduct(t, repo, 1)
+		start := time.Now()
+		end := start.Add(time.Hour)
+		createTestSchedule(t, repo, productID, 60, start, &end)
+
+		_, err := repo.ApplyPriceSchedules(ctx, start.Add(time.Second), 100)
+		require.NoError(t, err)
+
+		product, err := repo.Get(ctx, productID)
+		require.NoError(t, err)
+		product.Name = "Другое название"
+		readAt := product.UpdatedAt
+		product.UpdatedAt = time.Now()
+
+		updated, err := repo.Update(ctx, product, readAt)
+		require.NoError(t, err)
+		assert.Equal(t, int64(60), updated.Price)
+		require.NotNil(t, updated.OriginalPrice)
+		assert.Equal(t, int64(100), *updated.OriginalPrice)
+	})
+}
+
+func priceOf(t *testing.T, repo *PostgresRepository, productID uuid.UUID) int64 {
+	t.Helper()
+
+	product, err := repo.Get(context.Background(), productID)
+	require.NoError(t, err)
+
+	return product.Price
+}
@@ -0,0 +1,382 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+var reservationColumns = []string{"order_id", "product_id", "variant_id", "quantity", "price", "status", "expires_at", "created_at"}
+
+// reservationRow is a single item of a reservation as it is stored.
+type reservationRow struct {
+	orderID   uuid.UUID
+	item      entity.ReservationItem
+	status    entity.ReservationStatus
+	expiresAt time.Time
+	createdAt time.Time
+}
+
+// ReserveStock takes the stock of every item of the reservation in one
+// transaction, so either all of it is reserved or none. Each decrement is
+// conditional on the stock left, which keeps concurrent reservations from
+// overselling without locking rows up front. A reservation that is already
+// active or committed for the order is returned as is.
+func (pr *PostgresRepository) ReserveStock(ctx context.Context, reservation *entity.Reservation) (*entity.Reservation, error) {
+	const op = "repository.postgres.ReserveStock"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Serializes the reservations of an order, so a retried request can't
+	// take the stock twice.
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1, 0))", reservation.OrderId.String()); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	existing, err := pr.queryReservations(ctx, tx, pr.pg.Builder.Select(reservationColumns...).
+		From("stock_reservations").
+		Where(squirrel.Eq{"order_id": reservation.OrderId}).
+		Where(squirrel.NotEq{"status": entity.ReservationReleased}))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(existing) > 0 {
+		return existing[0], nil
+	}
+
+	// Rows are locked in the same order by every transaction, so concurrent
+	// reservations of overlapping items can't deadlock.
+	items := slices.Clone(reservation.Items)
+	sortReservationItems(items)
+
+	insert := pr.pg.Builder.Insert("stock_reservations").
+		Columns("id", "order_id", "product_id", "variant_id", "quantity", "price", "status", "expires_at", "created_at", "updated_at")
+	for i := range items {
+		price, err := pr.takeStock(ctx, tx, items[i], reservation.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		items[i].Price = price
+
+		insert = insert.Values(entity.GenerateID(), reservation.OrderId, items[i].ProductId, items[i].VariantId, items[i].Quantity,
+			price, entity.ReservationActive, reservation.ExpiresAt, reservation.CreatedAt, reservation.CreatedAt)
+	}
+
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &entity.Reservation{
+		OrderId:   reservation.OrderId,
+		Items:     items,
+		Status:    entity.ReservationActive,
+		ExpiresAt: reservation.ExpiresAt,
+		CreatedAt: reservation.CreatedAt,
+	}, nil
+}
+
+// ReleaseReservation returns the stock of the active or committed
+// reservation of an order. It returns nil if there is nothing to release.
+func (pr *PostgresRepository) ReleaseReservation(ctx context.Context, orderID uuid.UUID, now time.Time) (*entity.Reservation, error) {
+	const op = "repository.postgres.ReleaseReservation"
+
+	released, err := pr.release(ctx, squirrel.And{
+		squirrel.Eq{"order_id": orderID},
+		squirrel.NotEq{"status": entity.ReservationReleased},
+	}, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(released) == 0 {
+		return nil, nil
+	}
+
+	return released[0], nil
+}
+
+// CommitReservation makes the active reservation of an order permanent,
+// unless it has expired. Committing a committed reservation returns it.
+func (pr *PostgresRepository) CommitReservation(ctx context.Context, orderID uuid.UUID, now time.Time) (*entity.Reservation, error) {
+	const op = "repository.postgres.CommitReservation"
+
+	committed, err := pr.queryReservations(ctx, pr.pg.Pool, pr.pg.Builder.Update("stock_reservations").
+		Set("status", entity.ReservationCommitted).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"order_id": orderID, "status": entity.ReservationActive}).
+		Where(squirrel.Gt{"expires_at": now}).
+		Suffix("RETURNING "+strings.Join(reservationColumns, ", ")))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(committed) > 0 {
+		return committed[0], nil
+	}
+
+	reservations, err := pr.queryReservations(ctx, pr.pg.Pool, pr.pg.Builder.Select(reservationColumns...).
+		From("stock_reservations").
+		Where(squirrel.Eq{"order_id": orderID}))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(reservations) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrReservationNotFound)
+	}
+
+	for _, reservation := range reservations {
+		if reservation.Status == entity.ReservationCommitted {
+			return reservation, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %w", op, entity.ErrReservationExpired)
+}
+
+// ReleaseExpiredReservations releases up to limit items of the active
+// reservations that expired by now. Rows locked by a concurrent release or
+// commit are skipped and left to the next run.
+func (pr *PostgresRepository) ReleaseExpiredReservations(ctx context.Context, now time.Time, limit uint64) ([]*entity.Reservation, error) {
+	const op = "repository.postgres.ReleaseExpiredReservations"
+
+	expired := pr.pg.Builder.Select("id").
+		From("stock_reservations").
+		Where(squirrel.Eq{"status": entity.ReservationActive}).
+		Where(squirrel.LtOrEq{"expires_at": now}).
+		OrderBy("expires_at").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		// The outer query numbers the placeholders.
+		PlaceholderFormat(squirrel.Question)
+
+	released, err := pr.release(ctx, squirrel.Expr("id IN (?)", expired), now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return released, nil
+}
+
+// release marks the reservation rows matching where as released and returns
+// their stock, in one transaction.
+func (pr *PostgresRepository) release(ctx context.Context, where squirrel.Sqlizer, now time.Time) ([]*entity.Reservation, error) {
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	released, err := pr.queryReservations(ctx, tx, pr.pg.Builder.Update("stock_reservations").
+		Set("status", entity.ReservationReleased).
+		Set("updated_at", now).
+		Where(where).
+		Suffix("RETURNING "+strings.Join(reservationColumns, ", ")))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []entity.ReservationItem
+	for _, reservation := range released {
+		items = append(items, reservation.Items...)
+	}
+	sortReservationItems(items)
+
+	for _, item := range items {
+		query, args, err := pr.pg.Builder.Update(stockTable(item)).
+			Set("stock", squirrel.Expr("stock + ?", item.Quantity)).
+			Set("updated_at", now).
+			Where(stockRow(item)).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		// A product or variant deleted in the meantime has no stock to
+		// return to.
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return released, nil
+}
+
+// takeStock decrements the stock of the item if enough is left and returns
+// its current price.
+func (pr *PostgresRepository) takeStock(ctx context.Context, tx pgx.Tx, item entity.ReservationItem, now time.Time) (int64, error) {
+	query, args, err := pr.pg.Builder.Update(stockTable(item)).
+		Set("stock", squirrel.Expr("stock - ?", item.Quantity)).
+		Set("updated_at", now).
+		Where(stockRow(item)).
+		Where(onSale(item)).
+		Where(squirrel.GtOrEq{"stock": item.Quantity}).
+		Suffix("RETURNING price").
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var price int64
+	err = tx.QueryRow(ctx, query, args...).Scan(&price)
+	if err == nil {
+		return price, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	// Nothing was updated: tell a missing product from one that isn't on
+	// sale and from a lack of stock.
+	query, args, err = pr.pg.Builder.Select().
+		Column(onSale(item)).
+		From(stockTable(item)).
+		Where(stockRow(item)).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var available bool
+	err = tx.QueryRow(ctx, query, args...).Scan(&available)
+	switch {
+	case err == nil && available:
+		return 0, fmt.Errorf("%w: %s", entity.ErrInsufficientStock, stockName(item))
+	case err == nil:
+		return 0, fmt.Errorf("%w: %s", entity.ErrProductUnavailable, stockName(item))
+	case !errors.Is(err, pgx.ErrNoRows):
+		return 0, err
+	case item.VariantId != nil:
+		return 0, fmt.Errorf("%w: %s", entity.ErrVariantNotFound, stockName(item))
+	default:
+		return 0, fmt.Errorf("%w: %s", entity.ErrProductNotFound, stockName(item))
+	}
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// queryReservations runs a query returning reservationColumns and groups the
+// rows into reservations by order and status.
+func (pr *PostgresRepository) queryReservations(ctx context.Context, q querier, builder squirrel.Sqlizer) ([]*entity.Reservation, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []*entity.Reservation
+	for rows.Next() {
+		var row reservationRow
+		if err := rows.Scan(&row.orderID, &row.item.ProductId, &row.item.VariantId, &row.item.Quantity, &row.item.Price,
+			&row.status, &row.expiresAt, &row.createdAt); err != nil {
+			return nil, err
+		}
+
+		i := slices.IndexFunc(reservations, func(r *entity.Reservation) bool {
+			return r.OrderId == row.orderID && r.Status == row.status
+		})
+		if i < 0 {
+			reservations = append(reservations, &entity.Reservation{
+				OrderId:   row.orderID,
+				Status:    row.status,
+				ExpiresAt: row.expiresAt,
+				CreatedAt: row.createdAt,
+			})
+			i = len(reservations) - 1
+		}
+		reservations[i].Items = append(reservations[i].Items, row.item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, reservation := range reservations {
+		sortReservationItems(reservation.Items)
+	}
+
+	return reservations, nil
+}
+
+// stockTable is the table holding the stock of the item: the variant's if one
+// was chosen, otherwise the product's.
+func stockTable(item entity.ReservationItem) string {
+	if item.VariantId != nil {
+		return "product_variants"
+	}
+
+	return "products"
+}
+
+func stockRow(item entity.ReservationItem) squirrel.Eq {
+	if item.VariantId != nil {
+		return squirrel.Eq{"id": *item.VariantId, "product_id": item.ProductId}
+	}
+
+	return squirrel.Eq{"id": item.ProductId}
+}
+
+// onSale matches the stock row of item if its product is published.
+func onSale(item entity.ReservationItem) squirrel.Sqlizer {
+	if item.VariantId != nil {
+		return squirrel.Expr("EXISTS (SELECT 1 FROM products WHERE products.id = product_variants.product_id AND products.status = ?)", entity.ProductPublished)
+	}
+
+	return published
+}
+
+func stockName(item entity.ReservationItem) string {
+	if item.VariantId != nil {
+		return fmt.Sprintf("variant %s of product %s", *item.VariantId, item.ProductId)
+	}
+
+	return fmt.Sprintf("product %s", item.ProductId)
+}
+
+func sortReservationItems(items []entity.ReservationItem) {
+	slices.SortFunc(items, func(a, b entity.ReservationItem) int {
+		if c := bytes.Compare(a.ProductId[:], b.ProductId[:]); c != 0 {
+			return c
+		}
+
+		switch {
+		case a.VariantId == nil && b.VariantId == nil:
+			return 0
+		case a.VariantId == nil:
+			return -1
+		case b.VariantId == nil:
+			return 1
+		default:
+			return bytes.Compare(a.VariantId[:], b.VariantId[:])
+		}
+	})
+}
@@ -0,0 +1,439 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+var reviewColumns = []string{"id", "product_id", "user_id", "order_id", "rating", "text", "status", "reply", "replied_at", "open_reports", "created_at", "updated_at"}
+
+var reviewImageColumns = []string{"id", "review_id", "position", "content_type", "width", "height", "size", "storage_key", "thumbnails", "created_at"}
+
+// CreateReview stores a review and adds its rating to the product.
+func (pr *PostgresRepository) CreateReview(ctx context.Context, review *entity.Review) (*entity.Review, error) {
+	const op = "repository.postgres.CreateReview"
+
+	query, args, err := pr.pg.Builder.Insert("reviews").
+		Columns(reviewColumns...).
+		Values(review.Id, review.ProductId, review.UserId, review.OrderId, review.Rating, review.Text, review.Status,
+			review.Reply, review.RepliedAt, review.OpenReports, review.CreatedAt, review.UpdatedAt).
+		Suffix("RETURNING " + strings.Join(reviewColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	created, err := scanReview(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, reviewError(err))
+	}
+
+	if created.Status == entity.ReviewPublished {
+		if err := addRating(ctx, tx, created.ProductId, created.Rating, 1); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+func (pr *PostgresRepository) GetReview(ctx context.Context, id uuid.UUID) (*entity.Review, error) {
+	const op = "repository.postgres.GetReview"
+
+	query, args, err := pr.pg.Builder.Select(reviewColumns...).
+		From("reviews").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	review, err := scanReview(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, reviewError(err))
+	}
+
+	return review, nil
+}
+
+// ListReviewPage returns up to limit published reviews of the product,
+// newest first, starting after the cursor if there is one.
+func (pr *PostgresRepository) ListReviewPage(ctx context.Context, productID uuid.UUID, after *entity.ReviewCursor, limit int) ([]*entity.Review, error) {
+	const op = "repository.postgres.ListReviewPage"
+
+	builder := pr.pg.Builder.Select(reviewColumns...).
+		From("reviews").
+		Where(squirrel.Eq{"product_id": productID, "status": entity.ReviewPublished}).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(uint64(limit))
+	if after != nil {
+		builder = builder.Where(squirrel.Expr("(created_at, id) < (?, ?)", after.CreatedAt, after.Id))
+	}
+
+	reviews, err := pr.queryReviews(ctx, builder)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return reviews, nil
+}
+
+// ListReportedReviews returns up to limit reviews with open reports, the
+// most reported first.
+func (pr *PostgresRepository) ListReportedReviews(ctx context.Context, limit int) ([]*entity.Review, error) {
+	const op = "repository.postgres.ListReportedReviews"
+
+	builder := pr.pg.Builder.Select(reviewColumns...).
+		From("reviews").
+		Where("open_reports > 0").
+		OrderBy("open_reports DESC", "created_at").
+		Limit(uint64(limit))
+
+	reviews, err := pr.queryReviews(ctx, builder)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return reviews, nil
+}
+
+// DeleteReview deletes a review with its images and reports and takes its
+// rating off the product.
+func (pr *PostgresRepository) DeleteReview(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.postgres.DeleteReview"
+
+	query, args, err := pr.pg.Builder.Delete("reviews").
+		Where(squirrel.Eq{"id": id}).
+		Suffix("RETURNING " + strings.Join(reviewColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	deleted, err := scanReview(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, reviewError(err))
+	}
+
+	if deleted.Status == entity.ReviewPublished {
+		if err := addRating(ctx, tx, deleted.ProductId, -deleted.Rating, -1); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ReplyToReview sets the reply of the seller.
+func (pr *PostgresRepository) ReplyToReview(ctx context.Context, id uuid.UUID, reply string, now time.Time) (*entity.Review, error) {
+	const op = "repository.postgres.ReplyToReview"
+
+	query, args, err := pr.pg.Builder.Update("reviews").
+		Set("reply", reply).
+		Set("replied_at", now).
+		Where(squirrel.Eq{"id": id}).
+		Suffix("RETURNING " + strings.Join(reviewColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	review, err := scanReview(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, reviewError(err))
+	}
+
+	return review, nil
+}
+
+// ReportReview records a report and counts it on the review, unless the
+// user has already reported the review.
+func (pr *PostgresRepository) ReportReview(ctx context.Context, report *entity.ReviewReport) error {
+	const op = "repository.postgres.ReportReview"
+
+	query, args, err := pr.pg.Builder.Insert("review_reports").
+		Columns("review_id", "user_id", "reason", "created_at").
+		Values(report.ReviewId, report.UserId, report.Reason, report.CreatedAt).
+		Suffix("ON CONFLICT (review_id, user_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, reviewError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE reviews SET open_reports = open_reports + 1 WHERE id = $1", report.ReviewId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ModerateReview sets the status of a review, closes its reports and moves
+// its rating in or out of the rating of the product.
+func (pr *PostgresRepository) ModerateReview(ctx context.Context, id uuid.UUID, status entity.ReviewStatus, now time.Time) (*entity.Review, error) {
+	const op = "repository.postgres.ModerateReview"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := pr.pg.Builder.Select(reviewColumns...).
+		From("reviews").
+		Where(squirrel.Eq{"id": id}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	current, err := scanReview(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, reviewError(err))
+	}
+
+	switch {
+	case current.Status == status:
+	case status == entity.ReviewPublished:
+		err = addRating(ctx, tx, current.ProductId, current.Rating, 1)
+	default:
+		err = addRating(ctx, tx, current.ProductId, -current.Rating, -1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query, args, err = pr.pg.Builder.Update("reviews").
+		Set("status", status).
+		Set("open_reports", 0).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": id}).
+		Suffix("RETURNING " + strings.Join(reviewColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	review, err := scanReview(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return review, nil
+}
+
+// CreateReviewImage adds an image after the last image of the review,
+// unless the review already has maxImages images.
+func (pr *PostgresRepository) CreateReviewImage(ctx context.Context, image *entity.ReviewImage, maxImages int) (*entity.ReviewImage, error) {
+	const op = "repository.postgres.CreateReviewImage"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1, 0))", "review-images:"+image.ReviewId.String()); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query, args, err := pr.pg.Builder.Select("count(*)", "COALESCE(max(position) + 1, 0)").
+		From("review_images").
+		Where(squirrel.Eq{"review_id": image.ReviewId}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, query, args...).Scan(&count, &image.Position); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if count >= maxImages {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrTooManyReviewImages)
+	}
+
+	query, args, err = pr.pg.Builder.Insert("review_images").
+		Columns(reviewImageColumns...).
+		Values(image.Id, image.ReviewId, image.Position, image.ContentType, image.Width, image.Height, image.Size, image.Key, image.Thumbnails, image.CreatedAt).
+		Suffix("RETURNING " + strings.Join(reviewImageColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := scanReviewImage(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, reviewError(err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+// ListReviewImages returns the images of the reviews, each review's in
+// order.
+func (pr *PostgresRepository) ListReviewImages(ctx context.Context, reviewIDs []uuid.UUID) ([]*entity.ReviewImage, error) {
+	const op = "repository.postgres.ListReviewImages"
+
+	query, args, err := pr.pg.Builder.Select(reviewImageColumns...).
+		From("review_images").
+		Where(squirrel.Eq{"review_id": reviewIDs}).
+		OrderBy("review_id", "position").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	images := []*entity.ReviewImage{}
+	for rows.Next() {
+		image, err := scanReviewImage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		images = append(images, image)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return images, nil
+}
+
+func (pr *PostgresRepository) queryReviews(ctx context.Context, builder squirrel.SelectBuilder) ([]*entity.Review, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []*entity.Review{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+// addRating adds a rating to the rating of a product, or takes it off with
+// negative values.
+func addRating(ctx context.Context, tx pgx.Tx, productID uuid.UUID, rating, count int) error {
+	_, err := tx.Exec(ctx, "UPDATE products SET rating_sum = rating_sum + $1, rating_count = rating_count + $2 WHERE id = $3",
+		rating, count, productID)
+	return err
+}
+
+func scanReview(row pgx.Row) (*entity.Review, error) {
+	review := &entity.Review{}
+	err := row.Scan(&review.Id, &review.ProductId, &review.UserId, &review.OrderId, &review.Rating, &review.Text, &review.Status,
+		&review.Reply, &review.RepliedAt, &review.OpenReports, &review.CreatedAt, &review.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func scanReviewImage(row pgx.Row) (*entity.ReviewImage, error) {
+	image := &entity.ReviewImage{}
+	if err := row.Scan(&image.Id, &image.ReviewId, &image.Position, &image.ContentType, &image.Width, &image.Height, &image.Size, &image.Key, &image.Thumbnails, &image.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// reviewError translates the errors of review queries: a second review of a
+// user breaks the unique key, and a broken foreign key means the product or
+// the review is missing.
+func reviewError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.ErrReviewNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgUniqueViolation && pgErr.TableName == "reviews":
+			return entity.ErrReviewExists
+		case pgErr.Code == pgForeignKeyViolation && pgErr.TableName == "reviews":
+			return entity.ErrProductNotFound
+		case pgErr.Code == pgForeignKeyViolation:
+			return entity.ErrReviewNotFound
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+func createTestReview(t *testing.T, repo *PostgresRepository, productID uuid.UUID, rating int) *entity.Review {
+	t.Helper()
+
+	now := time.Now()
+	review, err := repo.CreateReview(context.Background(), &entity.Review{
+		Id:        entity.GenerateID(),
+		ProductId: productID,
+		UserId:    uuid.New(),
+		OrderId:   uuid.New(),
+		Rating:    rating,
+		Status:    entity.ReviewPublished,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	require.NoError(t, err)
+
+	return review
+}
+
+func ratingOf(t *testing.T, repo *PostgresRepository, productID uuid.UUID) (float64, int64) {
+	t.Helper()
+
+	product, err := repo.Get(context.Background(), productID)
+	require.NoError(t, err)
+
+	return product.RatingAverage(), product.RatingCount
+}
+
+func TestPostgresRepository_Reviews(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	t.Run("the rating counts the published reviews", func(t *testing.T) {
+		productID := createTestProduct(t, repo, 1)
+		createTestReview(t, repo, productID, 5)
+		hidden := createTestReview(t, repo, productID, 2)
+		deleted := createTestReview(t, repo, productID, 4)
+
+		average, count := ratingOf(t, repo, productID)
+		assert.InDelta(t, 11.0/3, average, 1e-9)
+		assert.Equal(t, int64(3), count)
+
+		_, err := repo.ModerateReview(ctx, hidden.Id, entity.ReviewHidden, time.Now())
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteReview(ctx, deleted.Id))
+
+		average, count = ratingOf(t, repo, productID)
+		assert.InDelta(t, 5.0, average, 1e-9)
+		assert.Equal(t, int64(1), count)
+
+		page, err := repo.ListReviewPage(ctx, productID, nil, 10)
+		require.NoError(t, err)
+		assert.Len(t, page, 1)
+
+		_, err = repo.ModerateReview(ctx, hidden.Id, entity.ReviewPublished, time.Now())
+		require.NoError(t, err)
+
+		average, count = ratingOf(t, repo, productID)
+		assert.InDelta(t, 3.5, average, 1e-9)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("one review per buyer", func(t *testing.T) {
+		productID := createTestProduct(t, repo, 1)
+		review := createTestReview(t, repo, productID, 5)
+
+		again := *review
+		again.Id = entity.GenerateID()
+		_, err := repo.CreateReview(ctx, &again)
+
+		assert.ErrorIs(t, err, entity.ErrReviewExists)
+	})
+
+	t.Run("a user reports a review once", func(t *testing.T) {
+		productID := createTestProduct(t, repo, 1)
+		review := createTestReview(t, repo, productID, 1)
+		report := &entity.ReviewReport{ReviewId: review.Id, UserId: uuid.New(), Reason: "спам", CreatedAt: time.Now()}
+
+		require.NoError(t, repo.ReportReview(ctx, report))
+		require.NoError(t, repo.ReportReview(ctx, report))
+
+		reported, err := repo.ListReportedReviews(ctx, 100)
+		require.NoError(t, err)
+		var found *entity.Review
+		for _, r := range reported {
+			if r.Id == review.Id {
+				found = r
+			}
+		}
+		require.NotNil(t, found)
+		assert.Equal(t, 1, found.OpenReports)
+
+		moderated, err := repo.ModerateReview(ctx, review.Id, entity.ReviewPublished, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, moderated.OpenReports)
+	})
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+const (
+	searchConfig = "russian"
+
+	// Postgres marks the matches with these control characters, so that the
+	// text can be escaped before they are turned into <mark> tags.
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+
+	nameHeadlineOptions        = `HighlightAll=true, StartSel="` + highlightStart + `", StopSel="` + highlightStop + `"`
+	descriptionHeadlineOptions = `MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … ", StartSel="` + highlightStart + `", StopSel="` + highlightStop + `"`
+)
+
+var highlighter = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// searchFilters holds the conditions of a search, one per filter, so the
+// facets can leave out the filter they count. Only published products are
+// searched, whatever the filters.
+type searchFilters struct {
+	text     squirrel.Sqlizer
+	price    squirrel.And
+	stock    squirrel.And
+	category squirrel.And
+}
+
+// newSearchFilters builds the conditions of req. category is the searched
+// category, if any.
+func newSearchFilters(req *entity.SearchProductsRequest, category *entity.Category) searchFilters {
+	f := searchFilters{text: squirrel.Expr("TRUE")}
+	if req.Query != "" {
+		f.text = squirrel.Expr("search @@ websearch_to_tsquery('"+searchConfig+"', ?)", req.Query)
+	}
+	if req.MinPrice != nil {
+		f.price = append(f.price, squirrel.GtOrEq{"price": *req.MinPrice})
+	}
+	if req.MaxPrice != nil {
+		f.price = append(f.price, squirrel.LtOrEq{"price": *req.MaxPrice})
+	}
+	if req.InStock {
+		f.stock = append(f.stock, squirrel.Gt{"stock": 0})
+	}
+	if category != nil {
+		f.category = append(f.category, inCategorySubtree(category.Path))
+	}
+
+	return f
+}
+
+// Search returns a page of the products that match req, with the facets of
+// all of them. facetBounds split the prices into the ranges of the price
+// facet.
+func (pr *PostgresRepository) Search(ctx context.Context, req *entity.SearchProductsRequest, facetBounds []int64) (*entity.SearchResult, error) {
+	const op = "repository.postgres.Search"
+
+	var category *entity.Category
+	if req.CategoryId != nil {
+		var err error
+		if category, err = pr.GetCategory(ctx, *req.CategoryId); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	filters := newSearchFilters(req, category)
+
+	hits, err := pr.searchHits(ctx, req, filters)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	total, facets, err := pr.searchFacets(ctx, filters, facetBounds)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	facets.Categories, err = pr.categoryFacets(ctx, filters, category)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &entity.SearchResult{Hits: hits, Total: total, Facets: facets}, nil
+}
+
+func (pr *PostgresRepository) searchHits(ctx context.Context, req *entity.SearchProductsRequest, filters searchFilters) ([]*entity.SearchHit, error) {
+	tsQuery := squirrel.Expr("websearch_to_tsquery('"+searchConfig+"', ?)", req.Query)
+
+	rank := squirrel.Expr("0::real AS rank")
+	if req.Query != "" {
+		rank = squirrel.Expr("ts_rank(search, ?) AS rank", tsQuery)
+	}
+
+	orderBy := searchOrder(req)
+
+	// Headlines are expensive, so they are built for the page only.
+	page := pr.pg.Builder.Select(productColumns...).
+		Column(rank).
+		From("products").
+		Where(published).
+		Where(filters.text).
+		Where(filters.price).
+		Where(filters.stock).
+		Where(filters.category).
+		OrderBy(orderBy...).
+		Offset(uint64(req.Offset)).
+		Limit(uint64(req.Limit))
+
+	query, args, err := pr.pg.Builder.Select(productColumns...).
+		Column("rank").
+		Column(squirrel.Expr("ts_headline('"+searchConfig+"', name, ?, ?)", tsQuery, nameHeadlineOptions)).
+		Column(squirrel.Expr("ts_headline('"+searchConfig+"', description, ?, ?)", tsQuery, descriptionHeadlineOptions)).
+		FromSelect(page, "page").
+		OrderBy(orderBy...).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []*entity.SearchHit{}
+	for rows.Next() {
+		hit := &entity.SearchHit{Product: &entity.Product{}}
+		if err := rows.Scan(append(productFields(hit.Product), &hit.Rank, &hit.NameHighlight, &hit.DescriptionSnippet)...); err != nil {
+			return nil, err
+		}
+
+		hit.NameHighlight = highlight(hit.NameHighlight)
+		hit.DescriptionSnippet = highlight(hit.DescriptionSnippet)
+		hits = append(hits, hit)
+	}
+
+	return hits, rows.Err()
+}
+
+// searchFacets counts the matches in one pass over them. Each facet applies
+// every filter but its own, see categoryFacets for the category facet.
+func (pr *PostgresRepository) searchFacets(ctx context.Context, filters searchFilters, facetBounds []int64) (int64, entity.SearchFacets, error) {
+	count := func(conds ...squirrel.Sqlizer) squirrel.Sqlizer {
+		return squirrel.Expr("count(*) FILTER (WHERE ?)", squirrel.And(conds))
+	}
+
+	ranges := priceRanges(facetBounds)
+
+	builder := pr.pg.Builder.Select().
+		Column(count(filters.price, filters.stock)).
+		Column(count(filters.price, squirrel.Gt{"stock": 0})).
+		Column(count(filters.price, squirrel.Eq{"stock": 0}))
+	for _, r := range ranges {
+		inRange := squirrel.And{squirrel.GtOrEq{"price": r.Min}}
+		if r.Max != 0 {
+			inRange = append(inRange, squirrel.Lt{"price": r.Max})
+		}
+		builder = builder.Column(count(inRange, filters.stock))
+	}
+
+	query, args, err := builder.From("products").Where(published).Where(filters.text).Where(filters.category).ToSql()
+	if err != nil {
+		return 0, entity.SearchFacets{}, err
+	}
+
+	var total int64
+	facets := entity.SearchFacets{PriceRanges: ranges}
+
+	dest := []any{&total, &facets.InStock, &facets.OutOfStock}
+	for i := range facets.PriceRanges {
+		dest = append(dest, &facets.PriceRanges[i].Count)
+	}
+	if err := pr.pg.Pool.QueryRow(ctx, query, args...).Scan(dest...); err != nil {
+		return 0, entity.SearchFacets{}, err
+	}
+
+	return total, facets, nil
+}
+
+// categoryFacets counts the matches per child of the searched category, or
+// per root category. A product counts once per child however many of its
+// categories lie under it.
+func (pr *PostgresRepository) categoryFacets(ctx context.Context, filters searchFilters, parent *entity.Category) ([]entity.CategoryFacet, error) {
+	var children squirrel.Sqlizer = squirrel.Eq{"ch.parent_id": nil}
+	if parent != nil {
+		children = squirrel.Eq{"ch.parent_id": parent.Id}
+	}
+
+	query, args, err := pr.pg.Builder.Select("ch.id", "ch.name", "count(DISTINCT products.id) AS matches").
+		From("categories ch").
+		Join("categories c ON c.path LIKE ch.path || '%'").
+		Join("product_categories pc ON pc.category_id = c.id").
+		Join("products ON products.id = pc.product_id").
+		Where(children).
+		Where(published).
+		Where(filters.text).
+		Where(filters.price).
+		Where(filters.stock).
+		GroupBy("ch.id", "ch.name").
+		OrderBy("matches DESC", "ch.name").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facets := []entity.CategoryFacet{}
+	for rows.Next() {
+		var facet entity.CategoryFacet
+		if err := rows.Scan(&facet.CategoryId, &facet.Name, &facet.Count); err != nil {
+			return nil, err
+		}
+
+		facets = append(facets, facet)
+	}
+
+	return facets, rows.Err()
+}
+
+func searchOrder(req *entity.SearchProductsRequest) []string {
+	sort := req.Sort
+	if sort == entity.SortDefault {
+		sort = entity.SortNewest
+		if req.Query != "" {
+			sort = entity.SortRelevance
+		}
+	}
+
+	// id keeps the order stable between pages.
+	switch sort {
+	case entity.SortRelevance:
+		return []string{"rank DESC", "created_at DESC", "id"}
+	case entity.SortPriceAsc:
+		return []string{"price ASC", "id"}
+	case entity.SortPriceDesc:
+		return []string{"price DESC", "id"}
+	default:
+		return []string{"created_at DESC", "id"}
+	}
+}
+
+// priceRanges turns the ascending bounds b1 < b2 < ... into the ranges
+// [0, b1), [b1, b2), ..., [bn, ∞).
+func priceRanges(bounds []int64) []entity.PriceRangeFacet {
+	ranges := make([]entity.PriceRangeFacet, 0, len(bounds)+1)
+
+	var lower int64
+	for _, bound := range bounds {
+		ranges = append(ranges, entity.PriceRangeFacet{Min: lower, Max: bound})
+		lower = bound
+	}
+
+	return append(ranges, entity.PriceRangeFacet{Min: lower})
+}
+
+func highlight(headline string) string {
+	return highlighter.Replace(html.EscapeString(headline))
+}
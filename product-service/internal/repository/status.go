@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+// ChangeProductStatus moves a product from change.FromStatus to
+// change.ToStatus and records the change. It fails with
+// entity.ErrStatusConflict if the product is no longer in FromStatus.
+func (pr *PostgresRepository) ChangeProductStatus(ctx context.Context, change *entity.ProductStatusChange) (*entity.Product, error) {
+	const op = "repository.postgres.ChangeProductStatus"
+
+	tx, err := pr.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	builder := pr.pg.Builder.Update("products").
+		Set("status", change.ToStatus).
+		Set("updated_at", change.ChangedAt)
+	switch change.ToStatus {
+	case entity.ProductPublished:
+		builder = builder.Set("published_at", squirrel.Expr("COALESCE(published_at, ?)", change.ChangedAt))
+	case entity.ProductDeleted:
+		builder = builder.Set("deleted_at", change.ChangedAt)
+	}
+
+	query, args, err := builder.
+		Where(squirrel.Eq{"id": change.ProductId, "status": change.FromStatus}).
+		Suffix("RETURNING " + strings.Join(productColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	product, err := scanProduct(tx.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", change.ProductId).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("%s: %w", op, entity.ErrProductNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrStatusConflict)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := pr.recordStatus(ctx, tx, change); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return product, nil
+}
+
+// ListProductStatusHistory lists the status changes of a product, oldest
+// first.
+func (pr *PostgresRepository) ListProductStatusHistory(ctx context.Context, productID uuid.UUID) ([]*entity.ProductStatusChange, error) {
+	const op = "repository.postgres.ListProductStatusHistory"
+
+	query, args, err := pr.pg.Builder.Select("id", "product_id", "COALESCE(from_status, '')", "to_status", "changed_by", "reason", "changed_at").
+		From("product_status_history").
+		Where(squirrel.Eq{"product_id": productID}).
+		OrderBy("changed_at", "id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	history := []*entity.ProductStatusChange{}
+	for rows.Next() {
+		change := &entity.ProductStatusChange{}
+		if err := rows.Scan(&change.Id, &change.ProductId, &change.FromStatus, &change.ToStatus, &change.ChangedBy, &change.Reason, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return history, nil
+}
+
+func (pr *PostgresRepository) recordStatus(ctx context.Context, tx pgx.Tx, change *entity.ProductStatusChange) error {
+	query, args, err := pr.pg.Builder.Insert("product_status_history").
+		Columns("id", "product_id", "from_status", "to_status", "changed_by", "reason", "changed_at").
+		Values(entity.GenerateID(), change.ProductId, squirrel.Expr("NULLIF(?, '')", change.FromStatus), change.ToStatus, change.ChangedBy, change.Reason, change.ChangedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+func TestPostgresRepository_ProductStatus(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	createDraft := func(t *testing.T) *entity.Product {
+		t.Helper()
+
+		now := time.Now()
+		product := &entity.Product{
+			Id:          entity.GenerateID(),
+			SellerId:    uuid.New(),
+			Name:        "Ноутбук",
+			Description: "Лёгкий ноутбук",
+			Price:       100,
+			Stock:       5,
+			Status:      entity.ProductDraft,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		_, err := repo.Create(ctx, product)
+		require.NoError(t, err)
+
+		return product
+	}
+
+	change := func(product *entity.Product, from, to entity.ProductStatus) *entity.ProductStatusChange {
+		return &entity.ProductStatusChange{ProductId: product.Id, FromStatus: from, ToStatus: to, ChangedBy: product.SellerId, ChangedAt: time.Now()}
+	}
+
+	t.Run("every change is recorded", func(t *testing.T) {
+		product := createDraft(t)
+
+		_, err := repo.ChangeProductStatus(ctx, change(product, entity.ProductDraft, entity.ProductPendingReview))
+		require.NoError(t, err)
+		published, err := repo.ChangeProductStatus(ctx, change(product, entity.ProductPendingReview, entity.ProductPublished))
+		require.NoError(t, err)
+
+		assert.Equal(t, entity.ProductPublished, published.Status)
+		assert.NotNil(t, published.PublishedAt)
+
+		history, err := repo.ListProductStatusHistory(ctx, product.Id)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.Equal(t, entity.ProductStatus(""), history[0].FromStatus)
+		assert.Equal(t, entity.ProductDraft, history[0].ToStatus)
+		assert.Equal(t, entity.ProductPublished, history[2].ToStatus)
+	})
+
+	t.Run("a stale status is a conflict", func(t *testing.T) {
+		product := createDraft(t)
+
+		_, err := repo.ChangeProductStatus(ctx, change(product, entity.ProductPublished, entity.ProductArchived))
+		assert.ErrorIs(t, err, entity.ErrStatusConflict)
+
+		_, err = repo.ChangeProductStatus(ctx, change(&entity.Product{Id: uuid.New()}, entity.ProductDraft, entity.ProductPendingReview))
+		assert.ErrorIs(t, err, entity.ErrProductNotFound)
+	})
+
+	t.Run("only published products are listed and sold", func(t *testing.T) {
+		product := createDraft(t)
+
+		page, err := repo.ListPage(ctx, nil, 100)
+		require.NoError(t, err)
+		for _, p := range page {
+			assert.NotEqual(t, product.Id, p.Id)
+		}
+
+		_, err = repo.ReserveStock(ctx, testReservation(product.Id, 1, time.Minute))
+		assert.ErrorIs(t, err, entity.ErrProductUnavailable)
+	})
+
+	t.Run("deleted products are kept", func(t *testing.T) {
+		productID := createTestProduct(t, repo, 1)
+		current, err := repo.Get(ctx, productID)
+		require.NoError(t, err)
+
+		_, err = repo.ChangeProductStatus(ctx, change(current, entity.ProductPublished, entity.ProductDeleted))
+		require.NoError(t, err)
+
+		deleted, err := repo.Get(ctx, productID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.ProductDeleted, deleted.Status)
+		assert.NotNil(t, deleted.DeletedAt)
+	})
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+var (
+	attributeColumns = []string{"id", "category_id", "code", "name", "type", "allowed_values", "required", "created_at"}
+	variantColumns   = []string{"id", "product_id", "sku", "price", "stock", "attributes", "created_at", "updated_at"}
+)
+
+func (pr *PostgresRepository) CreateAttributeDefinition(ctx context.Context, def *entity.AttributeDefinition) (*entity.AttributeDefinition, error) {
+	const op = "repository.postgres.CreateAttributeDefinition"
+
+	query, args, err := pr.pg.Builder.Insert("attribute_definitions").
+		Columns(attributeColumns...).
+		Values(def.Id, def.CategoryId, def.Code, def.Name, def.Type, def.AllowedValues, def.Required, def.CreatedAt).
+		Suffix("RETURNING " + strings.Join(attributeColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := scanAttribute(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return nil, fmt.Errorf("%s: %w", op, entity.ErrAttributeExists)
+		}
+		return nil, fmt.Errorf("%s: %w", op, categoryError(err))
+	}
+
+	return created, nil
+}
+
+// ListAttributeDefinitions returns the definitions of the category and of
+// its ancestors, from the root down.
+func (pr *PostgresRepository) ListAttributeDefinitions(ctx context.Context, categoryID uuid.UUID) ([]*entity.AttributeDefinition, error) {
+	const op = "repository.postgres.ListAttributeDefinitions"
+
+	category, err := pr.GetCategory(ctx, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query, args, err := pr.pg.Builder.Select(prefixed("a", attributeColumns)...).
+		From("attribute_definitions a").
+		Join("categories c ON c.id = a.category_id").
+		Where("? LIKE c.path || '%'", category.Path).
+		OrderBy("length(c.path)", "a.code").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	definitions := []*entity.AttributeDefinition{}
+	for rows.Next() {
+		def, err := scanAttribute(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		definitions = append(definitions, def)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return definitions, nil
+}
+
+func (pr *PostgresRepository) DeleteAttributeDefinition(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.postgres.DeleteAttributeDefinition"
+
+	query, args, err := pr.pg.Builder.Delete("attribute_definitions").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := pr.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, entity.ErrAttributeNotFound)
+	}
+
+	return nil
+}
+
+func (pr *PostgresRepository) CreateVariant(ctx context.Context, variant *entity.Variant) (*entity.Variant, error) {
+	const op = "repository.postgres.CreateVariant"
+
+	query, args, err := pr.pg.Builder.Insert("product_variants").
+		Columns(variantColumns...).
+		Values(variant.Id, variant.ProductId, variant.Sku, variant.Price, variant.Stock, variant.Attributes, variant.CreatedAt, variant.UpdatedAt).
+		Suffix("RETURNING " + strings.Join(variantColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := scanVariant(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, variantError(err))
+	}
+
+	return created, nil
+}
+
+func (pr *PostgresRepository) GetVariant(ctx context.Context, id uuid.UUID) (*entity.Variant, error) {
+	const op = "repository.postgres.GetVariant"
+
+	query, args, err := pr.pg.Builder.Select(variantColumns...).
+		From("product_variants").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	variant, err := scanVariant(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, variantError(err))
+	}
+
+	return variant, nil
+}
+
+func (pr *PostgresRepository) UpdateVariant(ctx context.Context, variant *entity.Variant) (*entity.Variant, error) {
+	const op = "repository.postgres.UpdateVariant"
+
+	query, args, err := pr.pg.Builder.Update("product_variants").
+		Set("sku", variant.Sku).
+		Set("price", variant.Price).
+		Set("stock", variant.Stock).
+		Set("attributes", variant.Attributes).
+		Set("updated_at", variant.UpdatedAt).
+		Where(squirrel.Eq{"id": variant.Id}).
+		Suffix("RETURNING " + strings.Join(variantColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := scanVariant(pr.pg.Pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, variantError(err))
+	}
+
+	return updated, nil
+}
+
+func (pr *PostgresRepository) DeleteVariant(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.postgres.DeleteVariant"
+
+	query, args, err := pr.pg.Builder.Delete("product_variants").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := pr.pg.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, entity.ErrVariantNotFound)
+	}
+
+	return nil
+}
+
+func (pr *PostgresRepository) ListVariants(ctx context.Context, productID uuid.UUID) ([]*entity.Variant, error) {
+	const op = "repository.postgres.ListVariants"
+
+	query, args, err := pr.pg.Builder.Select(variantColumns...).
+		From("product_variants").
+		Where(squirrel.Eq{"product_id": productID}).
+		OrderBy("created_at", "id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := pr.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	variants := []*entity.Variant{}
+	for rows.Next() {
+		variant, err := scanVariant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		variants = append(variants, variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return variants, nil
+}
+
+func scanAttribute(row pgx.Row) (*entity.AttributeDefinition, error) {
+	def := &entity.AttributeDefinition{}
+	if err := row.Scan(&def.Id, &def.CategoryId, &def.Code, &def.Name, &def.Type, &def.AllowedValues, &def.Required, &def.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return def, nil
+}
+
+func scanVariant(row pgx.Row) (*entity.Variant, error) {
+	variant := &entity.Variant{}
+	if err := row.Scan(&variant.Id, &variant.ProductId, &variant.Sku, &variant.Price, &variant.Stock, &variant.Attributes, &variant.CreatedAt, &variant.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+// variantError translates the errors of variant queries, telling a taken SKU
+// from a duplicate combination of attribute values by the constraint.
+func variantError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.ErrVariantNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == "product_variants_sku_key":
+			return entity.ErrVariantExists
+		case pgErr.Code == pgUniqueViolation:
+			return entity.ErrDuplicateVariant
+		case pgErr.Code == pgForeignKeyViolation:
+			return entity.ErrProductNotFound
+		}
+	}
+
+	return err
+}
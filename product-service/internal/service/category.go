@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+const maxCategoryNameLength = 100
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func (s *ProductService) CreateCategory(ctx context.Context, req *entity.CreateCategoryRequest) (*entity.Category, error) {
+	const op = "ProductService.CreateCategory"
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := validateCategory(req.Name, req.Slug); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	category, err := s.db.CreateCategory(ctx, &entity.Category{
+		Id:        entity.GenerateID(),
+		ParentId:  req.ParentId,
+		Name:      req.Name,
+		Slug:      req.Slug,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return category, nil
+}
+
+func (s *ProductService) GetCategory(ctx context.Context, id uuid.UUID) (*entity.Category, error) {
+	const op = "ProductService.GetCategory"
+
+	category, err := s.db.GetCategory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return category, nil
+}
+
+func (s *ProductService) UpdateCategory(ctx context.Context, req *entity.UpdateCategoryRequest) (*entity.Category, error) {
+	const op = "ProductService.UpdateCategory"
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := validateCategory(req.Name, req.Slug); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if req.ParentId != nil && *req.ParentId == req.Id {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrCategoryCycle)
+	}
+
+	category, err := s.db.UpdateCategory(ctx, &entity.Category{
+		Id:        req.Id,
+		ParentId:  req.ParentId,
+		Name:      req.Name,
+		Slug:      req.Slug,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return category, nil
+}
+
+func (s *ProductService) DeleteCategory(ctx context.Context, id uuid.UUID) (bool, error) {
+	const op = "ProductService.DeleteCategory"
+
+	if err := requireAdmin(ctx); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.DeleteCategory(ctx, id); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+func (s *ProductService) ListCategories(ctx context.Context) ([]*entity.Category, error) {
+	const op = "ProductService.ListCategories"
+
+	categories, err := s.db.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return categories, nil
+}
+
+func (s *ProductService) ListCategoryProducts(ctx context.Context, categoryID uuid.UUID, offset, limit int64) ([]*entity.Product, error) {
+	const op = "ProductService.ListCategoryProducts"
+
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+	if offset < 0 || limit < 0 || limit > MaxSearchLimit {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidPage)
+	}
+
+	products, err := s.db.ListCategoryProducts(ctx, categoryID, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return products, nil
+}
+
+// SetProductCategories replaces the categories of a product. Duplicates of the
+// primary category among the secondary ones are dropped.
+func (s *ProductService) SetProductCategories(ctx context.Context, req *entity.SetProductCategoriesRequest) (*entity.ProductCategories, error) {
+	const op = "ProductService.SetProductCategories"
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if req.PrimaryId == nil && len(req.SecondaryIds) > 0 {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrSecondaryCategory)
+	}
+
+	seen := map[uuid.UUID]bool{}
+	if req.PrimaryId != nil {
+		seen[*req.PrimaryId] = true
+	}
+	secondary := make([]uuid.UUID, 0, len(req.SecondaryIds))
+	for _, id := range req.SecondaryIds {
+		if !seen[id] {
+			seen[id] = true
+			secondary = append(secondary, id)
+		}
+	}
+	req.SecondaryIds = secondary
+
+	if err := s.db.SetProductCategories(ctx, req); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	categories, err := s.db.GetProductCategories(ctx, req.ProductId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return categories, nil
+}
+
+func (s *ProductService) GetProductCategories(ctx context.Context, productID uuid.UUID) (*entity.ProductCategories, error) {
+	const op = "ProductService.GetProductCategories"
+
+	categories, err := s.db.GetProductCategories(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return categories, nil
+}
+
+func validateCategory(name, slug string) error {
+	if name == "" || len(name) > maxCategoryNameLength {
+		return entity.ErrInvalidCategory
+	}
+
+	if len(slug) > maxCategoryNameLength || !slugPattern.MatchString(slug) {
+		return entity.ErrInvalidSlug
+	}
+
+	return nil
+}
+
+// requireAdmin lets through the callers with the admin role.
+func requireAdmin(ctx context.Context) error {
+	caller, ok := entity.CallerFromContext(ctx)
+	if !ok {
+		return entity.ErrUnauthenticated
+	}
+	if !caller.IsAdmin() {
+		return entity.ErrPermissionDenied
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+func TestService_CreateCategory(t *testing.T) {
+	admin := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: entity.RoleAdmin})
+	client := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: "client"})
+	parentID := uuid.New()
+
+	t.Run("successful creation", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		created := &entity.Category{Id: uuid.New(), ParentId: &parentID, Name: "Ноутбуки", Slug: "laptops"}
+		dbMock.On("CreateCategory", admin, mock.MatchedBy(func(c *entity.Category) bool {
+			return c.Id != uuid.Nil && *c.ParentId == parentID && c.Slug == "laptops"
+		})).Return(created, nil)
+
+		category, err := svc.CreateCategory(admin, &entity.CreateCategoryRequest{Name: "Ноутбуки", Slug: "laptops", ParentId: &parentID})
+
+		assert.NoError(t, err)
+		assert.Equal(t, created, category)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("requires the admin role", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+		req := &entity.CreateCategoryRequest{Name: "Ноутбуки", Slug: "laptops"}
+
+		_, err := svc.CreateCategory(context.Background(), req)
+		assert.ErrorIs(t, err, entity.ErrUnauthenticated)
+
+		_, err = svc.CreateCategory(client, req)
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+	})
+
+	t.Run("invalid slug", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		for _, slug := range []string{"", "Laptops", "laptops-", "new laptops"} {
+			_, err := svc.CreateCategory(admin, &entity.CreateCategoryRequest{Name: "Ноутбуки", Slug: slug})
+			assert.ErrorIs(t, err, entity.ErrInvalidSlug, slug)
+		}
+	})
+}
+
+func TestService_UpdateCategory(t *testing.T) {
+	admin := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: entity.RoleAdmin})
+
+	t.Run("category can't be its own parent", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+		id := uuid.New()
+
+		_, err := svc.UpdateCategory(admin, &entity.UpdateCategoryRequest{Id: id, Name: "Ноутбуки", Slug: "laptops", ParentId: &id})
+
+		assert.ErrorIs(t, err, entity.ErrCategoryCycle)
+	})
+}
+
+func TestService_SetProductCategories(t *testing.T) {
+	admin := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: entity.RoleAdmin})
+	productID, primaryID, secondaryID := uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("drops duplicate categories", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		categories := &entity.ProductCategories{Breadcrumbs: []*entity.Category{{Id: primaryID}}}
+		dbMock.On("SetProductCategories", admin, &entity.SetProductCategoriesRequest{
+			ProductId:    productID,
+			PrimaryId:    &primaryID,
+			SecondaryIds: []uuid.UUID{secondaryID},
+		}).Return(nil)
+		dbMock.On("GetProductCategories", admin, productID).Return(categories, nil)
+
+		got, err := svc.SetProductCategories(admin, &entity.SetProductCategoriesRequest{
+			ProductId:    productID,
+			PrimaryId:    &primaryID,
+			SecondaryIds: []uuid.UUID{secondaryID, primaryID, secondaryID},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, primaryID, got.Primary().Id)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("secondary categories require a primary one", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.SetProductCategories(admin, &entity.SetProductCategoriesRequest{
+			ProductId:    productID,
+			SecondaryIds: []uuid.UUID{secondaryID},
+		})
+
+		assert.ErrorIs(t, err, entity.ErrSecondaryCategory)
+	})
+}
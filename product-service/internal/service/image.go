@@ -0,0 +1,341 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/utils"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	minImageSide     = 100
+	maxImageSide     = 6000
+	maxImagePixels   = 25_000_000
+	maxProductImages = 20
+	thumbnailQuality = 85
+)
+
+// thumbnailSizes are the boxes the thumbnails of every image are made for.
+var thumbnailSizes = []entity.ThumbnailSize{
+	{Name: "small", Side: 160},
+	{Name: "medium", Side: 480},
+	{Name: "large", Side: 1200},
+}
+
+// imageExtensions are the accepted types of images, by content type.
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// ImageStorage keeps the images and thumbnails, see blob.Storage.
+type ImageStorage interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// UploadProductImage validates an image, makes its thumbnails and adds it
+// after the other images of the product.
+func (s *ProductService) UploadProductImage(ctx context.Context, req *entity.UploadImageRequest) (*entity.ProductImage, error) {
+	const op = "ProductService.UploadProductImage"
+
+	if s.images == nil {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrImagesNotSupported)
+	}
+
+	if err := s.checkOwner(ctx, req.ProductId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The limit is checked again on insert, this check only saves decoding
+	// an image that can't be added.
+	existing, err := s.db.ListImages(ctx, []uuid.UUID{req.ProductId})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(existing) >= maxProductImages {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrTooManyImages)
+	}
+
+	id := entity.GenerateID()
+	stored, err := s.storeImage(ctx, fmt.Sprintf("products/%s/%s/", req.ProductId, id), req.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	image := &entity.ProductImage{
+		Id:          id,
+		ProductId:   req.ProductId,
+		ContentType: stored.ContentType,
+		Width:       stored.Width,
+		Height:      stored.Height,
+		Size:        int64(len(req.Data)),
+		Key:         stored.Key,
+		Thumbnails:  stored.Thumbnails,
+		CreatedAt:   time.Now(),
+	}
+
+	created, err := s.db.CreateImage(ctx, image, maxProductImages)
+	if err != nil {
+		s.deleteBlobs(stored.keys)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.dropCachedProduct(ctx, req.ProductId)
+	s.resolveImageURLs(created)
+
+	return created, nil
+}
+
+// storedImage is an image and its thumbnails put in the storage.
+type storedImage struct {
+	ContentType string
+	Width       int
+	Height      int
+	Key         string
+	Thumbnails  []entity.Thumbnail
+
+	// keys are every object stored for the image.
+	keys []string
+}
+
+// storeImage validates an image and puts it and its thumbnails in the
+// storage under prefix. Nothing is left in the storage when it fails.
+func (s *ProductService) storeImage(ctx context.Context, prefix string, data []byte) (*storedImage, error) {
+	img, contentType, err := decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := &storedImage{
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Key:         prefix + "original." + imageExtensions[contentType],
+	}
+
+	put := func(key, contentType string, data []byte) error {
+		if err := s.images.Put(ctx, key, contentType, data); err != nil {
+			return err
+		}
+		stored.keys = append(stored.keys, key)
+		return nil
+	}
+
+	if err := put(stored.Key, contentType, data); err != nil {
+		return nil, err
+	}
+
+	for _, size := range thumbnailSizes {
+		thumbnail, thumbnailData, thumbnailType, err := makeThumbnail(img, contentType, size)
+		if err != nil {
+			s.deleteBlobs(stored.keys)
+			return nil, err
+		}
+
+		thumbnail.Key = prefix + size.Name + "." + imageExtensions[thumbnailType]
+		if err := put(thumbnail.Key, thumbnailType, thumbnailData); err != nil {
+			s.deleteBlobs(stored.keys)
+			return nil, err
+		}
+		stored.Thumbnails = append(stored.Thumbnails, thumbnail)
+	}
+
+	return stored, nil
+}
+
+// DeleteProductImage deletes an image of a product with its thumbnails.
+func (s *ProductService) DeleteProductImage(ctx context.Context, productID, imageID uuid.UUID) (bool, error) {
+	const op = "ProductService.DeleteProductImage"
+
+	image, err := s.db.GetImage(ctx, imageID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if image.ProductId != productID {
+		return false, fmt.Errorf("%s: %w", op, entity.ErrImageNotFound)
+	}
+
+	if err := s.checkOwner(ctx, productID); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.DeleteImage(ctx, imageID); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys := []string{image.Key}
+	for _, thumbnail := range image.Thumbnails {
+		keys = append(keys, thumbnail.Key)
+	}
+	s.deleteBlobs(keys)
+	s.dropCachedProduct(ctx, productID)
+
+	return true, nil
+}
+
+// ReorderProductImages puts the images of a product in the order of
+// imageIDs, which must list each of them once.
+func (s *ProductService) ReorderProductImages(ctx context.Context, productID uuid.UUID, imageIDs []uuid.UUID) ([]*entity.ProductImage, error) {
+	const op = "ProductService.ReorderProductImages"
+
+	if err := s.checkOwner(ctx, productID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.ReorderImages(ctx, productID, imageIDs); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.dropCachedProduct(ctx, productID)
+
+	images, err := s.db.ListImages(ctx, []uuid.UUID{productID})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.resolveImageURLs(images...)
+
+	return images, nil
+}
+
+// attachImages loads the images of the products with one query.
+func (s *ProductService) attachImages(ctx context.Context, products ...*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	byID := make(map[uuid.UUID]*entity.Product, len(products))
+	for _, product := range products {
+		ids = append(ids, product.Id)
+		byID[product.Id] = product
+	}
+
+	images, err := s.db.ListImages(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		if product, ok := byID[image.ProductId]; ok {
+			product.Images = append(product.Images, image)
+		}
+	}
+	for _, product := range products {
+		s.resolveImageURLs(product.Images...)
+	}
+
+	return nil
+}
+
+// resolveImageURLs sets the URLs of the images and their thumbnails. They
+// are not stored, so moving the storage doesn't break them.
+func (s *ProductService) resolveImageURLs(images ...*entity.ProductImage) {
+	if s.images == nil {
+		return
+	}
+
+	for _, image := range images {
+		image.URL = s.images.URL(image.Key)
+		for i := range image.Thumbnails {
+			image.Thumbnails[i].URL = s.images.URL(image.Thumbnails[i].Key)
+		}
+	}
+}
+
+// deleteBlobs removes stored objects on a best effort basis: an orphaned
+// object takes space but is never referenced.
+func (s *ProductService) deleteBlobs(keys []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, key := range keys {
+		_ = s.images.Delete(ctx, key)
+	}
+}
+
+// dropCachedProduct evicts a product whose images changed. Eviction is best
+// effort, a stale entry expires on its own.
+func (s *ProductService) dropCachedProduct(ctx context.Context, productID uuid.UUID) {
+	_ = s.cache.Delete(ctx, utils.GenerateCacheKey("product", productID))
+}
+
+// decodeImage checks the type, size and dimensions of an image before
+// decoding it, so a small file can't claim a huge bitmap.
+func decodeImage(data []byte) (image.Image, string, error) {
+	if len(data) == 0 {
+		return nil, "", fmt.Errorf("%w: the image is empty", entity.ErrInvalidImage)
+	}
+	if len(data) > entity.MaxImageSize {
+		return nil, "", fmt.Errorf("%w: at most %d bytes are allowed", entity.ErrImageTooLarge, entity.MaxImageSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := imageExtensions[contentType]; !ok {
+		return nil, "", fmt.Errorf("%w: %s is not supported, use JPEG, PNG or WebP", entity.ErrInvalidImage, contentType)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || "image/"+format != contentType {
+		return nil, "", fmt.Errorf("%w: the file is not a valid %s", entity.ErrInvalidImage, contentType)
+	}
+
+	if config.Width < minImageSide || config.Height < minImageSide ||
+		config.Width > maxImageSide || config.Height > maxImageSide || config.Width*config.Height > maxImagePixels {
+		return nil, "", fmt.Errorf("%w: %dx%d is out of bounds, each side must be between %d and %d pixels",
+			entity.ErrInvalidImage, config.Width, config.Height, minImageSide, maxImageSide)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: the file is not a valid %s", entity.ErrInvalidImage, contentType)
+	}
+
+	return img, contentType, nil
+}
+
+// makeThumbnail scales an image down to fit the size. JPEG images get JPEG
+// thumbnails, the types that may be transparent get PNG ones.
+func makeThumbnail(img image.Image, contentType string, size entity.ThumbnailSize) (entity.Thumbnail, []byte, string, error) {
+	width, height := fitInto(img.Bounds().Dx(), img.Bounds().Dy(), size.Side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	thumbnailType := "image/png"
+	if contentType == "image/jpeg" {
+		thumbnailType = contentType
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+			return entity.Thumbnail{}, nil, "", err
+		}
+	} else if err := png.Encode(&buf, dst); err != nil {
+		return entity.Thumbnail{}, nil, "", err
+	}
+
+	return entity.Thumbnail{Size: size.Name, Width: width, Height: height}, buf.Bytes(), thumbnailType, nil
+}
+
+// fitInto returns the dimensions of a width x height image scaled down to
+// fit a side x side box. Smaller images keep their size.
+func fitInto(width, height, side int) (int, int) {
+	if width <= side && height <= side {
+		return width, height
+	}
+	if width >= height {
+		return side, max(1, height*side/width)
+	}
+
+	return max(1, width*side/height), side
+}
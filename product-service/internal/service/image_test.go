@@ -0,0 +1,254 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+// memoryStorage is an ImageStorage that keeps objects in a map.
+type memoryStorage struct {
+	objects map[string][]byte
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{objects: map[string][]byte{}}
+}
+
+func (m *memoryStorage) Put(_ context.Context, key, _ string, data []byte) error {
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memoryStorage) Delete(_ context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *memoryStorage) URL(key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+func testJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, height/2, color.RGBA{R: 200, A: 255})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestDecodeImage(t *testing.T) {
+	t.Run("valid images", func(t *testing.T) {
+		_, contentType, err := decodeImage(testJPEG(t, 400, 300))
+		assert.NoError(t, err)
+		assert.Equal(t, "image/jpeg", contentType)
+
+		_, contentType, err = decodeImage(testPNG(t, 100, 6000))
+		assert.NoError(t, err)
+		assert.Equal(t, "image/png", contentType)
+	})
+
+	t.Run("invalid images", func(t *testing.T) {
+		truncated := testJPEG(t, 400, 300)[:200]
+
+		for name, data := range map[string][]byte{
+			"empty":     nil,
+			"not image": []byte(strings.Repeat("plain text ", 20)),
+			"gif":       []byte("GIF89a" + strings.Repeat("\x00", 100)),
+			"truncated": truncated,
+			"too small": testPNG(t, 99, 400),
+			"too wide":  testPNG(t, 6001, 100),
+		} {
+			_, _, err := decodeImage(data)
+			assert.ErrorIs(t, err, entity.ErrInvalidImage, name)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		_, _, err := decodeImage(make([]byte, entity.MaxImageSize+1))
+		assert.ErrorIs(t, err, entity.ErrImageTooLarge)
+	})
+}
+
+func TestFitInto(t *testing.T) {
+	for _, tc := range []struct {
+		width, height, side int
+		wantW, wantH        int
+	}{
+		{2000, 1000, 160, 160, 80},
+		{1000, 2000, 480, 240, 480},
+		{300, 200, 480, 300, 200},
+		{5000, 100, 160, 160, 3},
+	} {
+		w, h := fitInto(tc.width, tc.height, tc.side)
+		assert.Equal(t, []int{tc.wantW, tc.wantH}, []int{w, h}, "%dx%d into %d", tc.width, tc.height, tc.side)
+	}
+}
+
+func TestService_UploadProductImage(t *testing.T) {
+	sellerID, productID := uuid.New(), uuid.New()
+	ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID})
+	req := &entity.UploadImageRequest{ProductId: productID, Data: testJPEG(t, 2000, 1000)}
+
+	owned := func(dbMock *MockDatabase) {
+		dbMock.On("Get", ctx, productID).Return(&entity.Product{Id: productID, SellerId: sellerID}, nil)
+	}
+
+	t.Run("stores the image and its thumbnails", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		storage := newMemoryStorage()
+		svc := NewProductService(dbMock, cacheMock, WithImageStorage(storage))
+		owned(dbMock)
+
+		dbMock.On("ListImages", ctx, []uuid.UUID{productID}).Return([]*entity.ProductImage{}, nil)
+		created := &entity.ProductImage{}
+		dbMock.On("CreateImage", ctx, mock.AnythingOfType("*entity.ProductImage"), maxProductImages).
+			Run(func(args mock.Arguments) { *created = *args.Get(1).(*entity.ProductImage) }).
+			Return(created, nil)
+		cacheMock.On("Delete", ctx, "product:"+productID.String()).Return(nil)
+
+		image, err := svc.UploadProductImage(ctx, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "image/jpeg", image.ContentType)
+		assert.Equal(t, []int{2000, 1000}, []int{image.Width, image.Height})
+		assert.Equal(t, "https://cdn.example.com/"+image.Key, image.URL)
+		assert.Len(t, storage.objects, 1+len(thumbnailSizes))
+
+		require.Len(t, image.Thumbnails, len(thumbnailSizes))
+		for i, want := range [][2]int{{160, 80}, {480, 240}, {1200, 600}} {
+			thumbnail := image.Thumbnails[i]
+			assert.Equal(t, want, [2]int{thumbnail.Width, thumbnail.Height}, thumbnail.Size)
+			assert.True(t, strings.HasSuffix(thumbnail.Key, ".jpg"), thumbnail.Key)
+
+			stored, _, err := decodeImageConfig(storage.objects[thumbnail.Key])
+			require.NoError(t, err)
+			assert.Equal(t, want, [2]int{stored.Width, stored.Height})
+		}
+		dbMock.AssertExpectations(t)
+		cacheMock.AssertExpectations(t)
+	})
+
+	t.Run("removes the stored objects if the insert fails", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		storage := newMemoryStorage()
+		svc := NewProductService(dbMock, nil, WithImageStorage(storage))
+		owned(dbMock)
+
+		dbMock.On("ListImages", ctx, []uuid.UUID{productID}).Return([]*entity.ProductImage{}, nil)
+		dbMock.On("CreateImage", ctx, mock.Anything, maxProductImages).Return(nil, entity.ErrTooManyImages)
+
+		_, err := svc.UploadProductImage(ctx, req)
+
+		assert.ErrorIs(t, err, entity.ErrTooManyImages)
+		assert.Empty(t, storage.objects)
+	})
+
+	t.Run("rejects a product with too many images before decoding", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		storage := newMemoryStorage()
+		svc := NewProductService(dbMock, nil, WithImageStorage(storage))
+		owned(dbMock)
+
+		dbMock.On("ListImages", ctx, []uuid.UUID{productID}).Return(make([]*entity.ProductImage, maxProductImages), nil)
+
+		_, err := svc.UploadProductImage(ctx, &entity.UploadImageRequest{ProductId: productID, Data: []byte("not an image")})
+
+		assert.ErrorIs(t, err, entity.ErrTooManyImages)
+		assert.Empty(t, storage.objects)
+	})
+
+	t.Run("only the seller uploads", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil, WithImageStorage(newMemoryStorage()))
+		stranger := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New()})
+
+		dbMock.On("Get", stranger, productID).Return(&entity.Product{Id: productID, SellerId: sellerID}, nil)
+
+		_, err := svc.UploadProductImage(stranger, req)
+
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+	})
+
+	t.Run("without storage", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.UploadProductImage(ctx, req)
+
+		assert.ErrorIs(t, err, entity.ErrImagesNotSupported)
+	})
+}
+
+func TestService_DeleteProductImage(t *testing.T) {
+	sellerID, productID, imageID := uuid.New(), uuid.New(), uuid.New()
+	ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID})
+	stored := &entity.ProductImage{
+		Id:         imageID,
+		ProductId:  productID,
+		Key:        "products/p/i/original.jpg",
+		Thumbnails: []entity.Thumbnail{{Size: "small", Key: "products/p/i/small.jpg"}},
+	}
+
+	t.Run("deletes the image and its thumbnails", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		storage := newMemoryStorage()
+		storage.objects[stored.Key] = []byte("image")
+		storage.objects[stored.Thumbnails[0].Key] = []byte("thumbnail")
+		svc := NewProductService(dbMock, cacheMock, WithImageStorage(storage))
+
+		dbMock.On("GetImage", ctx, imageID).Return(stored, nil)
+		dbMock.On("Get", ctx, productID).Return(&entity.Product{Id: productID, SellerId: sellerID}, nil)
+		dbMock.On("DeleteImage", ctx, imageID).Return(nil)
+		cacheMock.On("Delete", ctx, "product:"+productID.String()).Return(errors.New("cache is down"))
+
+		deleted, err := svc.DeleteProductImage(ctx, productID, imageID)
+
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+		assert.Empty(t, storage.objects)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("image of another product", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil, WithImageStorage(newMemoryStorage()))
+
+		dbMock.On("GetImage", ctx, imageID).Return(stored, nil)
+
+		_, err := svc.DeleteProductImage(ctx, uuid.New(), imageID)
+
+		assert.ErrorIs(t, err, entity.ErrImageNotFound)
+		dbMock.AssertNotCalled(t, "DeleteImage", mock.Anything, mock.Anything)
+	})
+}
+
+func decodeImageConfig(data []byte) (image.Config, string, error) {
+	return image.DecodeConfig(bytes.NewReader(data))
+}
@@ -0,0 +1,451 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+const (
+	// importBatchSize is the number of rows written in one transaction.
+	importBatchSize = 200
+	// exportBatchSize is the number of products read at a time by an export.
+	exportBatchSize = 500
+)
+
+// importColumns are the columns of a CSV import file, and the fields of the
+// objects of a JSON one. Exports write them in this order.
+var importColumns = []string{"sku", "name", "description", "price", "stock"}
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// importRecord is a product as it is imported and exported in JSON.
+type importRecord struct {
+	Sku         string `json:"sku"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       int64  `json:"price"`
+	Stock       int64  `json:"stock"`
+}
+
+// ImportProducts checks the rows of an import file with the rules of
+// CreateProduct and queues the valid ones to be written by ProcessImports.
+// The file itself is refused only when it can't be read as a whole; a bad
+// row is reported in the job and the rest of the file goes on.
+func (s *ProductService) ImportProducts(ctx context.Context, req *entity.ImportProductsRequest) (*entity.ImportJob, error) {
+	const op = "ProductService.ImportProducts"
+
+	caller, ok := entity.CallerFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrUnauthenticated)
+	}
+
+	if len(req.Data) > entity.MaxImportSize {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrImportTooLarge)
+	}
+
+	var (
+		rows      []*entity.ImportRow
+		rowErrors []*entity.ImportRowError
+		err       error
+	)
+	switch req.Format {
+	case entity.CatalogCSV:
+		rows, rowErrors, err = parseImportCSV(req.Data)
+	case entity.CatalogJSON:
+		rows, rowErrors, err = parseImportJSON(req.Data)
+	default:
+		err = entity.ErrInvalidCatalogFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	total := len(rows) + len(rowErrors)
+	if total == 0 {
+		return nil, fmt.Errorf("%s: %w: the file has no products", op, entity.ErrInvalidImportFile)
+	}
+
+	valid, invalid := validateImportRows(rows)
+	rowErrors = append(rowErrors, invalid...)
+	slices.SortFunc(rowErrors, func(a, b *entity.ImportRowError) int {
+		return a.Row - b.Row
+	})
+
+	now := time.Now()
+	job := &entity.ImportJob{
+		Id:            entity.GenerateID(),
+		SellerId:      caller.UserId,
+		Format:        req.Format,
+		DryRun:        req.DryRun,
+		Status:        entity.ImportPending,
+		TotalRows:     total,
+		ProcessedRows: len(rowErrors),
+		Failed:        len(rowErrors),
+		Errors:        rowErrors[:min(len(rowErrors), entity.MaxImportErrors)],
+		CreatedAt:     now,
+	}
+	if len(valid) == 0 {
+		job.Status = entity.ImportCompleted
+		job.FinishedAt = &now
+	}
+
+	job, err = s.db.CreateImportJob(ctx, job, valid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// GetImportJob returns the progress of an import of the caller.
+func (s *ProductService) GetImportJob(ctx context.Context, id uuid.UUID) (*entity.ImportJob, error) {
+	const op = "ProductService.GetImportJob"
+
+	caller, ok := entity.CallerFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrUnauthenticated)
+	}
+
+	job, err := s.db.GetImportJob(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if job.SellerId != caller.UserId && !caller.IsAdmin() {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrPermissionDenied)
+	}
+
+	return job, nil
+}
+
+// ProcessImports writes the queued rows of every unfinished import and
+// reports the number of products written.
+func (s *ProductService) ProcessImports(ctx context.Context) (int, error) {
+	const op = "ProductService.ProcessImports"
+
+	written := 0
+	for {
+		job, products, err := s.db.ProcessImportBatch(ctx, importBatchSize, time.Now())
+		if err != nil {
+			return written, fmt.Errorf("%s: %w", op, err)
+		}
+		if job == nil {
+			return written, nil
+		}
+
+		for _, id := range products {
+			s.dropCachedProduct(ctx, id)
+		}
+		written += len(products)
+	}
+}
+
+// ExportProducts writes the products of the caller to w, newest first, in
+// the format ImportProducts takes.
+func (s *ProductService) ExportProducts(ctx context.Context, format entity.CatalogFormat, w io.Writer) error {
+	const op = "ProductService.ExportProducts"
+
+	caller, ok := entity.CallerFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%s: %w", op, entity.ErrUnauthenticated)
+	}
+
+	out, err := newCatalogWriter(format, w)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var after *entity.ProductCursor
+	for {
+		products, err := s.db.ListSellerPage(ctx, caller.UserId, after, exportBatchSize)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, product := range products {
+			if err := out.Write(product); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		if len(products) < exportBatchSize {
+			break
+		}
+		cursor := entity.CursorOf(products[len(products)-1])
+		after = &cursor
+	}
+
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// validateImportRows splits the rows into the valid ones and the errors of
+// the others. A SKU repeated in the file is refused after its first row.
+func validateImportRows(rows []*entity.ImportRow) ([]*entity.ImportRow, []*entity.ImportRowError) {
+	valid := make([]*entity.ImportRow, 0, len(rows))
+	var invalid []*entity.ImportRowError
+
+	first := make(map[string]int, len(rows))
+	for _, row := range rows {
+		err := validateProduct(&entity.CreateProductRequest{
+			Name:        row.Name,
+			Description: row.Description,
+			Price:       row.Price,
+			Stock:       row.Stock,
+		})
+		if !skuPattern.MatchString(row.Sku) {
+			err = entity.ErrInvalidSku
+		}
+		if err != nil {
+			invalid = append(invalid, &entity.ImportRowError{Row: row.Row, Sku: row.Sku, Message: err.Error()})
+			continue
+		}
+
+		if at, ok := first[row.Sku]; ok {
+			invalid = append(invalid, &entity.ImportRowError{Row: row.Row, Sku: row.Sku, Message: fmt.Sprintf("sku is repeated, first at row %d", at)})
+			continue
+		}
+		first[row.Sku] = row.Row
+
+		valid = append(valid, row)
+	}
+
+	return valid, invalid
+}
+
+// parseImportCSV reads a header naming the import columns in any order, then
+// a product per line. Rows are numbered by their line.
+func parseImportCSV(data []byte) ([]*entity.ImportRow, []*entity.ImportRowError, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", entity.ErrInvalidImportFile, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(importColumns, name) {
+			return nil, nil, fmt.Errorf("%w: unknown column %q", entity.ErrInvalidImportFile, name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, nil, fmt.Errorf("%w: column %q is repeated", entity.ErrInvalidImportFile, name)
+		}
+		columns[name] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("%w: column %q is missing", entity.ErrInvalidImportFile, name)
+		}
+	}
+
+	var (
+		rows      []*entity.ImportRow
+		rowErrors []*entity.ImportRowError
+	)
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", entity.ErrInvalidImportFile, err)
+		}
+		if len(rows)+len(rowErrors) == entity.MaxImportRows {
+			return nil, nil, fmt.Errorf("%w: more than %d products", entity.ErrImportTooLarge, entity.MaxImportRows)
+		}
+
+		line, _ := r.FieldPos(0)
+		if len(record) != len(header) {
+			rowErrors = append(rowErrors, &entity.ImportRowError{Row: line, Message: fmt.Sprintf("expected %d fields, got %d", len(header), len(record))})
+			continue
+		}
+
+		field := func(name string) string {
+			return record[columns[name]]
+		}
+
+		row := &entity.ImportRow{
+			Row:         line,
+			Sku:         strings.TrimSpace(field("sku")),
+			Name:        field("name"),
+			Description: field("description"),
+		}
+		if row.Price, err = strconv.ParseInt(strings.TrimSpace(field("price")), 10, 64); err != nil {
+			rowErrors = append(rowErrors, &entity.ImportRowError{Row: line, Sku: row.Sku, Message: "price must be an integer"})
+			continue
+		}
+		if row.Stock, err = strconv.ParseInt(strings.TrimSpace(field("stock")), 10, 64); err != nil {
+			rowErrors = append(rowErrors, &entity.ImportRowError{Row: line, Sku: row.Sku, Message: "stock must be an integer"})
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// parseImportJSON reads an array of products. Rows are numbered by their
+// position in the array.
+func parseImportJSON(data []byte) ([]*entity.ImportRow, []*entity.ImportRowError, error) {
+	dec := json.NewDecoder(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	dec.DisallowUnknownFields()
+
+	if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+		return nil, nil, fmt.Errorf("%w: the file must be an array of products", entity.ErrInvalidImportFile)
+	}
+
+	var (
+		rows      []*entity.ImportRow
+		rowErrors []*entity.ImportRowError
+	)
+	for i := 1; dec.More(); i++ {
+		if i > entity.MaxImportRows {
+			return nil, nil, fmt.Errorf("%w: more than %d products", entity.ErrImportTooLarge, entity.MaxImportRows)
+		}
+
+		// A value of the wrong type is read to its end before it's
+		// refused, so the decoder can go on to the next product.
+		var record importRecord
+		if err := dec.Decode(&record); err != nil {
+			message, ok := jsonRowError(err)
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: %v", entity.ErrInvalidImportFile, err)
+			}
+			rowErrors = append(rowErrors, &entity.ImportRowError{Row: i, Sku: record.Sku, Message: message})
+			continue
+		}
+
+		rows = append(rows, &entity.ImportRow{
+			Row:         i,
+			Sku:         strings.TrimSpace(record.Sku),
+			Name:        record.Name,
+			Description: record.Description,
+			Price:       record.Price,
+			Stock:       record.Stock,
+		})
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", entity.ErrInvalidImportFile, err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("%w: unexpected data after the array", entity.ErrInvalidImportFile)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// jsonRowError describes an error of a single product of a JSON file. It
+// reports false for the errors that make the rest of the file unreadable.
+func jsonRowError(err error) (string, bool) {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field == "":
+		return "a product must be an object", true
+	case errors.As(err, &typeErr) && typeErr.Type.Kind() == reflect.Int64:
+		return typeErr.Field + " must be an integer", true
+	case errors.As(err, &typeErr):
+		return typeErr.Field + " must be a string", true
+	case strings.HasPrefix(err.Error(), "json: unknown field"):
+		return strings.TrimPrefix(err.Error(), "json: "), true
+	default:
+		return "", false
+	}
+}
+
+// catalogWriter writes the products of an export one by one.
+type catalogWriter interface {
+	Write(product *entity.Product) error
+	Close() error
+}
+
+// newCatalogWriter starts an export file in w.
+func newCatalogWriter(format entity.CatalogFormat, w io.Writer) (catalogWriter, error) {
+	switch format {
+	case entity.CatalogCSV:
+		out := &csvCatalogWriter{w: csv.NewWriter(w)}
+		return out, out.w.Write(importColumns)
+	case entity.CatalogJSON:
+		_, err := io.WriteString(w, "[")
+		return &jsonCatalogWriter{w: w}, err
+	default:
+		return nil, entity.ErrInvalidCatalogFormat
+	}
+}
+
+type csvCatalogWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvCatalogWriter) Write(product *entity.Product) error {
+	return c.w.Write([]string{
+		product.Sku,
+		product.Name,
+		product.Description,
+		strconv.FormatInt(product.Price, 10),
+		strconv.FormatInt(product.Stock, 10),
+	})
+}
+
+func (c *csvCatalogWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonCatalogWriter writes an array with a product per line.
+type jsonCatalogWriter struct {
+	w       io.Writer
+	written int
+}
+
+func (j *jsonCatalogWriter) Write(product *entity.Product) error {
+	data, err := json.Marshal(importRecord{
+		Sku:         product.Sku,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Stock:       product.Stock,
+	})
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n  "
+	if j.written == 0 {
+		separator = "\n  "
+	}
+	j.written++
+
+	_, err = io.WriteString(j.w, separator+string(data))
+	return err
+}
+
+func (j *jsonCatalogWriter) Close() error {
+	end := "\n]\n"
+	if j.written == 0 {
+		end = "]\n"
+	}
+
+	_, err := io.WriteString(j.w, end)
+	return err
+}
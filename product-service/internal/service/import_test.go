@@ -0,0 +1,250 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/utils"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	t.Run("columns in any order, rows numbered by line", func(t *testing.T) {
+		data := "\xef\xbb\xbfName,SKU,price,stock,description\n" +
+			"Ноутбук,NB-1,1000,5,\"Лёгкий,\nтонкий\"\n" +
+			"Мышь,MS-1,дорого,5,Беспроводная\n" +
+			"Коврик,PAD-1,100\n"
+
+		rows, rowErrors, err := parseImportCSV([]byte(data))
+
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, &entity.ImportRow{Row: 2, Sku: "NB-1", Name: "Ноутбук", Description: "Лёгкий,\nтонкий", Price: 1000, Stock: 5}, rows[0])
+		assert.Equal(t, []*entity.ImportRowError{
+			{Row: 4, Sku: "MS-1", Message: "price must be an integer"},
+			{Row: 5, Message: "expected 5 fields, got 3"},
+		}, rowErrors)
+	})
+
+	t.Run("an unreadable header refuses the file", func(t *testing.T) {
+		for name, data := range map[string]string{
+			"empty":          "",
+			"unknown column": "sku,name,description,price,stock,colour\n",
+			"missing column": "sku,name,price,stock\n",
+			"repeated":       "sku,name,description,price,stock,sku\n",
+		} {
+			_, _, err := parseImportCSV([]byte(data))
+			assert.ErrorIs(t, err, entity.ErrInvalidImportFile, name)
+		}
+	})
+
+	t.Run("too many rows", func(t *testing.T) {
+		data := "sku,name,description,price,stock\n" + strings.Repeat("A,B,C,1,1\n", entity.MaxImportRows+1)
+
+		_, _, err := parseImportCSV([]byte(data))
+
+		assert.ErrorIs(t, err, entity.ErrImportTooLarge)
+	})
+}
+
+func TestParseImportJSON(t *testing.T) {
+	t.Run("a bad product doesn't stop the file", func(t *testing.T) {
+		data := `[
+			{"sku": "NB-1", "name": "Ноутбук", "description": "Лёгкий", "price": 1000, "stock": 5},
+			{"sku": "MS-1", "name": "Мышь", "description": "Беспроводная", "price": "1000", "stock": 5},
+			{"sku": "PAD-1", "colour": "red"},
+			42
+		]`
+
+		rows, rowErrors, err := parseImportJSON([]byte(data))
+
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, &entity.ImportRow{Row: 1, Sku: "NB-1", Name: "Ноутбук", Description: "Лёгкий", Price: 1000, Stock: 5}, rows[0])
+		require.Len(t, rowErrors, 3)
+		assert.Equal(t, &entity.ImportRowError{Row: 2, Sku: "MS-1", Message: "price must be an integer"}, rowErrors[0])
+		assert.Equal(t, 3, rowErrors[1].Row)
+		assert.Contains(t, rowErrors[1].Message, "colour")
+		assert.Equal(t, &entity.ImportRowError{Row: 4, Message: "a product must be an object"}, rowErrors[2])
+	})
+
+	t.Run("an unreadable file is refused", func(t *testing.T) {
+		for name, data := range map[string]string{
+			"not an array": `{"sku": "NB-1"}`,
+			"broken":       `[{"sku": "NB-1",`,
+			"trailing":     `[] []`,
+		} {
+			_, _, err := parseImportJSON([]byte(data))
+			assert.ErrorIs(t, err, entity.ErrInvalidImportFile, name)
+		}
+	})
+}
+
+func TestService_ImportProducts(t *testing.T) {
+	sellerID := uuid.New()
+	ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID, Role: "client"})
+
+	t.Run("valid rows are queued and the others reported", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		data := "sku,name,description,price,stock\n" +
+			"NB-1,Ноутбук,Лёгкий,1000,5\n" +
+			"MS-1,Мышь,Беспроводная,0,5\n" +
+			"NB-1,Ноутбук,Повтор,1000,5\n" +
+			"bad sku,Коврик,Большой,100,1\n" +
+			"PAD-1,Коврик,Большой,100,1\n"
+
+		dbMock.On("CreateImportJob", ctx, mock.Anything, mock.Anything).Return(&entity.ImportJob{}, nil)
+
+		_, err := svc.ImportProducts(ctx, &entity.ImportProductsRequest{Format: entity.CatalogCSV, DryRun: true, Data: []byte(data)})
+
+		require.NoError(t, err)
+		job := dbMock.Calls[0].Arguments.Get(1).(*entity.ImportJob)
+		assert.Equal(t, sellerID, job.SellerId)
+		assert.True(t, job.DryRun)
+		assert.Equal(t, entity.ImportPending, job.Status)
+		assert.Equal(t, 5, job.TotalRows)
+		assert.Equal(t, 3, job.ProcessedRows)
+		assert.Equal(t, 3, job.Failed)
+		assert.Equal(t, []*entity.ImportRowError{
+			{Row: 3, Sku: "MS-1", Message: entity.ErrInvalidPrice.Error()},
+			{Row: 4, Sku: "NB-1", Message: "sku is repeated, first at row 2"},
+			{Row: 5, Sku: "bad sku", Message: entity.ErrInvalidSku.Error()},
+		}, job.Errors)
+
+		queued := dbMock.Calls[0].Arguments.Get(2).([]*entity.ImportRow)
+		require.Len(t, queued, 2)
+		assert.Equal(t, "NB-1", queued[0].Sku)
+		assert.Equal(t, "PAD-1", queued[1].Sku)
+	})
+
+	t.Run("a file without valid rows is finished right away", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("CreateImportJob", ctx, mock.MatchedBy(func(job *entity.ImportJob) bool {
+			return job.Status == entity.ImportCompleted && job.FinishedAt != nil && job.Failed == 1
+		}), []*entity.ImportRow{}).Return(&entity.ImportJob{Status: entity.ImportCompleted}, nil)
+
+		_, err := svc.ImportProducts(ctx, &entity.ImportProductsRequest{Format: entity.CatalogJSON, Data: []byte(`[{"sku": "NB-1"}]`)})
+
+		require.NoError(t, err)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("refused files", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.ImportProducts(ctx, &entity.ImportProductsRequest{Data: []byte("[]")})
+		assert.ErrorIs(t, err, entity.ErrInvalidCatalogFormat)
+
+		_, err = svc.ImportProducts(ctx, &entity.ImportProductsRequest{Format: entity.CatalogJSON, Data: []byte("[]")})
+		assert.ErrorIs(t, err, entity.ErrInvalidImportFile)
+
+		_, err = svc.ImportProducts(context.Background(), &entity.ImportProductsRequest{Format: entity.CatalogJSON, Data: []byte("[]")})
+		assert.ErrorIs(t, err, entity.ErrUnauthenticated)
+	})
+}
+
+func TestService_GetImportJob(t *testing.T) {
+	sellerID, jobID := uuid.New(), uuid.New()
+	job := &entity.ImportJob{Id: jobID, SellerId: sellerID}
+
+	dbMock := new(MockDatabase)
+	svc := NewProductService(dbMock, nil)
+	dbMock.On("GetImportJob", mock.Anything, jobID).Return(job, nil)
+
+	own := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID, Role: "client"})
+	result, err := svc.GetImportJob(own, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, job, result)
+
+	admin := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: entity.RoleAdmin})
+	_, err = svc.GetImportJob(admin, jobID)
+	require.NoError(t, err)
+
+	other := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: "client"})
+	_, err = svc.GetImportJob(other, jobID)
+	assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+}
+
+func TestService_ProcessImports(t *testing.T) {
+	ctx := context.Background()
+	first, second := uuid.New(), uuid.New()
+
+	dbMock := new(MockDatabase)
+	cacheMock := new(MockCache)
+	svc := NewProductService(dbMock, cacheMock)
+
+	dbMock.On("ProcessImportBatch", ctx, importBatchSize, mock.Anything).Return(&entity.ImportJob{Status: entity.ImportRunning}, []uuid.UUID{first, second}, nil).Once()
+	dbMock.On("ProcessImportBatch", ctx, importBatchSize, mock.Anything).Return(&entity.ImportJob{Status: entity.ImportCompleted, DryRun: true}, []uuid.UUID(nil), nil).Once()
+	dbMock.On("ProcessImportBatch", ctx, importBatchSize, mock.Anything).Return(nil, nil, nil).Once()
+	cacheMock.On("Delete", ctx, utils.GenerateCacheKey("product", first)).Return(nil)
+	cacheMock.On("Delete", ctx, utils.GenerateCacheKey("product", second)).Return(nil)
+
+	written, err := svc.ProcessImports(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, written)
+	dbMock.AssertExpectations(t)
+	cacheMock.AssertExpectations(t)
+}
+
+func TestService_ExportProducts(t *testing.T) {
+	sellerID := uuid.New()
+	ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID, Role: "client"})
+
+	products := []*entity.Product{
+		{Id: uuid.New(), Sku: "NB-1", Name: "Ноутбук", Description: "Лёгкий, \"тонкий\"", Price: 1000, Stock: 5},
+		{Id: uuid.New(), Name: "Мышь", Description: "Беспроводная", Price: 500, Stock: 0},
+	}
+
+	for _, format := range []entity.CatalogFormat{entity.CatalogCSV, entity.CatalogJSON} {
+		t.Run("the export reads back as an import in "+string(format), func(t *testing.T) {
+			dbMock := new(MockDatabase)
+			svc := NewProductService(dbMock, nil)
+			dbMock.On("ListSellerPage", ctx, sellerID, (*entity.ProductCursor)(nil), exportBatchSize).Return(products, nil)
+
+			var out bytes.Buffer
+			require.NoError(t, svc.ExportProducts(ctx, format, &out))
+
+			parse := parseImportCSV
+			if format == entity.CatalogJSON {
+				parse = parseImportJSON
+			}
+			rows, rowErrors, err := parse(out.Bytes())
+			require.NoError(t, err)
+			assert.Empty(t, rowErrors)
+			require.Len(t, rows, 2)
+			assert.Equal(t, "NB-1", rows[0].Sku)
+			assert.Equal(t, products[0].Description, rows[0].Description)
+			assert.Equal(t, int64(500), rows[1].Price)
+		})
+	}
+
+	t.Run("an empty catalog is an empty file", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+		dbMock.On("ListSellerPage", ctx, sellerID, (*entity.ProductCursor)(nil), exportBatchSize).Return([]*entity.Product{}, nil)
+
+		var out bytes.Buffer
+		require.NoError(t, svc.ExportProducts(ctx, entity.CatalogJSON, &out))
+
+		assert.Equal(t, "[]\n", out.String())
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		err := svc.ExportProducts(ctx, "xml", &bytes.Buffer{})
+
+		assert.ErrorIs(t, err, entity.ErrInvalidCatalogFormat)
+	})
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+// productTransitions are the status changes ChangeProductStatus makes, and
+// whether only admins make them. Products are deleted by DeleteProduct.
+var productTransitions = map[entity.ProductStatus]map[entity.ProductStatus]bool{
+	entity.ProductDraft:         {entity.ProductPendingReview: false},
+	entity.ProductPendingReview: {entity.ProductDraft: false, entity.ProductPublished: true},
+	entity.ProductPublished:     {entity.ProductArchived: false},
+	entity.ProductArchived:      {entity.ProductPublished: false},
+}
+
+// sellerStatuses are the statuses of the products a seller sees among their
+// own.
+var sellerStatuses = []entity.ProductStatus{entity.ProductDraft, entity.ProductPendingReview, entity.ProductPublished, entity.ProductArchived}
+
+// ChangeProductStatus moves a product along its lifecycle: the seller sends
+// a draft to review and takes it back, an admin publishes it or returns it
+// to draft with a reason, and the seller archives a published product and
+// publishes it again.
+func (s *ProductService) ChangeProductStatus(ctx context.Context, req *entity.ChangeProductStatusRequest) (*entity.Product, error) {
+	const op = "ProductService.ChangeProductStatus"
+
+	if req.Status == "" {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidStatus)
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if utf8.RuneCountInString(req.Reason) > entity.MaxStatusReasonLength {
+		return nil, fmt.Errorf("%s: %w: at most %d characters are allowed", op, entity.ErrInvalidStatusReason, entity.MaxStatusReasonLength)
+	}
+
+	product, err := s.ownedProduct(ctx, req.ProductId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if product.Status == entity.ProductDeleted {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrProductNotFound)
+	}
+
+	adminOnly, ok := productTransitions[product.Status][req.Status]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w: %s to %s", op, entity.ErrInvalidStatusChange, product.Status, req.Status)
+	}
+	if adminOnly {
+		if err := requireAdmin(ctx); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	changed, err := s.changeStatus(ctx, product, req.Status, req.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.attachImages(ctx, changed); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.dropCachedProduct(ctx, changed.Id)
+
+	return changed, nil
+}
+
+// ListProductStatusHistory lists the status changes of a product, oldest
+// first, to its seller and admins.
+func (s *ProductService) ListProductStatusHistory(ctx context.Context, productID uuid.UUID) ([]*entity.ProductStatusChange, error) {
+	const op = "ProductService.ListProductStatusHistory"
+
+	if _, err := s.ownedProduct(ctx, productID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	history, err := s.db.ListProductStatusHistory(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return history, nil
+}
+
+// ListPendingProducts lists the products waiting for review, newest first,
+// to admins.
+func (s *ProductService) ListPendingProducts(ctx context.Context, pageSize int, pageToken string) (*entity.ProductPage, error) {
+	const op = "ProductService.ListPendingProducts"
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page, err := s.productPage(ctx, pageSize, pageToken, func(ctx context.Context, after *entity.ProductCursor, limit int) ([]*entity.Product, error) {
+		return s.db.ListStatusPage(ctx, entity.ProductPendingReview, after, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return page, nil
+}
+
+// changeStatus records the change of product to the given status on behalf
+// of the caller. It fails if the status of the product changed since it was
+// read.
+func (s *ProductService) changeStatus(ctx context.Context, product *entity.Product, status entity.ProductStatus, reason string) (*entity.Product, error) {
+	caller, _ := entity.CallerFromContext(ctx)
+
+	return s.db.ChangeProductStatus(ctx, &entity.ProductStatusChange{
+		ProductId:  product.Id,
+		FromStatus: product.Status,
+		ToStatus:   status,
+		ChangedBy:  caller.UserId,
+		Reason:     reason,
+		ChangedAt:  time.Now(),
+	})
+}
+
+// canView tells whether the caller may see a product. Products that were
+// never published are seen by their seller and admins only; the others stay
+// visible after they are archived or deleted, for the orders that refer to
+// them.
+func canView(ctx context.Context, product *entity.Product) bool {
+	if product.PublishedAt != nil {
+		return true
+	}
+
+	caller, ok := entity.CallerFromContext(ctx)
+	return ok && (caller.IsAdmin() || caller.UserId == product.SellerId)
+}
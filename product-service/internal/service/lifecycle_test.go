@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/utils"
+)
+
+func TestService_ChangeProductStatus(t *testing.T) {
+	sellerID, productID := uuid.New(), uuid.New()
+	seller := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID, Role: "client"})
+	admin := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: entity.RoleAdmin})
+	productIn := func(status entity.ProductStatus) *entity.Product {
+		return &entity.Product{Id: productID, SellerId: sellerID, Status: status}
+	}
+
+	t.Run("the seller sends a draft to review", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		svc := NewProductService(dbMock, cacheMock)
+
+		dbMock.On("Get", seller, productID).Return(productIn(entity.ProductDraft), nil)
+		dbMock.On("ChangeProductStatus", seller, mock.MatchedBy(func(c *entity.ProductStatusChange) bool {
+			return c.ProductId == productID && c.FromStatus == entity.ProductDraft &&
+				c.ToStatus == entity.ProductPendingReview && c.ChangedBy == sellerID && c.Reason == "готово"
+		})).Return(productIn(entity.ProductPendingReview), nil)
+		dbMock.On("ListImages", seller, []uuid.UUID{productID}).Return([]*entity.ProductImage{}, nil)
+		cacheMock.On("Delete", seller, utils.GenerateCacheKey("product", productID)).Return(nil)
+
+		product, err := svc.ChangeProductStatus(seller, &entity.ChangeProductStatusRequest{ProductId: productID, Status: entity.ProductPendingReview, Reason: " готово "})
+
+		require.NoError(t, err)
+		assert.Equal(t, entity.ProductPendingReview, product.Status)
+		dbMock.AssertExpectations(t)
+		cacheMock.AssertExpectations(t)
+	})
+
+	t.Run("only admins publish the products under review", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("Get", seller, productID).Return(productIn(entity.ProductPendingReview), nil)
+
+		_, err := svc.ChangeProductStatus(seller, &entity.ChangeProductStatusRequest{ProductId: productID, Status: entity.ProductPublished})
+
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+		dbMock.AssertNotCalled(t, "ChangeProductStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("admins publish the products under review", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		svc := NewProductService(dbMock, cacheMock)
+
+		dbMock.On("Get", admin, productID).Return(productIn(entity.ProductPendingReview), nil)
+		dbMock.On("ChangeProductStatus", admin, mock.MatchedBy(func(c *entity.ProductStatusChange) bool {
+			return c.FromStatus == entity.ProductPendingReview && c.ToStatus == entity.ProductPublished
+		})).Return(productIn(entity.ProductPublished), nil)
+		dbMock.On("ListImages", admin, []uuid.UUID{productID}).Return([]*entity.ProductImage{}, nil)
+		cacheMock.On("Delete", admin, utils.GenerateCacheKey("product", productID)).Return(nil)
+
+		product, err := svc.ChangeProductStatus(admin, &entity.ChangeProductStatusRequest{ProductId: productID, Status: entity.ProductPublished})
+
+		require.NoError(t, err)
+		assert.Equal(t, entity.ProductPublished, product.Status)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("changes outside the lifecycle", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			from, to entity.ProductStatus
+			err      error
+		}{
+			"draft to published":    {entity.ProductDraft, entity.ProductPublished, entity.ErrInvalidStatusChange},
+			"published to draft":    {entity.ProductPublished, entity.ProductDraft, entity.ErrInvalidStatusChange},
+			"deleted by status":     {entity.ProductPublished, entity.ProductDeleted, entity.ErrInvalidStatusChange},
+			"a deleted product":     {entity.ProductDeleted, entity.ProductPublished, entity.ErrProductNotFound},
+			"archived to in review": {entity.ProductArchived, entity.ProductPendingReview, entity.ErrInvalidStatusChange},
+		} {
+			dbMock := new(MockDatabase)
+			svc := NewProductService(dbMock, nil)
+
+			dbMock.On("Get", admin, productID).Return(productIn(tc.from), nil)
+
+			_, err := svc.ChangeProductStatus(admin, &entity.ChangeProductStatusRequest{ProductId: productID, Status: tc.to})
+
+			assert.ErrorIs(t, err, tc.err, name)
+			dbMock.AssertNotCalled(t, "ChangeProductStatus", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("other sellers can't", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: "client"})
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("Get", ctx, productID).Return(productIn(entity.ProductDraft), nil)
+
+		_, err := svc.ChangeProductStatus(ctx, &entity.ChangeProductStatusRequest{ProductId: productID, Status: entity.ProductPendingReview})
+
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.ChangeProductStatus(seller, &entity.ChangeProductStatusRequest{ProductId: productID})
+		assert.ErrorIs(t, err, entity.ErrInvalidStatus)
+
+		_, err = svc.ChangeProductStatus(seller, &entity.ChangeProductStatusRequest{
+			ProductId: productID,
+			Status:    entity.ProductPendingReview,
+			Reason:    strings.Repeat("я", entity.MaxStatusReasonLength+1),
+		})
+		assert.ErrorIs(t, err, entity.ErrInvalidStatusReason)
+	})
+
+	t.Run("a concurrent change wins", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("Get", seller, productID).Return(productIn(entity.ProductPublished), nil)
+		dbMock.On("ChangeProductStatus", seller, mock.Anything).Return(nil, entity.ErrStatusConflict)
+
+		_, err := svc.ChangeProductStatus(seller, &entity.ChangeProductStatusRequest{ProductId: productID, Status: entity.ProductArchived})
+
+		assert.ErrorIs(t, err, entity.ErrStatusConflict)
+	})
+}
+
+func TestService_ProductVisibility(t *testing.T) {
+	sellerID, productID := uuid.New(), uuid.New()
+	draft := &entity.Product{Id: productID, SellerId: sellerID, Status: entity.ProductDraft}
+	cacheMiss := errors.New("cache miss")
+
+	t.Run("drafts are hidden from other users", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: "client"})
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		svc := NewProductService(dbMock, cacheMock)
+
+		cacheMock.On("Get", ctx, utils.GenerateCacheKey("product", productID)).Return(nil, cacheMiss)
+		dbMock.On("Get", ctx, productID).Return(draft, nil)
+
+		_, err := svc.GetProduct(ctx, productID)
+		assert.ErrorIs(t, err, entity.ErrProductNotFound)
+
+		_, err = svc.GetPriceHistory(ctx, productID, 0)
+		assert.ErrorIs(t, err, entity.ErrProductNotFound)
+	})
+
+	t.Run("the seller sees their drafts", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID, Role: "client"})
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		svc := NewProductService(dbMock, cacheMock)
+
+		cacheKey := utils.GenerateCacheKey("product", productID)
+		cacheMock.On("Get", ctx, cacheKey).Return(nil, cacheMiss)
+		cacheMock.On("Set", ctx, cacheKey, mock.Anything, duration).Return(nil)
+		dbMock.On("Get", ctx, productID).Return(draft, nil)
+		dbMock.On("ListImages", ctx, []uuid.UUID{productID}).Return([]*entity.ProductImage{}, nil)
+
+		product, err := svc.GetProduct(ctx, productID)
+
+		require.NoError(t, err)
+		assert.Equal(t, entity.ProductDraft, product.Status)
+	})
+
+	t.Run("the seller lists the products that aren't on sale", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID, Role: "client"})
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("ListBySeller", ctx, sellerID, sellerStatuses, int64(0), int64(DefaultSearchLimit)).Return([]*entity.Product{draft}, nil)
+
+		products, err := svc.ListProductsBySeller(ctx, sellerID, 0, 0)
+
+		require.NoError(t, err)
+		assert.Len(t, products, 1)
+	})
+}
+
+func TestService_ListPendingProducts(t *testing.T) {
+	admin := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: entity.RoleAdmin})
+
+	t.Run("admins list the products under review", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		pending := []*entity.Product{{Id: uuid.New(), Status: entity.ProductPendingReview}}
+		dbMock.On("ListStatusPage", admin, entity.ProductPendingReview, (*entity.ProductCursor)(nil), DefaultProductsPageSize+1).Return(pending, nil)
+		dbMock.On("ListImages", admin, []uuid.UUID{pending[0].Id}).Return([]*entity.ProductImage{}, nil)
+
+		page, err := svc.ListPendingProducts(admin, 0, "")
+
+		require.NoError(t, err)
+		assert.Equal(t, pending, page.Products)
+		assert.Empty(t, page.NextPageToken)
+	})
+
+	t.Run("sellers can't", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: "client"})
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.ListPendingProducts(ctx, 0, "")
+
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+	})
+}
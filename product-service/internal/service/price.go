@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+const (
+	// maxScheduleAhead is how far ahead a price change can be planned.
+	maxScheduleAhead = 365 * 24 * time.Hour
+	// scheduleClockSkew lets through a start time slightly in the past, as
+	// sent by a client whose clock is behind.
+	scheduleClockSkew = time.Minute
+
+	DefaultHistoryDays = 30
+	MaxHistoryDays     = 365
+
+	// applyBatchSize is the number of schedules applied in one transaction.
+	applyBatchSize = 500
+)
+
+// CreatePriceSchedule plans a price change of a product of the caller. A
+// schedule with an end time is a sale, whose price must be below the regular
+// price of the product.
+func (s *ProductService) CreatePriceSchedule(ctx context.Context, req *entity.CreatePriceScheduleRequest) (*entity.PriceSchedule, error) {
+	const op = "ProductService.CreatePriceSchedule"
+
+	now := time.Now()
+	schedule, err := newPriceSchedule(req, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.checkOwner(ctx, req.ProductId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if schedule.Kind == entity.PriceSale {
+		product, err := s.db.Get(ctx, req.ProductId)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		regular := product.Price
+		if product.OriginalPrice != nil {
+			regular = *product.OriginalPrice
+		}
+		if schedule.Price >= regular {
+			return nil, fmt.Errorf("%s: %w: the sale price must be below the regular price of %d", op, entity.ErrInvalidPriceSchedule, regular)
+		}
+	}
+
+	created, err := s.db.CreatePriceSchedule(ctx, schedule)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+func (s *ProductService) ListPriceSchedules(ctx context.Context, productID uuid.UUID) ([]*entity.PriceSchedule, error) {
+	const op = "ProductService.ListPriceSchedules"
+
+	if err := s.checkOwner(ctx, productID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	schedules, err := s.db.ListPriceSchedules(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return schedules, nil
+}
+
+// CancelPriceSchedule cancels a pending schedule, or ends a running sale.
+func (s *ProductService) CancelPriceSchedule(ctx context.Context, id uuid.UUID) (*entity.PriceSchedule, error) {
+	const op = "ProductService.CancelPriceSchedule"
+
+	schedule, err := s.db.GetPriceSchedule(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.checkOwner(ctx, schedule.ProductId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	schedule, err = s.db.CancelPriceSchedule(ctx, id, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.dropCachedProduct(ctx, schedule.ProductId)
+
+	return schedule, nil
+}
+
+// GetPriceHistory returns the price changes of a product over the last days
+// and the lowest price it sold at over them, including the price in effect
+// when the period started.
+func (s *ProductService) GetPriceHistory(ctx context.Context, productID uuid.UUID, days int) (*entity.PriceHistory, error) {
+	const op = "ProductService.GetPriceHistory"
+
+	if days == 0 {
+		days = DefaultHistoryDays
+	}
+	if days < 0 || days > MaxHistoryDays {
+		return nil, fmt.Errorf("%s: %w: days must be between 1 and %d", op, entity.ErrInvalidHistoryPeriod, MaxHistoryDays)
+	}
+
+	product, err := s.db.Get(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !canView(ctx, product) {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrProductNotFound)
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	changes, err := s.db.ListPriceHistory(ctx, productID, since)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lowest := product.Price
+	for _, change := range changes {
+		lowest = min(lowest, change.Price)
+	}
+
+	return &entity.PriceHistory{
+		Changes:     changes,
+		LowestPrice: lowest,
+		Since:       since,
+	}, nil
+}
+
+// ApplyPriceSchedules applies every schedule that is due and reports the
+// number of products whose price changed.
+func (s *ProductService) ApplyPriceSchedules(ctx context.Context) (int, error) {
+	const op = "ProductService.ApplyPriceSchedules"
+
+	products := map[uuid.UUID]bool{}
+	for {
+		applied, err := s.db.ApplyPriceSchedules(ctx, time.Now(), applyBatchSize)
+		if err != nil {
+			return len(products), fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, schedule := range applied {
+			if schedule.Status == entity.PriceScheduleSkipped {
+				continue
+			}
+			products[schedule.ProductId] = true
+			s.dropCachedProduct(ctx, schedule.ProductId)
+		}
+
+		if len(applied) < applyBatchSize {
+			return len(products), nil
+		}
+	}
+}
+
+func newPriceSchedule(req *entity.CreatePriceScheduleRequest, now time.Time) (*entity.PriceSchedule, error) {
+	if req.Price < 1 {
+		return nil, fmt.Errorf("%w: price must be positive", entity.ErrInvalidPriceSchedule)
+	}
+
+	startsAt := req.StartsAt
+	if startsAt.IsZero() || (startsAt.Before(now) && now.Sub(startsAt) <= scheduleClockSkew) {
+		startsAt = now
+	}
+	if startsAt.Before(now) {
+		return nil, fmt.Errorf("%w: starts_at is in the past", entity.ErrInvalidPriceSchedule)
+	}
+	if startsAt.Sub(now) > maxScheduleAhead {
+		return nil, fmt.Errorf("%w: starts_at is more than a year ahead", entity.ErrInvalidPriceSchedule)
+	}
+
+	schedule := &entity.PriceSchedule{
+		Id:        entity.GenerateID(),
+		ProductId: req.ProductId,
+		Kind:      entity.PriceChange,
+		Price:     req.Price,
+		StartsAt:  startsAt,
+		Status:    entity.PriceSchedulePending,
+		CreatedAt: now,
+	}
+
+	if req.EndsAt != nil {
+		if !req.EndsAt.After(startsAt) {
+			return nil, fmt.Errorf("%w: ends_at must be after starts_at", entity.ErrInvalidPriceSchedule)
+		}
+		if req.EndsAt.Sub(now) > maxScheduleAhead {
+			return nil, fmt.Errorf("%w: ends_at is more than a year ahead", entity.ErrInvalidPriceSchedule)
+		}
+
+		endsAt := *req.EndsAt
+		schedule.Kind = entity.PriceSale
+		schedule.EndsAt = &endsAt
+	}
+
+	return schedule, nil
+}
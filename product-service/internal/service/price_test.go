@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/utils"
+)
+
+func TestService_CreatePriceSchedule(t *testing.T) {
+	sellerID, productID := uuid.New(), uuid.New()
+	ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID, Role: "client"})
+	product := &entity.Product{Id: productID, SellerId: sellerID, Price: 1000}
+
+	t.Run("a change starts now by default", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("Get", ctx, productID).Return(product, nil)
+		dbMock.On("CreatePriceSchedule", ctx, mock.MatchedBy(func(s *entity.PriceSchedule) bool {
+			return s.Kind == entity.PriceChange && s.Price == 1200 && s.EndsAt == nil &&
+				s.Status == entity.PriceSchedulePending && time.Since(s.StartsAt) < time.Minute
+		})).Return(&entity.PriceSchedule{Kind: entity.PriceChange}, nil)
+
+		schedule, err := svc.CreatePriceSchedule(ctx, &entity.CreatePriceScheduleRequest{ProductId: productID, Price: 1200})
+
+		require.NoError(t, err)
+		assert.Equal(t, entity.PriceChange, schedule.Kind)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("a schedule with an end is a sale", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		startsAt := time.Now().Add(time.Hour)
+		endsAt := startsAt.Add(24 * time.Hour)
+		dbMock.On("Get", ctx, productID).Return(product, nil)
+		dbMock.On("CreatePriceSchedule", ctx, mock.MatchedBy(func(s *entity.PriceSchedule) bool {
+			return s.Kind == entity.PriceSale && s.StartsAt.Equal(startsAt) && s.EndsAt.Equal(endsAt)
+		})).Return(&entity.PriceSchedule{Kind: entity.PriceSale}, nil)
+
+		_, err := svc.CreatePriceSchedule(ctx, &entity.CreatePriceScheduleRequest{ProductId: productID, Price: 800, StartsAt: startsAt, EndsAt: &endsAt})
+
+		require.NoError(t, err)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("a sale must be below the regular price", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		onSale := &entity.Product{Id: productID, SellerId: sellerID, Price: 700, OriginalPrice: ptr(int64(1000))}
+		dbMock.On("Get", ctx, productID).Return(onSale, nil)
+
+		endsAt := time.Now().Add(time.Hour)
+		_, err := svc.CreatePriceSchedule(ctx, &entity.CreatePriceScheduleRequest{ProductId: productID, Price: 1000, EndsAt: &endsAt})
+
+		assert.ErrorIs(t, err, entity.ErrInvalidPriceSchedule)
+		dbMock.AssertNotCalled(t, "CreatePriceSchedule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("only the seller schedules prices", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		other := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: "client"})
+		dbMock.On("Get", other, productID).Return(product, nil)
+
+		_, err := svc.CreatePriceSchedule(other, &entity.CreatePriceScheduleRequest{ProductId: productID, Price: 1200})
+
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+	})
+
+	t.Run("invalid schedules", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		now := time.Now()
+		for name, req := range map[string]*entity.CreatePriceScheduleRequest{
+			"zero price":         {ProductId: productID},
+			"in the past":        {ProductId: productID, Price: 100, StartsAt: now.Add(-time.Hour)},
+			"too far ahead":      {ProductId: productID, Price: 100, StartsAt: now.Add(2 * maxScheduleAhead)},
+			"ends before starts": {ProductId: productID, Price: 100, StartsAt: now.Add(time.Hour), EndsAt: ptr(now)},
+		} {
+			_, err := svc.CreatePriceSchedule(ctx, req)
+			assert.ErrorIs(t, err, entity.ErrInvalidPriceSchedule, name)
+		}
+	})
+}
+
+func TestService_CancelPriceSchedule(t *testing.T) {
+	sellerID, productID, scheduleID := uuid.New(), uuid.New(), uuid.New()
+	ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID, Role: "client"})
+
+	dbMock := new(MockDatabase)
+	cacheMock := new(MockCache)
+	svc := NewProductService(dbMock, cacheMock)
+
+	dbMock.On("GetPriceSchedule", ctx, scheduleID).Return(&entity.PriceSchedule{Id: scheduleID, ProductId: productID, Status: entity.PriceScheduleActive}, nil)
+	dbMock.On("Get", ctx, productID).Return(&entity.Product{Id: productID, SellerId: sellerID}, nil)
+	dbMock.On("CancelPriceSchedule", ctx, scheduleID, mock.Anything).
+		Return(&entity.PriceSchedule{Id: scheduleID, ProductId: productID, Status: entity.PriceScheduleCancelled}, nil)
+	cacheMock.On("Delete", ctx, utils.GenerateCacheKey("product", productID)).Return(nil)
+
+	schedule, err := svc.CancelPriceSchedule(ctx, scheduleID)
+
+	require.NoError(t, err)
+	assert.Equal(t, entity.PriceScheduleCancelled, schedule.Status)
+	dbMock.AssertExpectations(t)
+	cacheMock.AssertExpectations(t)
+}
+
+func TestService_GetPriceHistory(t *testing.T) {
+	ctx := context.Background()
+	productID := uuid.New()
+
+	t.Run("the lowest price includes the one in effect at the start", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("Get", ctx, productID).Return(&entity.Product{Id: productID, Price: 1000, Status: entity.ProductPublished, PublishedAt: ptr(time.Now())}, nil)
+		dbMock.On("ListPriceHistory", ctx, productID, mock.MatchedBy(func(since time.Time) bool {
+			return time.Since(since).Round(time.Hour) == DefaultHistoryDays*24*time.Hour
+		})).Return([]*entity.PriceHistoryEntry{
+			{Price: 900, Reason: entity.PriceUpdated},
+			{Price: 700, OriginalPrice: ptr(int64(900)), Reason: entity.PriceSaleStarted},
+			{Price: 1000, Reason: entity.PriceSaleEnded},
+		}, nil)
+
+		history, err := svc.GetPriceHistory(ctx, productID, 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(700), history.LowestPrice)
+		assert.Len(t, history.Changes, 3)
+	})
+
+	t.Run("no changes in the period", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("Get", ctx, productID).Return(&entity.Product{Id: productID, Price: 1000, Status: entity.ProductPublished, PublishedAt: ptr(time.Now())}, nil)
+		dbMock.On("ListPriceHistory", ctx, productID, mock.Anything).Return([]*entity.PriceHistoryEntry{}, nil)
+
+		history, err := svc.GetPriceHistory(ctx, productID, 7)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), history.LowestPrice)
+	})
+
+	t.Run("invalid period", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		for _, days := range []int{-1, MaxHistoryDays + 1} {
+			_, err := svc.GetPriceHistory(ctx, productID, days)
+			assert.ErrorIs(t, err, entity.ErrInvalidHistoryPeriod)
+		}
+	})
+}
+
+func TestService_ApplyPriceSchedules(t *testing.T) {
+	ctx := context.Background()
+	first, second := uuid.New(), uuid.New()
+
+	dbMock := new(MockDatabase)
+	cacheMock := new(MockCache)
+	svc := NewProductService(dbMock, cacheMock)
+
+	full := make([]*entity.PriceSchedule, applyBatchSize)
+	for i := range full {
+		full[i] = &entity.PriceSchedule{ProductId: first, Status: entity.PriceScheduleCompleted}
+	}
+	dbMock.On("ApplyPriceSchedules", ctx, mock.Anything, uint64(applyBatchSize)).Return(full, nil).Once()
+	dbMock.On("ApplyPriceSchedules", ctx, mock.Anything, uint64(applyBatchSize)).Return([]*entity.PriceSchedule{
+		{ProductId: second, Status: entity.PriceScheduleActive},
+		{ProductId: uuid.New(), Status: entity.PriceScheduleSkipped},
+	}, nil).Once()
+	cacheMock.On("Delete", ctx, utils.GenerateCacheKey("product", first)).Return(nil)
+	cacheMock.On("Delete", ctx, utils.GenerateCacheKey("product", second)).Return(nil)
+
+	changed, err := svc.ApplyPriceSchedules(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+	dbMock.AssertExpectations(t)
+	cacheMock.AssertExpectations(t)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
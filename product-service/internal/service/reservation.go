@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/utils"
+)
+
+const (
+	DefaultReservationTTL = 15 * time.Minute
+	MaxReservationTTL     = time.Hour
+	maxReservationItems   = 100
+	// sweepBatchSize is the number of reservation items released by the
+	// sweeper in one transaction.
+	sweepBatchSize = 500
+)
+
+// ReserveStock holds stock for an order. The reservations are made by the
+// checkout of the gateway, so like the rest of them it is refused to users
+// other than admins.
+func (s *ProductService) ReserveStock(ctx context.Context, req *entity.ReserveStockRequest) (*entity.Reservation, error) {
+	const op = "ProductService.ReserveStock"
+
+	if err := requireSystem(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	items, err := validateReservation(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = DefaultReservationTTL
+	}
+
+	now := time.Now()
+	reservation, err := s.db.ReserveStock(ctx, &entity.Reservation{
+		OrderId:   req.OrderId,
+		Items:     items,
+		Status:    entity.ReservationActive,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.dropCachedStock(ctx, reservation)
+
+	return reservation, nil
+}
+
+// ReleaseReservation returns the stock held for an order, committed or not,
+// since a checkout that fails after the commit undoes it. It reports false if
+// the order had nothing reserved, so it is safe to retry.
+func (s *ProductService) ReleaseReservation(ctx context.Context, orderID uuid.UUID) (bool, error) {
+	const op = "ProductService.ReleaseReservation"
+
+	if err := requireSystem(ctx); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	reservation, err := s.db.ReleaseReservation(ctx, orderID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if reservation == nil {
+		return false, nil
+	}
+
+	s.dropCachedStock(ctx, reservation)
+
+	return true, nil
+}
+
+func (s *ProductService) CommitReservation(ctx context.Context, orderID uuid.UUID) (*entity.Reservation, error) {
+	const op = "ProductService.CommitReservation"
+
+	if err := requireSystem(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	reservation, err := s.db.CommitReservation(ctx, orderID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return reservation, nil
+}
+
+// ReleaseExpiredReservations returns the stock of every active reservation
+// that has expired and reports the number of orders it was held for.
+func (s *ProductService) ReleaseExpiredReservations(ctx context.Context) (int, error) {
+	const op = "ProductService.ReleaseExpiredReservations"
+
+	orders := map[uuid.UUID]bool{}
+	for {
+		released, err := s.db.ReleaseExpiredReservations(ctx, time.Now(), sweepBatchSize)
+		if err != nil {
+			return len(orders), fmt.Errorf("%s: %w", op, err)
+		}
+
+		items := 0
+		for _, reservation := range released {
+			orders[reservation.OrderId] = true
+			items += len(reservation.Items)
+			s.dropCachedStock(ctx, reservation)
+		}
+
+		if items < sweepBatchSize {
+			return len(orders), nil
+		}
+	}
+}
+
+// requireSystem lets only the gateway, or an admin, change reservations.
+func requireSystem(ctx context.Context) error {
+	caller, ok := entity.CallerFromContext(ctx)
+	if !ok {
+		return entity.ErrUnauthenticated
+	}
+	if !caller.IsSystem() && !caller.IsAdmin() {
+		return entity.ErrPermissionDenied
+	}
+
+	return nil
+}
+
+// dropCachedStock evicts the cached products whose stock the reservation
+// changed. Eviction is best effort: the stock has already changed, and a
+// stale entry expires on its own.
+func (s *ProductService) dropCachedStock(ctx context.Context, reservation *entity.Reservation) {
+	for _, item := range reservation.Items {
+		_ = s.cache.Delete(ctx, utils.GenerateCacheKey("product", item.ProductId))
+	}
+}
+
+// validateReservation checks a reservation request and merges the items that
+// reserve the same product or variant.
+func validateReservation(req *entity.ReserveStockRequest) ([]entity.ReservationItem, error) {
+	if req.OrderId == uuid.Nil {
+		return nil, fmt.Errorf("%w: order_id is required", entity.ErrInvalidReservation)
+	}
+
+	if len(req.Items) == 0 || len(req.Items) > maxReservationItems {
+		return nil, fmt.Errorf("%w: between 1 and %d items are required", entity.ErrInvalidReservation, maxReservationItems)
+	}
+
+	if req.TTL < 0 || req.TTL > MaxReservationTTL {
+		return nil, fmt.Errorf("%w: ttl must not exceed %s", entity.ErrInvalidReservation, MaxReservationTTL)
+	}
+
+	type key struct {
+		product uuid.UUID
+		variant uuid.UUID
+	}
+
+	items := make([]entity.ReservationItem, 0, len(req.Items))
+	seen := map[key]int{}
+	for _, item := range req.Items {
+		if item.ProductId == uuid.Nil {
+			return nil, fmt.Errorf("%w: product_id is required", entity.ErrInvalidReservation)
+		}
+
+		if item.Quantity < 1 {
+			return nil, fmt.Errorf("%w: quantity of %s must be positive", entity.ErrInvalidReservation, item.ProductId)
+		}
+
+		k := key{product: item.ProductId}
+		if item.VariantId != nil {
+			k.variant = *item.VariantId
+		}
+
+		if i, ok := seen[k]; ok {
+			items[i].Quantity += item.Quantity
+			continue
+		}
+
+		seen[k] = len(items)
+		items = append(items, entity.ReservationItem{ProductId: item.ProductId, VariantId: item.VariantId, Quantity: item.Quantity})
+	}
+
+	return items, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/utils"
+)
+
+func TestService_ReserveStock(t *testing.T) {
+	ctx := entity.WithCaller(context.Background(), entity.Caller{Role: entity.RoleSystem})
+	orderID, productID, variantID := uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("merges items and applies the default ttl", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		svc := NewProductService(dbMock, cacheMock)
+
+		reserved := &entity.Reservation{
+			OrderId: orderID,
+			Items: []entity.ReservationItem{
+				{ProductId: productID, Quantity: 3, Price: 100},
+				{ProductId: productID, VariantId: &variantID, Quantity: 1, Price: 150},
+			},
+			Status: entity.ReservationActive,
+		}
+		dbMock.On("ReserveStock", ctx, mock.MatchedBy(func(r *entity.Reservation) bool {
+			ttl := r.ExpiresAt.Sub(r.CreatedAt)
+			return r.OrderId == orderID && len(r.Items) == 2 && r.Items[0].Quantity == 3 &&
+				*r.Items[1].VariantId == variantID && ttl == DefaultReservationTTL
+		})).Return(reserved, nil)
+		cacheMock.On("Delete", ctx, utils.GenerateCacheKey("product", productID)).Return(nil).Twice()
+
+		reservation, err := svc.ReserveStock(ctx, &entity.ReserveStockRequest{
+			OrderId: orderID,
+			Items: []entity.ReservationItem{
+				{ProductId: productID, Quantity: 1},
+				{ProductId: productID, VariantId: &variantID, Quantity: 1},
+				{ProductId: productID, Quantity: 2},
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, reserved, reservation)
+		dbMock.AssertExpectations(t)
+		cacheMock.AssertExpectations(t)
+	})
+
+	t.Run("insufficient stock", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, new(MockCache))
+
+		dbMock.On("ReserveStock", ctx, mock.Anything).Return(nil, entity.ErrInsufficientStock)
+
+		_, err := svc.ReserveStock(ctx, &entity.ReserveStockRequest{
+			OrderId: orderID,
+			Items:   []entity.ReservationItem{{ProductId: productID, Quantity: 1}},
+		})
+
+		assert.ErrorIs(t, err, entity.ErrInsufficientStock)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		for name, req := range map[string]*entity.ReserveStockRequest{
+			"no order":      {Items: []entity.ReservationItem{{ProductId: productID, Quantity: 1}}},
+			"no items":      {OrderId: orderID},
+			"zero quantity": {OrderId: orderID, Items: []entity.ReservationItem{{ProductId: productID}}},
+			"ttl too long":  {OrderId: orderID, Items: []entity.ReservationItem{{ProductId: productID, Quantity: 1}}, TTL: 2 * MaxReservationTTL},
+		} {
+			_, err := svc.ReserveStock(ctx, req)
+			assert.ErrorIs(t, err, entity.ErrInvalidReservation, name)
+		}
+	})
+}
+
+func TestService_ReleaseReservation(t *testing.T) {
+	ctx := entity.WithCaller(context.Background(), entity.Caller{Role: entity.RoleSystem})
+	orderID := uuid.New()
+
+	t.Run("nothing to release", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("ReleaseReservation", ctx, orderID, mock.Anything).Return(nil, nil)
+
+		released, err := svc.ReleaseReservation(ctx, orderID)
+
+		assert.NoError(t, err)
+		assert.False(t, released)
+	})
+}
+
+func TestService_ReservationAccess(t *testing.T) {
+	orderID := uuid.New()
+	req := &entity.ReserveStockRequest{OrderId: orderID, Items: []entity.ReservationItem{{ProductId: uuid.New(), Quantity: 1}}}
+	svc := NewProductService(nil, nil)
+
+	for name, tc := range map[string]struct {
+		ctx context.Context
+		err error
+	}{
+		"anonymous": {context.Background(), entity.ErrUnauthenticated},
+		"users":     {entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: "client"}), entity.ErrPermissionDenied},
+	} {
+		_, err := svc.ReserveStock(tc.ctx, req)
+		assert.ErrorIs(t, err, tc.err, name)
+
+		_, err = svc.ReleaseReservation(tc.ctx, orderID)
+		assert.ErrorIs(t, err, tc.err, name)
+
+		_, err = svc.CommitReservation(tc.ctx, orderID)
+		assert.ErrorIs(t, err, tc.err, name)
+	}
+}
+
+func TestService_ReleaseExpiredReservations(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("sweeps in batches until one is not full", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		svc := NewProductService(dbMock, cacheMock)
+
+		full := make([]entity.ReservationItem, sweepBatchSize)
+		for i := range full {
+			full[i] = entity.ReservationItem{ProductId: uuid.New(), Quantity: 1}
+		}
+		first := []*entity.Reservation{{OrderId: uuid.New(), Items: full}}
+		second := []*entity.Reservation{{OrderId: uuid.New(), Items: []entity.ReservationItem{{ProductId: uuid.New(), Quantity: 1}}}}
+
+		dbMock.On("ReleaseExpiredReservations", ctx, mock.AnythingOfType("time.Time"), uint64(sweepBatchSize)).Return(first, nil).Once()
+		dbMock.On("ReleaseExpiredReservations", ctx, mock.AnythingOfType("time.Time"), uint64(sweepBatchSize)).Return(second, nil).Once()
+		cacheMock.On("Delete", ctx, mock.Anything).Return(nil)
+
+		released, err := svc.ReleaseExpiredReservations(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, released)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("nothing expired", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("ReleaseExpiredReservations", ctx, mock.Anything, mock.Anything).Return([]*entity.Reservation{}, nil)
+
+		released, err := svc.ReleaseExpiredReservations(ctx)
+
+		assert.NoError(t, err)
+		assert.Zero(t, released)
+		dbMock.AssertNumberOfCalls(t, "ReleaseExpiredReservations", 1)
+	})
+}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+const (
+	DefaultReviewsPageSize = 20
+	MaxReviewsPageSize     = 100
+
+	DefaultReportedReviews = 50
+	MaxReportedReviews     = 100
+
+	maxReviewImages = 5
+)
+
+// CreateReview publishes the review of the caller. The order is checked by
+// the gateway, which alone calls this: it must be a delivered order of the
+// caller with the product in it.
+func (s *ProductService) CreateReview(ctx context.Context, req *entity.CreateReviewRequest) (*entity.Review, error) {
+	const op = "ProductService.CreateReview"
+
+	caller, ok := entity.CallerFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrUnauthenticated)
+	}
+
+	if req.OrderId == uuid.Nil {
+		return nil, fmt.Errorf("%s: %w: the order is required", op, entity.ErrInvalidReview)
+	}
+	if req.Rating < entity.MinRating || req.Rating > entity.MaxRating {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidRating)
+	}
+
+	text := strings.TrimSpace(req.Text)
+	if utf8.RuneCountInString(text) > entity.MaxReviewTextLength {
+		return nil, fmt.Errorf("%s: %w: the text must not exceed %d characters", op, entity.ErrInvalidReview, entity.MaxReviewTextLength)
+	}
+
+	now := time.Now()
+	review, err := s.db.CreateReview(ctx, &entity.Review{
+		Id:        entity.GenerateID(),
+		ProductId: req.ProductId,
+		UserId:    caller.UserId,
+		OrderId:   req.OrderId,
+		Rating:    req.Rating,
+		Text:      text,
+		Status:    entity.ReviewPublished,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.dropCachedProduct(ctx, req.ProductId)
+
+	return review, nil
+}
+
+// ListProductReviews lists the published reviews of a product newest
+// first, starting after the review the page token points at.
+func (s *ProductService) ListProductReviews(ctx context.Context, productID uuid.UUID, pageSize int, pageToken string) (*entity.ReviewPage, error) {
+	const op = "ProductService.ListProductReviews"
+
+	switch {
+	case pageSize < 0:
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidPageSize)
+	case pageSize == 0:
+		pageSize = DefaultReviewsPageSize
+	case pageSize > MaxReviewsPageSize:
+		pageSize = MaxReviewsPageSize
+	}
+
+	var after *entity.ReviewCursor
+	if pageToken != "" {
+		cursor, err := entity.ParseProductCursor(pageToken)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		after = &cursor
+	}
+
+	// One more review tells whether there is a next page.
+	reviews, err := s.db.ListReviewPage(ctx, productID, after, pageSize+1)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := &entity.ReviewPage{Reviews: reviews}
+	if len(reviews) > pageSize {
+		page.Reviews = reviews[:pageSize]
+		page.NextPageToken = entity.ReviewCursorOf(page.Reviews[pageSize-1]).Token()
+	}
+
+	if err := s.attachReviewImages(ctx, page.Reviews...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return page, nil
+}
+
+// DeleteReview deletes a review of the caller, or any review for admins.
+func (s *ProductService) DeleteReview(ctx context.Context, id uuid.UUID) (bool, error) {
+	const op = "ProductService.DeleteReview"
+
+	review, err := s.checkAuthor(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	images, err := s.db.ListReviewImages(ctx, []uuid.UUID{id})
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.DeleteReview(ctx, id); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if s.images != nil {
+		var keys []string
+		for _, image := range images {
+			keys = append(keys, image.Key)
+			for _, thumbnail := range image.Thumbnails {
+				keys = append(keys, thumbnail.Key)
+			}
+		}
+		s.deleteBlobs(keys)
+	}
+	s.dropCachedProduct(ctx, review.ProductId)
+
+	return true, nil
+}
+
+// UploadReviewImage adds an image to a review of the caller.
+func (s *ProductService) UploadReviewImage(ctx context.Context, req *entity.UploadReviewImageRequest) (*entity.ReviewImage, error) {
+	const op = "ProductService.UploadReviewImage"
+
+	if s.images == nil {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrImagesNotSupported)
+	}
+
+	if _, err := s.checkAuthor(ctx, req.ReviewId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The limit is checked again on insert, this check only saves decoding
+	// an image that can't be added.
+	existing, err := s.db.ListReviewImages(ctx, []uuid.UUID{req.ReviewId})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(existing) >= maxReviewImages {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrTooManyReviewImages)
+	}
+
+	id := entity.GenerateID()
+	stored, err := s.storeImage(ctx, fmt.Sprintf("reviews/%s/%s/", req.ReviewId, id), req.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := s.db.CreateReviewImage(ctx, &entity.ReviewImage{
+		Id:          id,
+		ReviewId:    req.ReviewId,
+		ContentType: stored.ContentType,
+		Width:       stored.Width,
+		Height:      stored.Height,
+		Size:        int64(len(req.Data)),
+		Key:         stored.Key,
+		Thumbnails:  stored.Thumbnails,
+		CreatedAt:   time.Now(),
+	}, maxReviewImages)
+	if err != nil {
+		s.deleteBlobs(stored.keys)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.resolveReviewImageURLs(created)
+
+	return created, nil
+}
+
+// ReplyToReview sets the reply of the seller of the product to a review.
+func (s *ProductService) ReplyToReview(ctx context.Context, id uuid.UUID, text string) (*entity.Review, error) {
+	const op = "ProductService.ReplyToReview"
+
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > entity.MaxReviewReplyLength {
+		return nil, fmt.Errorf("%s: %w: the reply must have from 1 to %d characters", op, entity.ErrInvalidReviewReply, entity.MaxReviewReplyLength)
+	}
+
+	review, err := s.db.GetReview(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.checkOwner(ctx, review.ProductId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	review, err = s.db.ReplyToReview(ctx, id, text, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.attachReviewImages(ctx, review); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return review, nil
+}
+
+// ReportReview flags a review for moderation on behalf of the caller.
+func (s *ProductService) ReportReview(ctx context.Context, id uuid.UUID, reason string) error {
+	const op = "ProductService.ReportReview"
+
+	caller, ok := entity.CallerFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%s: %w", op, entity.ErrUnauthenticated)
+	}
+
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > entity.MaxReportReasonLength {
+		return fmt.Errorf("%s: %w: the reason must not exceed %d characters", op, entity.ErrInvalidReport, entity.MaxReportReasonLength)
+	}
+
+	err := s.db.ReportReview(ctx, &entity.ReviewReport{
+		ReviewId:  id,
+		UserId:    caller.UserId,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ListReportedReviews returns the moderation queue to admins.
+func (s *ProductService) ListReportedReviews(ctx context.Context, limit int) ([]*entity.Review, error) {
+	const op = "ProductService.ListReportedReviews"
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch {
+	case limit < 0:
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidPageSize)
+	case limit == 0:
+		limit = DefaultReportedReviews
+	case limit > MaxReportedReviews:
+		limit = MaxReportedReviews
+	}
+
+	reviews, err := s.db.ListReportedReviews(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.attachReviewImages(ctx, reviews...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return reviews, nil
+}
+
+// ModerateReview publishes or hides a review and closes its reports.
+func (s *ProductService) ModerateReview(ctx context.Context, id uuid.UUID, status entity.ReviewStatus) (*entity.Review, error) {
+	const op = "ProductService.ModerateReview"
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if status != entity.ReviewPublished && status != entity.ReviewHidden {
+		return nil, fmt.Errorf("%s: %w: the status must be published or hidden", op, entity.ErrInvalidReview)
+	}
+
+	review, err := s.db.ModerateReview(ctx, id, status, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.dropCachedProduct(ctx, review.ProductId)
+
+	if err := s.attachReviewImages(ctx, review); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return review, nil
+}
+
+// checkAuthor returns the review if the caller wrote it or is an admin.
+func (s *ProductService) checkAuthor(ctx context.Context, id uuid.UUID) (*entity.Review, error) {
+	caller, ok := entity.CallerFromContext(ctx)
+	if !ok {
+		return nil, entity.ErrUnauthenticated
+	}
+
+	review, err := s.db.GetReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserId != caller.UserId && !caller.IsAdmin() {
+		return nil, entity.ErrPermissionDenied
+	}
+
+	return review, nil
+}
+
+// attachReviewImages loads the images of the reviews in one query.
+func (s *ProductService) attachReviewImages(ctx context.Context, reviews ...*entity.Review) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(reviews))
+	byID := make(map[uuid.UUID]*entity.Review, len(reviews))
+	for _, review := range reviews {
+		ids = append(ids, review.Id)
+		byID[review.Id] = review
+	}
+
+	images, err := s.db.ListReviewImages(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		if review, ok := byID[image.ReviewId]; ok {
+			review.Images = append(review.Images, image)
+		}
+	}
+	s.resolveReviewImageURLs(images...)
+
+	return nil
+}
+
+// resolveReviewImageURLs sets the URLs of the images, as resolveImageURLs
+// does for product images.
+func (s *ProductService) resolveReviewImageURLs(images ...*entity.ReviewImage) {
+	if s.images == nil {
+		return
+	}
+
+	for _, image := range images {
+		image.URL = s.images.URL(image.Key)
+		for i := range image.Thumbnails {
+			image.Thumbnails[i].URL = s.images.URL(image.Thumbnails[i].Key)
+		}
+	}
+}
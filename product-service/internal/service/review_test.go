@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/utils"
+)
+
+func TestService_CreateReview(t *testing.T) {
+	buyerID, productID, orderID := uuid.New(), uuid.New(), uuid.New()
+	ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: buyerID, Role: "client"})
+
+	t.Run("the review is published for the caller", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		svc := NewProductService(dbMock, cacheMock)
+
+		dbMock.On("CreateReview", ctx, mock.MatchedBy(func(r *entity.Review) bool {
+			return r.UserId == buyerID && r.ProductId == productID && r.OrderId == orderID &&
+				r.Rating == 4 && r.Text == "Хороший товар" && r.Status == entity.ReviewPublished
+		})).Return(&entity.Review{ProductId: productID, Rating: 4}, nil)
+		cacheMock.On("Delete", ctx, utils.GenerateCacheKey("product", productID)).Return(nil)
+
+		review, err := svc.CreateReview(ctx, &entity.CreateReviewRequest{ProductId: productID, OrderId: orderID, Rating: 4, Text: "  Хороший товар "})
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, review.Rating)
+		dbMock.AssertExpectations(t)
+		cacheMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid reviews", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		for name, tc := range map[string]struct {
+			req *entity.CreateReviewRequest
+			err error
+		}{
+			"no order":      {&entity.CreateReviewRequest{ProductId: productID, Rating: 5}, entity.ErrInvalidReview},
+			"zero rating":   {&entity.CreateReviewRequest{ProductId: productID, OrderId: orderID}, entity.ErrInvalidRating},
+			"rating over 5": {&entity.CreateReviewRequest{ProductId: productID, OrderId: orderID, Rating: 6}, entity.ErrInvalidRating},
+			"text too long": {&entity.CreateReviewRequest{ProductId: productID, OrderId: orderID, Rating: 5, Text: strings.Repeat("а", entity.MaxReviewTextLength+1)}, entity.ErrInvalidReview},
+		} {
+			_, err := svc.CreateReview(ctx, tc.req)
+			assert.ErrorIs(t, err, tc.err, name)
+		}
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.CreateReview(context.Background(), &entity.CreateReviewRequest{ProductId: productID, OrderId: orderID, Rating: 5})
+
+		assert.ErrorIs(t, err, entity.ErrUnauthenticated)
+	})
+}
+
+func TestService_ListProductReviews(t *testing.T) {
+	ctx := context.Background()
+	productID := uuid.New()
+
+	now := time.Now().Truncate(time.Microsecond)
+	reviews := make([]*entity.Review, 3)
+	for i := range reviews {
+		reviews[i] = &entity.Review{Id: uuid.New(), ProductId: productID, CreatedAt: now.Add(-time.Duration(i) * time.Minute)}
+	}
+
+	t.Run("the next page starts after the last review", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("ListReviewPage", ctx, productID, (*entity.ReviewCursor)(nil), 3).Return(reviews, nil)
+		dbMock.On("ListReviewImages", ctx, []uuid.UUID{reviews[0].Id, reviews[1].Id}).Return([]*entity.ReviewImage{}, nil)
+
+		page, err := svc.ListProductReviews(ctx, productID, 2, "")
+
+		require.NoError(t, err)
+		assert.Len(t, page.Reviews, 2)
+		assert.Equal(t, entity.ReviewCursorOf(reviews[1]).Token(), page.NextPageToken)
+
+		dbMock.On("ListReviewPage", ctx, productID, mock.MatchedBy(func(c *entity.ReviewCursor) bool {
+			return c.Id == reviews[1].Id && c.CreatedAt.Equal(reviews[1].CreatedAt)
+		}), 3).Return(reviews[2:], nil)
+		dbMock.On("ListReviewImages", ctx, []uuid.UUID{reviews[2].Id}).Return([]*entity.ReviewImage{}, nil)
+
+		page, err = svc.ListProductReviews(ctx, productID, 2, page.NextPageToken)
+
+		require.NoError(t, err)
+		assert.Len(t, page.Reviews, 1)
+		assert.Empty(t, page.NextPageToken)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.ListProductReviews(ctx, productID, -1, "")
+		assert.ErrorIs(t, err, entity.ErrInvalidPageSize)
+
+		_, err = svc.ListProductReviews(ctx, productID, 10, "garbage")
+		assert.ErrorIs(t, err, entity.ErrInvalidPageToken)
+	})
+}
+
+func TestService_DeleteReview(t *testing.T) {
+	authorID, productID, reviewID := uuid.New(), uuid.New(), uuid.New()
+	review := &entity.Review{Id: reviewID, ProductId: productID, UserId: authorID}
+
+	t.Run("the author deletes the review", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: authorID, Role: "client"})
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		svc := NewProductService(dbMock, cacheMock)
+
+		dbMock.On("GetReview", ctx, reviewID).Return(review, nil)
+		dbMock.On("ListReviewImages", ctx, []uuid.UUID{reviewID}).Return([]*entity.ReviewImage{}, nil)
+		dbMock.On("DeleteReview", ctx, reviewID).Return(nil)
+		cacheMock.On("Delete", ctx, utils.GenerateCacheKey("product", productID)).Return(nil)
+
+		success, err := svc.DeleteReview(ctx, reviewID)
+
+		require.NoError(t, err)
+		assert.True(t, success)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("other users can't", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: "client"})
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("GetReview", ctx, reviewID).Return(review, nil)
+
+		_, err := svc.DeleteReview(ctx, reviewID)
+
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+		dbMock.AssertNotCalled(t, "DeleteReview", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_ReplyToReview(t *testing.T) {
+	sellerID, productID, reviewID := uuid.New(), uuid.New(), uuid.New()
+	review := &entity.Review{Id: reviewID, ProductId: productID, UserId: uuid.New()}
+
+	t.Run("the seller replies", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID, Role: "client"})
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("GetReview", ctx, reviewID).Return(review, nil)
+		dbMock.On("Get", ctx, productID).Return(&entity.Product{Id: productID, SellerId: sellerID}, nil)
+		dbMock.On("ReplyToReview", ctx, reviewID, "Спасибо!", mock.Anything).Return(&entity.Review{Id: reviewID, Reply: "Спасибо!"}, nil)
+		dbMock.On("ListReviewImages", ctx, []uuid.UUID{reviewID}).Return([]*entity.ReviewImage{}, nil)
+
+		replied, err := svc.ReplyToReview(ctx, reviewID, " Спасибо! ")
+
+		require.NoError(t, err)
+		assert.Equal(t, "Спасибо!", replied.Reply)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("only the seller replies", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: review.UserId, Role: "client"})
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("GetReview", ctx, reviewID).Return(review, nil)
+		dbMock.On("Get", ctx, productID).Return(&entity.Product{Id: productID, SellerId: sellerID}, nil)
+
+		_, err := svc.ReplyToReview(ctx, reviewID, "Спасибо!")
+
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+		dbMock.AssertNotCalled(t, "ReplyToReview", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("empty reply", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.ReplyToReview(context.Background(), reviewID, "   ")
+
+		assert.ErrorIs(t, err, entity.ErrInvalidReviewReply)
+	})
+}
+
+func TestService_ModerateReview(t *testing.T) {
+	productID, reviewID := uuid.New(), uuid.New()
+
+	t.Run("admins hide reviews", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: entity.RoleAdmin})
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		svc := NewProductService(dbMock, cacheMock)
+
+		dbMock.On("ModerateReview", ctx, reviewID, entity.ReviewHidden, mock.Anything).
+			Return(&entity.Review{Id: reviewID, ProductId: productID, Status: entity.ReviewHidden}, nil)
+		dbMock.On("ListReviewImages", ctx, []uuid.UUID{reviewID}).Return([]*entity.ReviewImage{}, nil)
+		cacheMock.On("Delete", ctx, utils.GenerateCacheKey("product", productID)).Return(nil)
+
+		review, err := svc.ModerateReview(ctx, reviewID, entity.ReviewHidden)
+
+		require.NoError(t, err)
+		assert.Equal(t, entity.ReviewHidden, review.Status)
+		dbMock.AssertExpectations(t)
+		cacheMock.AssertExpectations(t)
+	})
+
+	t.Run("clients can't moderate", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: "client"})
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.ModerateReview(ctx, reviewID, entity.ReviewHidden)
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+
+		_, err = svc.ListReportedReviews(ctx, 0)
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+	})
+}
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
@@ -12,12 +14,85 @@ import (
 
 const duration = time.Second * 30
 
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	maxQueryLength     = 200
+)
+
+const (
+	DefaultProductsPageSize = 20
+	MaxProductsPageSize     = 100
+)
+
+// priceFacetBounds split prices into the ranges of the search price facet.
+var priceFacetBounds = []int64{1_000_00, 5_000_00, 10_000_00, 50_000_00}
+
 type Database interface {
 	Create(ctx context.Context, product *entity.Product) (uuid.UUID, error)
 	Get(ctx context.Context, id uuid.UUID) (*entity.Product, error)
-	Update(ctx context.Context, product *entity.Product) (*entity.Product, error)
-	Delete(ctx context.Context, id uuid.UUID) error
+	Update(ctx context.Context, product *entity.Product, unchangedSince time.Time) (*entity.Product, error)
 	List(ctx context.Context, offset, limit int64) ([]*entity.Product, error)
+	ListPage(ctx context.Context, after *entity.ProductCursor, limit int) ([]*entity.Product, error)
+	EstimateCount(ctx context.Context) (int64, error)
+	ListBySeller(ctx context.Context, sellerID uuid.UUID, statuses []entity.ProductStatus, offset, limit int64) ([]*entity.Product, error)
+	ListSellerPage(ctx context.Context, sellerID uuid.UUID, after *entity.ProductCursor, limit int) ([]*entity.Product, error)
+	Search(ctx context.Context, req *entity.SearchProductsRequest, facetBounds []int64) (*entity.SearchResult, error)
+
+	ChangeProductStatus(ctx context.Context, change *entity.ProductStatusChange) (*entity.Product, error)
+	ListProductStatusHistory(ctx context.Context, productID uuid.UUID) ([]*entity.ProductStatusChange, error)
+	ListStatusPage(ctx context.Context, status entity.ProductStatus, after *entity.ProductCursor, limit int) ([]*entity.Product, error)
+
+	CreateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error)
+	GetCategory(ctx context.Context, id uuid.UUID) (*entity.Category, error)
+	UpdateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error)
+	DeleteCategory(ctx context.Context, id uuid.UUID) error
+	ListCategories(ctx context.Context) ([]*entity.Category, error)
+	ListCategoryProducts(ctx context.Context, categoryID uuid.UUID, offset, limit int64) ([]*entity.Product, error)
+	SetProductCategories(ctx context.Context, req *entity.SetProductCategoriesRequest) error
+	GetProductCategories(ctx context.Context, productID uuid.UUID) (*entity.ProductCategories, error)
+
+	CreateAttributeDefinition(ctx context.Context, def *entity.AttributeDefinition) (*entity.AttributeDefinition, error)
+	ListAttributeDefinitions(ctx context.Context, categoryID uuid.UUID) ([]*entity.AttributeDefinition, error)
+	DeleteAttributeDefinition(ctx context.Context, id uuid.UUID) error
+	CreateVariant(ctx context.Context, variant *entity.Variant) (*entity.Variant, error)
+	GetVariant(ctx context.Context, id uuid.UUID) (*entity.Variant, error)
+	UpdateVariant(ctx context.Context, variant *entity.Variant) (*entity.Variant, error)
+	DeleteVariant(ctx context.Context, id uuid.UUID) error
+	ListVariants(ctx context.Context, productID uuid.UUID) ([]*entity.Variant, error)
+
+	ReserveStock(ctx context.Context, reservation *entity.Reservation) (*entity.Reservation, error)
+	ReleaseReservation(ctx context.Context, orderID uuid.UUID, now time.Time) (*entity.Reservation, error)
+	CommitReservation(ctx context.Context, orderID uuid.UUID, now time.Time) (*entity.Reservation, error)
+	ReleaseExpiredReservations(ctx context.Context, now time.Time, limit uint64) ([]*entity.Reservation, error)
+
+	CreateImage(ctx context.Context, image *entity.ProductImage, maxImages int) (*entity.ProductImage, error)
+	GetImage(ctx context.Context, id uuid.UUID) (*entity.ProductImage, error)
+	DeleteImage(ctx context.Context, id uuid.UUID) error
+	ListImages(ctx context.Context, productIDs []uuid.UUID) ([]*entity.ProductImage, error)
+	ReorderImages(ctx context.Context, productID uuid.UUID, imageIDs []uuid.UUID) error
+
+	CreatePriceSchedule(ctx context.Context, schedule *entity.PriceSchedule) (*entity.PriceSchedule, error)
+	GetPriceSchedule(ctx context.Context, id uuid.UUID) (*entity.PriceSchedule, error)
+	ListPriceSchedules(ctx context.Context, productID uuid.UUID) ([]*entity.PriceSchedule, error)
+	CancelPriceSchedule(ctx context.Context, id uuid.UUID, now time.Time) (*entity.PriceSchedule, error)
+	ApplyPriceSchedules(ctx context.Context, now time.Time, limit uint64) ([]*entity.PriceSchedule, error)
+	ListPriceHistory(ctx context.Context, productID uuid.UUID, since time.Time) ([]*entity.PriceHistoryEntry, error)
+
+	CreateImportJob(ctx context.Context, job *entity.ImportJob, rows []*entity.ImportRow) (*entity.ImportJob, error)
+	GetImportJob(ctx context.Context, id uuid.UUID) (*entity.ImportJob, error)
+	ProcessImportBatch(ctx context.Context, limit int, now time.Time) (*entity.ImportJob, []uuid.UUID, error)
+
+	CreateReview(ctx context.Context, review *entity.Review) (*entity.Review, error)
+	GetReview(ctx context.Context, id uuid.UUID) (*entity.Review, error)
+	ListReviewPage(ctx context.Context, productID uuid.UUID, after *entity.ReviewCursor, limit int) ([]*entity.Review, error)
+	ListReportedReviews(ctx context.Context, limit int) ([]*entity.Review, error)
+	DeleteReview(ctx context.Context, id uuid.UUID) error
+	ReplyToReview(ctx context.Context, id uuid.UUID, reply string, now time.Time) (*entity.Review, error)
+	ReportReview(ctx context.Context, report *entity.ReviewReport) error
+	ModerateReview(ctx context.Context, id uuid.UUID, status entity.ReviewStatus, now time.Time) (*entity.Review, error)
+	CreateReviewImage(ctx context.Context, image *entity.ReviewImage, maxImages int) (*entity.ReviewImage, error)
+	ListReviewImages(ctx context.Context, reviewIDs []uuid.UUID) ([]*entity.ReviewImage, error)
 }
 
 type Cache interface {
@@ -27,31 +102,39 @@ type Cache interface {
 }
 
 type ProductService struct {
-	db    Database
-	cache Cache
-}
-
-func NewProductService(db Database, cache Cache) *ProductService {
-	return &ProductService{db: db, cache: cache}
+	db     Database
+	cache  Cache
+	images ImageStorage
 }
 
-func (s *ProductService) CreateProduct(ctx context.Context, req *entity.CreateProductRequest) (uuid.UUID, error) {
-	const op = "ProductService.Create"
+type Option func(*ProductService)
 
-	if req.Name == "" || len(req.Name) > 99 {
-		return uuid.Nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidName)
+// WithImageStorage enables image uploads.
+func WithImageStorage(images ImageStorage) Option {
+	return func(s *ProductService) {
+		s.images = images
 	}
+}
 
-	if req.Description == "" || len(req.Description) > 9999 {
-		return uuid.Nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidDescription)
+func NewProductService(db Database, cache Cache, opts ...Option) *ProductService {
+	s := &ProductService{db: db, cache: cache}
+	for _, opt := range opts {
+		opt(s)
 	}
 
-	if req.Price < 1 {
-		return uuid.Nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidPrice)
+	return s
+}
+
+func (s *ProductService) CreateProduct(ctx context.Context, req *entity.CreateProductRequest) (uuid.UUID, error) {
+	const op = "ProductService.Create"
+
+	caller, ok := entity.CallerFromContext(ctx)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, entity.ErrUnauthenticated)
 	}
 
-	if req.Stock < 0 {
-		return uuid.Nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidStock)
+	if err := validateProduct(req); err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	id := entity.GenerateID()
@@ -59,10 +142,12 @@ func (s *ProductService) CreateProduct(ctx context.Context, req *entity.CreatePr
 
 	product := &entity.Product{
 		Id:          id,
+		SellerId:    caller.UserId,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
 		Stock:       req.Stock,
+		Status:      entity.ProductDraft,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
@@ -86,6 +171,28 @@ func (s *ProductService) CreateProduct(ctx context.Context, req *entity.CreatePr
 	return createdId, nil
 }
 
+// validateProduct checks a new product, for CreateProduct and for every row
+// of an import.
+func validateProduct(req *entity.CreateProductRequest) error {
+	if req.Name == "" || len(req.Name) > 99 {
+		return entity.ErrInvalidName
+	}
+
+	if req.Description == "" || len(req.Description) > 9999 {
+		return entity.ErrInvalidDescription
+	}
+
+	if req.Price < 1 {
+		return entity.ErrInvalidPrice
+	}
+
+	if req.Stock < 0 {
+		return entity.ErrInvalidStock
+	}
+
+	return nil
+}
+
 func (s *ProductService) GetProduct(ctx context.Context, id uuid.UUID) (*entity.Product, error) {
 	const op = "ProductService.Get"
 
@@ -98,6 +205,10 @@ func (s *ProductService) GetProduct(ctx context.Context, id uuid.UUID) (*entity.
 		if err != nil {
 			return &entity.Product{}, fmt.Errorf("%s: %w", op, err)
 		}
+		if !canView(ctx, product) {
+			return &entity.Product{}, fmt.Errorf("%s: %w", op, entity.ErrProductNotFound)
+		}
+		s.resolveImageURLs(product.Images...)
 
 		return product, nil
 	}
@@ -106,6 +217,13 @@ func (s *ProductService) GetProduct(ctx context.Context, id uuid.UUID) (*entity.
 	if err != nil {
 		return &entity.Product{}, fmt.Errorf("%s: %w", op, err)
 	}
+	if !canView(ctx, product) {
+		return &entity.Product{}, fmt.Errorf("%s: %w", op, entity.ErrProductNotFound)
+	}
+
+	if err := s.attachImages(ctx, product); err != nil {
+		return &entity.Product{}, fmt.Errorf("%s: %w", op, err)
+	}
 
 	cacheKey = utils.GenerateCacheKey("product", product.Id)
 	productSerialized, err := utils.Serialize(product)
@@ -140,6 +258,14 @@ func (s *ProductService) UpdateProduct(ctx context.Context, req *entity.UpdatePr
 		return &entity.Product{}, fmt.Errorf("%s: %w", op, entity.ErrInvalidStock)
 	}
 
+	if req.UpdatedAt.IsZero() {
+		return &entity.Product{}, fmt.Errorf("%s: %w", op, entity.ErrInvalidUpdatedAt)
+	}
+
+	if err := s.checkOwner(ctx, req.Id); err != nil {
+		return &entity.Product{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	now := time.Now()
 
 	product, err := s.db.Update(ctx, &entity.Product{
@@ -149,11 +275,15 @@ func (s *ProductService) UpdateProduct(ctx context.Context, req *entity.UpdatePr
 		Price:       req.Price,
 		Stock:       req.Stock,
 		UpdatedAt:   now,
-	})
+	}, req.UpdatedAt)
 	if err != nil {
 		return &entity.Product{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err := s.attachImages(ctx, product); err != nil {
+		return &entity.Product{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	cacheKey := utils.GenerateCacheKey("product", req.Id)
 	productSerialized, err := utils.Serialize(product)
 	if err != nil {
@@ -168,13 +298,22 @@ func (s *ProductService) UpdateProduct(ctx context.Context, req *entity.UpdatePr
 	return product, nil
 }
 
+// DeleteProduct moves a product to the deleted status. The row stays for the
+// orders and reviews that refer to it.
 func (s *ProductService) DeleteProduct(ctx context.Context, id uuid.UUID) (bool, error) {
 	const op = "ProductService.Delete"
 
-	err := s.db.Delete(ctx, id)
+	product, err := s.ownedProduct(ctx, id)
 	if err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
+	if product.Status == entity.ProductDeleted {
+		return false, fmt.Errorf("%s: %w", op, entity.ErrProductNotFound)
+	}
+
+	if _, err := s.changeStatus(ctx, product, entity.ProductDeleted, ""); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
 
 	cacheKey := utils.GenerateCacheKey("product", id)
 	err = s.cache.Delete(ctx, cacheKey)
@@ -185,13 +324,186 @@ func (s *ProductService) DeleteProduct(ctx context.Context, id uuid.UUID) (bool,
 	return true, nil
 }
 
+// ListProduct lists a page of the catalog by offset. It is kept for older
+// clients, ListProductsPage doesn't slow down on deep pages.
 func (s *ProductService) ListProduct(ctx context.Context, offset, limit int64) ([]*entity.Product, error) {
 	const op = "ProductService.List"
 
+	if offset < 0 || limit < 0 {
+		return []*entity.Product{}, fmt.Errorf("%s: %w", op, entity.ErrInvalidPage)
+	}
+	limit = min(limit, MaxProductsPageSize)
+
 	products, err := s.db.List(ctx, offset, limit)
 	if err != nil {
 		return []*entity.Product{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err := s.attachImages(ctx, products...); err != nil {
+		return []*entity.Product{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return products, nil
+}
+
+// ListProductsPage lists the catalog newest first, starting after the
+// product the page token points at.
+func (s *ProductService) ListProductsPage(ctx context.Context, pageSize int, pageToken string) (*entity.ProductPage, error) {
+	const op = "ProductService.ListProductsPage"
+
+	page, err := s.productPage(ctx, pageSize, pageToken, func(ctx context.Context, after *entity.ProductCursor, limit int) ([]*entity.Product, error) {
+		return s.db.ListPage(ctx, after, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return page, nil
+}
+
+// productPage reads a page of products with list, which returns up to limit
+// products after the cursor, and attaches their images.
+func (s *ProductService) productPage(ctx context.Context, pageSize int, pageToken string, list func(ctx context.Context, after *entity.ProductCursor, limit int) ([]*entity.Product, error)) (*entity.ProductPage, error) {
+	switch {
+	case pageSize < 0:
+		return nil, entity.ErrInvalidPageSize
+	case pageSize == 0:
+		pageSize = DefaultProductsPageSize
+	case pageSize > MaxProductsPageSize:
+		pageSize = MaxProductsPageSize
+	}
+
+	var after *entity.ProductCursor
+	if pageToken != "" {
+		cursor, err := entity.ParseProductCursor(pageToken)
+		if err != nil {
+			return nil, err
+		}
+		after = &cursor
+	}
+
+	// One more product tells whether there is a next page.
+	products, err := list(ctx, after, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &entity.ProductPage{Products: products}
+	if len(products) > pageSize {
+		page.Products = products[:pageSize]
+		page.NextPageToken = entity.CursorOf(page.Products[pageSize-1]).Token()
+	}
+
+	if err := s.attachImages(ctx, page.Products...); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// EstimateProductCount estimates the number of products in the catalog.
+func (s *ProductService) EstimateProductCount(ctx context.Context) (int64, error) {
+	const op = "ProductService.EstimateProductCount"
+
+	count, err := s.db.EstimateCount(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// ListProductsBySeller lists the published products of a seller. The seller
+// and admins also see the products that aren't on sale, except the deleted
+// ones.
+func (s *ProductService) ListProductsBySeller(ctx context.Context, sellerID uuid.UUID, offset, limit int64) ([]*entity.Product, error) {
+	const op = "ProductService.ListProductsBySeller"
+
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+	if offset < 0 || limit < 0 || limit > MaxSearchLimit {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidPage)
+	}
+
+	statuses := []entity.ProductStatus{entity.ProductPublished}
+	if caller, ok := entity.CallerFromContext(ctx); ok && (caller.IsAdmin() || caller.UserId == sellerID) {
+		statuses = sellerStatuses
+	}
+
+	products, err := s.db.ListBySeller(ctx, sellerID, statuses, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return products, nil
 }
+
+func (s *ProductService) SearchProducts(ctx context.Context, req *entity.SearchProductsRequest) (*entity.SearchResult, error) {
+	const op = "ProductService.Search"
+
+	req.Query = strings.TrimSpace(req.Query)
+	if utf8.RuneCountInString(req.Query) > maxQueryLength {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidQuery)
+	}
+
+	if (req.MinPrice != nil && *req.MinPrice < 0) || (req.MaxPrice != nil && *req.MaxPrice < 0) ||
+		(req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice) {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidPriceRange)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = DefaultSearchLimit
+	}
+	if req.Offset < 0 || req.Limit < 0 || req.Limit > MaxSearchLimit {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrInvalidPage)
+	}
+
+	result, err := s.db.Search(ctx, req, priceFacetBounds)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// checkOwner lets through admins and the seller of the product. Sellers
+// can't change their deleted products.
+func (s *ProductService) checkOwner(ctx context.Context, productID uuid.UUID) error {
+	caller, ok := entity.CallerFromContext(ctx)
+	if !ok {
+		return entity.ErrUnauthenticated
+	}
+	if caller.IsAdmin() {
+		return nil
+	}
+
+	product, err := s.ownedProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if product.Status == entity.ProductDeleted {
+		return entity.ErrProductNotFound
+	}
+
+	return nil
+}
+
+// ownedProduct returns a product, deleted or not, if the caller is an admin
+// or its seller.
+func (s *ProductService) ownedProduct(ctx context.Context, productID uuid.UUID) (*entity.Product, error) {
+	caller, ok := entity.CallerFromContext(ctx)
+	if !ok {
+		return nil, entity.ErrUnauthenticated
+	}
+
+	product, err := s.db.Get(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !caller.IsAdmin() && product.SellerId != caller.UserId {
+		return nil, entity.ErrPermissionDenied
+	}
+
+	return product, nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockDatabase реализует интерфейс Database для тестов
@@ -34,27 +36,411 @@ func (m *MockDatabase) Get(ctx context.Context, id uuid.UUID) (*entity.Product,
 	return args.Get(0).(*entity.Product), args.Error(1)
 }
 
-func (m *MockDatabase) Update(ctx context.Context, product *entity.Product) (*entity.Product, error) {
-	args := m.Called(ctx, product)
+func (m *MockDatabase) Update(ctx context.Context, product *entity.Product, unchangedSince time.Time) (*entity.Product, error) {
+	args := m.Called(ctx, product, unchangedSince)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*entity.Product), args.Error(1)
 }
 
-func (m *MockDatabase) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockDatabase) List(ctx context.Context, offset, limit int64) ([]*entity.Product, error) {
+	args := m.Called(ctx, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Product), args.Error(1)
+}
+
+func (m *MockDatabase) ListPage(ctx context.Context, after *entity.ProductCursor, limit int) ([]*entity.Product, error) {
+	args := m.Called(ctx, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Product), args.Error(1)
+}
+
+func (m *MockDatabase) EstimateCount(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDatabase) ListBySeller(ctx context.Context, sellerID uuid.UUID, statuses []entity.ProductStatus, offset, limit int64) ([]*entity.Product, error) {
+	args := m.Called(ctx, sellerID, statuses, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Product), args.Error(1)
+}
+
+func (m *MockDatabase) ListSellerPage(ctx context.Context, sellerID uuid.UUID, after *entity.ProductCursor, limit int) ([]*entity.Product, error) {
+	args := m.Called(ctx, sellerID, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Product), args.Error(1)
+}
+
+func (m *MockDatabase) Search(ctx context.Context, req *entity.SearchProductsRequest, facetBounds []int64) (*entity.SearchResult, error) {
+	args := m.Called(ctx, req, facetBounds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.SearchResult), args.Error(1)
+}
+
+func (m *MockDatabase) ChangeProductStatus(ctx context.Context, change *entity.ProductStatusChange) (*entity.Product, error) {
+	args := m.Called(ctx, change)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Product), args.Error(1)
+}
+
+func (m *MockDatabase) ListProductStatusHistory(ctx context.Context, productID uuid.UUID) ([]*entity.ProductStatusChange, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.ProductStatusChange), args.Error(1)
+}
+
+func (m *MockDatabase) ListStatusPage(ctx context.Context, status entity.ProductStatus, after *entity.ProductCursor, limit int) ([]*entity.Product, error) {
+	args := m.Called(ctx, status, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Product), args.Error(1)
+}
+
+func (m *MockDatabase) CreateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error) {
+	args := m.Called(ctx, category)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Category), args.Error(1)
+}
+
+func (m *MockDatabase) GetCategory(ctx context.Context, id uuid.UUID) (*entity.Category, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Category), args.Error(1)
+}
+
+func (m *MockDatabase) UpdateCategory(ctx context.Context, category *entity.Category) (*entity.Category, error) {
+	args := m.Called(ctx, category)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Category), args.Error(1)
+}
+
+func (m *MockDatabase) DeleteCategory(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockDatabase) List(ctx context.Context, offset, limit int64) ([]*entity.Product, error) {
-	args := m.Called(ctx, offset, limit)
+func (m *MockDatabase) ListCategories(ctx context.Context) ([]*entity.Category, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Category), args.Error(1)
+}
+
+func (m *MockDatabase) ListCategoryProducts(ctx context.Context, categoryID uuid.UUID, offset, limit int64) ([]*entity.Product, error) {
+	args := m.Called(ctx, categoryID, offset, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*entity.Product), args.Error(1)
 }
 
+func (m *MockDatabase) SetProductCategories(ctx context.Context, req *entity.SetProductCategoriesRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) GetProductCategories(ctx context.Context, productID uuid.UUID) (*entity.ProductCategories, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ProductCategories), args.Error(1)
+}
+
+func (m *MockDatabase) CreateAttributeDefinition(ctx context.Context, def *entity.AttributeDefinition) (*entity.AttributeDefinition, error) {
+	args := m.Called(ctx, def)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockDatabase) ListAttributeDefinitions(ctx context.Context, categoryID uuid.UUID) ([]*entity.AttributeDefinition, error) {
+	args := m.Called(ctx, categoryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockDatabase) DeleteAttributeDefinition(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) CreateVariant(ctx context.Context, variant *entity.Variant) (*entity.Variant, error) {
+	args := m.Called(ctx, variant)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Variant), args.Error(1)
+}
+
+func (m *MockDatabase) GetVariant(ctx context.Context, id uuid.UUID) (*entity.Variant, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Variant), args.Error(1)
+}
+
+func (m *MockDatabase) UpdateVariant(ctx context.Context, variant *entity.Variant) (*entity.Variant, error) {
+	args := m.Called(ctx, variant)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Variant), args.Error(1)
+}
+
+func (m *MockDatabase) DeleteVariant(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) ListVariants(ctx context.Context, productID uuid.UUID) ([]*entity.Variant, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Variant), args.Error(1)
+}
+
+func (m *MockDatabase) ReserveStock(ctx context.Context, reservation *entity.Reservation) (*entity.Reservation, error) {
+	args := m.Called(ctx, reservation)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Reservation), args.Error(1)
+}
+
+func (m *MockDatabase) ReleaseReservation(ctx context.Context, orderID uuid.UUID, now time.Time) (*entity.Reservation, error) {
+	args := m.Called(ctx, orderID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Reservation), args.Error(1)
+}
+
+func (m *MockDatabase) CommitReservation(ctx context.Context, orderID uuid.UUID, now time.Time) (*entity.Reservation, error) {
+	args := m.Called(ctx, orderID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Reservation), args.Error(1)
+}
+
+func (m *MockDatabase) ReleaseExpiredReservations(ctx context.Context, now time.Time, limit uint64) ([]*entity.Reservation, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Reservation), args.Error(1)
+}
+
+func (m *MockDatabase) CreateImage(ctx context.Context, image *entity.ProductImage, maxImages int) (*entity.ProductImage, error) {
+	args := m.Called(ctx, image, maxImages)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ProductImage), args.Error(1)
+}
+
+func (m *MockDatabase) GetImage(ctx context.Context, id uuid.UUID) (*entity.ProductImage, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ProductImage), args.Error(1)
+}
+
+func (m *MockDatabase) DeleteImage(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) ListImages(ctx context.Context, productIDs []uuid.UUID) ([]*entity.ProductImage, error) {
+	args := m.Called(ctx, productIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.ProductImage), args.Error(1)
+}
+
+func (m *MockDatabase) ReorderImages(ctx context.Context, productID uuid.UUID, imageIDs []uuid.UUID) error {
+	args := m.Called(ctx, productID, imageIDs)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) CreatePriceSchedule(ctx context.Context, schedule *entity.PriceSchedule) (*entity.PriceSchedule, error) {
+	args := m.Called(ctx, schedule)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PriceSchedule), args.Error(1)
+}
+
+func (m *MockDatabase) GetPriceSchedule(ctx context.Context, id uuid.UUID) (*entity.PriceSchedule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PriceSchedule), args.Error(1)
+}
+
+func (m *MockDatabase) ListPriceSchedules(ctx context.Context, productID uuid.UUID) ([]*entity.PriceSchedule, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.PriceSchedule), args.Error(1)
+}
+
+func (m *MockDatabase) CancelPriceSchedule(ctx context.Context, id uuid.UUID, now time.Time) (*entity.PriceSchedule, error) {
+	args := m.Called(ctx, id, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PriceSchedule), args.Error(1)
+}
+
+func (m *MockDatabase) ApplyPriceSchedules(ctx context.Context, now time.Time, limit uint64) ([]*entity.PriceSchedule, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.PriceSchedule), args.Error(1)
+}
+
+func (m *MockDatabase) ListPriceHistory(ctx context.Context, productID uuid.UUID, since time.Time) ([]*entity.PriceHistoryEntry, error) {
+	args := m.Called(ctx, productID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.PriceHistoryEntry), args.Error(1)
+}
+
+func (m *MockDatabase) CreateImportJob(ctx context.Context, job *entity.ImportJob, rows []*entity.ImportRow) (*entity.ImportJob, error) {
+	args := m.Called(ctx, job, rows)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ImportJob), args.Error(1)
+}
+
+func (m *MockDatabase) GetImportJob(ctx context.Context, id uuid.UUID) (*entity.ImportJob, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ImportJob), args.Error(1)
+}
+
+func (m *MockDatabase) ProcessImportBatch(ctx context.Context, limit int, now time.Time) (*entity.ImportJob, []uuid.UUID, error) {
+	args := m.Called(ctx, limit, now)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*entity.ImportJob), args.Get(1).([]uuid.UUID), args.Error(2)
+}
+
+func (m *MockDatabase) CreateReview(ctx context.Context, review *entity.Review) (*entity.Review, error) {
+	args := m.Called(ctx, review)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Review), args.Error(1)
+}
+
+func (m *MockDatabase) GetReview(ctx context.Context, id uuid.UUID) (*entity.Review, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Review), args.Error(1)
+}
+
+func (m *MockDatabase) ListReviewPage(ctx context.Context, productID uuid.UUID, after *entity.ReviewCursor, limit int) ([]*entity.Review, error) {
+	args := m.Called(ctx, productID, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Review), args.Error(1)
+}
+
+func (m *MockDatabase) ListReportedReviews(ctx context.Context, limit int) ([]*entity.Review, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Review), args.Error(1)
+}
+
+func (m *MockDatabase) DeleteReview(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) ReplyToReview(ctx context.Context, id uuid.UUID, reply string, now time.Time) (*entity.Review, error) {
+	args := m.Called(ctx, id, reply, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Review), args.Error(1)
+}
+
+func (m *MockDatabase) ReportReview(ctx context.Context, report *entity.ReviewReport) error {
+	args := m.Called(ctx, report)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) ModerateReview(ctx context.Context, id uuid.UUID, status entity.ReviewStatus, now time.Time) (*entity.Review, error) {
+	args := m.Called(ctx, id, status, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Review), args.Error(1)
+}
+
+func (m *MockDatabase) CreateReviewImage(ctx context.Context, image *entity.ReviewImage, maxImages int) (*entity.ReviewImage, error) {
+	args := m.Called(ctx, image, maxImages)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ReviewImage), args.Error(1)
+}
+
+func (m *MockDatabase) ListReviewImages(ctx context.Context, reviewIDs []uuid.UUID) ([]*entity.ReviewImage, error) {
+	args := m.Called(ctx, reviewIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.ReviewImage), args.Error(1)
+}
+
 // MockCache реализует интерфейс Cache для тестов
 type MockCache struct {
 	mock.Mock
@@ -80,9 +466,23 @@ func (m *MockCache) Delete(ctx context.Context, key string) error {
 
 // Тесты
 func TestService_Create(t *testing.T) {
-	ctx := context.Background()
+	sellerID := uuid.New()
+	ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID})
 	testID := uuid.New()
 
+	t.Run("requires a caller", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.CreateProduct(context.Background(), &entity.CreateProductRequest{
+			Name:        "Laptop",
+			Description: "High-performance laptop",
+			Price:       999,
+			Stock:       10,
+		})
+
+		assert.ErrorIs(t, err, entity.ErrUnauthenticated)
+	})
+
 	t.Run("successful creation", func(t *testing.T) {
 		dbMock := new(MockDatabase)
 		cacheMock := new(MockCache)
@@ -96,7 +496,8 @@ func TestService_Create(t *testing.T) {
 		}
 
 		dbMock.On("Create", ctx, mock.MatchedBy(func(p *entity.Product) bool {
-			return p.Name == req.Name &&
+			return p.SellerId == sellerID &&
+				p.Name == req.Name &&
 				p.Description == req.Description &&
 				p.Price == req.Price &&
 				p.Stock == req.Stock
@@ -253,12 +654,15 @@ func TestService_Create(t *testing.T) {
 func TestService_Get(t *testing.T) {
 	ctx := context.Background()
 	testID := uuid.New()
+	publishedAt := time.Now().UTC().Truncate(time.Second)
 	testProduct := &entity.Product{
 		Id:          testID,
 		Name:        "Laptop",
 		Description: "High-performance laptop",
 		Price:       999,
 		Stock:       10,
+		Status:      entity.ProductPublished,
+		PublishedAt: &publishedAt,
 	}
 
 	productSerialized, err := utils.Serialize(testProduct)
@@ -317,6 +721,7 @@ func TestService_Get(t *testing.T) {
 
 		cacheMock.On("Get", ctx, "product:"+testID.String()).Return(nil, errors.New("not found"))
 		dbMock.On("Get", ctx, testID).Return(testProduct, nil)
+		dbMock.On("ListImages", ctx, []uuid.UUID{testID}).Return([]*entity.ProductImage{}, nil)
 
 		serialized, _ := utils.Serialize(testProduct)
 		cacheMock.On("Set", ctx, "product:"+testID.String(), serialized, duration).Return(nil)
@@ -359,15 +764,18 @@ func TestService_Get(t *testing.T) {
 }
 
 func TestService_Update(t *testing.T) {
-	ctx := context.Background()
+	sellerID := uuid.New()
+	ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID})
 	testID := uuid.New()
 	testProduct := &entity.Product{
 		Id:          testID,
+		SellerId:    sellerID,
 		Name:        "Laptop",
 		Description: "High-performance laptop",
 		Price:       999,
 		Stock:       10,
 	}
+	readAt := time.Now()
 
 	t.Run("invalid name", func(t *testing.T) {
 		dbMock := new(MockDatabase)
@@ -443,6 +851,25 @@ func TestService_Update(t *testing.T) {
 		assert.ErrorIs(t, err, entity.ErrInvalidStock)
 	})
 
+	t.Run("missing updated_at", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		svc := NewProductService(dbMock, cacheMock)
+
+		req := &entity.UpdateProductRequest{
+			Id:          testID,
+			Name:        "Valid Name",
+			Description: "Valid description",
+			Price:       100,
+			Stock:       10,
+		}
+
+		_, err := svc.UpdateProduct(ctx, req)
+
+		assert.ErrorIs(t, err, entity.ErrInvalidUpdatedAt)
+		dbMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
 	t.Run("database update error", func(t *testing.T) {
 		dbMock := new(MockDatabase)
 		cacheMock := new(MockCache)
@@ -454,9 +881,11 @@ func TestService_Update(t *testing.T) {
 			Description: "Valid description",
 			Price:       100,
 			Stock:       10,
+			UpdatedAt:   readAt,
 		}
 
-		dbMock.On("Update", ctx, mock.AnythingOfType("*entity.Product")).Return(nil, fmt.Errorf("database update error"))
+		dbMock.On("Get", ctx, testID).Return(testProduct, nil)
+		dbMock.On("Update", ctx, mock.AnythingOfType("*entity.Product"), readAt).Return(nil, fmt.Errorf("database update error"))
 
 		_, err := svc.UpdateProduct(ctx, req)
 
@@ -489,7 +918,7 @@ func TestService_Update(t *testing.T) {
 		cacheMock := new(MockCache)
 		svc := NewProductService(dbMock, cacheMock)
 
-		dbMock.On("Update", ctx, mock.AnythingOfType("*entity.Product")).Return(testProduct, nil)
+		dbMock.On("Update", ctx, mock.AnythingOfType("*entity.Product"), readAt).Return(testProduct, nil)
 
 		cacheKey := utils.GenerateCacheKey("product", testID)
 
@@ -515,14 +944,18 @@ func TestService_Update(t *testing.T) {
 			Description: "Updated high-performance laptop",
 			Price:       1099,
 			Stock:       15,
+			UpdatedAt:   readAt,
 		}
 
+		dbMock.On("Get", ctx, testID).Return(testProduct, nil)
 		dbMock.On("Update", ctx, mock.MatchedBy(func(p *entity.Product) bool {
 			return p.Name == req.Name &&
 				p.Description == req.Description &&
 				p.Price == req.Price &&
 				p.Stock == req.Stock
-		})).Return(testProduct, nil)
+		}), readAt).Return(testProduct, nil)
+
+		dbMock.On("ListImages", ctx, []uuid.UUID{testID}).Return([]*entity.ProductImage{}, nil)
 
 		cacheKey := utils.GenerateCacheKey("product", testID)
 		productSerialized, _ := utils.Serialize(testProduct)
@@ -538,15 +971,21 @@ func TestService_Update(t *testing.T) {
 }
 
 func TestService_Delete(t *testing.T) {
-	ctx := context.Background()
+	sellerID := uuid.New()
+	ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID})
 	testID := uuid.New()
+	testProduct := &entity.Product{Id: testID, SellerId: sellerID, Status: entity.ProductPublished}
+	deletion := mock.MatchedBy(func(c *entity.ProductStatusChange) bool {
+		return c.ProductId == testID && c.FromStatus == entity.ProductPublished && c.ToStatus == entity.ProductDeleted && c.ChangedBy == sellerID
+	})
 
 	t.Run("successful delete", func(t *testing.T) {
 		dbMock := new(MockDatabase)
 		cacheMock := new(MockCache)
 		svc := NewProductService(dbMock, cacheMock)
 
-		dbMock.On("Delete", ctx, testID).Return(nil)
+		dbMock.On("Get", ctx, testID).Return(testProduct, nil)
+		dbMock.On("ChangeProductStatus", ctx, deletion).Return(&entity.Product{Id: testID, Status: entity.ProductDeleted}, nil)
 
 		cacheKey := utils.GenerateCacheKey("product", testID)
 		cacheMock.On("Delete", ctx, cacheKey).Return(nil)
@@ -564,7 +1003,8 @@ func TestService_Delete(t *testing.T) {
 		cacheMock := new(MockCache)
 		svc := NewProductService(dbMock, cacheMock)
 
-		dbMock.On("Delete", ctx, testID).Return(fmt.Errorf("database delete error"))
+		dbMock.On("Get", ctx, testID).Return(testProduct, nil)
+		dbMock.On("ChangeProductStatus", ctx, deletion).Return(nil, fmt.Errorf("database delete error"))
 
 		deleted, err := svc.DeleteProduct(ctx, testID)
 
@@ -579,7 +1019,8 @@ func TestService_Delete(t *testing.T) {
 		cacheMock := new(MockCache)
 		svc := NewProductService(dbMock, cacheMock)
 
-		dbMock.On("Delete", ctx, testID).Return(nil)
+		dbMock.On("Get", ctx, testID).Return(testProduct, nil)
+		dbMock.On("ChangeProductStatus", ctx, deletion).Return(&entity.Product{Id: testID, Status: entity.ProductDeleted}, nil)
 
 		cacheKey := utils.GenerateCacheKey("product", testID)
 		cacheMock.On("Delete", ctx, cacheKey).Return(fmt.Errorf("cache delete error"))
@@ -591,6 +1032,18 @@ func TestService_Delete(t *testing.T) {
 		dbMock.AssertExpectations(t)
 		cacheMock.AssertExpectations(t)
 	})
+
+	t.Run("already deleted", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("Get", ctx, testID).Return(&entity.Product{Id: testID, SellerId: sellerID, Status: entity.ProductDeleted}, nil)
+
+		_, err := svc.DeleteProduct(ctx, testID)
+
+		assert.ErrorIs(t, err, entity.ErrProductNotFound)
+		dbMock.AssertNotCalled(t, "ChangeProductStatus", mock.Anything, mock.Anything)
+	})
 }
 
 func TestService_List(t *testing.T) {
@@ -621,6 +1074,7 @@ func TestService_List(t *testing.T) {
 		}
 
 		dbMock.On("List", ctx, offset, limit).Return(testProducts, nil)
+		dbMock.On("ListImages", ctx, []uuid.UUID{testProducts[0].Id, testProducts[1].Id}).Return([]*entity.ProductImage{}, nil)
 
 		products, err := svc.ListProduct(ctx, offset, limit)
 
@@ -643,3 +1097,229 @@ func TestService_List(t *testing.T) {
 		dbMock.AssertExpectations(t)
 	})
 }
+
+func TestService_ListProductsPage(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	products := make([]*entity.Product, 3)
+	for i := range products {
+		products[i] = &entity.Product{Id: uuid.New(), CreatedAt: now.Add(-time.Duration(i) * time.Minute)}
+	}
+
+	t.Run("pages are walked with the token", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		cursor := entity.CursorOf(products[1])
+		dbMock.On("ListPage", ctx, (*entity.ProductCursor)(nil), 3).Return(products, nil)
+		dbMock.On("ListPage", ctx, &cursor, 3).Return(products[2:], nil)
+		dbMock.On("ListImages", ctx, mock.Anything).Return([]*entity.ProductImage{}, nil)
+
+		first, err := svc.ListProductsPage(ctx, 2, "")
+		require.NoError(t, err)
+		assert.Equal(t, products[:2], first.Products)
+		require.NotEmpty(t, first.NextPageToken)
+
+		second, err := svc.ListProductsPage(ctx, 2, first.NextPageToken)
+		require.NoError(t, err)
+		assert.Equal(t, products[2:], second.Products)
+		assert.Empty(t, second.NextPageToken)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("page size is defaulted and clamped", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("ListPage", ctx, (*entity.ProductCursor)(nil), DefaultProductsPageSize+1).Return([]*entity.Product{}, nil)
+		dbMock.On("ListPage", ctx, (*entity.ProductCursor)(nil), MaxProductsPageSize+1).Return([]*entity.Product{}, nil)
+
+		_, err := svc.ListProductsPage(ctx, 0, "")
+		require.NoError(t, err)
+		_, err = svc.ListProductsPage(ctx, MaxProductsPageSize*10, "")
+		require.NoError(t, err)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid page", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.ListProductsPage(ctx, -1, "")
+		assert.ErrorIs(t, err, entity.ErrInvalidPageSize)
+
+		for _, token := range []string{"not a token", "bm90LWEtY3Vyc29y"} {
+			_, err = svc.ListProductsPage(ctx, 10, token)
+			assert.ErrorIs(t, err, entity.ErrInvalidPageToken, token)
+		}
+	})
+}
+
+func TestService_SearchProducts(t *testing.T) {
+	ctx := context.Background()
+	price := func(v int64) *int64 { return &v }
+
+	t.Run("successful search", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		result := &entity.SearchResult{
+			Hits:  []*entity.SearchHit{{Product: &entity.Product{Id: uuid.New(), Name: "Laptop"}, NameHighlight: "<mark>Laptop</mark>"}},
+			Total: 1,
+		}
+		dbMock.On("Search", ctx, mock.MatchedBy(func(req *entity.SearchProductsRequest) bool {
+			return req.Query == "laptop" && req.Limit == DefaultSearchLimit && *req.MinPrice == 100
+		}), priceFacetBounds).Return(result, nil)
+
+		got, err := svc.SearchProducts(ctx, &entity.SearchProductsRequest{Query: "  laptop ", MinPrice: price(100)})
+
+		assert.NoError(t, err)
+		assert.Equal(t, result, got)
+		dbMock.AssertExpectations(t)
+	})
+
+	invalid := []struct {
+		name string
+		req  *entity.SearchProductsRequest
+		err  error
+	}{
+		{"too long query", &entity.SearchProductsRequest{Query: strings.Repeat("я", maxQueryLength+1)}, entity.ErrInvalidQuery},
+		{"negative price", &entity.SearchProductsRequest{MinPrice: price(-1)}, entity.ErrInvalidPriceRange},
+		{"min price above max price", &entity.SearchProductsRequest{MinPrice: price(200), MaxPrice: price(100)}, entity.ErrInvalidPriceRange},
+		{"negative offset", &entity.SearchProductsRequest{Offset: -1}, entity.ErrInvalidPage},
+		{"too large limit", &entity.SearchProductsRequest{Limit: MaxSearchLimit + 1}, entity.ErrInvalidPage},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewProductService(nil, nil)
+
+			_, err := svc.SearchProducts(ctx, tt.req)
+
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	t.Run("database search error", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("Search", ctx, mock.Anything, priceFacetBounds).Return(nil, fmt.Errorf("database search error"))
+
+		_, err := svc.SearchProducts(ctx, &entity.SearchProductsRequest{Query: "laptop"})
+
+		assert.ErrorContains(t, err, "database search error")
+		dbMock.AssertExpectations(t)
+	})
+}
+
+func TestService_ProductOwnership(t *testing.T) {
+	sellerID := uuid.New()
+	testID := uuid.New()
+	testProduct := &entity.Product{Id: testID, SellerId: sellerID, Name: "Laptop"}
+	req := &entity.UpdateProductRequest{
+		Id:          testID,
+		Name:        "Laptop",
+		Description: "High-performance laptop",
+		Price:       999,
+		Stock:       10,
+		UpdatedAt:   time.Now(),
+	}
+
+	t.Run("another seller can't update or delete", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New()})
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("Get", ctx, testID).Return(testProduct, nil)
+
+		_, err := svc.UpdateProduct(ctx, req)
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+
+		deleted, err := svc.DeleteProduct(ctx, testID)
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+		assert.False(t, deleted)
+
+		dbMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+		dbMock.AssertNotCalled(t, "ChangeProductStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("anonymous callers are rejected", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.UpdateProduct(context.Background(), req)
+		assert.ErrorIs(t, err, entity.ErrUnauthenticated)
+
+		_, err = svc.DeleteProduct(context.Background(), testID)
+		assert.ErrorIs(t, err, entity.ErrUnauthenticated)
+	})
+
+	t.Run("missing product", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: sellerID})
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("Get", ctx, testID).Return(nil, entity.ErrProductNotFound)
+
+		_, err := svc.DeleteProduct(ctx, testID)
+		assert.ErrorIs(t, err, entity.ErrProductNotFound)
+	})
+
+	t.Run("admins manage any product", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: entity.RoleAdmin})
+		dbMock := new(MockDatabase)
+		cacheMock := new(MockCache)
+		svc := NewProductService(dbMock, cacheMock)
+
+		dbMock.On("Get", ctx, testID).Return(testProduct, nil)
+		dbMock.On("ChangeProductStatus", ctx, mock.Anything).Return(&entity.Product{Id: testID, Status: entity.ProductDeleted}, nil)
+		cacheMock.On("Delete", ctx, utils.GenerateCacheKey("product", testID)).Return(nil)
+
+		deleted, err := svc.DeleteProduct(ctx, testID)
+
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("another seller can't add variants", func(t *testing.T) {
+		ctx := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New()})
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		dbMock.On("Get", ctx, testID).Return(testProduct, nil)
+
+		_, err := svc.CreateVariant(ctx, &entity.CreateVariantRequest{ProductId: testID, Sku: "LAPTOP", Price: 999})
+
+		assert.ErrorIs(t, err, entity.ErrPermissionDenied)
+		dbMock.AssertNotCalled(t, "CreateVariant", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_ListProductsBySeller(t *testing.T) {
+	ctx := context.Background()
+	sellerID := uuid.New()
+
+	t.Run("default page", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		products := []*entity.Product{{Id: uuid.New(), SellerId: sellerID}}
+		dbMock.On("ListBySeller", ctx, sellerID, []entity.ProductStatus{entity.ProductPublished}, int64(0), int64(DefaultSearchLimit)).Return(products, nil)
+
+		result, err := svc.ListProductsBySeller(ctx, sellerID, 0, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, products, result)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid page", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.ListProductsBySeller(ctx, sellerID, -1, 10)
+		assert.ErrorIs(t, err, entity.ErrInvalidPage)
+
+		_, err = svc.ListProductsBySeller(ctx, sellerID, 0, MaxSearchLimit+1)
+		assert.ErrorIs(t, err, entity.ErrInvalidPage)
+	})
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+var (
+	attributeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+	skuPattern           = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+)
+
+func (s *ProductService) CreateAttributeDefinition(ctx context.Context, req *entity.CreateAttributeDefinitionRequest) (*entity.AttributeDefinition, error) {
+	const op = "ProductService.CreateAttributeDefinition"
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := validateAttributeDefinition(req); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	allowedValues := req.AllowedValues
+	if allowedValues == nil {
+		allowedValues = []string{}
+	}
+
+	def, err := s.db.CreateAttributeDefinition(ctx, &entity.AttributeDefinition{
+		Id:            entity.GenerateID(),
+		CategoryId:    req.CategoryId,
+		Code:          req.Code,
+		Name:          req.Name,
+		Type:          req.Type,
+		AllowedValues: allowedValues,
+		Required:      req.Required,
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return def, nil
+}
+
+func (s *ProductService) ListAttributeDefinitions(ctx context.Context, categoryID uuid.UUID) ([]*entity.AttributeDefinition, error) {
+	const op = "ProductService.ListAttributeDefinitions"
+
+	definitions, err := s.db.ListAttributeDefinitions(ctx, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return definitions, nil
+}
+
+func (s *ProductService) DeleteAttributeDefinition(ctx context.Context, id uuid.UUID) (bool, error) {
+	const op = "ProductService.DeleteAttributeDefinition"
+
+	if err := requireAdmin(ctx); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.DeleteAttributeDefinition(ctx, id); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+func (s *ProductService) CreateVariant(ctx context.Context, req *entity.CreateVariantRequest) (*entity.Variant, error) {
+	const op = "ProductService.CreateVariant"
+
+	if err := s.checkOwner(ctx, req.ProductId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	attributes, err := s.validateVariant(ctx, req.ProductId, req.Sku, req.Price, req.Stock, req.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	variant, err := s.db.CreateVariant(ctx, &entity.Variant{
+		Id:         entity.GenerateID(),
+		ProductId:  req.ProductId,
+		Sku:        req.Sku,
+		Price:      req.Price,
+		Stock:      req.Stock,
+		Attributes: attributes,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return variant, nil
+}
+
+func (s *ProductService) GetVariant(ctx context.Context, id uuid.UUID) (*entity.Variant, error) {
+	const op = "ProductService.GetVariant"
+
+	variant, err := s.db.GetVariant(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return variant, nil
+}
+
+func (s *ProductService) UpdateVariant(ctx context.Context, req *entity.UpdateVariantRequest) (*entity.Variant, error) {
+	const op = "ProductService.UpdateVariant"
+
+	current, err := s.db.GetVariant(ctx, req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.checkOwner(ctx, current.ProductId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	attributes, err := s.validateVariant(ctx, current.ProductId, req.Sku, req.Price, req.Stock, req.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	variant, err := s.db.UpdateVariant(ctx, &entity.Variant{
+		Id:         req.Id,
+		Sku:        req.Sku,
+		Price:      req.Price,
+		Stock:      req.Stock,
+		Attributes: attributes,
+		UpdatedAt:  time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return variant, nil
+}
+
+func (s *ProductService) DeleteVariant(ctx context.Context, id uuid.UUID) (bool, error) {
+	const op = "ProductService.DeleteVariant"
+
+	variant, err := s.db.GetVariant(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.checkOwner(ctx, variant.ProductId); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.DeleteVariant(ctx, id); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+// GetProductVariants returns the variants of a product with the variant
+// matrix built from them.
+func (s *ProductService) GetProductVariants(ctx context.Context, productID uuid.UUID) ([]*entity.Variant, []entity.VariantAxis, error) {
+	const op = "ProductService.GetProductVariants"
+
+	variants, err := s.db.ListVariants(ctx, productID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(variants) == 0 {
+		return variants, []entity.VariantAxis{}, nil
+	}
+
+	definitions, err := s.productAttributes(ctx, productID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return variants, entity.VariantAxes(definitions, variants), nil
+}
+
+// validateVariant checks the fields of a variant and returns its attributes,
+// never nil.
+func (s *ProductService) validateVariant(ctx context.Context, productID uuid.UUID, sku string, price, stock int64, attributes map[string]string) (map[string]string, error) {
+	if !skuPattern.MatchString(sku) {
+		return nil, entity.ErrInvalidSku
+	}
+
+	if price < 1 {
+		return nil, entity.ErrInvalidPrice
+	}
+
+	if stock < 0 {
+		return nil, entity.ErrInvalidStock
+	}
+
+	definitions, err := s.productAttributes(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := entity.ValidateAttributes(definitions, attributes); err != nil {
+		return nil, err
+	}
+
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+
+	return attributes, nil
+}
+
+// productAttributes returns the attribute definitions that apply to the
+// variants of a product: those of its primary category and its ancestors.
+func (s *ProductService) productAttributes(ctx context.Context, productID uuid.UUID) ([]*entity.AttributeDefinition, error) {
+	categories, err := s.db.GetProductCategories(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	primary := categories.Primary()
+	if primary == nil {
+		return []*entity.AttributeDefinition{}, nil
+	}
+
+	return s.db.ListAttributeDefinitions(ctx, primary.Id)
+}
+
+func validateAttributeDefinition(req *entity.CreateAttributeDefinitionRequest) error {
+	if !attributeCodePattern.MatchString(req.Code) {
+		return fmt.Errorf("%w: invalid code", entity.ErrInvalidAttributeDefinition)
+	}
+
+	if req.Name == "" || len(req.Name) > maxCategoryNameLength {
+		return fmt.Errorf("%w: invalid name", entity.ErrInvalidAttributeDefinition)
+	}
+
+	switch req.Type {
+	case entity.AttributeEnum:
+		if len(req.AllowedValues) == 0 {
+			return fmt.Errorf("%w: enum attributes need allowed values", entity.ErrInvalidAttributeDefinition)
+		}
+	case entity.AttributeText, entity.AttributeNumber:
+		if len(req.AllowedValues) > 0 {
+			return fmt.Errorf("%w: only enum attributes have allowed values", entity.ErrInvalidAttributeDefinition)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", entity.ErrInvalidAttributeDefinition, req.Type)
+	}
+
+	return nil
+}
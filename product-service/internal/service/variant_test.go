@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+)
+
+func TestService_CreateAttributeDefinition(t *testing.T) {
+	admin := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: entity.RoleAdmin})
+	categoryID := uuid.New()
+
+	t.Run("requires the admin role", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.CreateAttributeDefinition(context.Background(), &entity.CreateAttributeDefinitionRequest{})
+
+		assert.ErrorIs(t, err, entity.ErrUnauthenticated)
+	})
+
+	t.Run("invalid definitions", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		for name, req := range map[string]*entity.CreateAttributeDefinitionRequest{
+			"bad code":            {CategoryId: categoryID, Code: "Color", Name: "Цвет", Type: entity.AttributeText},
+			"unknown type":        {CategoryId: categoryID, Code: "color", Name: "Цвет", Type: "date"},
+			"enum without values": {CategoryId: categoryID, Code: "size", Name: "Размер", Type: entity.AttributeEnum},
+			"text with values":    {CategoryId: categoryID, Code: "color", Name: "Цвет", Type: entity.AttributeText, AllowedValues: []string{"red"}},
+		} {
+			_, err := svc.CreateAttributeDefinition(admin, req)
+			assert.ErrorIs(t, err, entity.ErrInvalidAttributeDefinition, name)
+		}
+	})
+
+	t.Run("successful creation", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+
+		created := &entity.AttributeDefinition{Id: uuid.New(), CategoryId: categoryID, Code: "memory"}
+		dbMock.On("CreateAttributeDefinition", admin, mock.MatchedBy(func(def *entity.AttributeDefinition) bool {
+			return def.Id != uuid.Nil && def.Code == "memory" && def.AllowedValues != nil
+		})).Return(created, nil)
+
+		def, err := svc.CreateAttributeDefinition(admin, &entity.CreateAttributeDefinitionRequest{
+			CategoryId: categoryID,
+			Code:       "memory",
+			Name:       "Память, ГБ",
+			Type:       entity.AttributeNumber,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, created, def)
+		dbMock.AssertExpectations(t)
+	})
+}
+
+func TestService_CreateVariant(t *testing.T) {
+	admin := entity.WithCaller(context.Background(), entity.Caller{UserId: uuid.New(), Role: entity.RoleAdmin})
+	productID, categoryID := uuid.New(), uuid.New()
+	definitions := []*entity.AttributeDefinition{
+		{Code: "color", Name: "Цвет", Type: entity.AttributeEnum, AllowedValues: []string{"black", "white"}, Required: true},
+		{Code: "memory", Name: "Память", Type: entity.AttributeNumber},
+	}
+
+	withCategory := func(dbMock *MockDatabase) {
+		dbMock.On("GetProductCategories", admin, productID).
+			Return(&entity.ProductCategories{Breadcrumbs: []*entity.Category{{Id: categoryID}}}, nil)
+		dbMock.On("ListAttributeDefinitions", admin, categoryID).Return(definitions, nil)
+	}
+
+	t.Run("successful creation", func(t *testing.T) {
+		dbMock := new(MockDatabase)
+		svc := NewProductService(dbMock, nil)
+		withCategory(dbMock)
+
+		attributes := map[string]string{"color": "black", "memory": "256"}
+		created := &entity.Variant{Id: uuid.New(), ProductId: productID, Sku: "PHONE-BLK-256"}
+		dbMock.On("CreateVariant", admin, mock.MatchedBy(func(v *entity.Variant) bool {
+			return v.ProductId == productID && v.Sku == "PHONE-BLK-256" && v.Attributes["memory"] == "256"
+		})).Return(created, nil)
+
+		variant, err := svc.CreateVariant(admin, &entity.CreateVariantRequest{
+			ProductId: productID, Sku: "PHONE-BLK-256", Price: 99900, Stock: 5, Attributes: attributes,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, created, variant)
+		dbMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid attributes", func(t *testing.T) {
+		for name, attributes := range map[string]map[string]string{
+			"missing required": {"memory": "256"},
+			"not allowed":      {"color": "red"},
+			"not a number":     {"color": "black", "memory": "a lot"},
+			"unknown":          {"color": "black", "weight": "180"},
+		} {
+			dbMock := new(MockDatabase)
+			svc := NewProductService(dbMock, nil)
+			withCategory(dbMock)
+
+			_, err := svc.CreateVariant(admin, &entity.CreateVariantRequest{
+				ProductId: productID, Sku: "PHONE", Price: 99900, Attributes: attributes,
+			})
+
+			assert.ErrorIs(t, err, entity.ErrInvalidAttributes, name)
+			dbMock.AssertNotCalled(t, "CreateVariant", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("invalid sku", func(t *testing.T) {
+		svc := NewProductService(nil, nil)
+
+		_, err := svc.CreateVariant(admin, &entity.CreateVariantRequest{ProductId: productID, Sku: "phone black", Price: 1})
+
+		assert.ErrorIs(t, err, entity.ErrInvalidSku)
+	})
+}
+
+func TestVariantAxes(t *testing.T) {
+	definitions := []*entity.AttributeDefinition{
+		{Code: "color", Name: "Цвет", Type: entity.AttributeEnum, AllowedValues: []string{"black", "white", "blue"}},
+		{Code: "memory", Name: "Память", Type: entity.AttributeNumber},
+	}
+	variants := []*entity.Variant{
+		{Attributes: map[string]string{"color": "white", "memory": "512"}},
+		{Attributes: map[string]string{"color": "black", "memory": "256"}},
+		{Attributes: map[string]string{"color": "black", "edition": "pro"}},
+	}
+
+	axes := entity.VariantAxes(definitions, variants)
+
+	assert.Equal(t, []entity.VariantAxis{
+		{Code: "color", Name: "Цвет", Values: []string{"black", "white"}},
+		{Code: "memory", Name: "Память", Values: []string{"256", "512"}},
+		{Code: "edition", Name: "edition", Values: []string{"pro"}},
+	}, axes)
+}
@@ -61,7 +61,7 @@ type Server struct {
 	prometheusFactory metric.PrometheusFactory
 }
 
-func New(ctx context.Context, port, metricsPort string, otlpConfig config.OTLPConfig, log *logger.Logger, service Service) (*Server, error) {
+func New(ctx context.Context, port, metricsPort string, otlpConfig config.OTLPConfig, callerConfig config.CallerConfig, log *logger.Logger, service Service) (*Server, error) {
 	logSpanTraceID := func(ctx context.Context) logging.Fields {
 		if span := trace.SpanContextFromContext(ctx); span.IsSampled() {
 			return logging.Fields{
@@ -127,6 +127,13 @@ func New(ctx context.Context, port, metricsPort string, otlpConfig config.OTLPCo
 			srvMetrics.UnaryServerInterceptor(grpcprom.WithExemplarFromContext(spanTraceFromContext)),
 			logging.UnaryServerInterceptor(InterceptorLogger(log), logging.WithFieldsFromContext(logSpanTraceID)),
 			recovery.UnaryServerInterceptor(recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
+			CallerUnaryServerInterceptor([]byte(callerConfig.SecretKey), callerConfig.MaxAge),
+		),
+		grpc.ChainStreamInterceptor(
+			srvMetrics.StreamServerInterceptor(grpcprom.WithExemplarFromContext(spanTraceFromContext)),
+			logging.StreamServerInterceptor(InterceptorLogger(log), logging.WithFieldsFromContext(logSpanTraceID)),
+			recovery.StreamServerInterceptor(recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
+			CallerStreamServerInterceptor([]byte(callerConfig.SecretKey), callerConfig.MaxAge),
 		),
 		grpc.KeepaliveEnforcementPolicy(kaep),
 		grpc.KeepaliveParams(kasp),
@@ -0,0 +1,210 @@
+package grpcServer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/api/product"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (t *ProductService) CreateCategory(ctx context.Context, input *product.CreateCategoryRequest) (*product.CreateCategoryResponse, error) {
+	const op = "Service.CreateCategory"
+
+	parentID, err := parseOptionalID("parent_id", input.GetParentId())
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := t.service.CreateCategory(ctx, &entity.CreateCategoryRequest{
+		Name:     input.GetName(),
+		Slug:     input.GetSlug(),
+		ParentId: parentID,
+	})
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.CreateCategoryResponse{Category: categoryToProto(category)}, nil
+}
+
+func (t *ProductService) GetCategory(ctx context.Context, input *product.GetCategoryRequest) (*product.GetCategoryResponse, error) {
+	const op = "Service.GetCategory"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := t.service.GetCategory(ctx, id)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.GetCategoryResponse{Category: categoryToProto(category)}, nil
+}
+
+func (t *ProductService) UpdateCategory(ctx context.Context, input *product.UpdateCategoryRequest) (*product.UpdateCategoryResponse, error) {
+	const op = "Service.UpdateCategory"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	parentID, err := parseOptionalID("parent_id", input.GetParentId())
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := t.service.UpdateCategory(ctx, &entity.UpdateCategoryRequest{
+		Id:       id,
+		Name:     input.GetName(),
+		Slug:     input.GetSlug(),
+		ParentId: parentID,
+	})
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.UpdateCategoryResponse{Category: categoryToProto(category)}, nil
+}
+
+func (t *ProductService) DeleteCategory(ctx context.Context, input *product.DeleteCategoryRequest) (*product.DeleteCategoryResponse, error) {
+	const op = "Service.DeleteCategory"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	success, err := t.service.DeleteCategory(ctx, id)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.DeleteCategoryResponse{Success: success}, nil
+}
+
+func (t *ProductService) ListCategories(ctx context.Context, _ *product.ListCategoriesRequest) (*product.ListCategoriesResponse, error) {
+	const op = "Service.ListCategories"
+
+	categories, err := t.service.ListCategories(ctx)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.ListCategoriesResponse{Categories: categoriesToProto(categories)}, nil
+}
+
+func (t *ProductService) ListCategoryProducts(ctx context.Context, input *product.ListCategoryProductsRequest) (*product.ListCategoryProductsResponse, error) {
+	const op = "Service.ListCategoryProducts"
+
+	id, err := parseID("category_id", input.GetCategoryId())
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := t.service.ListCategoryProducts(ctx, id, input.GetOffset(), input.GetLimit())
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	resp := &product.ListCategoryProductsResponse{Products: make([]*product.Product, 0, len(products))}
+	for _, p := range products {
+		resp.Products = append(resp.Products, toProto(p))
+	}
+
+	return resp, nil
+}
+
+func (t *ProductService) SetProductCategories(ctx context.Context, input *product.SetProductCategoriesRequest) (*product.SetProductCategoriesResponse, error) {
+	const op = "Service.SetProductCategories"
+
+	productID, err := parseID("product_id", input.GetProductId())
+	if err != nil {
+		return nil, err
+	}
+
+	primaryID, err := parseOptionalID("primary_category_id", input.GetPrimaryCategoryId())
+	if err != nil {
+		return nil, err
+	}
+
+	secondaryIDs := make([]uuid.UUID, 0, len(input.GetSecondaryCategoryIds()))
+	for _, raw := range input.GetSecondaryCategoryIds() {
+		id, err := parseID("secondary_category_ids", raw)
+		if err != nil {
+			return nil, err
+		}
+		secondaryIDs = append(secondaryIDs, id)
+	}
+
+	categories, err := t.service.SetProductCategories(ctx, &entity.SetProductCategoriesRequest{
+		ProductId:    productID,
+		PrimaryId:    primaryID,
+		SecondaryIds: secondaryIDs,
+	})
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	resp := &product.SetProductCategoriesResponse{SecondaryCategories: categoriesToProto(categories.Secondary)}
+	if primary := categories.Primary(); primary != nil {
+		resp.PrimaryCategory = categoryToProto(primary)
+	}
+
+	return resp, nil
+}
+
+func categoryToProto(c *entity.Category) *product.Category {
+	category := &product.Category{
+		Id:        c.Id.String(),
+		Name:      c.Name,
+		Slug:      c.Slug,
+		Depth:     int32(c.Depth()),
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+	}
+	if c.ParentId != nil {
+		category.ParentId = c.ParentId.String()
+	}
+
+	return category
+}
+
+func categoriesToProto(categories []*entity.Category) []*product.Category {
+	out := make([]*product.Category, 0, len(categories))
+	for _, c := range categories {
+		out = append(out, categoryToProto(c))
+	}
+
+	return out
+}
+
+func parseID(field, raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid %s: %v", field, err)
+	}
+
+	return id, nil
+}
+
+// parseOptionalID parses an id that may be left empty.
+func parseOptionalID(field, raw string) (*uuid.UUID, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	id, err := parseID(field, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &id, nil
+}
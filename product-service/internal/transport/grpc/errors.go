@@ -0,0 +1,86 @@
+package grpcServer
+
+import (
+	"errors"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HandleError maps the errors of the service layer to gRPC statuses, so the
+// gateway can tell a bad request from a failure.
+func HandleError(err error) error {
+	switch {
+	case errors.Is(err, entity.ErrInvalidName),
+		errors.Is(err, entity.ErrInvalidDescription),
+		errors.Is(err, entity.ErrInvalidPrice),
+		errors.Is(err, entity.ErrInvalidStock),
+		errors.Is(err, entity.ErrInvalidUpdatedAt),
+		errors.Is(err, entity.ErrInvalidQuery),
+		errors.Is(err, entity.ErrInvalidPriceRange),
+		errors.Is(err, entity.ErrInvalidPage),
+		errors.Is(err, entity.ErrInvalidPageToken),
+		errors.Is(err, entity.ErrInvalidPageSize),
+		errors.Is(err, entity.ErrInvalidCategory),
+		errors.Is(err, entity.ErrInvalidSlug),
+		errors.Is(err, entity.ErrSecondaryCategory),
+		errors.Is(err, entity.ErrInvalidAttributeDefinition),
+		errors.Is(err, entity.ErrInvalidAttributes),
+		errors.Is(err, entity.ErrInvalidSku),
+		errors.Is(err, entity.ErrInvalidReservation),
+		errors.Is(err, entity.ErrInvalidImage),
+		errors.Is(err, entity.ErrImageTooLarge),
+		errors.Is(err, entity.ErrInvalidImageOrder),
+		errors.Is(err, entity.ErrInvalidPriceSchedule),
+		errors.Is(err, entity.ErrInvalidHistoryPeriod),
+		errors.Is(err, entity.ErrInvalidImportFile),
+		errors.Is(err, entity.ErrImportTooLarge),
+		errors.Is(err, entity.ErrInvalidCatalogFormat),
+		errors.Is(err, entity.ErrInvalidReview),
+		errors.Is(err, entity.ErrInvalidRating),
+		errors.Is(err, entity.ErrInvalidReviewReply),
+		errors.Is(err, entity.ErrInvalidReport),
+		errors.Is(err, entity.ErrInvalidStatus),
+		errors.Is(err, entity.ErrInvalidStatusReason):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, entity.ErrCategoryNotFound),
+		errors.Is(err, entity.ErrProductNotFound),
+		errors.Is(err, entity.ErrAttributeNotFound),
+		errors.Is(err, entity.ErrVariantNotFound),
+		errors.Is(err, entity.ErrReservationNotFound),
+		errors.Is(err, entity.ErrImageNotFound),
+		errors.Is(err, entity.ErrPriceScheduleNotFound),
+		errors.Is(err, entity.ErrImportJobNotFound),
+		errors.Is(err, entity.ErrReviewNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, entity.ErrCategoryExists),
+		errors.Is(err, entity.ErrAttributeExists),
+		errors.Is(err, entity.ErrVariantExists),
+		errors.Is(err, entity.ErrDuplicateVariant),
+		errors.Is(err, entity.ErrReviewExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, entity.ErrCategoryNotEmpty),
+		errors.Is(err, entity.ErrCategoryCycle),
+		errors.Is(err, entity.ErrInsufficientStock),
+		errors.Is(err, entity.ErrReservationExpired),
+		errors.Is(err, entity.ErrTooManyImages),
+		errors.Is(err, entity.ErrTooManyReviewImages),
+		errors.Is(err, entity.ErrSaleOverlap),
+		errors.Is(err, entity.ErrPriceScheduleFinished),
+		errors.Is(err, entity.ErrInvalidStatusChange),
+		errors.Is(err, entity.ErrProductUnavailable):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, entity.ErrStatusConflict),
+		errors.Is(err, entity.ErrProductConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, entity.ErrImagesNotSupported):
+		return status.Error(codes.Unimplemented, err.Error())
+	case errors.Is(err, entity.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, entity.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,134 @@
+package grpcServer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/api/product"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (t *ProductService) UploadProductImage(stream grpc.ClientStreamingServer[product.UploadProductImageRequest, product.UploadProductImageResponse]) error {
+	const op = "Service.UploadProductImage"
+
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "the upload is empty")
+	}
+	if err != nil {
+		return err
+	}
+
+	productID, err := parseID("product_id", first.GetProductId())
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// The upload is cut off as soon as it's too large to be accepted.
+		if len(data)+len(msg.GetChunk()) > entity.MaxImageSize {
+			return HandleError(fmt.Errorf("%s: %w", op, entity.ErrImageTooLarge))
+		}
+		data = append(data, msg.GetChunk()...)
+	}
+
+	image, err := t.service.UploadProductImage(stream.Context(), &entity.UploadImageRequest{ProductId: productID, Data: data})
+	if err != nil {
+		return HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return stream.SendAndClose(&product.UploadProductImageResponse{Image: imageToProto(image)})
+}
+
+func (t *ProductService) ReorderProductImages(ctx context.Context, input *product.ReorderProductImagesRequest) (*product.ReorderProductImagesResponse, error) {
+	const op = "Service.ReorderProductImages"
+
+	productID, err := parseID("product_id", input.GetProductId())
+	if err != nil {
+		return nil, err
+	}
+
+	imageIDs := make([]uuid.UUID, 0, len(input.GetImageIds()))
+	for _, raw := range input.GetImageIds() {
+		id, err := parseID("image_ids", raw)
+		if err != nil {
+			return nil, err
+		}
+		imageIDs = append(imageIDs, id)
+	}
+
+	images, err := t.service.ReorderProductImages(ctx, productID, imageIDs)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.ReorderProductImagesResponse{Images: imagesToProto(images)}, nil
+}
+
+func (t *ProductService) DeleteProductImage(ctx context.Context, input *product.DeleteProductImageRequest) (*product.DeleteProductImageResponse, error) {
+	const op = "Service.DeleteProductImage"
+
+	productID, err := parseID("product_id", input.GetProductId())
+	if err != nil {
+		return nil, err
+	}
+
+	imageID, err := parseID("image_id", input.GetImageId())
+	if err != nil {
+		return nil, err
+	}
+
+	success, err := t.service.DeleteProductImage(ctx, productID, imageID)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.DeleteProductImageResponse{Success: success}, nil
+}
+
+func imageToProto(image *entity.ProductImage) *product.ProductImage {
+	thumbnails := make([]*product.Thumbnail, 0, len(image.Thumbnails))
+	for _, thumbnail := range image.Thumbnails {
+		thumbnails = append(thumbnails, &product.Thumbnail{
+			Size:   thumbnail.Size,
+			Url:    thumbnail.URL,
+			Width:  int32(thumbnail.Width),
+			Height: int32(thumbnail.Height),
+		})
+	}
+
+	return &product.ProductImage{
+		Id:          image.Id.String(),
+		Url:         image.URL,
+		Position:    image.Position,
+		ContentType: image.ContentType,
+		Width:       int32(image.Width),
+		Height:      int32(image.Height),
+		Size:        image.Size,
+		Thumbnails:  thumbnails,
+	}
+}
+
+func imagesToProto(images []*entity.ProductImage) []*product.ProductImage {
+	res := make([]*product.ProductImage, 0, len(images))
+	for _, image := range images {
+		res = append(res, imageToProto(image))
+	}
+
+	return res
+}
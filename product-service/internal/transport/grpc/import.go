@@ -0,0 +1,170 @@
+package grpcServer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/api/product"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// exportChunkSize is the size of the chunks ExportProducts sends.
+const exportChunkSize = 64 << 10
+
+var catalogFormats = map[product.CatalogFormat]entity.CatalogFormat{
+	product.CatalogFormat_CATALOG_FORMAT_CSV:  entity.CatalogCSV,
+	product.CatalogFormat_CATALOG_FORMAT_JSON: entity.CatalogJSON,
+}
+
+var importStatuses = map[entity.ImportStatus]product.ImportJobStatus{
+	entity.ImportPending:   product.ImportJobStatus_IMPORT_JOB_STATUS_PENDING,
+	entity.ImportRunning:   product.ImportJobStatus_IMPORT_JOB_STATUS_RUNNING,
+	entity.ImportCompleted: product.ImportJobStatus_IMPORT_JOB_STATUS_COMPLETED,
+}
+
+func (t *ProductService) ImportProducts(stream grpc.ClientStreamingServer[product.ImportProductsRequest, product.ImportJob]) error {
+	const op = "Service.ImportProducts"
+
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "the import is empty")
+	}
+	if err != nil {
+		return err
+	}
+	if first.GetOptions() == nil {
+		return status.Error(codes.InvalidArgument, "the first message must carry the options")
+	}
+
+	var data []byte
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// The upload is cut off as soon as it's too large to be accepted.
+		if len(data)+len(msg.GetChunk()) > entity.MaxImportSize {
+			return HandleError(fmt.Errorf("%s: %w", op, entity.ErrImportTooLarge))
+		}
+		data = append(data, msg.GetChunk()...)
+	}
+
+	job, err := t.service.ImportProducts(stream.Context(), &entity.ImportProductsRequest{
+		Format: catalogFormats[first.GetOptions().GetFormat()],
+		DryRun: first.GetOptions().GetDryRun(),
+		Data:   data,
+	})
+	if err != nil {
+		return HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return stream.SendAndClose(importJobToProto(job))
+}
+
+func (t *ProductService) GetImportJob(ctx context.Context, input *product.GetImportJobRequest) (*product.ImportJob, error) {
+	const op = "Service.GetImportJob"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := t.service.GetImportJob(ctx, id)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return importJobToProto(job), nil
+}
+
+func (t *ProductService) ExportProducts(input *product.ExportProductsRequest, stream grpc.ServerStreamingServer[product.ExportProductsChunk]) error {
+	const op = "Service.ExportProducts"
+
+	w := &chunkWriter{send: func(data []byte) error {
+		return stream.Send(&product.ExportProductsChunk{Data: data})
+	}}
+
+	err := t.service.ExportProducts(stream.Context(), catalogFormats[input.GetFormat()], w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		return HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// chunkWriter gathers what is written into chunks of exportChunkSize and
+// sends them as they fill up.
+type chunkWriter struct {
+	buf  []byte
+	send func(data []byte) error
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	c.buf = append(c.buf, p...)
+	for len(c.buf) >= exportChunkSize {
+		if err := c.send(c.buf[:exportChunkSize]); err != nil {
+			return 0, err
+		}
+		c.buf = c.buf[exportChunkSize:]
+	}
+
+	return len(p), nil
+}
+
+// Flush sends the rest of the data.
+func (c *chunkWriter) Flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+
+	err := c.send(c.buf)
+	c.buf = nil
+	return err
+}
+
+func importJobToProto(job *entity.ImportJob) *product.ImportJob {
+	errs := make([]*product.ImportRowError, 0, len(job.Errors))
+	for _, rowErr := range job.Errors {
+		errs = append(errs, &product.ImportRowError{
+			Row:     int32(rowErr.Row),
+			Sku:     rowErr.Sku,
+			Message: rowErr.Message,
+		})
+	}
+
+	format := product.CatalogFormat_CATALOG_FORMAT_UNSPECIFIED
+	for protoFormat, entityFormat := range catalogFormats {
+		if entityFormat == job.Format {
+			format = protoFormat
+		}
+	}
+
+	return &product.ImportJob{
+		Id:            job.Id.String(),
+		SellerId:      job.SellerId.String(),
+		Format:        format,
+		DryRun:        job.DryRun,
+		Status:        importStatuses[job.Status],
+		TotalRows:     int32(job.TotalRows),
+		ProcessedRows: int32(job.ProcessedRows),
+		Created:       int32(job.Created),
+		Updated:       int32(job.Updated),
+		Failed:        int32(job.Failed),
+		Errors:        errs,
+		CreatedAt:     timestamppb.New(job.CreatedAt),
+		FinishedAt:    timestampOrNil(job.FinishedAt),
+	}
+}
@@ -2,10 +2,21 @@ package grpcServer
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"log/slog"
+	"strconv"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func InterceptorLogger(l *logger.Logger) logging.Logger {
@@ -13,3 +24,84 @@ func InterceptorLogger(l *logger.Logger) logging.Logger {
 		l.Log(ctx, slog.Level(lvl), msg, fields...)
 	})
 }
+
+const (
+	userIDMetadataKey          = "x-user-id"
+	userRoleMetadataKey        = "x-user-role"
+	callerIssuedAtMetadataKey  = "x-caller-issued-at"
+	callerSignatureMetadataKey = "x-caller-signature"
+)
+
+// CallerUnaryServerInterceptor stores the caller the gateway passes in the
+// x-user-id and x-user-role metadata in the context, see
+// entity.CallerFromContext. The gateway signs these keys with key, see
+// callerSignature; calls whose signature doesn't match or is older than
+// maxAge are rejected, calls without caller metadata are anonymous.
+func CallerUnaryServerInterceptor(key []byte, maxAge time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := withCaller(ctx, key, maxAge, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// CallerStreamServerInterceptor is CallerUnaryServerInterceptor for streams.
+func CallerStreamServerInterceptor(key []byte, maxAge time.Duration) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := withCaller(ss.Context(), key, maxAge, time.Now())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &callerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type callerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *callerStream) Context() context.Context {
+	return s.ctx
+}
+
+func withCaller(ctx context.Context, key []byte, maxAge time.Duration, now time.Time) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	ids, roles := md.Get(userIDMetadataKey), md.Get(userRoleMetadataKey)
+	if len(ids) == 0 && len(roles) == 0 {
+		return ctx, nil
+	}
+
+	issued, signatures := md.Get(callerIssuedAtMetadataKey), md.Get(callerSignatureMetadataKey)
+	if len(ids) != 1 || len(roles) != 1 || len(issued) != 1 || len(signatures) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "invalid caller metadata")
+	}
+
+	want := callerSignature(key, ids[0], roles[0], issued[0])
+	if !hmac.Equal([]byte(signatures[0]), []byte(want)) {
+		return nil, status.Error(codes.Unauthenticated, "invalid caller signature")
+	}
+
+	issuedAt, err := strconv.ParseInt(issued[0], 10, 64)
+	if err != nil || now.Sub(time.Unix(issuedAt, 0)).Abs() > maxAge {
+		return nil, status.Error(codes.Unauthenticated, "expired caller signature")
+	}
+
+	userID, err := uuid.Parse(ids[0])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid caller metadata")
+	}
+
+	return entity.WithCaller(ctx, entity.Caller{UserId: userID, Role: roles[0]}), nil
+}
+
+// callerSignature is the hex HMAC-SHA256 of the caller metadata, computed as
+// the gateway does.
+func callerSignature(key []byte, userID, role, issuedAt string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(userID + "\n" + role + "\n" + issuedAt))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,68 @@
+package grpcServer
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestWithCaller(t *testing.T) {
+	key := []byte("caller-key")
+	now := time.Now()
+	userID := uuid.New()
+
+	incoming := func(userID, role string, issuedAt time.Time, key []byte) context.Context {
+		issued := strconv.FormatInt(issuedAt.Unix(), 10)
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			userIDMetadataKey, userID,
+			userRoleMetadataKey, role,
+			callerIssuedAtMetadataKey, issued,
+			callerSignatureMetadataKey, callerSignature(key, userID, role, issued),
+		))
+	}
+
+	t.Run("signed caller", func(t *testing.T) {
+		ctx, err := withCaller(incoming(userID.String(), entity.RoleAdmin, now, key), key, time.Minute, now)
+		require.NoError(t, err)
+
+		caller, ok := entity.CallerFromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, entity.Caller{UserId: userID, Role: entity.RoleAdmin}, caller)
+	})
+
+	t.Run("anonymous call", func(t *testing.T) {
+		ctx, err := withCaller(context.Background(), key, time.Minute, now)
+		require.NoError(t, err)
+
+		_, ok := entity.CallerFromContext(ctx)
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects unsigned and forged callers", func(t *testing.T) {
+		unsigned := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			userIDMetadataKey, userID.String(),
+			userRoleMetadataKey, entity.RoleAdmin,
+		))
+		_, err := withCaller(unsigned, key, time.Minute, now)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		forged := incoming(userID.String(), entity.RoleAdmin, now, []byte("other-key"))
+		_, err = withCaller(forged, key, time.Minute, now)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("rejects old signatures", func(t *testing.T) {
+		old := incoming(userID.String(), entity.RoleAdmin, now.Add(-2*time.Minute), key)
+		_, err := withCaller(old, key, time.Minute, now)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
@@ -0,0 +1,90 @@
+package grpcServer
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/api/product"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var productStatuses = map[entity.ProductStatus]product.ProductStatus{
+	entity.ProductDraft:         product.ProductStatus_PRODUCT_STATUS_DRAFT,
+	entity.ProductPendingReview: product.ProductStatus_PRODUCT_STATUS_PENDING_REVIEW,
+	entity.ProductPublished:     product.ProductStatus_PRODUCT_STATUS_PUBLISHED,
+	entity.ProductArchived:      product.ProductStatus_PRODUCT_STATUS_ARCHIVED,
+	entity.ProductDeleted:       product.ProductStatus_PRODUCT_STATUS_DELETED,
+}
+
+var lifecycleStatuses = map[product.ProductStatus]entity.ProductStatus{
+	product.ProductStatus_PRODUCT_STATUS_DRAFT:          entity.ProductDraft,
+	product.ProductStatus_PRODUCT_STATUS_PENDING_REVIEW: entity.ProductPendingReview,
+	product.ProductStatus_PRODUCT_STATUS_PUBLISHED:      entity.ProductPublished,
+	product.ProductStatus_PRODUCT_STATUS_ARCHIVED:       entity.ProductArchived,
+	product.ProductStatus_PRODUCT_STATUS_DELETED:        entity.ProductDeleted,
+}
+
+func (t *ProductService) ChangeProductStatus(ctx context.Context, input *product.ChangeProductStatusRequest) (*product.ChangeProductStatusResponse, error) {
+	const op = "Service.ChangeProductStatus"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := t.service.ChangeProductStatus(ctx, &entity.ChangeProductStatusRequest{
+		ProductId: id,
+		Status:    lifecycleStatuses[input.GetStatus()],
+		Reason:    input.GetReason(),
+	})
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.ChangeProductStatusResponse{Product: toProto(changed)}, nil
+}
+
+func (t *ProductService) ListProductStatusHistory(ctx context.Context, input *product.ListProductStatusHistoryRequest) (*product.ListProductStatusHistoryResponse, error) {
+	const op = "Service.ListProductStatusHistory"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := t.service.ListProductStatusHistory(ctx, id)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	changes := make([]*product.ProductStatusChange, 0, len(history))
+	for _, change := range history {
+		changes = append(changes, &product.ProductStatusChange{
+			Id:         change.Id.String(),
+			FromStatus: productStatuses[change.FromStatus],
+			ToStatus:   productStatuses[change.ToStatus],
+			ChangedBy:  change.ChangedBy.String(),
+			Reason:     change.Reason,
+			ChangedAt:  timestamppb.New(change.ChangedAt),
+		})
+	}
+
+	return &product.ListProductStatusHistoryResponse{Changes: changes}, nil
+}
+
+func (t *ProductService) ListPendingProducts(ctx context.Context, input *product.ListPendingProductsRequest) (*product.ListPendingProductsResponse, error) {
+	const op = "Service.ListPendingProducts"
+
+	page, err := t.service.ListPendingProducts(ctx, int(input.GetPageSize()), input.GetPageToken())
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	products := make([]*product.Product, 0, len(page.Products))
+	for _, p := range page.Products {
+		products = append(products, toProto(p))
+	}
+
+	return &product.ListPendingProductsResponse{Products: products, NextPageToken: page.NextPageToken}, nil
+}
@@ -0,0 +1,152 @@
+package grpcServer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/api/product"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var priceScheduleKinds = map[entity.PriceScheduleKind]product.PriceScheduleKind{
+	entity.PriceChange: product.PriceScheduleKind_PRICE_SCHEDULE_KIND_CHANGE,
+	entity.PriceSale:   product.PriceScheduleKind_PRICE_SCHEDULE_KIND_SALE,
+}
+
+var priceScheduleStatuses = map[entity.PriceScheduleStatus]product.PriceScheduleStatus{
+	entity.PriceSchedulePending:   product.PriceScheduleStatus_PRICE_SCHEDULE_STATUS_PENDING,
+	entity.PriceScheduleActive:    product.PriceScheduleStatus_PRICE_SCHEDULE_STATUS_ACTIVE,
+	entity.PriceScheduleCompleted: product.PriceScheduleStatus_PRICE_SCHEDULE_STATUS_COMPLETED,
+	entity.PriceScheduleCancelled: product.PriceScheduleStatus_PRICE_SCHEDULE_STATUS_CANCELLED,
+	entity.PriceScheduleSkipped:   product.PriceScheduleStatus_PRICE_SCHEDULE_STATUS_SKIPPED,
+}
+
+var priceChangeReasons = map[entity.PriceChangeReason]product.PriceChangeReason{
+	entity.PriceCreated:     product.PriceChangeReason_PRICE_CHANGE_REASON_CREATED,
+	entity.PriceUpdated:     product.PriceChangeReason_PRICE_CHANGE_REASON_UPDATED,
+	entity.PriceScheduled:   product.PriceChangeReason_PRICE_CHANGE_REASON_SCHEDULED,
+	entity.PriceSaleStarted: product.PriceChangeReason_PRICE_CHANGE_REASON_SALE_STARTED,
+	entity.PriceSaleEnded:   product.PriceChangeReason_PRICE_CHANGE_REASON_SALE_ENDED,
+}
+
+func (t *ProductService) CreatePriceSchedule(ctx context.Context, input *product.CreatePriceScheduleRequest) (*product.CreatePriceScheduleResponse, error) {
+	const op = "Service.CreatePriceSchedule"
+
+	productID, err := parseID("product_id", input.GetProductId())
+	if err != nil {
+		return nil, err
+	}
+
+	req := &entity.CreatePriceScheduleRequest{
+		ProductId: productID,
+		Price:     input.GetPrice(),
+	}
+	if input.StartsAt != nil {
+		req.StartsAt = input.GetStartsAt().AsTime()
+	}
+	if input.EndsAt != nil {
+		endsAt := input.GetEndsAt().AsTime()
+		req.EndsAt = &endsAt
+	}
+
+	schedule, err := t.service.CreatePriceSchedule(ctx, req)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.CreatePriceScheduleResponse{Schedule: priceScheduleToProto(schedule)}, nil
+}
+
+func (t *ProductService) ListPriceSchedules(ctx context.Context, input *product.ListPriceSchedulesRequest) (*product.ListPriceSchedulesResponse, error) {
+	const op = "Service.ListPriceSchedules"
+
+	productID, err := parseID("product_id", input.GetProductId())
+	if err != nil {
+		return nil, err
+	}
+
+	schedules, err := t.service.ListPriceSchedules(ctx, productID)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	resp := &product.ListPriceSchedulesResponse{Schedules: make([]*product.PriceSchedule, 0, len(schedules))}
+	for _, schedule := range schedules {
+		resp.Schedules = append(resp.Schedules, priceScheduleToProto(schedule))
+	}
+
+	return resp, nil
+}
+
+func (t *ProductService) CancelPriceSchedule(ctx context.Context, input *product.CancelPriceScheduleRequest) (*product.CancelPriceScheduleResponse, error) {
+	const op = "Service.CancelPriceSchedule"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := t.service.CancelPriceSchedule(ctx, id)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.CancelPriceScheduleResponse{Schedule: priceScheduleToProto(schedule)}, nil
+}
+
+func (t *ProductService) GetPriceHistory(ctx context.Context, input *product.GetPriceHistoryRequest) (*product.GetPriceHistoryResponse, error) {
+	const op = "Service.GetPriceHistory"
+
+	productID, err := parseID("product_id", input.GetProductId())
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := t.service.GetPriceHistory(ctx, productID, int(input.GetDays()))
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	resp := &product.GetPriceHistoryResponse{
+		Changes:     make([]*product.PriceChange, 0, len(history.Changes)),
+		LowestPrice: history.LowestPrice,
+		Since:       timestamppb.New(history.Since),
+	}
+	for _, change := range history.Changes {
+		c := &product.PriceChange{
+			Price:         change.Price,
+			OriginalPrice: change.OriginalPrice,
+			Reason:        priceChangeReasons[change.Reason],
+			ChangedAt:     timestamppb.New(change.ChangedAt),
+		}
+		if change.ScheduleId != nil {
+			c.ScheduleId = change.ScheduleId.String()
+		}
+		resp.Changes = append(resp.Changes, c)
+	}
+
+	return resp, nil
+}
+
+func priceScheduleToProto(s *entity.PriceSchedule) *product.PriceSchedule {
+	return &product.PriceSchedule{
+		Id:        s.Id.String(),
+		ProductId: s.ProductId.String(),
+		Kind:      priceScheduleKinds[s.Kind],
+		Price:     s.Price,
+		StartsAt:  timestamppb.New(s.StartsAt),
+		EndsAt:    timestampOrNil(s.EndsAt),
+		Status:    priceScheduleStatuses[s.Status],
+		CreatedAt: timestamppb.New(s.CreatedAt),
+	}
+}
+
+func timestampOrNil(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+
+	return timestamppb.New(*t)
+}
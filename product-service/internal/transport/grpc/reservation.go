@@ -0,0 +1,107 @@
+package grpcServer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/api/product"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var reservationStatuses = map[entity.ReservationStatus]product.ReservationStatus{
+	entity.ReservationActive:    product.ReservationStatus_RESERVATION_STATUS_ACTIVE,
+	entity.ReservationCommitted: product.ReservationStatus_RESERVATION_STATUS_COMMITTED,
+	entity.ReservationReleased:  product.ReservationStatus_RESERVATION_STATUS_RELEASED,
+}
+
+func (t *ProductService) ReserveStock(ctx context.Context, input *product.ReserveStockRequest) (*product.ReserveStockResponse, error) {
+	const op = "Service.ReserveStock"
+
+	orderID, err := parseID("order_id", input.GetOrderId())
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]entity.ReservationItem, 0, len(input.GetItems()))
+	for _, item := range input.GetItems() {
+		productID, err := parseID("product_id", item.GetProductId())
+		if err != nil {
+			return nil, err
+		}
+
+		variantID, err := parseOptionalID("variant_id", item.GetVariantId())
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, entity.ReservationItem{ProductId: productID, VariantId: variantID, Quantity: item.GetQuantity()})
+	}
+
+	reservation, err := t.service.ReserveStock(ctx, &entity.ReserveStockRequest{
+		OrderId: orderID,
+		Items:   items,
+		TTL:     time.Duration(input.GetTtlSeconds()) * time.Second,
+	})
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.ReserveStockResponse{Reservation: reservationToProto(reservation)}, nil
+}
+
+func (t *ProductService) ReleaseReservation(ctx context.Context, input *product.ReleaseReservationRequest) (*product.ReleaseReservationResponse, error) {
+	const op = "Service.ReleaseReservation"
+
+	orderID, err := parseID("order_id", input.GetOrderId())
+	if err != nil {
+		return nil, err
+	}
+
+	released, err := t.service.ReleaseReservation(ctx, orderID)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.ReleaseReservationResponse{Released: released}, nil
+}
+
+func (t *ProductService) CommitReservation(ctx context.Context, input *product.CommitReservationRequest) (*product.CommitReservationResponse, error) {
+	const op = "Service.CommitReservation"
+
+	orderID, err := parseID("order_id", input.GetOrderId())
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := t.service.CommitReservation(ctx, orderID)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.CommitReservationResponse{Reservation: reservationToProto(reservation)}, nil
+}
+
+func reservationToProto(r *entity.Reservation) *product.Reservation {
+	reservation := &product.Reservation{
+		OrderId:   r.OrderId.String(),
+		Items:     make([]*product.ReservationItem, 0, len(r.Items)),
+		Status:    reservationStatuses[r.Status],
+		ExpiresAt: timestamppb.New(r.ExpiresAt),
+		CreatedAt: timestamppb.New(r.CreatedAt),
+	}
+	for _, item := range r.Items {
+		out := &product.ReservationItem{
+			ProductId: item.ProductId.String(),
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+		}
+		if item.VariantId != nil {
+			out.VariantId = item.VariantId.String()
+		}
+		reservation.Items = append(reservation.Items, out)
+	}
+
+	return reservation
+}
@@ -0,0 +1,236 @@
+package grpcServer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/api/product"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var reviewStatuses = map[entity.ReviewStatus]product.ReviewStatus{
+	entity.ReviewPublished: product.ReviewStatus_REVIEW_STATUS_PUBLISHED,
+	entity.ReviewHidden:    product.ReviewStatus_REVIEW_STATUS_HIDDEN,
+}
+
+var moderationStatuses = map[product.ReviewStatus]entity.ReviewStatus{
+	product.ReviewStatus_REVIEW_STATUS_PUBLISHED: entity.ReviewPublished,
+	product.ReviewStatus_REVIEW_STATUS_HIDDEN:    entity.ReviewHidden,
+}
+
+func (t *ProductService) CreateReview(ctx context.Context, input *product.CreateReviewRequest) (*product.CreateReviewResponse, error) {
+	const op = "Service.CreateReview"
+
+	productID, err := parseID("product_id", input.GetProductId())
+	if err != nil {
+		return nil, err
+	}
+
+	orderID, err := parseID("order_id", input.GetOrderId())
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := t.service.CreateReview(ctx, &entity.CreateReviewRequest{
+		ProductId: productID,
+		OrderId:   orderID,
+		Rating:    int(input.GetRating()),
+		Text:      input.GetText(),
+	})
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.CreateReviewResponse{Review: reviewToProto(review)}, nil
+}
+
+func (t *ProductService) ListProductReviews(ctx context.Context, input *product.ListProductReviewsRequest) (*product.ListProductReviewsResponse, error) {
+	const op = "Service.ListProductReviews"
+
+	productID, err := parseID("product_id", input.GetProductId())
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := t.service.ListProductReviews(ctx, productID, int(input.GetPageSize()), input.GetPageToken())
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.ListProductReviewsResponse{Reviews: reviewsToProto(page.Reviews), NextPageToken: page.NextPageToken}, nil
+}
+
+func (t *ProductService) DeleteReview(ctx context.Context, input *product.DeleteReviewRequest) (*product.DeleteReviewResponse, error) {
+	const op = "Service.DeleteReview"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	success, err := t.service.DeleteReview(ctx, id)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.DeleteReviewResponse{Success: success}, nil
+}
+
+func (t *ProductService) UploadReviewImage(stream grpc.ClientStreamingServer[product.UploadReviewImageRequest, product.UploadReviewImageResponse]) error {
+	const op = "Service.UploadReviewImage"
+
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "the upload is empty")
+	}
+	if err != nil {
+		return err
+	}
+
+	reviewID, err := parseID("review_id", first.GetReviewId())
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// The upload is cut off as soon as it's too large to be accepted.
+		if len(data)+len(msg.GetChunk()) > entity.MaxImageSize {
+			return HandleError(fmt.Errorf("%s: %w", op, entity.ErrImageTooLarge))
+		}
+		data = append(data, msg.GetChunk()...)
+	}
+
+	image, err := t.service.UploadReviewImage(stream.Context(), &entity.UploadReviewImageRequest{ReviewId: reviewID, Data: data})
+	if err != nil {
+		return HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return stream.SendAndClose(&product.UploadReviewImageResponse{Image: reviewImageToProto(image)})
+}
+
+func (t *ProductService) ReplyToReview(ctx context.Context, input *product.ReplyToReviewRequest) (*product.ReplyToReviewResponse, error) {
+	const op = "Service.ReplyToReview"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := t.service.ReplyToReview(ctx, id, input.GetText())
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.ReplyToReviewResponse{Review: reviewToProto(review)}, nil
+}
+
+func (t *ProductService) ReportReview(ctx context.Context, input *product.ReportReviewRequest) (*product.ReportReviewResponse, error) {
+	const op = "Service.ReportReview"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.service.ReportReview(ctx, id, input.GetReason()); err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.ReportReviewResponse{Success: true}, nil
+}
+
+func (t *ProductService) ListReportedReviews(ctx context.Context, input *product.ListReportedReviewsRequest) (*product.ListReportedReviewsResponse, error) {
+	const op = "Service.ListReportedReviews"
+
+	reviews, err := t.service.ListReportedReviews(ctx, int(input.GetLimit()))
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.ListReportedReviewsResponse{Reviews: reviewsToProto(reviews)}, nil
+}
+
+func (t *ProductService) ModerateReview(ctx context.Context, input *product.ModerateReviewRequest) (*product.ModerateReviewResponse, error) {
+	const op = "Service.ModerateReview"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := t.service.ModerateReview(ctx, id, moderationStatuses[input.GetStatus()])
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.ModerateReviewResponse{Review: reviewToProto(review)}, nil
+}
+
+func reviewToProto(r *entity.Review) *product.Review {
+	images := make([]*product.ReviewImage, 0, len(r.Images))
+	for _, image := range r.Images {
+		images = append(images, reviewImageToProto(image))
+	}
+
+	return &product.Review{
+		Id:          r.Id.String(),
+		ProductId:   r.ProductId.String(),
+		UserId:      r.UserId.String(),
+		Rating:      int32(r.Rating),
+		Text:        r.Text,
+		Images:      images,
+		Reply:       r.Reply,
+		RepliedAt:   timestampOrNil(r.RepliedAt),
+		Status:      reviewStatuses[r.Status],
+		OpenReports: int32(r.OpenReports),
+		CreatedAt:   timestamppb.New(r.CreatedAt),
+		UpdatedAt:   timestamppb.New(r.UpdatedAt),
+	}
+}
+
+func reviewsToProto(reviews []*entity.Review) []*product.Review {
+	res := make([]*product.Review, 0, len(reviews))
+	for _, review := range reviews {
+		res = append(res, reviewToProto(review))
+	}
+
+	return res
+}
+
+func reviewImageToProto(image *entity.ReviewImage) *product.ReviewImage {
+	thumbnails := make([]*product.Thumbnail, 0, len(image.Thumbnails))
+	for _, thumbnail := range image.Thumbnails {
+		thumbnails = append(thumbnails, &product.Thumbnail{
+			Size:   thumbnail.Size,
+			Url:    thumbnail.URL,
+			Width:  int32(thumbnail.Width),
+			Height: int32(thumbnail.Height),
+		})
+	}
+
+	return &product.ReviewImage{
+		Id:          image.Id.String(),
+		Url:         image.URL,
+		Position:    image.Position,
+		ContentType: image.ContentType,
+		Width:       int32(image.Width),
+		Height:      int32(image.Height),
+		Size:        image.Size,
+		Thumbnails:  thumbnails,
+	}
+}
@@ -3,10 +3,13 @@ package grpcServer
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/google/uuid"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
 	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/api/product"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -16,6 +19,58 @@ type Service interface {
 	UpdateProduct(ctx context.Context, input *entity.UpdateProductRequest) (*entity.Product, error)
 	DeleteProduct(ctx context.Context, id uuid.UUID) (bool, error)
 	ListProduct(ctx context.Context, offset, limit int64) ([]*entity.Product, error)
+	ListProductsPage(ctx context.Context, pageSize int, pageToken string) (*entity.ProductPage, error)
+	EstimateProductCount(ctx context.Context) (int64, error)
+	ListProductsBySeller(ctx context.Context, sellerID uuid.UUID, offset, limit int64) ([]*entity.Product, error)
+	SearchProducts(ctx context.Context, req *entity.SearchProductsRequest) (*entity.SearchResult, error)
+
+	ChangeProductStatus(ctx context.Context, req *entity.ChangeProductStatusRequest) (*entity.Product, error)
+	ListProductStatusHistory(ctx context.Context, productID uuid.UUID) ([]*entity.ProductStatusChange, error)
+	ListPendingProducts(ctx context.Context, pageSize int, pageToken string) (*entity.ProductPage, error)
+
+	CreateCategory(ctx context.Context, req *entity.CreateCategoryRequest) (*entity.Category, error)
+	GetCategory(ctx context.Context, id uuid.UUID) (*entity.Category, error)
+	UpdateCategory(ctx context.Context, req *entity.UpdateCategoryRequest) (*entity.Category, error)
+	DeleteCategory(ctx context.Context, id uuid.UUID) (bool, error)
+	ListCategories(ctx context.Context) ([]*entity.Category, error)
+	ListCategoryProducts(ctx context.Context, categoryID uuid.UUID, offset, limit int64) ([]*entity.Product, error)
+	SetProductCategories(ctx context.Context, req *entity.SetProductCategoriesRequest) (*entity.ProductCategories, error)
+	GetProductCategories(ctx context.Context, productID uuid.UUID) (*entity.ProductCategories, error)
+
+	CreateAttributeDefinition(ctx context.Context, req *entity.CreateAttributeDefinitionRequest) (*entity.AttributeDefinition, error)
+	ListAttributeDefinitions(ctx context.Context, categoryID uuid.UUID) ([]*entity.AttributeDefinition, error)
+	DeleteAttributeDefinition(ctx context.Context, id uuid.UUID) (bool, error)
+	CreateVariant(ctx context.Context, req *entity.CreateVariantRequest) (*entity.Variant, error)
+	GetVariant(ctx context.Context, id uuid.UUID) (*entity.Variant, error)
+	UpdateVariant(ctx context.Context, req *entity.UpdateVariantRequest) (*entity.Variant, error)
+	DeleteVariant(ctx context.Context, id uuid.UUID) (bool, error)
+	GetProductVariants(ctx context.Context, productID uuid.UUID) ([]*entity.Variant, []entity.VariantAxis, error)
+
+	ReserveStock(ctx context.Context, req *entity.ReserveStockRequest) (*entity.Reservation, error)
+	ReleaseReservation(ctx context.Context, orderID uuid.UUID) (bool, error)
+	CommitReservation(ctx context.Context, orderID uuid.UUID) (*entity.Reservation, error)
+
+	UploadProductImage(ctx context.Context, req *entity.UploadImageRequest) (*entity.ProductImage, error)
+	ReorderProductImages(ctx context.Context, productID uuid.UUID, imageIDs []uuid.UUID) ([]*entity.ProductImage, error)
+	DeleteProductImage(ctx context.Context, productID, imageID uuid.UUID) (bool, error)
+
+	CreatePriceSchedule(ctx context.Context, req *entity.CreatePriceScheduleRequest) (*entity.PriceSchedule, error)
+	ListPriceSchedules(ctx context.Context, productID uuid.UUID) ([]*entity.PriceSchedule, error)
+	CancelPriceSchedule(ctx context.Context, id uuid.UUID) (*entity.PriceSchedule, error)
+	GetPriceHistory(ctx context.Context, productID uuid.UUID, days int) (*entity.PriceHistory, error)
+
+	ImportProducts(ctx context.Context, req *entity.ImportProductsRequest) (*entity.ImportJob, error)
+	GetImportJob(ctx context.Context, id uuid.UUID) (*entity.ImportJob, error)
+	ExportProducts(ctx context.Context, format entity.CatalogFormat, w io.Writer) error
+
+	CreateReview(ctx context.Context, req *entity.CreateReviewRequest) (*entity.Review, error)
+	ListProductReviews(ctx context.Context, productID uuid.UUID, pageSize int, pageToken string) (*entity.ReviewPage, error)
+	DeleteReview(ctx context.Context, id uuid.UUID) (bool, error)
+	UploadReviewImage(ctx context.Context, req *entity.UploadReviewImageRequest) (*entity.ReviewImage, error)
+	ReplyToReview(ctx context.Context, id uuid.UUID, text string) (*entity.Review, error)
+	ReportReview(ctx context.Context, id uuid.UUID, reason string) error
+	ListReportedReviews(ctx context.Context, limit int) ([]*entity.Review, error)
+	ModerateReview(ctx context.Context, id uuid.UUID, status entity.ReviewStatus) (*entity.Review, error)
 }
 
 type ProductService struct {
@@ -39,7 +94,7 @@ func (t *ProductService) CreateProduct(ctx context.Context, input *product.Creat
 
 	id, err := t.service.CreateProduct(ctx, data)
 	if err != nil {
-		return &product.CreateProductResponse{}, fmt.Errorf("%s: %w", op, err)
+		return &product.CreateProductResponse{}, HandleError(fmt.Errorf("%s: %w", op, err))
 	}
 
 	return &product.CreateProductResponse{
@@ -60,16 +115,22 @@ func (t *ProductService) GetProduct(ctx context.Context, input *product.GetProdu
 		return &product.GetProductResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	categories, err := t.service.GetProductCategories(ctx, id)
+	if err != nil {
+		return &product.GetProductResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	variants, axes, err := t.service.GetProductVariants(ctx, id)
+	if err != nil {
+		return &product.GetProductResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return &product.GetProductResponse{
-		Product: &product.Product{
-			Id:          input.Id,
-			Name:        products.Name,
-			Description: products.Description,
-			Price:       products.Price,
-			Stock:       products.Stock,
-			CreatedAt:   timestamppb.New(products.CreatedAt),
-			UpdatedAt:   timestamppb.New(products.UpdatedAt),
-		},
+		Breadcrumbs:         categoriesToProto(categories.Breadcrumbs),
+		SecondaryCategories: categoriesToProto(categories.Secondary),
+		Variants:            variantsToProto(variants),
+		VariantAxes:         axesToProto(axes),
+		Product:             toProto(products),
 	}, nil
 }
 
@@ -88,22 +149,17 @@ func (t *ProductService) UpdateProduct(ctx context.Context, input *product.Updat
 		Price:       input.GetPrice(),
 		Stock:       input.GetStock(),
 	}
+	if input.GetUpdatedAt() != nil {
+		data.UpdatedAt = input.GetUpdatedAt().AsTime()
+	}
 
 	products, err := t.service.UpdateProduct(ctx, data)
 	if err != nil {
-		return &product.UpdateProductResponse{}, fmt.Errorf("%s: %w", op, err)
+		return &product.UpdateProductResponse{}, HandleError(fmt.Errorf("%s: %w", op, err))
 	}
 
 	return &product.UpdateProductResponse{
-		Product: &product.Product{
-			Id:          products.Id.String(),
-			Name:        products.Name,
-			Description: products.Description,
-			Price:       products.Price,
-			Stock:       products.Stock,
-			CreatedAt:   timestamppb.New(products.CreatedAt),
-			UpdatedAt:   timestamppb.New(products.UpdatedAt),
-		},
+		Product: toProto(products),
 	}, nil
 }
 
@@ -117,7 +173,7 @@ func (t *ProductService) DeleteProduct(ctx context.Context, input *product.Delet
 
 	success, err := t.service.DeleteProduct(ctx, id)
 	if err != nil {
-		return &product.DeleteProductResponse{}, fmt.Errorf("%s: %w", op, err)
+		return &product.DeleteProductResponse{}, HandleError(fmt.Errorf("%s: %w", op, err))
 	}
 
 	return &product.DeleteProductResponse{
@@ -126,30 +182,157 @@ func (t *ProductService) DeleteProduct(ctx context.Context, input *product.Delet
 }
 
 func (t *ProductService) ListProducts(ctx context.Context, input *product.ListProductsRequest) (*product.ListProductsResponse, error) {
-	const op = "Service.CreateProduct"
+	const op = "Service.ListProducts"
 
-	products, err := t.service.ListProduct(ctx, input.GetOffset(), input.GetLimit())
-	if err != nil {
-		return &product.ListProductsResponse{}, fmt.Errorf("%s: %w", op, err)
+	var (
+		products      []*entity.Product
+		nextPageToken string
+	)
+	// Callers that set neither page_size nor page_token page by offset, as
+	// before page tokens existed.
+	if input.GetPageSize() == 0 && input.GetPageToken() == "" {
+		var err error
+		products, err = t.service.ListProduct(ctx, input.GetOffset(), input.GetLimit())
+		if err != nil {
+			return &product.ListProductsResponse{}, HandleError(fmt.Errorf("%s: %w", op, err))
+		}
+	} else {
+		page, err := t.service.ListProductsPage(ctx, int(input.GetPageSize()), input.GetPageToken())
+		if err != nil {
+			return &product.ListProductsResponse{}, HandleError(fmt.Errorf("%s: %w", op, err))
+		}
+		products, nextPageToken = page.Products, page.NextPageToken
 	}
 
 	var protobufProducts []*product.Product
 	for _, products := range products {
-		protobufProducts = append(protobufProducts, &product.Product{
-			Id:          products.Id.String(),
-			Name:        products.Name,
-			Description: products.Description,
-			Price:       products.Price,
-			Stock:       products.Stock,
-			CreatedAt:   timestamppb.New(products.CreatedAt),
-			UpdatedAt:   timestamppb.New(products.UpdatedAt),
+		protobufProducts = append(protobufProducts, toProto(products))
+	}
+
+	resp := &product.ListProductsResponse{
+		Products:      protobufProducts,
+		NextPageToken: nextPageToken,
+	}
+
+	if input.GetIncludeTotal() {
+		total, err := t.service.EstimateProductCount(ctx)
+		if err != nil {
+			return &product.ListProductsResponse{}, HandleError(fmt.Errorf("%s: %w", op, err))
+		}
+		resp.TotalSize = &total
+	}
+
+	return resp, nil
+}
+
+func (t *ProductService) ListProductsBySeller(ctx context.Context, input *product.ListProductsBySellerRequest) (*product.ListProductsBySellerResponse, error) {
+	const op = "Service.ListProductsBySeller"
+
+	sellerID, err := parseID("seller_id", input.GetSellerId())
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := t.service.ListProductsBySeller(ctx, sellerID, input.GetOffset(), input.GetLimit())
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	resp := &product.ListProductsBySellerResponse{Products: make([]*product.Product, 0, len(products))}
+	for _, p := range products {
+		resp.Products = append(resp.Products, toProto(p))
+	}
+
+	return resp, nil
+}
+
+func (t *ProductService) SearchProducts(ctx context.Context, input *product.SearchProductsRequest) (*product.SearchProductsResponse, error) {
+	const op = "Service.SearchProducts"
+
+	sort, ok := searchSorts[input.GetSort()]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown sort %d", input.GetSort())
+	}
+
+	categoryID, err := parseOptionalID("category_id", input.GetCategoryId())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.service.SearchProducts(ctx, &entity.SearchProductsRequest{
+		Query:      input.GetQuery(),
+		MinPrice:   input.MinPrice,
+		MaxPrice:   input.MaxPrice,
+		InStock:    input.GetInStock(),
+		Sort:       sort,
+		Offset:     input.GetOffset(),
+		Limit:      input.GetLimit(),
+		CategoryId: categoryID,
+	})
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	hits := make([]*product.SearchHit, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		hits = append(hits, &product.SearchHit{
+			Product:            toProto(hit.Product),
+			NameHighlight:      hit.NameHighlight,
+			DescriptionSnippet: hit.DescriptionSnippet,
+			Rank:               hit.Rank,
 		})
 	}
 
-	return &product.ListProductsResponse{
-		Products: protobufProducts,
+	priceRanges := make([]*product.PriceRangeFacet, 0, len(result.Facets.PriceRanges))
+	for _, r := range result.Facets.PriceRanges {
+		priceRanges = append(priceRanges, &product.PriceRangeFacet{Min: r.Min, Max: r.Max, Count: r.Count})
+	}
+
+	categoryFacets := make([]*product.CategoryFacet, 0, len(result.Facets.Categories))
+	for _, c := range result.Facets.Categories {
+		categoryFacets = append(categoryFacets, &product.CategoryFacet{CategoryId: c.CategoryId.String(), Name: c.Name, Count: c.Count})
+	}
+
+	return &product.SearchProductsResponse{
+		Hits:  hits,
+		Total: result.Total,
+		Facets: &product.SearchFacets{
+			PriceRanges: priceRanges,
+			InStock:     result.Facets.InStock,
+			OutOfStock:  result.Facets.OutOfStock,
+			Categories:  categoryFacets,
+		},
 	}, nil
+}
 
+var searchSorts = map[product.SearchSort]entity.SearchSort{
+	product.SearchSort_SEARCH_SORT_UNSPECIFIED: entity.SortDefault,
+	product.SearchSort_SEARCH_SORT_RELEVANCE:   entity.SortRelevance,
+	product.SearchSort_SEARCH_SORT_PRICE_ASC:   entity.SortPriceAsc,
+	product.SearchSort_SEARCH_SORT_PRICE_DESC:  entity.SortPriceDesc,
+	product.SearchSort_SEARCH_SORT_NEWEST:      entity.SortNewest,
+}
+
+func toProto(p *entity.Product) *product.Product {
+	return &product.Product{
+		Id:            p.Id.String(),
+		SellerId:      p.SellerId.String(),
+		Name:          p.Name,
+		Description:   p.Description,
+		Price:         p.Price,
+		Stock:         p.Stock,
+		CreatedAt:     timestamppb.New(p.CreatedAt),
+		UpdatedAt:     timestamppb.New(p.UpdatedAt),
+		Images:        imagesToProto(p.Images),
+		OriginalPrice: p.OriginalPrice,
+		SaleEndsAt:    timestampOrNil(p.SaleEndsAt),
+		Sku:           p.Sku,
+		RatingAverage: p.RatingAverage(),
+		RatingCount:   p.RatingCount,
+		Status:        productStatuses[p.Status],
+		PublishedAt:   timestampOrNil(p.PublishedAt),
+		DeletedAt:     timestampOrNil(p.DeletedAt),
+	}
 }
 
 func ParseUUID(id string) (uuid.UUID, error) {
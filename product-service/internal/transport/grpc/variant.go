@@ -0,0 +1,201 @@
+package grpcServer
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/internal/entity"
+	"gitlab.crja72.ru/golang/2025/spring/course/projects/go7/marketplace/product-microservice/pkg/api/product"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var attributeTypes = map[product.AttributeType]entity.AttributeType{
+	product.AttributeType_ATTRIBUTE_TYPE_TEXT:   entity.AttributeText,
+	product.AttributeType_ATTRIBUTE_TYPE_NUMBER: entity.AttributeNumber,
+	product.AttributeType_ATTRIBUTE_TYPE_ENUM:   entity.AttributeEnum,
+}
+
+func (t *ProductService) CreateAttributeDefinition(ctx context.Context, input *product.CreateAttributeDefinitionRequest) (*product.CreateAttributeDefinitionResponse, error) {
+	const op = "Service.CreateAttributeDefinition"
+
+	categoryID, err := parseID("category_id", input.GetCategoryId())
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := t.service.CreateAttributeDefinition(ctx, &entity.CreateAttributeDefinitionRequest{
+		CategoryId:    categoryID,
+		Code:          input.GetCode(),
+		Name:          input.GetName(),
+		Type:          attributeTypes[input.GetType()],
+		AllowedValues: input.GetAllowedValues(),
+		Required:      input.GetRequired(),
+	})
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.CreateAttributeDefinitionResponse{Definition: attributeToProto(def)}, nil
+}
+
+func (t *ProductService) ListAttributeDefinitions(ctx context.Context, input *product.ListAttributeDefinitionsRequest) (*product.ListAttributeDefinitionsResponse, error) {
+	const op = "Service.ListAttributeDefinitions"
+
+	categoryID, err := parseID("category_id", input.GetCategoryId())
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := t.service.ListAttributeDefinitions(ctx, categoryID)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	resp := &product.ListAttributeDefinitionsResponse{Definitions: make([]*product.AttributeDefinition, 0, len(definitions))}
+	for _, def := range definitions {
+		resp.Definitions = append(resp.Definitions, attributeToProto(def))
+	}
+
+	return resp, nil
+}
+
+func (t *ProductService) DeleteAttributeDefinition(ctx context.Context, input *product.DeleteAttributeDefinitionRequest) (*product.DeleteAttributeDefinitionResponse, error) {
+	const op = "Service.DeleteAttributeDefinition"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	success, err := t.service.DeleteAttributeDefinition(ctx, id)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.DeleteAttributeDefinitionResponse{Success: success}, nil
+}
+
+func (t *ProductService) CreateVariant(ctx context.Context, input *product.CreateVariantRequest) (*product.CreateVariantResponse, error) {
+	const op = "Service.CreateVariant"
+
+	productID, err := parseID("product_id", input.GetProductId())
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := t.service.CreateVariant(ctx, &entity.CreateVariantRequest{
+		ProductId:  productID,
+		Sku:        input.GetSku(),
+		Price:      input.GetPrice(),
+		Stock:      input.GetStock(),
+		Attributes: input.GetAttributes(),
+	})
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.CreateVariantResponse{Variant: variantToProto(variant)}, nil
+}
+
+func (t *ProductService) GetVariant(ctx context.Context, input *product.GetVariantRequest) (*product.GetVariantResponse, error) {
+	const op = "Service.GetVariant"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := t.service.GetVariant(ctx, id)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.GetVariantResponse{Variant: variantToProto(variant)}, nil
+}
+
+func (t *ProductService) UpdateVariant(ctx context.Context, input *product.UpdateVariantRequest) (*product.UpdateVariantResponse, error) {
+	const op = "Service.UpdateVariant"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := t.service.UpdateVariant(ctx, &entity.UpdateVariantRequest{
+		Id:         id,
+		Sku:        input.GetSku(),
+		Price:      input.GetPrice(),
+		Stock:      input.GetStock(),
+		Attributes: input.GetAttributes(),
+	})
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.UpdateVariantResponse{Variant: variantToProto(variant)}, nil
+}
+
+func (t *ProductService) DeleteVariant(ctx context.Context, input *product.DeleteVariantRequest) (*product.DeleteVariantResponse, error) {
+	const op = "Service.DeleteVariant"
+
+	id, err := parseID("id", input.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	success, err := t.service.DeleteVariant(ctx, id)
+	if err != nil {
+		return nil, HandleError(fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &product.DeleteVariantResponse{Success: success}, nil
+}
+
+func attributeToProto(def *entity.AttributeDefinition) *product.AttributeDefinition {
+	out := &product.AttributeDefinition{
+		Id:            def.Id.String(),
+		CategoryId:    def.CategoryId.String(),
+		Code:          def.Code,
+		Name:          def.Name,
+		AllowedValues: def.AllowedValues,
+		Required:      def.Required,
+	}
+	for protoType, entityType := range attributeTypes {
+		if entityType == def.Type {
+			out.Type = protoType
+		}
+	}
+
+	return out
+}
+
+func variantToProto(v *entity.Variant) *product.Variant {
+	return &product.Variant{
+		Id:         v.Id.String(),
+		ProductId:  v.ProductId.String(),
+		Sku:        v.Sku,
+		Price:      v.Price,
+		Stock:      v.Stock,
+		Attributes: v.Attributes,
+		CreatedAt:  timestamppb.New(v.CreatedAt),
+		UpdatedAt:  timestamppb.New(v.UpdatedAt),
+	}
+}
+
+func variantsToProto(variants []*entity.Variant) []*product.Variant {
+	out := make([]*product.Variant, 0, len(variants))
+	for _, v := range variants {
+		out = append(out, variantToProto(v))
+	}
+
+	return out
+}
+
+func axesToProto(axes []entity.VariantAxis) []*product.VariantAxis {
+	out := make([]*product.VariantAxis, 0, len(axes))
+	for _, axis := range axes {
+		out = append(out, &product.VariantAxis{Code: axis.Code, Name: axis.Name, Values: axis.Values})
+	}
+
+	return out
+}
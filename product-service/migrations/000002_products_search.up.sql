@@ -0,0 +1,8 @@
+ALTER TABLE products ADD COLUMN search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', name), 'A') ||
+    setweight(to_tsvector('russian', description), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS products_search_idx ON products USING GIN (search);
+CREATE INDEX IF NOT EXISTS products_price_idx ON products (price);
+CREATE INDEX IF NOT EXISTS products_created_at_idx ON products (created_at DESC);
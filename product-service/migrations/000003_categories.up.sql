@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id UUID PRIMARY KEY NOT NULL,
+    parent_id UUID REFERENCES categories (id) ON DELETE RESTRICT,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    -- Materialized path of the ids from the root, "/<root id>/.../<id>/".
+    path TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS categories_path_idx ON categories (path text_pattern_ops);
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id);
+
+CREATE TABLE IF NOT EXISTS product_categories (
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories (id) ON DELETE RESTRICT,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (product_id, category_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_categories_primary_idx ON product_categories (product_id) WHERE is_primary;
+CREATE INDEX IF NOT EXISTS product_categories_category_id_idx ON product_categories (category_id);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS attribute_definitions (
+    id UUID PRIMARY KEY NOT NULL,
+    category_id UUID NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('text', 'number', 'enum')),
+    allowed_values TEXT[] NOT NULL DEFAULT '{}',
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (category_id, code)
+);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id UUID PRIMARY KEY NOT NULL,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    sku VARCHAR(64) NOT NULL UNIQUE,
+    price INTEGER NOT NULL,
+    stock INTEGER NOT NULL,
+    attributes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    -- A product has one variant per combination of attribute values.
+    UNIQUE (product_id, attributes)
+);
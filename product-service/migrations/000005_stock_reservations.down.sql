@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS stock_reservations;
+
+ALTER TABLE product_variants DROP CONSTRAINT IF EXISTS product_variants_stock_check;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_stock_check;
@@ -0,0 +1,22 @@
+-- Stock is only taken by conditional decrements, these guard against any
+-- other write driving it below zero.
+ALTER TABLE products ADD CONSTRAINT products_stock_check CHECK (stock >= 0);
+ALTER TABLE product_variants ADD CONSTRAINT product_variants_stock_check CHECK (stock >= 0);
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id UUID PRIMARY KEY NOT NULL,
+    order_id UUID NOT NULL,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants (id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    price INTEGER NOT NULL,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('active', 'committed', 'released')),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS stock_reservations_order_id_idx ON stock_reservations (order_id);
+
+-- Serves the sweeper of expired reservations.
+CREATE INDEX IF NOT EXISTS stock_reservations_expires_at_idx ON stock_reservations (expires_at) WHERE status = 'active';
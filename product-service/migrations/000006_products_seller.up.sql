@@ -0,0 +1,7 @@
+-- Products created before sellers existed belong to the system seller,
+-- entity.SystemSellerId. Admins can still manage them.
+ALTER TABLE products ADD COLUMN seller_id UUID;
+UPDATE products SET seller_id = '00000000-0000-0000-0000-000000000001' WHERE seller_id IS NULL;
+ALTER TABLE products ALTER COLUMN seller_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS products_seller_idx ON products (seller_id, created_at DESC, id);
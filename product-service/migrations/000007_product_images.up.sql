@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS product_images (
+    id UUID PRIMARY KEY NOT NULL,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 0),
+    content_type VARCHAR(20) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size INTEGER NOT NULL,
+    storage_key TEXT NOT NULL,
+    -- [{"size": "small", "key": "...", "width": 160, "height": 120}, ...]
+    thumbnails JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT NOW(),
+    -- Reordering rewrites every position of a product in one statement.
+    UNIQUE (product_id, position) DEFERRABLE INITIALLY DEFERRED
+);
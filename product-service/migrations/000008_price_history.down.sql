@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS price_history;
+DROP TABLE IF EXISTS price_schedules;
+ALTER TABLE products DROP COLUMN IF EXISTS sale_ends_at;
+ALTER TABLE products DROP COLUMN IF EXISTS original_price;
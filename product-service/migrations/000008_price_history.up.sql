@@ -0,0 +1,40 @@
+-- While a sale runs, price is the sale price and original_price the regular
+-- one it returns to at sale_ends_at.
+ALTER TABLE products ADD COLUMN original_price INTEGER;
+ALTER TABLE products ADD COLUMN sale_ends_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS price_schedules (
+    id UUID PRIMARY KEY NOT NULL,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('change', 'sale')),
+    price INTEGER NOT NULL CHECK (price > 0),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('pending', 'active', 'completed', 'cancelled', 'skipped')),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    -- Only sales end, and they end after they start.
+    CHECK ((kind = 'sale') = (ends_at IS NOT NULL) AND (ends_at IS NULL OR ends_at > starts_at))
+);
+
+CREATE INDEX IF NOT EXISTS price_schedules_product_idx ON price_schedules (product_id, starts_at);
+
+-- Serve the job that applies the schedules.
+CREATE INDEX IF NOT EXISTS price_schedules_due_idx ON price_schedules (starts_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS price_schedules_ending_idx ON price_schedules (ends_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS price_history (
+    id UUID PRIMARY KEY NOT NULL,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price INTEGER NOT NULL,
+    original_price INTEGER,
+    reason VARCHAR(20) NOT NULL,
+    schedule_id UUID REFERENCES price_schedules (id) ON DELETE SET NULL,
+    changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS price_history_product_idx ON price_history (product_id, changed_at);
+
+-- The history starts with the prices products had when it was introduced.
+INSERT INTO price_history (id, product_id, price, reason, changed_at)
+SELECT gen_random_uuid(), id, price, 'created', COALESCE(updated_at, created_at, NOW()) FROM products;
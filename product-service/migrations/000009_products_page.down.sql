@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS products_created_idx;
+ALTER TABLE products ALTER COLUMN created_at DROP NOT NULL;
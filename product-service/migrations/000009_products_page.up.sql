@@ -0,0 +1,6 @@
+-- Pages of the catalog are read by (created_at, id), which must not be NULL
+-- for the row comparison of page tokens to hold.
+UPDATE products SET created_at = NOW() WHERE created_at IS NULL;
+ALTER TABLE products ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS products_created_idx ON products (created_at DESC, id DESC);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS import_errors;
+DROP TABLE IF EXISTS import_rows;
+DROP TABLE IF EXISTS import_jobs;
+DROP INDEX IF EXISTS products_seller_sku_idx;
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
@@ -0,0 +1,43 @@
+-- Imports match the products of a seller by SKU. Products created one by one
+-- have none.
+ALTER TABLE products ADD COLUMN sku VARCHAR(64) NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS products_seller_sku_idx ON products (seller_id, sku) WHERE sku <> '';
+
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id UUID PRIMARY KEY NOT NULL,
+    seller_id UUID NOT NULL,
+    format VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'json')),
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('pending', 'running', 'completed')),
+    total_rows INTEGER NOT NULL,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    created INTEGER NOT NULL DEFAULT 0,
+    updated INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+-- Serve the job that writes the imports.
+CREATE INDEX IF NOT EXISTS import_jobs_unfinished_idx ON import_jobs (created_at) WHERE status <> 'completed';
+
+-- The checked rows that are yet to be written, deleted as they are.
+CREATE TABLE IF NOT EXISTS import_rows (
+    job_id UUID NOT NULL REFERENCES import_jobs (id) ON DELETE CASCADE,
+    row_index INTEGER NOT NULL,
+    sku VARCHAR(64) NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    price INTEGER NOT NULL,
+    stock INTEGER NOT NULL,
+    PRIMARY KEY (job_id, row_index)
+);
+
+CREATE TABLE IF NOT EXISTS import_errors (
+    job_id UUID NOT NULL REFERENCES import_jobs (id) ON DELETE CASCADE,
+    row_index INTEGER NOT NULL,
+    sku TEXT NOT NULL DEFAULT '',
+    message TEXT NOT NULL,
+    PRIMARY KEY (job_id, row_index)
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS review_reports;
+DROP TABLE IF EXISTS review_images;
+DROP TABLE IF EXISTS reviews;
+ALTER TABLE products DROP COLUMN IF EXISTS rating_count;
+ALTER TABLE products DROP COLUMN IF EXISTS rating_sum;
@@ -0,0 +1,49 @@
+-- The rating of a product over its published reviews, kept up to date with
+-- them so reading a product doesn't aggregate its reviews.
+ALTER TABLE products ADD COLUMN rating_sum INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS reviews (
+    id UUID PRIMARY KEY NOT NULL,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    -- The delivered order the product was bought in.
+    order_id UUID NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text TEXT NOT NULL DEFAULT '',
+    status VARCHAR(10) NOT NULL CHECK (status IN ('published', 'hidden')),
+    reply TEXT NOT NULL DEFAULT '',
+    replied_at TIMESTAMP,
+    -- The reports received since the last moderation.
+    open_reports INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (product_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_product_page_idx ON reviews (product_id, created_at DESC, id DESC) WHERE status = 'published';
+
+-- Serves the moderation queue.
+CREATE INDEX IF NOT EXISTS reviews_reported_idx ON reviews (open_reports DESC, created_at) WHERE open_reports > 0;
+
+CREATE TABLE IF NOT EXISTS review_images (
+    id UUID PRIMARY KEY NOT NULL,
+    review_id UUID NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 0),
+    content_type VARCHAR(20) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size INTEGER NOT NULL,
+    storage_key TEXT NOT NULL,
+    thumbnails JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (review_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS review_reports (
+    review_id UUID NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (review_id, user_id)
+);
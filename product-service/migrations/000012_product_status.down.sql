@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS product_status_history;
+DROP INDEX IF EXISTS products_pending_idx;
+DROP INDEX IF EXISTS products_published_idx;
+DELETE FROM products WHERE status = 'deleted';
+DROP INDEX IF EXISTS products_seller_sku_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS products_seller_sku_idx ON products (seller_id, sku) WHERE sku <> '';
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE products DROP COLUMN IF EXISTS published_at;
+ALTER TABLE products DROP COLUMN IF EXISTS status;
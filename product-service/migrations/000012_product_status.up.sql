@@ -0,0 +1,36 @@
+-- Products go from draft through review to published and archived, and are
+-- deleted by status so that orders and reviews keep pointing at them.
+-- published_at is the first publication: products never published are
+-- shown to their seller and admins only.
+ALTER TABLE products ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'draft'
+    CHECK (status IN ('draft', 'pending_review', 'published', 'archived', 'deleted'));
+ALTER TABLE products ADD COLUMN published_at TIMESTAMP;
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMP;
+
+-- The products sold so far stay on sale.
+UPDATE products SET status = 'published', published_at = COALESCE(created_at, NOW());
+
+-- The catalog and its estimate read the published products only.
+CREATE INDEX IF NOT EXISTS products_published_idx ON products (created_at DESC, id DESC) WHERE status = 'published';
+CREATE INDEX IF NOT EXISTS products_pending_idx ON products (created_at DESC, id DESC) WHERE status = 'pending_review';
+
+-- A deleted product frees its SKU for a new one.
+DROP INDEX IF EXISTS products_seller_sku_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS products_seller_sku_idx ON products (seller_id, sku) WHERE sku <> '' AND status <> 'deleted';
+
+CREATE TABLE IF NOT EXISTS product_status_history (
+    id UUID PRIMARY KEY NOT NULL,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    -- NULL when the product was created.
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    changed_by UUID NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS product_status_history_product_idx ON product_status_history (product_id, changed_at);
+
+-- The history starts with the products published when it was introduced.
+INSERT INTO product_status_history (id, product_id, to_status, changed_by, changed_at)
+SELECT gen_random_uuid(), id, status, seller_id, published_at FROM products;
@@ -0,0 +1,14 @@
+// Package blob keeps binary objects, such as product images, by key.
+package blob
+
+import "context"
+
+// Storage is an object storage. Keys are slash-separated paths; objects are
+// immutable once written, so they can be cached forever by their URL.
+type Storage interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of an object.
+	URL(key string) string
+}
@@ -2,8 +2,10 @@ package grpcapp
 
 import (
 	"context"
+	"errors"
 	"log"
 	"userService/internal/entity"
+	"userService/internal/utils/errs"
 
 	client "userService/pkg/api/client"
 
@@ -61,6 +63,11 @@ func (tus *UserService) CreateProfile(ctx context.Context, r *client.CreateProfi
 
 	if _, err = tus.service.CreateProfile(ctx, userID, r.GetUsername(), r.GetFirstname(), r.GetMiddlename(),
 		r.GetLastname(), r.GetPhoneNumber(), r.GetEmail()); err != nil {
+		// The gateway retries a sign up it lost the answer to and needs to
+		// tell that the profile is already there.
+		if errors.Is(err, errs.ErrProfileExists) {
+			return nil, errs.HandleErrors(err)
+		}
 		return nil, status.Error(codes.Internal, "failed to create user profile")
 	}
 
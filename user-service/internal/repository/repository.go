@@ -7,6 +7,7 @@ import (
 	"fmt"
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"userService/internal/entity"
 	"userService/internal/utils/errs"
 	"userService/pkg/storage/postgres"
@@ -62,6 +63,11 @@ func (r *Repository) Create(ctx context.Context, newProfile *entity.UserProfile)
 
 	var id uuid.UUID
 	if err = row.Scan(&id); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return uuid.Nil, fmt.Errorf("%s: %w", op, errs.ErrProfileExists)
+		}
+
 		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
 	}
 
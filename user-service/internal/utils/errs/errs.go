@@ -9,6 +9,7 @@ import (
 var (
 	ErrNoProfileFound = errors.New("no user found")
 	ErrValidating     = errors.New("invalid data specified")
+	ErrProfileExists  = errors.New("profile already exists")
 )
 
 func HandleErrors(err error) error {
@@ -17,6 +18,8 @@ func HandleErrors(err error) error {
 		return status.Error(codes.NotFound, err.Error())
 	case errors.Is(err, ErrValidating):
 		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrProfileExists):
+		return status.Error(codes.AlreadyExists, err.Error())
 	default:
 		return status.Error(codes.Internal, "internal error")
 	}